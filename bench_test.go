@@ -0,0 +1,225 @@
+package genworldvoronoi
+
+import (
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Flokey82/genworldvoronoi/various"
+)
+
+// Benchmark parameters.
+const (
+	benchSeed = 1234
+	benchZoom = 3
+)
+
+// benchSizes are the numbers of points the benchmarks are run with.
+var benchSizes = []int{10000, 50000, 100000}
+
+func TestMain(m *testing.M) {
+	// Silence the progress logs of the generation.
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// benchMaps caches the worlds used by the endpoint benchmarks.
+var benchMaps = make(map[int]*Map)
+
+// getBenchMap returns the (cached) world with the given number of points.
+func getBenchMap(b *testing.B, numPoints int) *Map {
+	if m, ok := benchMaps[numPoints]; ok {
+		return m
+	}
+	cfg := NewConfig()
+	cfg.GeoConfig.NumPoints = numPoints
+	m, err := NewMapFromConfig(benchSeed, cfg)
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchMaps[numPoints] = m
+	return m
+}
+
+// benchSizesRun runs fn as a sub-benchmark for each of the benchmark sizes.
+func benchSizesRun(b *testing.B, fn func(b *testing.B, m *Map)) {
+	for _, n := range benchSizes {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			fn(b, getBenchMap(b, n))
+		})
+	}
+}
+
+// benchEndpoint runs the given endpoint b.N times on the benchmark worlds.
+func benchEndpoint(b *testing.B, fn func(m *Map)) {
+	benchSizesRun(b, func(b *testing.B, m *Map) {
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			fn(m)
+		}
+	})
+}
+
+// stageTotal holds the summed up measurements of a generation stage.
+type stageTotal struct {
+	dur    time.Duration
+	allocs uint64
+	bytes  uint64
+}
+
+// BenchmarkGenerateStages generates whole worlds and reports the duration
+// and the allocations of each stage of GenerateGeology, GenerateCivilization
+// and GenerateBiology per world, as recorded by various.RecordStages.
+//
+// Run with: go test -run NONE -bench GenerateStages -benchtime 3x -timeout 0
+func BenchmarkGenerateStages(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			defer various.StopRecordingStages()
+			totals := make(map[string]*stageTotal)
+			var names []string
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				rec := various.RecordStages()
+				cfg := NewConfig()
+				cfg.GeoConfig.NumPoints = n
+				if _, err := NewMapFromConfig(benchSeed, cfg); err != nil {
+					b.Fatal(err)
+				}
+				for _, st := range rec.Stages {
+					t, ok := totals[st.Name]
+					if !ok {
+						t = &stageTotal{}
+						totals[st.Name] = t
+						names = append(names, st.Name)
+					}
+					t.dur += st.Duration
+					t.allocs += st.Allocs
+					t.bytes += st.Bytes
+				}
+			}
+
+			// Metric units must not contain white space.
+			for _, name := range names {
+				t := totals[name]
+				unit := strings.ReplaceAll(name, " ", "_")
+				b.ReportMetric(float64(t.dur.Nanoseconds())/float64(b.N), unit+"-ns/op")
+				b.ReportMetric(float64(t.allocs)/float64(b.N), unit+"-allocs/op")
+				b.ReportMetric(float64(t.bytes)/float64(b.N), unit+"-B/op")
+			}
+		})
+	}
+}
+
+func BenchmarkGetTile(b *testing.B) {
+	x, y := 1<<benchZoom/2, 1<<benchZoom/2
+	b.Run("Biomes", func(b *testing.B) {
+		benchEndpoint(b, func(m *Map) {
+			m.GetTile(x, y, benchZoom, 1, 0, true, true, true, true, false, false)
+		})
+	})
+
+	// Display mode 0 uses the refined terrain at high zoom levels, which is
+	// cached after the first request. We benchmark the refinement (with an
+	// empty cache) and the cache hits separately.
+	rx, ry := 1<<tileRefineMinZoom/2, 1<<tileRefineMinZoom/2
+	b.Run("Refine", func(b *testing.B) {
+		benchSizesRun(b, func(b *testing.B, m *Map) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				m.TileCache = NewTileCache(m.BaseObject)
+				b.StartTimer()
+				m.GetTile(rx, ry, tileRefineMinZoom, 0, 0, true, true, true, true, false, false)
+			}
+		})
+	})
+	b.Run("Cached", func(b *testing.B) {
+		benchSizesRun(b, func(b *testing.B, m *Map) {
+			m.TileCache = NewTileCache(m.BaseObject)
+			m.GetTile(rx, ry, tileRefineMinZoom, 0, 0, true, true, true, true, false, false)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				m.GetTile(rx, ry, tileRefineMinZoom, 0, 0, true, true, true, true, false, false)
+			}
+		})
+	})
+}
+
+func BenchmarkGet3DTile(b *testing.B) {
+	x, y := 1<<benchZoom/2, 1<<benchZoom/2
+	benchEndpoint(b, func(m *Map) { m.Get3DTile(x, y, benchZoom) })
+}
+
+func BenchmarkGetHeightMapTile(b *testing.B) {
+	x, y := 1<<benchZoom/2, 1<<benchZoom/2
+	benchEndpoint(b, func(m *Map) { m.GetHeightMapTile(x, y, benchZoom) })
+}
+
+func BenchmarkGetGeoJSONCities(b *testing.B) {
+	benchEndpoint(b, func(m *Map) { m.GetGeoJSONCities(-85, -180, 85, 180, benchZoom) })
+}
+
+func BenchmarkGetGeoJSONBorders(b *testing.B) {
+	benchEndpoint(b, func(m *Map) { m.GetGeoJSONBorders(-85, -180, 85, 180, benchZoom, 0) })
+}
+
+func BenchmarkGetGeoJSONFeatures(b *testing.B) {
+	benchEndpoint(b, func(m *Map) { m.GetGeoJSONFeatures(-85, -180, 85, 180, benchZoom) })
+}
+
+func BenchmarkGetGeoJSONStormTracks(b *testing.B) {
+	benchEndpoint(b, func(m *Map) { m.GetGeoJSONStormTracks(-85, -180, 85, 180, benchZoom) })
+}
+
+// worldHeapBudgetMB is the maximum live heap of a world with 1M regions.
+const worldHeapBudgetMB = 1024
+
+// BenchmarkWorldHeap reports the live heap of generated worlds in MB, with
+// float64 and with float32 storage. It fails if a world with 1M regions
+// exceeds worldHeapBudgetMB.
+//
+// Run with: go test -run NONE -bench WorldHeap -benchtime 1x -timeout 0
+func BenchmarkWorldHeap(b *testing.B) {
+	for _, n := range []int{100000, 1000000} {
+		for _, lowPrecision := range []bool{false, true} {
+			name := strconv.Itoa(n) + "/float64"
+			if lowPrecision {
+				name = strconv.Itoa(n) + "/float32"
+			}
+			b.Run(name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					// Collect garbage first, so only the heap of the world is measured.
+					var before, after runtime.MemStats
+					runtime.GC()
+					runtime.ReadMemStats(&before)
+
+					cfg := NewConfig()
+					cfg.GeoConfig.NumPoints = n
+					cfg.GeoConfig.Float32Storage = lowPrecision
+					m, err := NewMapFromConfig(benchSeed, cfg)
+					if err != nil {
+						b.Fatal(err)
+					}
+
+					runtime.GC()
+					runtime.ReadMemStats(&after)
+					runtime.KeepAlive(m)
+					heapMB := float64(after.HeapAlloc-before.HeapAlloc) / (1 << 20)
+					b.ReportMetric(heapMB, "heap-MB")
+					if n >= 1000000 && heapMB > worldHeapBudgetMB {
+						b.Fatalf("world with %d regions uses %.0f MB, budget is %d MB", n, heapMB, worldHeapBudgetMB)
+					}
+				}
+			})
+		}
+	}
+}
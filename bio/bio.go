@@ -38,7 +38,9 @@ func NewBio(geo *geo.Geo, cfg *BioConfig) *Bio {
 
 func (b *Bio) GenerateBiology() {
 	// Calculate the duration of the potential growth period for each region.
+	start := various.StartStage()
 	b.calcGrowthPeriod()
+	various.LogStage("growth period", start)
 
 	// TODO: Calculate a score for each region that reflects how well
 	// suited it is for agriculture during the growth period. This
@@ -60,14 +62,19 @@ func (b *Bio) GenerateBiology() {
 		b.placeAllSpecies(KingdomFlora)
 		b.placeAllSpecies(KingdomFungi)
 	*/
+	start = various.StartStage()
 	b.placeAllSpecies(GenusCereal)
 
 	// Generate the species.
 	if b.EnableRandomSpecies {
 		b.genNRandomSpecies(b.NumSpecies)
 	}
+	various.LogStage("placing species", start)
+
+	start = various.StartStage()
 	b.SpeciesRegions = b.expandSpecies()
 	b.SpeciesFamilyToRegions = b.expandSpecies2()
+	various.LogStage("expanding species", start)
 }
 
 // calcGrowthPeriod calculates the duration of the potential growth
@@ -81,7 +88,7 @@ func (b *Bio) calcGrowthPeriod() {
 	useGoRoutines := true
 	// Use go routines to process a chunk of regions at a time.
 	if useGoRoutines {
-		various.KickOffChunkWorkers(b.SphereMesh.NumRegions, b.calcGrowthPeriodChunk)
+		b.GetExecutor().ParallelFor(b.SphereMesh.NumRegions, b.calcGrowthPeriodChunk)
 	} else {
 		b.calcGrowthPeriodChunk(0, b.SphereMesh.NumRegions)
 	}
@@ -93,7 +100,7 @@ func (b *Bio) calcGrowthPeriodChunk(start, end int) {
 	for r := start; r < end; r++ {
 		var growthDays int
 		var totalInsolation float64
-		for i := 0; i < 356; i++ {
+		for i := 1; i <= b.DaysPerYear; i++ {
 			// Calculate daily average temperature.
 			min, max := b.GetMinMaxTemperatureOfDay(b.LatLon[r][0], i)
 			avg := (min + max) / 2
@@ -102,9 +109,9 @@ func (b *Bio) calcGrowthPeriodChunk(start, end int) {
 			// is above 0. This is not correct, as we should be counting days
 			// where the average temperature is above a certain minimum.
 			// We should also take in account when there is precipitation.
-			if avg > 0 && b.Rainfall[r] > 0 {
+			if avg > 0 && b.Rainfall.At(r) > 0 {
 				growthDays++
-				totalInsolation += geo.CalcSolarRadiation(b.LatLon[r][0], i)
+				totalInsolation += b.SolarRadiation(various.DegToRad(b.LatLon[r][0]), i)
 			}
 		}
 		b.GrowthDays[r] = growthDays
@@ -0,0 +1,50 @@
+package bio
+
+import "github.com/Flokey82/genworldvoronoi/geo"
+
+// speciesWipeOutIntensity is the intensity of a disaster above which the local
+// population of a species is wiped out.
+const speciesWipeOutIntensity = 0.8
+
+// DisasterImpact is the impact of a disaster event on a species.
+type DisasterImpact struct {
+	Species  *Species // Affected species
+	Regions  int      // Number of affected regions
+	WipedOut int      // Number of regions where the local population was wiped out
+}
+
+// ApplyDisasterEvent applies the given disaster event to all species within
+// its footprint and returns the impact on each affected species (in the order
+// of b.Species).
+//
+// Where the intensity of the disaster is high enough, the local population of
+// the species is wiped out and the region is no longer inhabited.
+func (b *Bio) ApplyDisasterEvent(ev *geo.DisasterEvent) []*DisasterImpact {
+	// The species regions are identified by the origin of the species.
+	impactByOrigin := make(map[int]*DisasterImpact)
+	for _, r := range ev.GetRegions() {
+		origin := b.SpeciesRegions[r]
+		if origin < 0 {
+			continue
+		}
+		impact, ok := impactByOrigin[origin]
+		if !ok {
+			impact = &DisasterImpact{}
+			impactByOrigin[origin] = impact
+		}
+		impact.Regions++
+		if ev.GetIntensity(r) >= speciesWipeOutIntensity {
+			b.SpeciesRegions[r] = -1
+			impact.WipedOut++
+		}
+	}
+
+	var impacts []*DisasterImpact
+	for _, s := range b.Species {
+		if impact, ok := impactByOrigin[s.Origin]; ok {
+			impact.Species = s
+			impacts = append(impacts, impact)
+		}
+	}
+	return impacts
+}
@@ -82,23 +82,29 @@ func (b *Bio) expandSpecies() []int {
 	// We might need to create a full index of all regions for each unique
 	// competition hash.... or, which is more wasteful, per species.
 	var seedPoints []int
-	originToSpecFit := make(map[int]func(int) float64)
 	for _, s := range b.Species {
 		seedPoints = append(seedPoints, s.Origin)
-		originToSpecFit[s.Origin] = b.getToleranceScoreFunc(s.SpeciesTolerances)
+	}
+
+	// The environment of the regions is shared by all tolerance score
+	// functions, so we only calculate it once.
+	env := b.newToleranceEnv()
+	originToSpecFit := make(map[int]func(int) float64)
+	for _, s := range b.Species {
+		originToSpecFit[s.Origin] = b.getToleranceScoreFuncWithEnv(env, s.SpeciesTolerances)
 	}
 	var queue geo.AscPriorityQueue
 	heap.Init(&queue)
 	outReg := make([]int, 0, 8)
 
 	// Get maxFlux and maxElev for normalizing.
-	_, maxFlux := minMax(b.Flux)
-	_, maxElev := minMax(b.Elevation)
+	_, maxFlux := b.Flux.MinMax()
+	_, maxElev := b.Elevation.MinMax()
 
 	// TODO: Move this to a generic function.
 	terrainWeight := func(o, u, v int) float64 {
 		// Don't cross from water to land and vice versa.
-		if (b.Elevation[u] > 0) != (b.Elevation[v] > 0) {
+		if (b.Elevation.At(u) > 0) != (b.Elevation.At(v) > 0) {
 			return -1
 		}
 
@@ -110,7 +116,7 @@ func (b *Bio) expandSpecies() []int {
 		horiz := various.Haversine(ulat, ulon, vlat, vlon) / (2 * math.Pi)
 
 		// Calculate vertical distance.
-		vert := (b.Elevation[v] - b.Elevation[u]) / maxElev
+		vert := (b.Elevation.At(v) - b.Elevation.At(u)) / maxElev
 		if vert > 0 {
 			vert /= 10
 		}
@@ -118,8 +124,8 @@ func (b *Bio) expandSpecies() []int {
 
 		// NOTE: Flux should only apply to animals since plants and fungi
 		// don't need to worry about drowning.
-		diff += 100 * math.Sqrt(b.Flux[u]/maxFlux)
-		if b.Elevation[u] <= 0 {
+		diff += 100 * math.Sqrt(b.Flux.At(u)/maxFlux)
+		if b.Elevation.At(u) <= 0 {
 			diff = 100
 		}
 		return horiz * diff
@@ -234,7 +240,7 @@ func (b *Bio) getSpeciesScores(s *Species) []float64 {
 	useGoRoutines := true
 	if useGoRoutines {
 		// Use goroutines to process the chunks.
-		various.KickOffChunkWorkers(b.SphereMesh.NumRegions, chunkProcessor)
+		b.GetExecutor().ParallelFor(b.SphereMesh.NumRegions, chunkProcessor)
 	} else {
 		// Use the main thread to process the chunks.
 		chunkProcessor(0, b.SphereMesh.NumRegions)
@@ -20,9 +20,10 @@ func (b *Bio) expandSpecies2() map[SpeciesFamily][]int {
 	var seedPoints []int
 	originToSpecFit := make(map[int]func(int) float64)
 	originToSecies := make(map[int]*Species)
+	env := b.newToleranceEnv()
 	for _, s := range b.Species {
 		seedPoints = append(seedPoints, s.Origin)
-		originToSpecFit[s.Origin] = b.getToleranceScoreFunc(s.SpeciesTolerances)
+		originToSpecFit[s.Origin] = b.getToleranceScoreFuncWithEnv(env, s.SpeciesTolerances)
 		originToSecies[s.Origin] = s
 	}
 	var queue geo.AscPriorityQueue
@@ -30,13 +31,13 @@ func (b *Bio) expandSpecies2() map[SpeciesFamily][]int {
 	outReg := make([]int, 0, 8)
 
 	// Get maxFlux and maxElev for normalizing.
-	_, maxFlux := minMax(b.Flux)
-	_, maxElev := minMax(b.Elevation)
+	_, maxFlux := b.Flux.MinMax()
+	_, maxElev := b.Elevation.MinMax()
 
 	// TODO: Move this to a generic function.
 	terrainWeight := func(o, u, v int) float64 {
 		// Don't cross from water to land and vice versa.
-		if (b.Elevation[u] > 0) != (b.Elevation[v] > 0) {
+		if (b.Elevation.At(u) > 0) != (b.Elevation.At(v) > 0) {
 			return -1
 		}
 
@@ -48,7 +49,7 @@ func (b *Bio) expandSpecies2() map[SpeciesFamily][]int {
 		horiz := various.Haversine(ulat, ulon, vlat, vlon) / (2 * math.Pi)
 
 		// Calculate vertical distance.
-		vert := (b.Elevation[v] - b.Elevation[u]) / maxElev
+		vert := (b.Elevation.At(v) - b.Elevation.At(u)) / maxElev
 		if vert > 0 {
 			vert /= 10
 		}
@@ -56,8 +57,8 @@ func (b *Bio) expandSpecies2() map[SpeciesFamily][]int {
 
 		// NOTE: Flux should only apply to animals since plants and fungi
 		// don't need to worry about drowning.
-		diff += 100 * math.Sqrt(b.Flux[u]/maxFlux)
-		if b.Elevation[u] <= 0 {
+		diff += 100 * math.Sqrt(b.Flux.At(u)/maxFlux)
+		if b.Elevation.At(u) <= 0 {
 			diff = 100
 		}
 		return horiz * diff
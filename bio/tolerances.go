@@ -6,6 +6,7 @@ import (
 
 	"github.com/Flokey82/genbiome"
 	"github.com/Flokey82/genworldvoronoi/geo"
+	"github.com/Flokey82/genworldvoronoi/various"
 )
 
 // SpeciesTolerances defines the environmental tolerances of a species.
@@ -35,14 +36,64 @@ func (s *SpeciesTolerances) String() string {
 	return str
 }
 
+// toleranceEnv holds the environment of all regions, which is used to pick
+// and score the tolerances of species. Calculating it is expensive, so it
+// should be shared by all species.
+type toleranceEnv struct {
+	minElev     float64         // Minimum elevation
+	maxElev     float64         // Maximum elevation
+	maxHum      float64         // Maximum moisture
+	maxRain     float64         // Maximum rainfall
+	steepness   []float64       // Steepness of all regions
+	temperature []float64       // Temperature of all regions
+	biome       []int           // Whittaker biome of all regions
+	ecosphere   []EcosphereType // Ecosphere of all regions
+}
+
+// newToleranceEnv calculates the environment of all regions in parallel.
+func (b *Bio) newToleranceEnv() *toleranceEnv {
+	numRegions := b.SphereMesh.NumRegions
+
+	// Get the minimum and maximum values for normalizing in a single pass.
+	// [minElev, maxElev, maxHum, maxRain]
+	extremes := various.ParallelReduce(b.GetExecutor(), numRegions, func(start, end int) [4]float64 {
+		res := [4]float64{math.Inf(1), math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+		for r := start; r < end; r++ {
+			res[0] = math.Min(res[0], b.Elevation.At(r))
+			res[1] = math.Max(res[1], b.Elevation.At(r))
+			res[2] = math.Max(res[2], b.Moisture.At(r))
+			res[3] = math.Max(res[3], b.Rainfall.At(r))
+		}
+		return res
+	}, func(a, c [4]float64) [4]float64 {
+		return [4]float64{math.Min(a[0], c[0]), math.Max(a[1], c[1]), math.Max(a[2], c[2]), math.Max(a[3], c[3])}
+	})
+	env := &toleranceEnv{
+		minElev:     extremes[0],
+		maxElev:     extremes[1],
+		maxHum:      extremes[2],
+		maxRain:     extremes[3],
+		steepness:   b.GetSteepness(),
+		temperature: make([]float64, numRegions),
+		biome:       make([]int, numRegions),
+		ecosphere:   make([]EcosphereType, numRegions),
+	}
+	bf := b.GetRegWhittakerModBiomeFunc()
+	b.GetExecutor().ParallelFor(numRegions, func(start, end int) {
+		for r := start; r < end; r++ {
+			env.temperature[r] = b.GetRegTemperature(r, env.maxElev)
+			env.biome[r] = bf(r)
+			env.ecosphere[r] = b.GetEcosphere(r)
+		}
+	})
+	return env
+}
+
 func (b *Bio) getTolerancesForRegionFunc() func(int) SpeciesTolerances {
-	minElev, maxElev := minMax(b.Elevation)
-	_, maxHum := minMax(b.Moisture)
-	_, maxRain := minMax(b.Rainfall)
-	steep := b.GetSteepness()
+	env := b.newToleranceEnv()
 	return func(r int) SpeciesTolerances {
 		s := SpeciesTolerances{
-			Ecosphere: b.GetEcosphere(r),
+			Ecosphere: env.ecosphere[r],
 		}
 
 		// minMaxRange returns a min and max range for the given value with the given variance.
@@ -59,44 +110,45 @@ func (b *Bio) getTolerancesForRegionFunc() func(int) SpeciesTolerances {
 
 		// Prefered elevation range.
 		if s.Ecosphere == EcosphereTypeOcean {
-			s.ElevRange = minMaxRange(b.Elevation[r], minElev, 0, variation)
+			s.ElevRange = minMaxRange(b.Elevation.At(r), env.minElev, 0, variation)
 		} else {
-			s.ElevRange = minMaxRange(b.Elevation[r], 0, maxElev, variation)
+			s.ElevRange = minMaxRange(b.Elevation.At(r), 0, env.maxElev, variation)
 		}
 
 		// Preferred temperature range.
-		s.TempRange = minMaxRange(b.GetRegTemperature(r, maxElev), float64(geo.MinTemp), float64(geo.MaxTemp), variation)
+		s.TempRange = minMaxRange(env.temperature[r], float64(geo.MinTemp), float64(geo.MaxTemp), variation)
 
 		// Preferred humidity range.
-		s.HumRange = minMaxRange(b.Moisture[r]/maxHum, 0, 1, variation)
+		s.HumRange = minMaxRange(b.Moisture.At(r)/env.maxHum, 0, 1, variation)
 
 		// Preferred rain range.
-		s.RainRange = minMaxRange(geo.MaxPrecipitation*b.Rainfall[r]/maxRain, 0, geo.MaxPrecipitation, variation)
+		s.RainRange = minMaxRange(geo.MaxPrecipitation*b.Rainfall.At(r)/env.maxRain, 0, geo.MaxPrecipitation, variation)
 
 		// Preferred steepness range.
-		s.SteepRange = minMaxRange(steep[r], 0, 1, variation)
+		s.SteepRange = minMaxRange(env.steepness[r], 0, 1, variation)
 
 		// If we are not in the ocean, we probably have a preferred biome.
 		if s.Ecosphere != EcosphereTypeOcean && b.rand.Float64() < 0.7 {
-			s.PreferredBiomes = []int{b.GetRegWhittakerModBiomeFunc()(r)}
+			s.PreferredBiomes = []int{env.biome[r]}
 		}
 		return s
 	}
 }
 
 func (b *Bio) getToleranceScoreFunc(s SpeciesTolerances) func(int) float64 {
-	_, maxElev := minMax(b.Elevation)
-	_, maxHum := minMax(b.Moisture)
-	_, maxRain := minMax(b.Rainfall)
-	bf := b.GetRegWhittakerModBiomeFunc()
-	steepness := b.GetSteepness()
+	return b.getToleranceScoreFuncWithEnv(b.newToleranceEnv(), s)
+}
+
+// getToleranceScoreFuncWithEnv returns the fitness function for the given
+// tolerances using the given (shared) environment.
+func (b *Bio) getToleranceScoreFuncWithEnv(env *toleranceEnv, s SpeciesTolerances) func(int) float64 {
 	return func(r int) float64 { // Check what ecosphere we are in and if it matches the species.
-		if !s.Ecosphere.isSet(b.GetEcosphere(r)) {
+		if !s.Ecosphere.isSet(env.ecosphere[r]) {
 			return -1.0
 		}
 
 		// Check if we require a specific biome.
-		if len(s.PreferredBiomes) > 0 && !isInIntList(s.PreferredBiomes, bf(r)) {
+		if len(s.PreferredBiomes) > 0 && !isInIntList(s.PreferredBiomes, env.biome[r]) {
 			return -1
 		}
 
@@ -104,7 +156,7 @@ func (b *Bio) getToleranceScoreFunc(s SpeciesTolerances) func(int) float64 {
 
 		// Check how much we diverge from the preferred temperature range.
 		if isRangeSet(s.TempRange) {
-			tempScore = getRangeFit(b.GetRegTemperature(r, maxElev), s.TempRange)
+			tempScore = getRangeFit(env.temperature[r], s.TempRange)
 			if tempScore == -1 {
 				return -1
 			}
@@ -114,7 +166,7 @@ func (b *Bio) getToleranceScoreFunc(s SpeciesTolerances) func(int) float64 {
 
 		// Check how much we diverge from the preferred humidity range.
 		if isRangeSet(s.HumRange) {
-			humScore = getRangeFit(b.Moisture[r]/maxHum, s.HumRange)
+			humScore = getRangeFit(b.Moisture.At(r)/env.maxHum, s.HumRange)
 			if humScore == -1 {
 				return -1
 			}
@@ -124,7 +176,7 @@ func (b *Bio) getToleranceScoreFunc(s SpeciesTolerances) func(int) float64 {
 
 		// Check how much we diverge from the preferred rain range.
 		if isRangeSet(s.RainRange) {
-			rainScore = getRangeFit(geo.MaxPrecipitation*b.Rainfall[r]/maxRain, s.RainRange)
+			rainScore = getRangeFit(geo.MaxPrecipitation*b.Rainfall.At(r)/env.maxRain, s.RainRange)
 			if rainScore == -1 {
 				return -1
 			}
@@ -134,7 +186,7 @@ func (b *Bio) getToleranceScoreFunc(s SpeciesTolerances) func(int) float64 {
 
 		// Check how much we diverge from the preferred elevation range.
 		if isRangeSet(s.ElevRange) {
-			elevScore = getRangeFit(b.Elevation[r], s.ElevRange)
+			elevScore = getRangeFit(b.Elevation.At(r), s.ElevRange)
 			if elevScore == -1 {
 				return -1
 			}
@@ -144,7 +196,7 @@ func (b *Bio) getToleranceScoreFunc(s SpeciesTolerances) func(int) float64 {
 
 		// Check how much we diverge from the preferred steepness range.
 		if isRangeSet(s.SteepRange) {
-			steepScore = getRangeFit(steepness[r], s.SteepRange)
+			steepScore = getRangeFit(env.steepness[r], s.SteepRange)
 			if steepScore == -1 {
 				return -1
 			}
@@ -179,7 +231,7 @@ func (e EcosphereType) isSet(t EcosphereType) bool {
 // GetEcosphere returns the ecosphere of the given region.
 func (b *Bio) GetEcosphere(r int) EcosphereType {
 	// Get the ecosphere we are in.
-	if b.Elevation[r] <= 0.0 {
+	if b.Elevation.At(r) <= 0.0 {
 		return EcosphereTypeOcean
 	}
 	if b.IsRegRiver(r) {
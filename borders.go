@@ -13,7 +13,7 @@ func (m *Map) getLakeBorders() [][]int {
 // getCustomBorders returns the borders/contours of all region in the supplied slice that have the same value.
 func (m *Map) getCustomBorders(regionToID []int) [][]int {
 	return m.GetCustomContour(func(idxA, idxB int) bool {
-		if m.Elevation[idxA] < 0 || m.Elevation[idxB] < 0 ||
+		if m.Elevation.At(idxA) < 0 || m.Elevation.At(idxB) < 0 ||
 			(regionToID[idxA] < 0 && regionToID[idxB] < 0) {
 			return false
 		}
@@ -25,6 +25,6 @@ func (m *Map) getCustomBorders(regionToID []int) [][]int {
 // as list of triangle center points.
 func (m *Map) getLandmassBorders() [][]int {
 	return m.GetCustomContour(func(idxA, idxB int) bool {
-		return m.Elevation[idxA] >= 0 && m.Elevation[idxB] < 0 || m.Elevation[idxA] < 0 && m.Elevation[idxB] >= 0
+		return m.Elevation.At(idxA) >= 0 && m.Elevation.At(idxB) < 0 || m.Elevation.At(idxA) < 0 && m.Elevation.At(idxB) >= 0
 	})
 }
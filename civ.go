@@ -2,12 +2,15 @@ package genworldvoronoi
 
 import (
 	"container/heap"
+	"fmt"
 	"log"
-	"time"
+	"math"
+	"math/rand"
 
 	"github.com/Flokey82/genbiome"
 	"github.com/Flokey82/genideas/genlandmarknames"
 	"github.com/Flokey82/genworldvoronoi/geo"
+	"github.com/Flokey82/genworldvoronoi/various"
 )
 
 type Civ struct {
@@ -29,6 +32,15 @@ type Civ struct {
 	// SettledBySpecies []int // (cultural) Which species settled the region first
 	NameGen     *genlandmarknames.NameGenerators
 	TradeRoutes [][]int
+
+	// OnDisasterEvent is called for each simulated disaster event after it
+	// has been applied to the cities and people (e.g. to apply it to the
+	// species of the map).
+	OnDisasterEvent func(ev *geo.DisasterEvent)
+
+	disasterEventFunc func(rnd *rand.Rand) []*geo.DisasterEvent // Cached disaster event generator
+	routePlanners     map[TravelMode]*RoutePlanner              // Cached route planners (see getRoutePlanner)
+	regionToFeature   []*geo.Feature                            // Most specific named land feature per region (see getFeatureName)
 }
 
 func NewCiv(g *geo.Geo, cfg *CivConfig) *Civ {
@@ -66,33 +78,39 @@ func (m *Civ) GenerateCivilization() {
 	// 9. Generate empires.
 
 	// Place cultures (and folk religions).
-	start := time.Now()
+	start := various.StartStage()
 	m.PlaceNCultures(m.NumCultures)
-	log.Println("Done cultures in ", time.Since(start).String())
+	various.LogStage("cultures", start)
+
+	// Name the geographic features after the local cultures.
+	start = various.StartStage()
+	m.nameFeatures()
+	various.LogStage("naming features", start)
 
 	// Place / expand folk religions.
-	start = time.Now()
+	start = various.StartStage()
 	m.PlaceNFolkReligions(m.NumCultures)
-	log.Println("Done expanding religions in ", time.Since(start).String())
+	various.LogStage("expanding religions", start)
 
 	// Place cities and territories in regions.
 	// TODO: Smaller towns should be found in the vicinity of larger cities.
-	start = time.Now()
+	start = various.StartStage()
 	m.PlaceNCities(m.NumCities, TownTypeDefault)
 	m.PlaceNCities(m.NumFarmingTowns, TownTypeFarming)
 	m.PlaceNCities(m.NumDesertOasis, TownTypeDesertOasis)
 	m.PlaceNCities(m.NumMiningTowns, TownTypeMining)
 	m.PlaceNCities(m.NumMiningGemsTowns, TownTypeMiningGems)
 	m.PlaceNCities(m.NumQuarryTowns, TownTypeQuarry)
-	log.Println("Done cities in ", time.Since(start).String())
+	m.PlaceNCities(m.NumPortTowns, TownTypePort)
+	various.LogStage("cities", start)
 
-	start = time.Now()
+	start = various.StartStage()
 	m.PlaceNCityStates(m.NumCityStates)
-	log.Println("Done city states in ", time.Since(start).String())
+	various.LogStage("city states", start)
 
-	start = time.Now()
+	start = various.StartStage()
 	m.PlaceNEmpires(m.NumEmpires)
-	log.Println("Done empires in ", time.Since(start).String())
+	various.LogStage("empires", start)
 
 	// Once we have established the territories, we can add trade towns
 	// (we need the territories for the trade routes).
@@ -100,45 +118,47 @@ func (m *Civ) GenerateCivilization() {
 	// that the trade towns will still be placed on the nexus points
 	// where trade routes meet.
 	if m.NumTradingTowns > 0 {
-		start = time.Now()
+		start = various.StartStage()
 		m.PlaceNCities(m.NumTradingTowns, TownTypeTrading)
-		log.Println("Done trade cities in ", time.Since(start).String())
+		various.LogStage("trade cities", start)
 	}
 
 	_, maxSettled := minMax64(m.Settled)
 	m.Geo.Calendar.SetYear(maxSettled)
 
-	start = time.Now()
+	start = various.StartStage()
 	m.calculateAgriculturalPotential(m.Cities)
-	log.Println("Done calculating agricultural potential in ", time.Since(start).String())
+	various.LogStage("calculating agricultural potential", start)
 
-	start = time.Now()
+	start = various.StartStage()
 	m.calculateAttractiveness(m.Cities)
-	log.Println("Done calculating attractiveness in ", time.Since(start).String())
+	various.LogStage("calculating attractiveness", start)
 
-	start = time.Now()
+	start = various.StartStage()
 	m.calculateResourcePotential(m.Cities)
-	log.Println("Done calculating resource potential in ", time.Since(start).String())
+	various.LogStage("calculating resource potential", start)
 
-	start = time.Now()
+	start = various.StartStage()
 	m.calculateEconomicPotential()
-	log.Println("Done calculating economic potential in ", time.Since(start).String())
+	various.LogStage("calculating economic potential", start)
 
 	// Age cities as they are founded, like good cheese.
 	// TODO: We should also introduce some kind of "aging" of city states or empires
 	// to generate some history.
 	if m.EnableCityAging {
-		start = time.Now()
+		start = various.StartStage()
 		m.ageCities()
-		log.Println("Done aging cities in ", time.Since(start).String())
+		various.LogStage("aging cities", start)
 	}
 
 	// Organized religions.
 	if m.EnableOrganizedReligions {
+		start = various.StartStage()
 		m.PlaceNOrganizedReligions(m.NumOrganizedReligions)
 		for _, r := range m.Religions {
 			log.Println(r.String())
 		}
+		various.LogStage("organized religions", start)
 	}
 }
 
@@ -153,6 +173,24 @@ func (m *Civ) Tick() {
 	//	m.TickCity(c)
 	// }
 
+	// Extract resources from the deposits and handle wildfires.
+	for _, c := range m.getExistingCities() {
+		m.tickCityMining(c, 1)
+
+		// Settlers might start a wildfire (clearing land, campfires, ...).
+		if m.Rand.Float64() < m.SettlementFireChance {
+			if m.IgniteWildfire(c.ID) {
+				m.AddEvent("Wildfire", fmt.Sprintf("A wildfire broke out near %s", c.Name), c.Ref())
+			}
+		}
+
+		// Wildfires burning in the city's region might reach the city.
+		if m.IsRegBurning(c.ID) && m.Rand.Float64() < m.FireIntensity[c.ID] {
+			intensity := m.FireIntensity[c.ID]
+			m.applyCityDisaster(c, geo.DisWildfire, geo.DisWildfire.PopulationLoss*intensity)
+		}
+	}
+
 	// TODO:
 	// Update city states.
 	// 1: Update wealth / taxation.
@@ -176,8 +214,21 @@ func (m *Civ) Tick() {
 	// city states or empires...
 }
 
+// onClimateChanged is called after the climate of the map has changed (see
+// geo.Geo.OnClimateChanged). It names new features and discards the cached
+// route planners and disaster event generator, which depend on the old
+// terrain and climate.
+func (m *Civ) onClimateChanged() {
+	m.nameFeatures()
+	m.resetRoutePlanners()
+	m.disasterEventFunc = nil
+}
+
 // getRegName attempts to generate a name for the given region.
 func (m *Civ) getRegName(r int) string {
+	if name := m.getFeatureName(r); name != "" {
+		return name
+	}
 	switch m.GetRegWhittakerModBiomeFunc()(r) {
 	case genbiome.WhittakerModBiomeBorealForestTaiga,
 		genbiome.WhittakerModBiomeTemperateRainforest,
@@ -192,6 +243,30 @@ func (m *Civ) getRegName(r int) string {
 	return ""
 }
 
+// getRegionsWithID returns all regions with the given ID in the given region
+// to ID mapping (e.g. RegionToEmpire).
+func getRegionsWithID(regToID []int, id int) []int {
+	var regs []int
+	for r, rid := range regToID {
+		if rid == id {
+			regs = append(regs, r)
+		}
+	}
+	return regs
+}
+
+// getRegionsByID returns the regions for each ID in the given region to ID
+// mapping (e.g. RegionToEmpire) in a single pass.
+func getRegionsByID(regToID []int) map[int][]int {
+	regsByID := make(map[int][]int)
+	for r, id := range regToID {
+		if id >= 0 {
+			regsByID[id] = append(regsByID[id], r)
+		}
+	}
+	return regsByID
+}
+
 func (m *Civ) GenerateTimeOfSettlement() {
 	// First we pick a "suitable" region where the cradle of civilization
 	// will be located.
@@ -241,10 +316,23 @@ func (m *Civ) GenerateTimeOfSettlement() {
 
 	// terrainWeight returns high scores for difficult terrain.
 	terrainWeight := m.getTerritoryWeightFunc()
+	circumference := m.DistToKm(2 * math.Pi)
 
 	// terrainArable returns high scores if the terrain is arable.
 	//terrainArable := m.getFitnessArableLand()
 
+	// isLandAt returns true if the region was land at the given settlement time.
+	// If land bridges are enabled, the sea level follows the glacial cycles
+	// starting at the configured year, so early settlers can cross regions that
+	// are only temporarily above sea level.
+	isLandAtSeaLevel := m.GetRegIsLandAtSeaLevelFunc()
+	isLandAt := func(r int, t int64) bool {
+		if !m.EnableLandBridges {
+			return m.Elevation.At(r) > 0
+		}
+		return isLandAtSeaLevel(r, geo.GetPaleoclimate(m.SettlementStartYear+t).SeaLevelOffset)
+	}
+
 	// TODO: The duration that it takes to settle a region should
 	// depend on how many regions there are in total (the size of
 	// the regions).
@@ -254,23 +342,29 @@ func (m *Civ) GenerateTimeOfSettlement() {
 		// that doesn't treat up- and downhill differently.
 		// Also, the penalty should be way higher for "impassable"
 		// terrain.
-		terrWeight := terrainWeight(bestRegion, u, v)
+		landU := isLandAt(u, settleTime[u])
+		landV := isLandAt(v, settleTime[u])
+		if landU && landV {
+			// If the terrain weight is positive (or zero), the destination region is land.
+			if terrWeight := terrainWeight(bestRegion, u, v, m.DistToKm(m.GetDistance(u, v))); terrWeight >= 0 {
+				// Settlement on land takes a fraction of 2000 years per (unit) region.
+				// 'terrWeight' already takes the actual distance between the regions
+				// (in km) into account, so we normalize it by the circumference.
+				return float64(settleTime[u]) + 2000*terrWeight/circumference // * (1-terrainArable(v))
+			}
 
-		// If the terrain weight is positive (or zero), the destination region is land.
-		var timeReqired float64
-		if terrWeight >= 0 {
-			// Settlement on land takes a fraction of 2000 years per (unit) region.
-			// 'terrWeight' already takes the actual distance between the regions
-			// into account.
-			return float64(settleTime[u]) + 2000*terrWeight // * (1-terrainArable(v))
+			// We are crossing a land bridge (the sea floor exposed by the low
+			// sea level), which is flat, so we only take the distance into account.
+			return float64(settleTime[u]) + 2000*m.GetDistance(u, v)/(2*math.Pi)
 		}
 
-		// If the terrain weight is negative, the source- and/or destination region is ocean.
-		// This means, we need boats to get there, which will require more time.
-		if m.Elevation[v] > 0 {
+		// If the source- and/or destination region is ocean, we need boats
+		// to get there, which will require more time.
+		var timeReqired float64
+		if landV {
 			// If we were at sea and arrive at land, we only need a year to disembark.
 			timeReqired = 1
-		} else if (m.Elevation[v] <= 0) && (m.Elevation[u] <= 0) {
+		} else if !landU {
 			// Once we are traveling at sea, we travel at a speed of 20 years
 			// per (unit) region.
 			timeReqired = 20
@@ -3,8 +3,6 @@ package genworldvoronoi
 import (
 	"log"
 	"math"
-
-	"github.com/Flokey82/genworldvoronoi/geo"
 )
 
 // GetCity returns the city at the given region / with the given ID.
@@ -52,9 +50,12 @@ func (m *Civ) ageCities() {
 			// }
 
 			// Age the city.
-			m.tickCityDays(c, gDisFunc, cultureFunc, 365)
+			m.tickCityDays(c, gDisFunc, cultureFunc, m.DaysPerYear)
 		}
 
+		// Simulate the earthquakes, eruptions and floods of this year.
+		m.tickDisasterEvents(m.DaysPerYear)
+
 		// Update attractiveness, agricultural potential, and resource potential
 		// for new cities.
 		if len(m.Cities) > knownCities {
@@ -126,13 +127,17 @@ func (m *Civ) calculateEconomicPotential() {
 	for _, c := range cities {
 		// The base radius is dependent on the population.
 		// ... allow for at least two regions distance.
-		radius := c.radius() + 2*distRegion
+		radius := c.radius(m.Circumference()) + 2*distRegion
 		tradeRadius = append(tradeRadius, radius)
 	}
 
 	economicPotential := make([]float64, len(cities))
 	for i, c := range cities {
 		economicPotential[i] = c.PotentialResources + c.PotentialAgricultural
+		if m.EnableIndustrialEra {
+			// Industry runs on coal, oil, gas and geothermal energy.
+			economicPotential[i] += c.PotentialEnergy
+		}
 	}
 
 	// Now we go through all the cities, and see if they might be able to
@@ -215,6 +220,7 @@ func (m *Civ) calculateCitiesStats(cities []*City) {
 	m.calculateAttractiveness(cities)
 	m.calculateAgriculturalPotential(cities)
 	m.calculateResourcePotential(cities)
+	m.calculateEnergyPotential(cities)
 }
 
 func (m *Civ) calculateAttractiveness(cities []*City) {
@@ -236,25 +242,46 @@ func (m *Civ) calculateAgriculturalPotential(cities []*City) {
 }
 
 func (m *Civ) calculateResourcePotential(cities []*City) {
-	// Now get the resource potential of all cities.
-	calcResourceValues := func(res []byte) {
-		for _, c := range cities {
-			// Sum up the normalized resource values.
-			c.PotentialResources += float64(geo.SumResources(res[c.ID])) / 36 // 36 is the maximum value.
+	// The maximum value of each resource type is the sum of the values of
+	// all (built-in and custom) resources of that type.
+	var types []int
+	maxValue := make(map[int]float64)
+	for _, def := range m.ResourceRegistry.Defs {
+		if _, ok := maxValue[def.Type]; !ok {
+			types = append(types, def.Type)
 		}
+		maxValue[def.Type] += def.Value
 	}
 
-	// Reset the resource potential.
+	// Now get the resource potential of all cities based on the value
+	// (resource, quality and remaining quantity) of the discovered local
+	// deposits. Each resource type contributes at most 1.
 	for _, c := range cities {
-		c.PotentialResources = 0
+		value := make(map[int]float64)
+		for _, d := range m.GetDeposits(c.ID) {
+			if d.Discovered {
+				value[d.Type] += d.Value()
+			}
+		}
+		var potential float64
+		for _, t := range types {
+			if maxValue[t] > 0 {
+				potential += math.Min(1, value[t]/maxValue[t])
+			}
+		}
+		c.PotentialResources = potential
 	}
+}
 
-	// Calculate the resource potential for each resource.
-	calcResourceValues(m.Metals)
-	calcResourceValues(m.Gems)
-	calcResourceValues(m.Stones)
-	calcResourceValues(m.Wood)
-	calcResourceValues(m.Various)
+func (m *Civ) calculateEnergyPotential(cities []*City) {
+	// The energy potential is the best energy resource within reach of
+	// the city (the city's region and its neighbors).
+	for _, c := range cities {
+		c.PotentialEnergy = m.Energy[c.ID]
+		for _, nb := range m.GetRegNeighbors(c.ID) {
+			c.PotentialEnergy = math.Max(c.PotentialEnergy, m.Energy[nb])
+		}
+	}
 }
 
 func (m *Civ) getAttractivenessFunc() func(int) float64 {
@@ -280,7 +307,7 @@ func (m *Civ) CalcCityScore(sf func(int) float64, distSeedFunc func() []int) []f
 	sfCity := func(r int) float64 {
 		// If we are below (or at) sea level, or we are in a pool of water,
 		// assign lowest score and continue.
-		if m.Elevation[r] <= 0 || m.Waterpool[r] > 0 {
+		if m.Elevation.At(r) <= 0 || m.Waterpool.At(r) > 0 {
 			return -1.0
 		}
 		return sf(r)
@@ -292,7 +319,7 @@ func (m *Civ) CalcCityScoreWithDistanceField(sf func(int) float64, regDistanceC
 	sfCity := func(r int) float64 {
 		// If we are below (or at) sea level, or we are in a pool of water,
 		// assign lowest score and continue.
-		if m.Elevation[r] <= 0 || m.Waterpool[r] > 0 {
+		if m.Elevation.At(r) <= 0 || m.Waterpool.At(r) > 0 {
 			return -1.0
 		}
 		return sf(r)
@@ -306,13 +333,14 @@ func (m *Civ) getFitnessTradingTowns() func(int) float64 {
 	// proximity to towns of other types.
 	tradeRoutes, connecting := m.GetTradeRoutes()
 	m.TradeRoutes = tradeRoutes
+	m.resetRoutePlanners()
 	return func(r int) float64 {
 		return float64(len(connecting[r]))
 	}
 }
 
 func (m *Civ) getFitnessCityDefault() func(int) float64 {
-	_, maxFlux := minMax(m.Flux)
+	_, maxFlux := m.Flux.MinMax()
 	steepness := m.GetSteepness()
 
 	// WARNING: Using this will prevent us from using the fitness function concurrently.
@@ -321,7 +349,7 @@ func (m *Civ) getFitnessCityDefault() func(int) float64 {
 	return func(r int) float64 {
 		// If we are below (or at) sea level, or we are in a pool of water,
 		// assign lowest score and continue.
-		if m.Elevation[r] <= 0 || m.Waterpool[r] > 0 {
+		if m.Elevation.At(r) <= 0 || m.Waterpool.At(r) > 0 {
 			return -1.0
 		}
 
@@ -332,7 +360,7 @@ func (m *Civ) getFitnessCityDefault() func(int) float64 {
 		// Initialize fitness score with the normalized flux value.
 		// This will favor placing cities along (and at the end of)
 		// large rivers.
-		score := math.Sqrt(m.Flux[r] / maxFlux)
+		score := math.Sqrt(m.Flux.At(r) / maxFlux)
 		for _, nb := range nbs {
 			// Add bonus if near ocean or lake.
 			if m.IsRegBelowOrAtSeaLevelOrPool(nb) {
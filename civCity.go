@@ -8,7 +8,6 @@ import (
 	"sort"
 
 	"github.com/Flokey82/genworldvoronoi/geo"
-	"github.com/Flokey82/go_gens/gameconstants"
 	"github.com/Flokey82/go_gens/genlanguage"
 	"github.com/Flokey82/go_gens/utils"
 )
@@ -28,7 +27,7 @@ func (m *Civ) tickCityDays(c *City, gDisFunc func(int) geo.GeoDisasterChance, cf
 	}
 
 	// Check if a random disaster strikes.
-	if m.Rand.Intn(100*356) < days {
+	if m.Rand.Intn(100*m.DaysPerYear) < days {
 		m.tickCityDisaster(c, gDisFunc, days)
 	}
 
@@ -42,13 +41,16 @@ func (m *Civ) tickCityDays(c *City, gDisFunc func(int) geo.GeoDisasterChance, cf
 	// - Compare the actual population with the population we calculate
 	//   here and kill off people if the actual population is larger.
 
+	// Extract resources from the local deposits.
+	m.tickCityMining(c, days)
+
 	// Calculate the new population.
 
 	// This variant uses the logistic growth function, taking into account
 	// the carrying capacity of the city.
 	maxPop := float64(c.MaxPopulationLimit())
 	curPop := float64(c.Population)
-	newPop := (curPop * maxPop) / (curPop + (maxPop-curPop)*math.Pow(math.E, -c.PopulationGrowthRate()*float64(days)/365))
+	newPop := (curPop * maxPop) / (curPop + (maxPop-curPop)*math.Pow(math.E, -c.PopulationGrowthRate()*float64(days)/float64(m.DaysPerYear)))
 	c.Population = int(math.Ceil(newPop))
 
 	// This variant uses the exponential growth function.
@@ -103,7 +105,7 @@ func (m *Civ) tickCityDays(c *City, gDisFunc func(int) geo.GeoDisasterChance, cf
 	// chance that a new religion might be founded.
 	//
 	// TODO: Maybe keep note of an inciting event, like a famine, war, etc.
-	if c.Religion == nil && m.Rand.Intn(3000*356) < days && c.Population > 0 {
+	if c.Religion == nil && m.Rand.Intn(3000*m.DaysPerYear) < days && c.Population > 0 {
 		c.Religion = m.genOrganizedReligion(c)
 		m.ExpandReligions()
 		m.History.AddEvent("Religion", fmt.Sprintf("A new religion was founded in %s", c.Name), c.Ref())
@@ -131,6 +133,8 @@ func (m *Civ) getCityDisasters(c *City, gDisFunc func(int) geo.GeoDisasterChance
 		ds = append(ds, geo.DisRockslide, geo.DisCaveIn)
 	case TownTypeDesertOasis:
 		ds = append(ds, geo.DisSandstorm)
+	case TownTypePort:
+		ds = append(ds, geo.DisStorm)
 	}
 	ds = append(ds, geo.DisDrought, geo.DisFamine)
 	// With increasing population, the city is be more prone to famine
@@ -145,7 +149,16 @@ func (m *Civ) getCityDisasters(c *City, gDisFunc func(int) geo.GeoDisasterChance
 		ds = append(ds, geo.DisPlague)
 	}
 	// Append the region specific disasters and return.
-	return append(ds, gDisFunc(c.ID).GetDisasters()...)
+	// NOTE: If enabled, earthquakes, volcanic eruptions and floods are
+	// simulated as disaster events with a spatial footprint instead (see
+	// tickDisasterEvents).
+	gDis := gDisFunc(c.ID)
+	if m.DisasterEventsPerYear > 0 {
+		gDis.Earthquake = 0
+		gDis.Volcano = 0
+		gDis.Flood = 0
+	}
+	return append(ds, gDis.GetDisasters()...)
 }
 
 func (m *Civ) tickCityDisaster(c *City, gDisFunc func(int) geo.GeoDisasterChance, days int) {
@@ -156,9 +169,6 @@ func (m *Civ) tickCityDisaster(c *City, gDisFunc func(int) geo.GeoDisasterChance
 	// - Also the life expectancy might be low in early history.
 	// - Wars might also have a negative effect on the population.
 
-	// Enable / disable migration of population when a disaster strikes.
-	enableDisasterMigration := true
-
 	// Pick a random disaster given their respective probabilities.
 	cityDisasters := m.getCityDisasters(c, gDisFunc)
 	dis := geo.RandDisaster(cityDisasters)
@@ -168,6 +178,17 @@ func (m *Civ) tickCityDisaster(c *City, gDisFunc func(int) geo.GeoDisasterChance
 
 	// Calculate the population loss.
 	popLoss := dis.PopulationLoss * (2 + rand.Float64()) / 3
+	m.applyCityDisaster(c, dis, popLoss)
+}
+
+// applyCityDisaster reduces the population of the city by the given fraction
+// (0.0-1.0) due to the given disaster and logs the disaster.
+// Depending on the population loss, some of the survivors might leave the
+// city.
+func (m *Civ) applyCityDisaster(c *City, dis geo.Disaster, popLoss float64) {
+	// Enable / disable migration of population when a disaster strikes.
+	enableDisasterMigration := true
+
 	dead := int(math.Ceil(float64(c.Population) * popLoss))
 
 	// HACK: Kill the people that died in the disaster.
@@ -225,8 +246,9 @@ func (m *Civ) relocateFromCity(c *City, population int) {
 
 	// Calculate the analog of distance between regions by taking the surface
 	// of a sphere with radius 1 and dividing it by the number of regions.
-	// The square root will work as a somewhat sensible approximation of distance.
-	distRegion := math.Sqrt(4 * math.Pi / float64(m.SphereMesh.NumRegions))
+	// The square root will work as a somewhat sensible approximation of distance
+	// (which we convert to km to compare it to the length of the route).
+	distRegion := m.DistToKm(math.Sqrt(4 * math.Pi / float64(m.SphereMesh.NumRegions)))
 
 	// Per distRegion traversed, there is a defined chance of death.
 	calcChanceDeath := func(dist float64) float64 {
@@ -241,6 +263,9 @@ func (m *Civ) relocateFromCity(c *City, population int) {
 		return m.GetDistance(c.ID, cities[i].ID) < m.GetDistance(c.ID, cities[j].ID)
 	})
 
+	// The migrants travel on foot.
+	planner := m.getRoutePlanner(TravelModeFoot)
+
 	// The closest city is the city itself, so skip it.
 	// Check if any of the n closest cities have enough space.
 	for _, city := range cities[1:utils.Min(len(cities), m.MigrationToNClosestCities+1)] {
@@ -249,6 +274,17 @@ func (m *Civ) relocateFromCity(c *City, population int) {
 
 		// If there is capacity, a portion of the population might move there.
 		if popCapacity > 0 {
+			// Skip the city if it can't be reached over land. Cities on other
+			// landmasses are skipped right away, since the search would have
+			// to explore the entire landmass to find out.
+			if m.Landmasses[c.ID] != m.Landmasses[city.ID] {
+				continue
+			}
+			route, ok := planner.Route(c.ID, city.ID)
+			if !ok {
+				continue
+			}
+
 			// Now pick a fraction of the population that will move to the city,
 			// with the largest fraction going to the closest city.
 			numMigrants := utils.Min(population, popCapacity/2)
@@ -260,8 +296,7 @@ func (m *Civ) relocateFromCity(c *City, population int) {
 			}
 
 			// Depending on the distance, some of the population might die on the way.
-			dist := m.GetDistance(c.ID, city.ID)
-			dead := int(math.Ceil(calcChanceDeath(dist) * float64(numMigrants)))
+			dead := int(math.Ceil(calcChanceDeath(route.Distance) * float64(numMigrants)))
 
 			// HACK: Kill the people that died on the way.
 			// c.People = m.killNPeople2(c.People, dead)
@@ -389,6 +424,7 @@ type City struct {
 	PotentialTrade        float64               // Trade value of the city (DYNAMIC)
 	PotentialResources    float64               // Resources value of the city (PARTLY DYNAMIC)
 	PotentialAgricultural float64               // Agriculture value of the city (STATIC)
+	PotentialEnergy       float64               // Energy resources value of the city (STATIC)
 	Attractiveness        float64               // Attractiveness of the city (STATIC)
 	TradePartners         []int                 // IDs of cities within trade range
 	People                []*Person             // People living in the city
@@ -402,12 +438,14 @@ func (c *City) Ref() ObjectReference {
 	}
 }
 
-func (c *City) radius() float64 {
+// radius returns the radius of the city as a fraction of the given planet
+// circumference (in km).
+func (c *City) radius(circumference float64) float64 {
 	// In kilometers.
 	if c.Population <= 0 {
 		return 0
 	}
-	return 100 * math.Sqrt(float64(c.Population)/math.Pi) / gameconstants.EarthCircumference
+	return 100 * math.Sqrt(float64(c.Population)/math.Pi) / circumference
 }
 
 // String returns a string representation of the city.
@@ -440,14 +478,14 @@ func (m *Civ) PlaceNCities(n int, cType TownType) {
 	stopRegions := make(map[int]bool)
 
 	// Place n cities of the given type.
-	regDistanceC := m.AssignDistanceField(distSeedFunc(), stopRegions)
+	distField := m.AssignGeodesicDistanceField(distSeedFunc(), stopRegions, nil)
 	for i := 0; i < n; i++ {
 		// Place a city at the region with the highest fitness score.
-		c := m.placeCityWithScore(cType, m.CalcCityScoreWithDistanceField(scoreFunc, regDistanceC))
+		c := m.placeCityWithScore(cType, m.CalcCityScoreWithDistanceField(scoreFunc, distField.Distance))
 		log.Printf("placing %s city %d: %s", cType, i, c.String())
 
 		// Update the distance field.
-		regDistanceC = m.UpdateDistanceField(regDistanceC, distSeedFunc(), stopRegions)
+		distField = m.UpdateGeodesicDistanceField(distField, distSeedFunc(), stopRegions, nil)
 	}
 }
 
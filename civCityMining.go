@@ -0,0 +1,60 @@
+package genworldvoronoi
+
+import (
+	"fmt"
+
+	"github.com/Flokey82/genworldvoronoi/geo"
+)
+
+// miningRatePerCapita is the amount of resources extracted per inhabitant
+// of a mining town per year.
+const miningRatePerCapita = 0.01
+
+// tickCityMining discovers and extracts the deposits in and around the given
+// city if it is a mining town or quarry.
+//
+// Depleted deposits are removed from the resource maps, which reduces the
+// resource potential of the city.
+func (m *Civ) tickCityMining(c *City, days int) {
+	resourceType := c.Type.MinedResourceType()
+	if resourceType < 0 || c.Population <= 0 {
+		return
+	}
+
+	// The miners work the deposits in the city's region and around it.
+	var deposits []*geo.Deposit
+	regs := append([]int{c.ID}, m.GetRegNeighbors(c.ID)...)
+	for _, r := range regs {
+		for _, d := range m.GetDepositsOfType(r, resourceType) {
+			if !d.IsAvailable() {
+				continue
+			}
+			if !d.Discovered {
+				// The easier a deposit is to reach, the sooner it is found.
+				if m.Rand.Float64()*float64(m.DaysPerYear) >= float64(days)*(1-d.Difficulty/2) {
+					continue
+				}
+				d.Discovered = true
+				m.AddEvent("Discovery", fmt.Sprintf("A deposit of %s was discovered near %s", d.Name(), c.Name), c.Ref())
+			}
+			deposits = append(deposits, d)
+		}
+	}
+	if len(deposits) == 0 {
+		return
+	}
+
+	// The extracted amount depends on the workforce, which is split evenly
+	// between the worked deposits.
+	amount := miningRatePerCapita * float64(c.Population) * float64(days) / float64(m.DaysPerYear) / float64(len(deposits))
+	for _, d := range deposits {
+		d.Extract(amount)
+		if d.IsDepleted() {
+			m.RemoveDepleted(d)
+			m.AddEvent("Depletion", fmt.Sprintf("The %s deposit near %s was exhausted", d.Name(), c.Name), c.Ref())
+		}
+	}
+
+	// Update the resource potential since the deposits have shrunk.
+	m.calculateResourcePotential([]*City{c})
+}
@@ -18,6 +18,11 @@ func (m *Civ) GetCityState(id int) *CityState {
 	return nil
 }
 
+// GetCityStateRegions returns the regions of the given city state.
+func (m *Civ) GetCityStateRegions(c *CityState) []int {
+	return getRegionsWithID(m.RegionToCityState, c.ID)
+}
+
 func (m *Civ) PlaceNCityStates(n int) {
 	m.ResetRand()
 	for i, c := range m.Cities {
@@ -32,19 +37,16 @@ func (m *Civ) PlaceNCityStates(n int) {
 
 // CityState represents a territory governed by a single city.
 type CityState struct {
-	ID      int      // Region where the city state originates
-	Capital *City    // Capital city
-	Culture *Culture // Culture of the city state
-	Cities  []*City  // Cities within the city state
-	Founded int64    // Year when the city state was founded
-
-	// TODO: DO NOT CACHE THIS!
-	Regions []int
-	*geo.Stats
+	ID         int      // Region where the city state originates
+	Capital    *City    // Capital city
+	Culture    *Culture // Culture of the city state
+	Cities     []*City  // Cities within the city state
+	Founded    int64    // Year when the city state was founded
+	*geo.Stats          // Stats of the regions (see GetCityStateRegions)
 }
 
 func (c *CityState) Log() {
-	log.Printf("The city state of %s: %d cities, %d regions", c.Capital.Name, len(c.Cities), len(c.Regions))
+	log.Printf("The city state of %s: %d cities, %d regions", c.Capital.Name, len(c.Cities), c.NumRegions)
 	c.Stats.Log()
 }
 
@@ -55,7 +57,6 @@ func (m *Civ) PlaceCityStateAt(r int, c *City) *CityState {
 		Culture: m.GetCulture(r),
 		Founded: c.Founded,            // TODO: Use current year.
 		Cities:  []*City{c},           // TODO: ??? Remove this?
-		Regions: []int{r},             // TODO: ??? Remove this?
 		Stats:   m.GetStats([]int{r}), // TODO: ??? Remove this?
 	}
 
@@ -83,17 +84,17 @@ func (m *Civ) expandCityStates() {
 	biomeWeight := m.getTerritoryBiomeWeightFunc()
 	cultureWeight := m.getTerritoryCultureWeightFunc()
 
-	m.RegionToCityState = m.regPlaceNTerritoriesCustom(m.RegionToCityState, seedCities, func(o, u, v int) float64 {
+	m.RegionToCityState = m.regPlaceNTerritoriesCustom(m.RegionToCityState, seedCities, func(o, u, v int, dist float64) float64 {
 		// TODO: Make sure we take in account expansionism, wealth, score, and culture.
-		w := weight(o, u, v)
+		w := weight(o, u, v, dist)
 		if w < 0 {
 			return -1
 		}
-		b := biomeWeight(o, u, v)
+		b := biomeWeight(o, u, v, dist)
 		if b < 0 {
 			return -1
 		}
-		c := cultureWeight(o, u, v)
+		c := cultureWeight(o, u, v, dist)
 		if c < 0 {
 			return -1
 		}
@@ -106,6 +107,7 @@ func (m *Civ) expandCityStates() {
 	// m.rRelaxTerritories(m.r_city, 5)
 
 	// Update the city states with the new regions.
+	regsByCityState := getRegionsByID(m.RegionToCityState)
 	for _, c := range m.CityStates {
 		// Loop through all cities and gather all that
 		// are within the current city state.
@@ -116,15 +118,9 @@ func (m *Civ) expandCityStates() {
 			}
 		}
 
-		// Collect all regions that are part of the
+		// Calculate the stats of all regions that are part of the
 		// current territory.
-		c.Regions = c.Regions[:0]
-		for r, terr := range m.RegionToCityState {
-			if terr == c.ID {
-				c.Regions = append(c.Regions, r)
-			}
-		}
-		c.Stats = m.GetStats(c.Regions)
+		c.Stats = m.GetStats(regsByCityState[c.ID])
 		c.Log()
 	}
 }
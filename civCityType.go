@@ -4,17 +4,18 @@ import (
 	"log"
 
 	"github.com/Flokey82/genbiome"
+	"github.com/Flokey82/genworldvoronoi/geo"
 )
 
 // getRegCityType returns the optimal type of city for a given region.
 func (m *Civ) getRegCityType(r int) TownType {
 	// If we have a lot of metals, gems, etc. we have a mining town.
-	if m.Metals[r] > 0 || m.Gems[r] > 0 {
+	if m.HasDepositsOfType(r, geo.ResourceTypeMetal) || m.HasDepositsOfType(r, geo.ResourceTypeGem) {
 		return TownTypeMining
 	}
 
 	// If we have stone, we have a quarry.
-	if m.Stones[r] > 0 {
+	if m.HasDepositsOfType(r, geo.ResourceTypeStone) {
 		return TownTypeQuarry
 	}
 
@@ -38,6 +39,7 @@ const (
 	TownTypeQuarry      TownType = "quarry"
 	TownTypeFarming     TownType = "agricultural"
 	TownTypeDesertOasis TownType = "desert oasis"
+	TownTypePort        TownType = "port"
 )
 
 // FoundingPopulation returns the starting population of a city type.
@@ -53,12 +55,29 @@ func (t TownType) FoundingPopulation() int {
 		return 20
 	case TownTypeDesertOasis:
 		return 20
+	case TownTypePort:
+		return 60
 	default:
 		log.Fatalf("unknown city type: %s", t)
 	}
 	return 0
 }
 
+// MinedResourceType returns the resource type (geo.ResourceTypeMetal, ...)
+// that a city type extracts from the deposits, or -1 if it doesn't mine.
+func (t TownType) MinedResourceType() int {
+	switch t {
+	case TownTypeMining:
+		return geo.ResourceTypeMetal
+	case TownTypeMiningGems:
+		return geo.ResourceTypeGem
+	case TownTypeQuarry:
+		return geo.ResourceTypeStone
+	default:
+		return -1
+	}
+}
+
 // GetDistanceSeedFunc returns the distance seed function for a city type.
 func (t TownType) GetDistanceSeedFunc(m *Civ) func() []int {
 	// For now we just maximize the distance to cities of the same type.
@@ -97,7 +116,7 @@ func (t TownType) GetFitnessFunction(m *Civ) func(int) float64 {
 		fc := m.GetFitnessProximityToWater()
 		fd := m.getFitnessProximityToCities(TownTypeMining, TownTypeMiningGems, TownTypeQuarry)
 		return func(r int) float64 {
-			if m.Stones[r] == 0 {
+			if !m.HasDepositsOfType(r, geo.ResourceTypeStone) {
 				return -1.0
 			}
 			return fd(r) * (fa(r)*fb(r) + fc(r)) / 2
@@ -108,7 +127,7 @@ func (t TownType) GetFitnessFunction(m *Civ) func(int) float64 {
 		fc := m.GetFitnessProximityToWater()
 		fd := m.getFitnessProximityToCities(TownTypeMining, TownTypeMiningGems, TownTypeQuarry)
 		return func(r int) float64 {
-			if m.Metals[r] == 0 {
+			if !m.HasDepositsOfType(r, geo.ResourceTypeMetal) {
 				return -1.0
 			}
 			return fd(r) * (fa(r)*fb(r) + fc(r)) / 2
@@ -119,7 +138,7 @@ func (t TownType) GetFitnessFunction(m *Civ) func(int) float64 {
 		fc := m.GetFitnessProximityToWater()
 		fd := m.getFitnessProximityToCities(TownTypeMining, TownTypeMiningGems, TownTypeQuarry)
 		return func(r int) float64 {
-			if m.Gems[r] == 0 {
+			if !m.HasDepositsOfType(r, geo.ResourceTypeGem) {
 				return -1.0
 			}
 			return fd(r) * (fa(r)*fb(r) + fc(r)) / 2
@@ -143,6 +162,17 @@ func (t TownType) GetFitnessFunction(m *Civ) func(int) float64 {
 			}
 			return 0
 		}
+	case TownTypePort:
+		// Ports are placed at coasts that are suitable for a harbor.
+		fa := m.GetFitnessClimate()
+		fb := m.getFitnessCityDefault()
+		return func(r int) float64 {
+			q := m.GetRegHarborQuality(r)
+			if q == 0 {
+				return -1.0
+			}
+			return q * fa(r) * fb(r)
+		}
 	default:
 		log.Fatalf("unknown city type: %s", t)
 	}
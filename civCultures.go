@@ -34,6 +34,11 @@ func (m *Civ) GetCulture(r int) *Culture {
 	return nil
 }
 
+// GetCultureRegions returns the regions of the given culture.
+func (m *Civ) GetCultureRegions(c *Culture) []int {
+	return getRegionsWithID(m.RegionToCulture, c.ID)
+}
+
 // PlaceNCultures places n cultures on the map.
 // This code is based on:
 // https://github.com/Azgaar/Fantasy-Map-Generator/blob/master/modules/cultures-generator.js
@@ -57,7 +62,7 @@ func (m *Civ) placeNCultures(n int) {
 	regCultureFunc := m.getRegionCultureTypeFunc()
 	climateFitness := m.GetFitnessClimate()
 	scoreFunc = func(r int) float64 {
-		if m.Elevation[r] <= 0 {
+		if m.Elevation.At(r) <= 0 {
 			return 0
 		}
 		return math.Sqrt((climateFitness(r) + 3.0) / 4.0)
@@ -76,15 +81,15 @@ func (m *Civ) placeNCultures(n int) {
 	stopRegions := make(map[int]bool)
 
 	// Place n cultures of any type.
-	regDistanceC := m.AssignDistanceField(distSeedFunc(), stopRegions)
+	distField := m.AssignGeodesicDistanceField(distSeedFunc(), stopRegions, nil)
 	for i := 0; i < n; i++ {
 		// We use the city score since it identifies regions that are well suited for
 		// settlement or general survival.
-		c := m.placeCultureWithScore(regCultureFunc, m.CalcCityScoreWithDistanceField(scoreFunc, regDistanceC))
+		c := m.placeCultureWithScore(regCultureFunc, m.CalcCityScoreWithDistanceField(scoreFunc, distField.Distance))
 		log.Printf("placing culture %d: %s", i, c.Name)
 
 		// Update the distance field to ensure we evenly distribute the cultures.
-		regDistanceC = m.UpdateDistanceField(regDistanceC, distSeedFunc(), stopRegions)
+		distField = m.UpdateGeodesicDistanceField(distField, distSeedFunc(), stopRegions, nil)
 	}
 }
 
@@ -100,42 +105,33 @@ func (m *Civ) ExpandCultures() {
 	}
 
 	rCellType := m.GetRegCellTypes()
-	_, maxElev := minMax(m.Elevation)
+	_, maxElev := m.Elevation.MinMax()
 	territoryWeightFunc := m.getTerritoryWeightFunc()
 	biomeWeight := m.getTerritoryBiomeWeightFunc()
-	m.RegionToCulture = m.regPlaceNTerritoriesCustom(m.RegionToCulture, seeds, func(o, u, v int) float64 {
+	m.RegionToCulture = m.regPlaceNTerritoriesCustom(m.RegionToCulture, seeds, func(o, u, v int, dist float64) float64 {
 		c := originToCulture[o]
 
 		// Get the cost to expand to this biome.
-		gotBiome := m.GetAzgaarRegionBiome(v, m.Elevation[v]/maxElev, maxElev)
-		biomePenalty := biomeWeight(o, u, v) * float64(genbiome.AzgaarBiomeMovementCost[gotBiome]) / 100
+		gotBiome := m.GetAzgaarRegionBiome(v, m.Elevation.At(v)/maxElev, maxElev)
+		biomePenalty := biomeWeight(o, u, v, dist) * float64(genbiome.AzgaarBiomeMovementCost[gotBiome]) / 100
 
 		// Check if we have a non-native biome, if so we apply an additional penalty.
 		// NOTE: This check has been disabled for now.
-		// if m.getAzgaarRegionBiome(o, m.Elevation[o]/maxElev, maxElev) != gotBiome {
+		// if m.getAzgaarRegionBiome(o, m.Elevation.At(o)/maxElev, maxElev) != gotBiome {
 		biomePenalty *= c.Type.BiomeCost(gotBiome)
 		// }
 
 		cellTypePenalty := c.Type.CellTypeCost(rCellType[v])
-		return biomePenalty + cellTypePenalty*territoryWeightFunc(o, u, v)/c.Expansionism
+		return biomePenalty + cellTypePenalty*territoryWeightFunc(o, u, v, dist)/c.Expansionism
 	})
 
 	// TODO: There are small islands that do not have a culture...
 	// We should (or could) fix that.
 
-	// Update the cultures with the new regions.
+	// Update the stats of the cultures with the new regions.
+	regsByCulture := getRegionsByID(m.RegionToCulture)
 	for _, c := range m.Cultures {
-		// Collect all regions that are part of the current culture.
-		c.Regions = c.Regions[:0]
-
-		// TODO: Maybe avoid repeatedly iterating over the regions and
-		// do it only once for all cultures.
-		for r, cu := range m.RegionToCulture {
-			if cu == c.ID {
-				c.Regions = append(c.Regions, r)
-			}
-		}
-		c.Stats = m.GetStats(c.Regions)
+		c.Stats = m.GetStats(regsByCulture[c.ID])
 	}
 
 	// TODO: Move this somewhere else or improve how it is handled.
@@ -202,16 +198,21 @@ type Culture struct {
 	// Parent    *Culture
 	// Children  []*Culture
 	// Extinct   bool
-	Language *genlanguage.Language // Language of the culture
-	Religion *Religion             // Religion of the culture
+	Language   *genlanguage.Language // Language of the culture
+	Religion   *Religion             // Religion of the culture
+	*geo.Stats                       // Stats of the regions (see GetCultureRegions)
+}
 
-	// TODO: DO NOT CACHE THIS!
-	Regions []int
-	*geo.Stats
+// numRegions returns the number of regions of the culture.
+func (c *Culture) numRegions() int {
+	if c.Stats == nil {
+		return 0
+	}
+	return c.NumRegions
 }
 
 func (c *Culture) Log() {
-	log.Printf("The Folk of %s (%s): %d regions", c.Name, c.Type.String(), len(c.Regions))
+	log.Printf("The Folk of %s (%s): %d regions", c.Name, c.Type.String(), c.numRegions())
 	log.Printf("Followers of %s (%s)", c.Religion.Name, c.Religion.Group)
 	c.Stats.Log()
 }
@@ -254,8 +255,7 @@ func (m *Civ) placeCultureWithScore(regCultureFunc func(int) CultureType, scores
 // TODO: Allow specifying the culture type?
 func (m *Civ) PlaceCultureAt(r int) *Culture {
 	c := m.newCulture(r, m.getRegionCultureTypeFunc()(r))
-	c.Regions = []int{r}
-	c.Stats = m.GetStats(c.Regions)
+	c.Stats = m.GetStats([]int{r})
 	m.Cultures = append(m.Cultures, c)
 	// m.RegionToCulture[r] = r
 	// NOTE: This might be quite expensive, so we might want to
@@ -269,13 +269,14 @@ func (m *Civ) PlaceCultureAt(r int) *Culture {
 func (m *Civ) getRegionCultureTypeFunc() func(int) CultureType {
 	cellType := m.GetRegCellTypes()
 	getType := m.GetRegionFeatureTypeFunc()
+	isOnIsle := m.GetRegIsOnIsleFunc()
 	biomeFunc := m.GetRegWhittakerModBiomeFunc()
-	_, maxElev := minMax(m.Elevation)
+	_, maxElev := m.Elevation.MinMax()
 	log.Println("TODO: Map whittaker to azgaar biomes")
 
 	// Return culture type based on culture center region.
 	return func(r int) CultureType {
-		eleVal := m.Elevation[r] / maxElev
+		eleVal := m.Elevation.At(r) / maxElev
 		gotBiome := m.GetAzgaarRegionBiome(r, eleVal, maxElev)
 		log.Println(gotBiome)
 		log.Println(biomeFunc(r))
@@ -299,7 +300,6 @@ func (m *Civ) getRegionCultureTypeFunc() func(int) CultureType {
 		// NOTE: harborSize indicates the number of neighbors that are water.
 		rHaven, harborSize := m.GetRegHaven(r)
 		havenType := getType(rHaven) // Get the haven type of the region.
-		regionType := getType(r)     // Get the region type of the region.
 
 		// Ensure only larger lakes will result in the 'lake' culture type.
 		if havenType == geo.FeatureTypeLake && m.WaterbodySize[rHaven] > 5 {
@@ -310,7 +310,7 @@ func (m *Civ) getRegionCultureTypeFunc() func(int) CultureType {
 		// we are potentially a naval culture.
 		if (harborSize > 0 && P(0.1) && havenType != geo.FeatureTypeLake) ||
 			(harborSize == 1 && P(0.6)) ||
-			(regionType == geo.FeatureTypeIsle && P(0.4)) {
+			(isOnIsle(r) && P(0.4)) {
 			return CultureTypeNaval // low water cross penalty and high for non-along-coastline growth
 		}
 
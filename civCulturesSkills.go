@@ -101,7 +101,7 @@ func (m *Civ) genCultureSkills() {
 	// TODO: Change this to "metalwork" with a speciality for each metal type.
 	for res := 0; res < geo.ResMaxMetals; res++ {
 		sort.Slice(cultureCopy, func(i, j int) bool {
-			return float64(cultureCopy[i].Stats.ResMetal[res])/float64(len(cultureCopy[i].Regions)) > float64(cultureCopy[j].Stats.ResMetal[res])/float64(len(cultureCopy[j].Regions))
+			return float64(cultureCopy[i].Stats.ResMetal[res])/float64(cultureCopy[i].numRegions()) > float64(cultureCopy[j].Stats.ResMetal[res])/float64(cultureCopy[j].numRegions())
 		})
 		for i, c := range cultureCopy {
 			if i >= 3 {
@@ -115,7 +115,7 @@ func (m *Civ) genCultureSkills() {
 	// TODO: Change this to "gemwork" with a speciality for each gem type.
 	for res := 0; res < geo.ResMaxGems; res++ {
 		sort.Slice(cultureCopy, func(i, j int) bool {
-			return float64(cultureCopy[i].Stats.ResGems[res])/float64(len(cultureCopy[i].Regions)) > float64(cultureCopy[j].Stats.ResGems[res])/float64(len(cultureCopy[j].Regions))
+			return float64(cultureCopy[i].Stats.ResGems[res])/float64(cultureCopy[i].numRegions()) > float64(cultureCopy[j].Stats.ResGems[res])/float64(cultureCopy[j].numRegions())
 		})
 		for i, c := range cultureCopy {
 			if i >= 3 {
@@ -129,7 +129,7 @@ func (m *Civ) genCultureSkills() {
 	// TODO: Change this to "stonework" with a speciality for each stone type.
 	for res := 0; res < geo.ResMaxStones; res++ {
 		sort.Slice(cultureCopy, func(i, j int) bool {
-			return float64(cultureCopy[i].Stats.ResStones[res])/float64(len(cultureCopy[i].Regions)) > float64(cultureCopy[j].Stats.ResStones[res])/float64(len(cultureCopy[j].Regions))
+			return float64(cultureCopy[i].Stats.ResStones[res])/float64(cultureCopy[i].numRegions()) > float64(cultureCopy[j].Stats.ResStones[res])/float64(cultureCopy[j].numRegions())
 		})
 		for i, c := range cultureCopy {
 			if i >= 3 {
@@ -143,7 +143,7 @@ func (m *Civ) genCultureSkills() {
 	// TODO: Change this to "woodwork" with a speciality for each wood type.
 	for res := 0; res < geo.ResMaxWoods; res++ {
 		sort.Slice(cultureCopy, func(i, j int) bool {
-			return float64(cultureCopy[i].Stats.ResWood[res])/float64(len(cultureCopy[i].Regions)) > float64(cultureCopy[j].Stats.ResWood[res])/float64(len(cultureCopy[j].Regions))
+			return float64(cultureCopy[i].Stats.ResWood[res])/float64(cultureCopy[i].numRegions()) > float64(cultureCopy[j].Stats.ResWood[res])/float64(cultureCopy[j].numRegions())
 		})
 		for i, c := range cultureCopy {
 			if i >= 3 {
@@ -153,6 +153,19 @@ func (m *Civ) genCultureSkills() {
 		}
 	}
 
+	// Custom resources.
+	for _, def := range m.ResourceRegistry.GetCustom() {
+		sort.Slice(cultureCopy, func(i, j int) bool {
+			return float64(cultureCopy[i].Stats.ResCustom[def.Name])/float64(cultureCopy[i].numRegions()) > float64(cultureCopy[j].Stats.ResCustom[def.Name])/float64(cultureCopy[j].numRegions())
+		})
+		for i, c := range cultureCopy {
+			if i >= 3 || cultureCopy[i].Stats.ResCustom[def.Name] == 0 {
+				break
+			}
+			skillMap[c] = append(skillMap[c], def.Name)
+		}
+	}
+
 	// Log all skills per culture.
 	for _, c := range m.Cultures {
 		log.Println(c.Name, "specialties:", skillMap[c])
@@ -45,17 +45,17 @@ func (m *Civ) generateCityFlavorText(c *City, p geo.RegProperty) string {
 	} else {
 		str += "large city"
 	}
-	if p.IsValley && p.DistanceToCoast > 3 {
+	if p.IsValley && p.DistanceToCoast > geo.DistanceNearby {
 		str += " in a valley"
 	} else if p.Steepness > 0.5 {
 		if p.Elevation > 0.5 {
 			str += " on a mountain"
-		} else if p.DistanceToCoast <= 1 {
+		} else if p.DistanceToCoast <= geo.DistanceAdjacent {
 			str += " on a coastal cliff"
 		} else {
 			str += " on a hillside"
 		}
-	} else if p.DistanceToCoast <= 1 {
+	} else if p.DistanceToCoast <= geo.DistanceAdjacent {
 		str += " on the coast"
 	}
 	str += ".\n"
@@ -0,0 +1,91 @@
+package genworldvoronoi
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/Flokey82/genworldvoronoi/geo"
+)
+
+// getDisasterEventFunc returns the (cached) function that generates random
+// disaster events with a spatial footprint.
+func (m *Civ) getDisasterEventFunc() func(rnd *rand.Rand) []*geo.DisasterEvent {
+	if m.disasterEventFunc == nil {
+		m.disasterEventFunc = m.Geo.GetDisasterEventFunc()
+	}
+	return m.disasterEventFunc
+}
+
+// tickDisasterEvents simulates the disaster events (earthquakes, volcanic
+// eruptions, floods and the tsunamis and landslides they trigger) that occur
+// within the given number of days and returns them.
+//
+// Each event is applied to the cities and people and passed to
+// OnDisasterEvent (if set).
+func (m *Civ) tickDisasterEvents(days int) []*geo.DisasterEvent {
+	if m.DisasterEventsPerYear <= 0 {
+		return nil
+	}
+
+	// Get the number of events within the given number of days.
+	expected := m.DisasterEventsPerYear * float64(days) / float64(m.DaysPerYear)
+	n := int(expected)
+	if m.Rand.Float64() < expected-float64(n) {
+		n++
+	}
+
+	genEvent := m.getDisasterEventFunc()
+	var events []*geo.DisasterEvent
+	for i := 0; i < n; i++ {
+		evs := genEvent(m.Rand)
+		if evs == nil {
+			break
+		}
+		for _, ev := range evs {
+			m.ApplyDisasterEvent(ev)
+			if m.OnDisasterEvent != nil {
+				m.OnDisasterEvent(ev)
+			}
+		}
+		events = append(events, evs...)
+	}
+	return events
+}
+
+// ApplyDisasterEvent applies the given disaster event to all cities and people
+// within its footprint and logs it to the history.
+func (m *Civ) ApplyDisasterEvent(ev *geo.DisasterEvent) {
+	ref := ObjectReference{
+		ID:   ev.Origin,
+		Type: ObjectTypeRegion,
+	}
+	if m.RegionIsVolcano.Has(ev.Origin) {
+		ref.Type = ObjectTypeVolcano
+	}
+	m.AddEvent(ev.Name, fmt.Sprintf("%s struck, affecting %d regions", ev.Name, len(ev.Regions)), ref)
+
+	// The population loss in each city depends on the local intensity.
+	for _, c := range m.getExistingCities() {
+		intensity := ev.GetIntensity(c.ID)
+		if intensity <= 0 || c.Population <= 0 {
+			continue
+		}
+		if t, ok := ev.TravelTime[c.ID]; ok {
+			m.AddEvent(ev.Name, fmt.Sprintf("The wave reached %s after %.1f hours", c.Name, t), c.Ref())
+		}
+		popLoss := ev.PopulationLoss * intensity * (2 + m.Rand.Float64()) / 3
+		m.applyCityDisaster(c, ev.Disaster, popLoss)
+	}
+
+	// Individual people in the footprint might die.
+	reason := strings.ToLower(ev.Name)
+	for _, p := range m.People {
+		if p.isDead() {
+			continue
+		}
+		if intensity := ev.GetIntensity(p.Region); intensity > 0 && m.Rand.Float64() < ev.PopulationLoss*intensity {
+			m.killPerson(p, reason)
+		}
+	}
+}
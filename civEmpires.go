@@ -22,6 +22,11 @@ func (m *Civ) GetEmpire(id int) *Empire {
 	return nil
 }
 
+// GetEmpireRegions returns the regions of the given empire.
+func (m *Civ) GetEmpireRegions(e *Empire) []int {
+	return getRegionsWithID(m.RegionToEmpire, e.ID)
+}
+
 func (m *Civ) PlaceNEmpires(n int) {
 	// NOTE: This is not very thought through.
 	// This will need quite a bit of tweaking.
@@ -145,6 +150,7 @@ func (m *Civ) expandEmpires() {
 	}
 
 	// Now update the empire territories.
+	regsByEmpire := getRegionsByID(m.RegionToEmpire)
 	for _, e := range m.Empires {
 		// Loop through all cities and gather all that
 		// are within the current territory.
@@ -157,15 +163,9 @@ func (m *Civ) expandEmpires() {
 			}
 		}
 
-		// Collect all regions that are part of the
+		// Calculate the stats of all regions that are part of the
 		// current territory.
-		e.Regions = e.Regions[:0]
-		for r, terr := range m.RegionToEmpire {
-			if terr == e.ID {
-				e.Regions = append(e.Regions, r)
-			}
-		}
-		e.Stats = m.GetStats(e.Regions)
+		e.Stats = m.GetStats(regsByEmpire[e.ID])
 		e.Log()
 	}
 }
@@ -174,28 +174,28 @@ func (m *Civ) getCityScoreForExpansion(c *City) float64 {
 	cc := m.GetCulture(c.ID)
 	if cc == nil {
 		// If there is no culture, we assume a base expansionism of 1.0.
-		return c.Score * float64(len(c.Culture.Regions))
+		return c.Score * float64(c.Culture.numRegions())
 	}
 	// Use the culture's expansionism as an indicator of
 	// how much the city state wants to expand.
-	return c.Score * cc.Expansionism * float64(len(c.Culture.Regions))
+	return c.Score * cc.Expansionism * float64(c.Culture.numRegions())
 }
 
 func (m *Civ) getCityScoreForMartial(c *City) float64 {
 	cc := m.GetCulture(c.ID)
 	if cc == nil {
 		// If there is no culture, we assume a base martialism of 1.0.
-		return c.Score * float64(len(c.Culture.Regions))
+		return c.Score * float64(c.Culture.numRegions())
 	}
 	// Use the culture's martialism as an indicator of
 	// how well the city can defend itself or its offensive
 	// or defensive capabilities.
-	return c.Score * cc.Martialism * float64(len(c.Culture.Regions))
+	return c.Score * cc.Martialism * float64(c.Culture.numRegions())
 }
 
 // Empire contains information about a territory with the given ID.
-// TODO: Maybe drop the regions since we can get that info
-// relatively cheaply.
+//
+// NOTE: The regions of the empire are not cached, use GetEmpireRegions.
 type Empire struct {
 	ID       int                   // Region where the empire originates (capital)
 	Name     string                // Name of the empire
@@ -204,9 +204,6 @@ type Empire struct {
 	Cities   []*City               // Cities within the territory
 	Culture  *Culture              // Primary culture of the empire
 	Language *genlanguage.Language // Primary language of the empire
-
-	// TODO: DO NOT CACHE THIS!
-	Regions []int // Regions that are part of the empire
 	*geo.Stats
 }
 
@@ -215,7 +212,7 @@ func (e *Empire) String() string {
 }
 
 func (e *Empire) Log() {
-	log.Printf("The Empire of %s: %d cities, %d regions, capital: %s", e.Name, len(e.Cities), len(e.Regions), e.Capital.Name)
+	log.Printf("The Empire of %s: %d cities, %d regions, capital: %s", e.Name, len(e.Cities), e.NumRegions, e.Capital.Name)
 	log.Printf("Emperor: %s", e.Emperor)
 	e.Stats.Log()
 }
@@ -0,0 +1,146 @@
+package genworldvoronoi
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Flokey82/genworldvoronoi/geo"
+)
+
+// nameFeatures names all unnamed geographic features (mountain ranges, seas,
+// ...) in the language of the culture that is most prevalent in or around the
+// feature.
+//
+// NOTE: This is also called when the features change with the climate (see
+// onClimateChanged), which names the new features and re-assigns the
+// features of all regions.
+func (m *Civ) nameFeatures() {
+	for _, f := range m.Features {
+		if f.Name != "" {
+			continue
+		}
+		c := m.getFeatureCulture(f)
+		if c == nil {
+			continue
+		}
+		name := c.Language.MakeName()
+		switch f.Type {
+		case geo.FeatureTypeMountainRange:
+			f.Name = fmt.Sprintf("%s Mountains", name)
+		case geo.FeatureTypeOcean:
+			f.Name = fmt.Sprintf("%s Ocean", name)
+		case geo.FeatureTypeSea:
+			f.Name = fmt.Sprintf("%s Sea", name)
+		case geo.FeatureTypeGulf:
+			f.Name = fmt.Sprintf("Gulf of %s", name)
+		case geo.FeatureTypeBay:
+			f.Name = fmt.Sprintf("%s Bay", name)
+		case geo.FeatureTypeStrait:
+			f.Name = fmt.Sprintf("Strait of %s", name)
+		case geo.FeatureTypeDesert:
+			f.Name = fmt.Sprintf("%s Desert", name)
+		case geo.FeatureTypeForest:
+			f.Name = fmt.Sprintf("%s Forest", name)
+		case geo.FeatureTypeSwamp:
+			f.Name = fmt.Sprintf("%s Swamp", name)
+		case geo.FeatureTypeIsle:
+			f.Name = fmt.Sprintf("%s Island", name)
+		default:
+			f.Name = name
+		}
+		m.AddEvent("Naming", fmt.Sprintf("The %s of %d regions was named %s by the %s", f.Type, len(f.Regions), f.Name, c.Name), ObjectReference{
+			ID:   f.Center,
+			Type: getFeatureObjectType(f),
+		})
+	}
+	m.assignRegionToFeature()
+}
+
+// getFeatureObjectType returns the object type used to reference the given
+// feature in the history. The ID of the reference is the center region of
+// the feature.
+func getFeatureObjectType(f *geo.Feature) byte {
+	switch f.Type {
+	case geo.FeatureTypeMountainRange:
+		return ObjectTypeMountain
+	case geo.FeatureTypeOcean, geo.FeatureTypeSea, geo.FeatureTypeGulf,
+		geo.FeatureTypeBay, geo.FeatureTypeStrait:
+		return ObjectTypeSea
+	}
+	return ObjectTypeRegion
+}
+
+// getFeatureCulture returns the culture that is most prevalent in the regions
+// of the given feature and their neighbors (so that seas are named by the
+// coastal cultures). If no culture is found, the culture closest to the center
+// of the feature is returned.
+func (m *Civ) getFeatureCulture(f *geo.Feature) *Culture {
+	if len(m.Cultures) == 0 {
+		return nil
+	}
+	count := make(map[int]int)
+	outRegs := make([]int, 0, 8)
+	for _, r := range f.Regions {
+		if c := m.RegionToCulture[r]; c >= 0 {
+			count[c]++
+		}
+		for _, nb := range m.SphereMesh.R_circulate_r(outRegs, r) {
+			if c := m.RegionToCulture[nb]; c >= 0 {
+				count[c]++
+			}
+		}
+	}
+
+	// NOTE: Cultures are iterated in order to keep the result deterministic.
+	var best *Culture
+	var bestCount int
+	for _, c := range m.Cultures {
+		if count[c.ID] > bestCount {
+			best = c
+			bestCount = count[c.ID]
+		}
+	}
+	if best != nil {
+		return best
+	}
+
+	// Fall back to the culture whose origin is closest to the feature.
+	bestDist := math.Inf(1)
+	for _, c := range m.Cultures {
+		if dist := m.GetDistance(c.ID, f.Center); dist < bestDist {
+			best = c
+			bestDist = dist
+		}
+	}
+	return best
+}
+
+// assignRegionToFeature assigns each region the named land feature (desert,
+// forest, mountain range, ...) it belongs to, preferring the smallest (most
+// specific) feature.
+func (m *Civ) assignRegionToFeature() {
+	m.regionToFeature = make([]*geo.Feature, m.SphereMesh.NumRegions)
+	for _, f := range m.Features {
+		if f.Name == "" {
+			continue
+		}
+		switch f.Type {
+		case geo.FeatureTypeMountainRange, geo.FeatureTypeDesert,
+			geo.FeatureTypeForest, geo.FeatureTypeSwamp, geo.FeatureTypeIsle:
+			for _, r := range f.Regions {
+				if cur := m.regionToFeature[r]; cur == nil || len(f.Regions) < len(cur.Regions) {
+					m.regionToFeature[r] = f
+				}
+			}
+		}
+	}
+}
+
+// getFeatureName returns the name of the named land feature the given region
+// belongs to (see assignRegionToFeature).
+func (m *Civ) getFeatureName(r int) string {
+	if m.regionToFeature == nil || m.regionToFeature[r] == nil {
+		return ""
+	}
+	return m.regionToFeature[r].Name
+}
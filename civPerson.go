@@ -59,7 +59,7 @@ func (m *Civ) tickPersonPregnancy(p *Person, nDays int, cf func(int) *Culture) *
 	if p.isOfChildbearingAge() && p.canBePregnant() {
 		// Approximately once every 5 years if no children.
 		// TODO: Figure out proper chance of birth.
-		chance := 5 * 365
+		chance := 5 * m.DaysPerYear
 		if p.Age > 40 {
 			// Over 40, it becomes more and more unlikely.
 			// TODO: Genetic variance?
@@ -227,7 +227,7 @@ func (m *Civ) newRandomPersonAt(r int, culture *Culture) *Person {
 		LastName:  lastName,
 		Birth: LifeEvent{
 			Year:   int(m.History.GetYear()) - ageOfAdulthood + rand.Intn(2*ageOfAdulthood),
-			Day:    rand.Intn(365),
+			Day:    rand.Intn(m.DaysPerYear),
 			Region: r, // TODO: Pick a birth region that makes sense.
 		},
 	}
@@ -368,7 +368,7 @@ func (m *Civ) advancePersonPregnancy(p *Person, nDays int, cf func(int) *Culture
 	child.Birth.Day = m.History.GetDayOfYear() - wasBornNDaysAgo
 	if child.Birth.Day < 0 {
 		child.Birth.Year--
-		child.Birth.Day += 365
+		child.Birth.Day += m.DaysPerYear
 		// Age the baby for the number of days it was born ago.
 		m.tickPerson(child, wasBornNDaysAgo, cf)
 	}
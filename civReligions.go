@@ -238,12 +238,12 @@ func (m *Civ) ExpandReligions() {
 	}
 
 	territoryWeightFunc := m.getTerritoryWeightFunc()
-	m.RegionToReligion = m.regPlaceNTerritoriesCustom(m.RegionToReligion, seeds, func(o, u, v int) float64 {
+	m.RegionToReligion = m.regPlaceNTerritoriesCustom(m.RegionToReligion, seeds, func(o, u, v int, dist float64) float64 {
 		r := originToReligion[o]
 		if r.Expansion == ReligionExpCulture && m.RegionToCulture[v] != r.Culture.ID ||
 			r.Expansion == ReligionExpState && m.RegionToCityState[v] != m.RegionToCityState[o] {
 			return -1
 		}
-		return territoryWeightFunc(o, u, v) / r.Expansionism
+		return territoryWeightFunc(o, u, v, dist) / r.Expansionism
 	})
 }
@@ -0,0 +1,384 @@
+package genworldvoronoi
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/Flokey82/genbiome"
+	"github.com/Flokey82/genworldvoronoi/geo"
+)
+
+// TravelMode is the means of transportation used for planning a route.
+type TravelMode int
+
+// The available travel modes.
+const (
+	TravelModeFoot      TravelMode = iota // Walking (land only)
+	TravelModeCart                        // Horse and cart (land only, avoids steep terrain)
+	TravelModeRiverBoat                   // River boat (rivers and lakes only)
+	TravelModeShip                        // Sea ship (ocean only)
+)
+
+// String returns the name of the travel mode.
+func (t TravelMode) String() string {
+	switch t {
+	case TravelModeFoot:
+		return "foot"
+	case TravelModeCart:
+		return "cart"
+	case TravelModeRiverBoat:
+		return "river boat"
+	case TravelModeShip:
+		return "ship"
+	}
+	return "unknown"
+}
+
+// Route planning parameters.
+const (
+	routeSpeedFoot       = 30.0  // Travel speed on foot in km per day
+	routeSpeedCart       = 40.0  // Travel speed by horse and cart in km per day
+	routeSpeedRiverBoat  = 60.0  // Travel speed of a river boat (downstream) in km per day
+	routeSpeedShip       = 150.0 // Travel speed of a sea ship in km per day
+	routeRoadFactorFoot  = 1.25  // Speed multiplier on roads on foot
+	routeRoadFactorCart  = 2.0   // Speed multiplier on roads by horse and cart
+	routeUpstreamFactor  = 0.5   // Speed multiplier of river boats going upstream
+	routeLakeFactor      = 0.75  // Speed multiplier of river boats on lakes
+	routeUphillPenalty   = 4.0   // Slowdown per (normalized) elevation gained
+	routeCartMaxSteep    = 0.5   // Maximum steepness passable by horse and cart
+	routeRiverCrossing   = 1.4   // Slowdown when crossing a river (without a road)
+	routeRiverValley     = 0.9   // Speedup when following a river valley
+	routeBorderDelayDays = 0.5   // Delay in days when crossing into another empire
+)
+
+// Route is a planned route between two regions.
+type Route struct {
+	Mode     TravelMode // Travel mode used
+	Path     []int      // Regions along the route (including start and destination)
+	Distance float64    // Length of the route in km
+	Duration float64    // Travel time in days
+	Cost     float64    // Total cost of the route (see RoutePlanner.CostFunc)
+}
+
+// RoutePlanner plans routes between regions for a given travel mode.
+//
+// The cost of traversing an edge is the travel time in days, which depends
+// on the slope, the biome, rivers, roads (trade routes) and borders.
+type RoutePlanner struct {
+	*Civ
+	Mode        TravelMode // Travel mode
+	BorderDelay float64    // Delay in days when crossing into another empire
+	RoadFactor  float64    // Speed multiplier on roads (trade routes)
+
+	// CostFunc optionally modifies the cost of traversing the edge from
+	// region 'u' to the neighbor region 'v', given the travel time 'days'.
+	// A negative cost means that the edge is impassable.
+	CostFunc func(u, v int, days float64) float64
+
+	// MinCostFactor is the smallest multiplier CostFunc applies to the
+	// travel time. The A* heuristic is scaled by it, so it never overestimates
+	// the cost. If CostFunc is set and MinCostFactor is 0 (e.g. because the
+	// discounts are unbounded), no heuristic is used (Dijkstra).
+	MinCostFactor float64
+
+	speed        float64         // Base speed in km per day
+	steepness    []float64       // Steepness of all regions
+	biomeFunc    func(r int) int // Biome of a region
+	maxElevation float64         // Maximum elevation
+	roads        map[[2]int]bool // Road segments (from the trade routes)
+}
+
+// NewRoutePlanner returns a new route planner for the given travel mode.
+func (m *Civ) NewRoutePlanner(mode TravelMode) *RoutePlanner {
+	p := &RoutePlanner{
+		Civ:         m,
+		Mode:        mode,
+		BorderDelay: routeBorderDelayDays,
+		RoadFactor:  1.0,
+	}
+	switch mode {
+	case TravelModeFoot:
+		p.speed = routeSpeedFoot
+		p.RoadFactor = routeRoadFactorFoot
+	case TravelModeCart:
+		p.speed = routeSpeedCart
+		p.RoadFactor = routeRoadFactorCart
+	case TravelModeRiverBoat:
+		p.speed = routeSpeedRiverBoat
+	case TravelModeShip:
+		p.speed = routeSpeedShip
+	}
+
+	// Only land routes depend on the terrain and the roads.
+	if mode == TravelModeFoot || mode == TravelModeCart {
+		p.steepness = m.GetSteepness()
+		p.biomeFunc = m.GetRegWhittakerModBiomeFunc()
+		_, p.maxElevation = m.Elevation.MinMax()
+		p.roads = make(map[[2]int]bool)
+		for _, path := range m.TradeRoutes {
+			for i := 1; i < len(path); i++ {
+				p.roads[getSegment(path[i-1], path[i])] = true
+			}
+		}
+	}
+	return p
+}
+
+// PlanRoute returns the fastest route between the given regions using the
+// given travel mode. If there is no route, false is returned.
+func (m *Civ) PlanRoute(from, to int, mode TravelMode) (*Route, bool) {
+	return m.getRoutePlanner(mode).Route(from, to)
+}
+
+// getRoutePlanner returns the cached route planner for the given travel mode,
+// so we don't have to re-calculate the steepness and roads for each route.
+//
+// NOTE: The returned planner must not be modified.
+func (m *Civ) getRoutePlanner(mode TravelMode) *RoutePlanner {
+	if p, ok := m.routePlanners[mode]; ok {
+		return p
+	}
+	if m.routePlanners == nil {
+		m.routePlanners = make(map[TravelMode]*RoutePlanner)
+	}
+	p := m.NewRoutePlanner(mode)
+	m.routePlanners[mode] = p
+	return p
+}
+
+// resetRoutePlanners clears the cached route planners, which needs to
+// happen whenever the roads (trade routes) or the terrain and climate (see
+// onClimateChanged) change.
+func (m *Civ) resetRoutePlanners() {
+	m.routePlanners = nil
+}
+
+// Route returns the cheapest route between the given regions using A*. If
+// there is no route, false is returned.
+//
+// For river boats and ships, the start and destination region may be on
+// land next to the water (e.g. a port).
+func (p *RoutePlanner) Route(from, to int) (*Route, bool) {
+	if from == to {
+		return &Route{Mode: p.Mode, Path: []int{from}}, true
+	}
+	cost := map[int]float64{from: 0}
+	prev := make(map[int]int)
+	done := make(map[int]bool)
+
+	var queue geo.AscPriorityQueue
+	heap.Init(&queue)
+	heap.Push(&queue, &geo.QueueEntry{
+		Score:       p.estimateCost(from, to),
+		Origin:      from,
+		Destination: from,
+	})
+
+	// Allocate a slice for the output of mesh.R_circulate_r.
+	outRegs := make([]int, 0, 8)
+	for queue.Len() > 0 {
+		u := heap.Pop(&queue).(*geo.QueueEntry).Destination
+		if u == to {
+			return p.buildRoute(from, to, prev, cost[to]), true
+		}
+		if done[u] {
+			continue
+		}
+		done[u] = true
+		for _, v := range p.SphereMesh.R_circulate_r(outRegs, u) {
+			if done[v] {
+				continue
+			}
+			days, ok := p.travelTime(u, v, from, to)
+			if !ok {
+				continue
+			}
+			if p.CostFunc != nil {
+				if days = p.CostFunc(u, v, days); days < 0 {
+					continue
+				}
+			}
+			newCost := cost[u] + days
+			if c, ok := cost[v]; ok && newCost >= c {
+				continue
+			}
+			cost[v] = newCost
+			prev[v] = u
+			heap.Push(&queue, &geo.QueueEntry{
+				Score:       newCost + p.estimateCost(v, to),
+				Origin:      from,
+				Destination: v,
+			})
+		}
+	}
+	return nil, false
+}
+
+// buildRoute reconstructs the route from the A* results.
+func (p *RoutePlanner) buildRoute(from, to int, prev map[int]int, cost float64) *Route {
+	path := []int{to}
+	for r := to; r != from; {
+		r = prev[r]
+		path = append(path, r)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	route := &Route{
+		Mode: p.Mode,
+		Path: path,
+		Cost: cost,
+	}
+	for i := 1; i < len(path); i++ {
+		route.Distance += p.DistToKm(p.GetDistance(path[i-1], path[i]))
+		days, _ := p.travelTime(path[i-1], path[i], from, to)
+		route.Duration += days
+	}
+	return route
+}
+
+// estimateCost returns the minimum cost between the given regions (the A*
+// heuristic), which is the minimum travel time in days scaled by the smallest
+// multiplier of CostFunc (see MinCostFactor).
+func (p *RoutePlanner) estimateCost(u, v int) float64 {
+	factor := 1.0
+	if p.CostFunc != nil {
+		if p.MinCostFactor <= 0 {
+			return 0
+		}
+		factor = p.MinCostFactor
+	}
+	return factor * p.DistToKm(p.GetDistance(u, v)) / (p.speed * math.Max(1, p.RoadFactor))
+}
+
+// travelTime returns the time in days it takes to travel from region 'u' to
+// the neighbor region 'v' and false if the edge is impassable. 'from' and
+// 'to' are the start and destination of the route.
+func (p *RoutePlanner) travelTime(u, v, from, to int) (float64, bool) {
+	km := p.DistToKm(p.GetDistance(u, v))
+	var days float64
+	switch p.Mode {
+	case TravelModeFoot, TravelModeCart:
+		factor, ok := p.landFactor(u, v)
+		if !ok {
+			return 0, false
+		}
+		days = km * factor / p.speed
+	case TravelModeRiverBoat, TravelModeShip:
+		// Embarking and disembarking happens on foot.
+		if (u == from || v == to) && !p.isNavigable(u, v) {
+			if !p.isNavigable(u, u) && !p.isNavigable(v, v) {
+				return 0, false
+			}
+			days = km / routeSpeedFoot
+			break
+		}
+		factor, ok := p.waterFactor(u, v)
+		if !ok {
+			return 0, false
+		}
+		days = km * factor / p.speed
+	}
+
+	// Crossing into another empire takes some time.
+	if eu, ev := p.RegionToEmpire[u], p.RegionToEmpire[v]; eu >= 0 && ev >= 0 && eu != ev {
+		days += p.BorderDelay
+	}
+	return days, true
+}
+
+// landFactor returns the slowdown of traveling over land from region 'u' to
+// the neighbor region 'v' and false if the edge is impassable.
+func (p *RoutePlanner) landFactor(u, v int) (float64, bool) {
+	if p.Elevation.At(u) <= 0 || p.Elevation.At(v) <= 0 || p.Waterpool.At(v) > 0 {
+		return 0, false
+	}
+	steep := p.steepness[v]
+	if p.Mode == TravelModeCart && steep > routeCartMaxSteep {
+		return 0, false
+	}
+
+	// Uphill is slower, downhill isn't much faster.
+	factor := 1.0
+	if rise := (p.Elevation.At(v) - p.Elevation.At(u)) / p.maxElevation; rise > 0 {
+		factor += rise * routeUphillPenalty
+	}
+
+	// The steeper the terrain, the slower.
+	factor *= 1.0 + steep*steep
+
+	// Roads are faster and make the biome and rivers irrelevant.
+	if p.roads[getSegment(u, v)] {
+		return factor / p.RoadFactor, true
+	}
+	factor *= routeBiomeFactor(p.biomeFunc(v))
+
+	if p.IsRegRiver(u) && p.IsRegRiver(v) {
+		factor *= routeRiverValley
+	} else if p.IsRegRiver(u) != p.IsRegRiver(v) {
+		factor *= routeRiverCrossing
+	}
+	return factor, true
+}
+
+// waterFactor returns the slowdown of traveling over water from region 'u'
+// to the neighbor region 'v' and false if the edge is impassable.
+func (p *RoutePlanner) waterFactor(u, v int) (float64, bool) {
+	if !p.isNavigable(u, v) {
+		return 0, false
+	}
+	if p.Mode == TravelModeShip {
+		return 1.0, true
+	}
+	if p.IsRegLake(u) && p.IsRegLake(v) {
+		return 1.0 / routeLakeFactor, true
+	}
+	if p.Downhill[v] == u {
+		return 1.0 / routeUpstreamFactor, true
+	}
+	return 1.0, true
+}
+
+// isNavigable returns true if the edge from region 'u' to the neighbor
+// region 'v' is navigable with the travel mode of the planner. If 'u' and 'v'
+// are the same, it returns true if the region is navigable.
+func (p *RoutePlanner) isNavigable(u, v int) bool {
+	switch p.Mode {
+	case TravelModeShip:
+		return p.Elevation.At(u) <= 0 && p.Elevation.At(v) <= 0
+	case TravelModeRiverBoat:
+		isWater := func(r int) bool {
+			return p.Elevation.At(r) > 0 && (p.IsRegRiver(r) || p.IsRegLake(r))
+		}
+		if !isWater(u) || !isWater(v) {
+			return false
+		}
+		// Boats can only follow the flow of the river (up or down) or
+		// cross lakes.
+		return u == v || p.Downhill[u] == v || p.Downhill[v] == u || (p.IsRegLake(u) && p.IsRegLake(v))
+	}
+	return false
+}
+
+// routeBiomeFactor returns the slowdown of traveling through the given biome.
+func routeBiomeFactor(biome int) float64 {
+	switch biome {
+	case genbiome.WhittakerModBiomeTropicalRainforest,
+		genbiome.WhittakerModBiomeTemperateRainforest,
+		genbiome.WhittakerModBiomeHotSwamp,
+		genbiome.WhittakerModBiomeWetlands,
+		genbiome.WhittakerModBiomeSnow:
+		return 2.0
+	case genbiome.WhittakerModBiomeTropicalSeasonalForest,
+		genbiome.WhittakerModBiomeTemperateSeasonalForest,
+		genbiome.WhittakerModBiomeBorealForestTaiga:
+		return 1.5
+	case genbiome.WhittakerModBiomeColdDesert,
+		genbiome.WhittakerModBiomeSubtropicalDesert:
+		return 1.3
+	case genbiome.WhittakerModBiomeTundra,
+		genbiome.WhittakerModBiomeWoodlandShrubland:
+		return 1.2
+	}
+	return 1.0
+}
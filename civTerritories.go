@@ -1,39 +1,57 @@
 package genworldvoronoi
 
 import (
-	"container/heap"
 	"math"
 
 	"github.com/Flokey82/genworldvoronoi/geo"
 	"github.com/Flokey82/genworldvoronoi/various"
 )
 
+// territoryRiverPenalty is the penalty (in km) for crossing the river with
+// the highest flux.
+const territoryRiverPenalty = 3600.0
+
+// getTerritoryPenaltyDist returns the distance (in km) over which the
+// per-region penalties of the territory weight functions are applied, which
+// is the average distance between the regions of the mesh.
+func (m *Civ) getTerritoryPenaltyDist() float64 {
+	return m.DistToKm(math.Sqrt(4 * math.Pi / float64(m.SphereMesh.NumRegions)))
+}
+
 // getTerritoryCultureWeightFunc returns a weight function which returns a penalty
 // for expanding from a region into a region with a different culture.
 // An additional penalty is applied if the destination region has a different
 // culture than the origin region.
-func (m *Civ) getTerritoryCultureWeightFunc() func(o, u, v int) float64 {
-	return func(o, u, v int) float64 {
+//
+// NOTE: The penalties are fractions of the circumference, converted to km, so
+// they can be added to the distances of getTerritoryWeightFunc. Crossing a
+// border costs the same on any mesh, while penalties that apply to each
+// region we pass through are scaled by the distance between the regions.
+func (m *Civ) getTerritoryCultureWeightFunc() geo.EdgeCostFunc {
+	circumference := m.DistToKm(2 * math.Pi)
+	penaltyDist := m.getTerritoryPenaltyDist()
+	return func(o, u, v int, dist float64) float64 {
 		var penalty float64
 		// TODO: Compare culture expansionism?
 		// If the destination has a higher culture expansionism than the
 		// origin culture, then it's less likely to expand into that territory.
 		if m.RegionToCulture[o] != m.RegionToCulture[v] {
-			penalty += 0.25
+			penalty += 0.25 * dist / penaltyDist
 		}
 		if m.RegionToCulture[u] != m.RegionToCulture[v] {
 			penalty += 0.75
 		}
-		return penalty
+		return penalty * circumference
 	}
 }
 
 // getTerritoryBiomeWeightFunc returns a weight function which returns a penalty
 // for expanding from a region into a region with a different biome.
-func (m *Civ) getTerritoryBiomeWeightFunc() func(o, u, v int) float64 {
+func (m *Civ) getTerritoryBiomeWeightFunc() geo.EdgeCostFunc {
 	biomeFunc := m.GetRegWhittakerModBiomeFunc()
 	climatFunc := m.GetFitnessClimate()
-	return func(o, u, v int) float64 {
+	circumference := m.DistToKm(2 * math.Pi)
+	return func(o, u, v int, dist float64) float64 {
 		var penalty float64
 
 		// Try to stick with original biome?
@@ -47,22 +65,21 @@ func (m *Civ) getTerritoryBiomeWeightFunc() func(o, u, v int) float64 {
 			// Penalty is higher for inhospitable climates.
 			penalty += 0.1 + 0.9*(1-(climatFunc(u)+climatFunc(v))/2)
 		}
-		return penalty
+		return penalty * circumference
 	}
 }
 
 // getTerritoryWeightFunc returns a weight function which returns a penalty
-// depending on the slope of the terrain, the distance, and changes in
-// flux (river crossings).
-func (m *Civ) getTerritoryWeightFunc() func(o, u, v int) float64 {
+// depending on the slope of the terrain, the distance, and river crossings.
+func (m *Civ) getTerritoryWeightFunc() geo.EdgeCostFunc {
 	// Get maxFlux and maxElev for normalizing.
-	_, maxFlux := minMax(m.Flux)
-	_, maxElev := minMax(m.Elevation)
+	_, maxFlux := m.Flux.MinMax()
+	_, maxElev := m.Elevation.MinMax()
 
-	return func(o, u, v int) float64 {
+	return func(o, u, v int, dist float64) float64 {
 		// Don't cross from water to land and vice versa,
 		// don't do anything below or at sea level.
-		if (m.Elevation[u] > 0) != (m.Elevation[v] > 0) || m.Elevation[v] <= 0 {
+		if (m.Elevation.At(u) > 0) != (m.Elevation.At(v) > 0) || m.Elevation.At(v) <= 0 {
 			return -1
 		}
 
@@ -79,72 +96,59 @@ func (m *Civ) getTerritoryWeightFunc() func(o, u, v int) float64 {
 		// originDist := haversine(vlat, vlon, oLat, oLon) / (2 * math.Pi)
 
 		// Calculate vertical distance.
-		vert := (m.Elevation[v] - m.Elevation[u]) / maxElev
+		vert := (m.Elevation.At(v) - m.Elevation.At(u)) / maxElev
 		if vert > 0 {
 			vert /= 10
 		}
 		diff := 1 + 0.25*math.Pow(vert/horiz, 2)
-		diff += 100 * math.Sqrt(m.Flux[u]/maxFlux)
-		if m.Elevation[u] <= 0 {
-			diff = 100
+
+		// Crossing a river (entering it from a region without a river)
+		// costs the same on any mesh, so the river penalty is not scaled by
+		// the distance between the regions. Following the river is free.
+		var river float64
+		if m.IsRegRiver(v) && !m.IsRegRiver(u) {
+			river = territoryRiverPenalty * math.Sqrt(m.Flux.At(v)/maxFlux)
 		}
-		return horiz * diff
+		return dist*diff + river
 	}
 }
 
-// NOTE: The weight function takes three parameters:
+// regPlaceNTerritoriesCustom expands the territories from the given seed
+// points (see geo.AssignGeodesicDistanceField) and returns the updated
+// mapping of region to territory. Regions that already belong to a
+// territory are not re-assigned.
+//
+// NOTE: The weight function takes four parameters:
 // o: The origin/seed region
 // u: The region we expand from
 // v: The region we expand to
-func (m *Civ) regPlaceNTerritoriesCustom(terr, seedPoints []int, weight func(o, u, v int) float64) []int {
-	var queue geo.AscPriorityQueue
-	heap.Init(&queue)
-	outReg := make([]int, 0, 8)
-
+// dist: The distance between u and v in km
+func (m *Civ) regPlaceNTerritoriesCustom(terr, seedPoints []int, weight geo.EdgeCostFunc) []int {
 	// If force rebuild is enabled, we will rebuild all territories from scratch.
 	forceRebuild := false
 
 	// 'terr' will hold a mapping of region to territory.
 	// The territory ID is the region number of the capital city.
-	//terr := initRegionSlice(m.SphereMesh.NumRegions)
-	for i := 0; i < len(seedPoints); i++ {
-		if !forceRebuild && terr[seedPoints[i]] >= 0 {
-			continue
-		}
-		terr[seedPoints[i]] = seedPoints[i]
-		for _, v := range m.SphereMesh.R_circulate_r(outReg, seedPoints[i]) {
-			newdist := weight(seedPoints[i], seedPoints[i], v)
-			if newdist < 0 {
-				continue
+	stopReg := make(map[int]bool)
+	if !forceRebuild {
+		for r, t := range terr {
+			if t >= 0 {
+				stopReg[r] = true
 			}
-			heap.Push(&queue, &geo.QueueEntry{
-				Score:       newdist,
-				Origin:      seedPoints[i],
-				Destination: v,
-			})
 		}
 	}
-
-	// Extend territories until the queue is empty.
-	for queue.Len() > 0 {
-		u := heap.Pop(&queue).(*geo.QueueEntry)
-		if terr[u.Destination] >= 0 {
-			continue
+	var seeds []int
+	for _, r := range seedPoints {
+		if !stopReg[r] {
+			seeds = append(seeds, r)
 		}
-		terr[u.Destination] = u.Origin
-		for _, v := range m.SphereMesh.R_circulate_r(outReg, u.Destination) {
-			if terr[v] >= 0 {
-				continue
-			}
-			newdist := weight(u.Origin, u.Destination, v)
-			if newdist < 0 {
-				continue
-			}
-			heap.Push(&queue, &geo.QueueEntry{
-				Score:       u.Score + newdist,
-				Origin:      u.Origin,
-				Destination: v,
-			})
+	}
+
+	// Extend the territories to the regions closest to their seed point.
+	df := m.AssignGeodesicDistanceField(seeds, stopReg, weight)
+	for r, o := range df.Label {
+		if o >= 0 {
+			terr[r] = o
 		}
 	}
 	return terr
@@ -2,11 +2,9 @@ package genworldvoronoi
 
 import (
 	"log"
-	"math"
 	"sort"
 
 	"github.com/Flokey82/genworldvoronoi/various"
-	goastar "github.com/beefsack/go-astar"
 )
 
 func (m *Civ) GetTradeRoutes() ([][]int, [][]int) {
@@ -20,97 +18,133 @@ func (m *Civ) GetTradeRoutes() ([][]int, [][]int) {
 	// will experience growth through trade passing through, which is something
 	// to consider later.
 	log.Println("Generating trade routes...")
-	nodeCache := make(map[int]*TradeTile)
-	steepness := m.GetSteepness()
-
 	cities := m.Cities
 	isCity := make(map[int]bool)
 	for _, c := range cities {
 		isCity[c.ID] = true
 	}
 
-	_, maxElevation := minMax(m.Elevation)
-
 	// linking will store which cities are linked through a trade route crossing
 	// the given region.
 	linking := make([][]int, m.SphereMesh.NumRegions)
 
-	// visited will store which city pairs have already been visited.
-	visited := make(map[[2]int]bool)
-
-	// visitedPathSeg will store how often path segments (neighboring regions connected by a trade route)
-	// have been used
-	visitedPathSeg := make(map[[2]int]int)
-
-	// wasVisited returns how often the path segment between the two given regions has been used.
-	var wasVisited func(i, j int) int
-	wasVisited = func(i, j int) int {
-		return visitedPathSeg[getSegment(i, j)]
-	}
+	// Trade routes are planned on foot (pack animals can handle steep terrain),
+	// with additional incentives to re-use existing routes (see
+	// connectTradeRoutes).
+	planner := m.NewRoutePlanner(TravelModeFoot)
 
-	// getTile returns the TradeTile for the given index from the node cache.
-	var getTile func(i int) *TradeTile
-	getTile = func(i int) *TradeTile {
-		// Make sure we re-use pre-existing nodes.
-		n, ok := nodeCache[i]
-		if ok {
-			return n
+	// TODO: Pair up by import/export of goods and taxes to the capital.
+	// Get the candidates for each city sorted by distance in parallel, as we
+	// try to connect the closest towns first.
+	// NOTE: Wouldn't it make sense to connect the largest cities first?
+	candidates := make([][]int, len(cities))
+	m.GetExecutor().ParallelFor(len(cities), func(start, end int) {
+		for i := start; i < end; i++ {
+			candidates[i] = m.getTradeRouteCandidates(cities, i)
 		}
-
-		// If we have no cached node for this index,
-		// create a new one.
-		n = &TradeTile{
-			steepness:    steepness,
-			r:            m,
-			index:        i,
-			getTile:      getTile,
-			isCity:       isCity,
-			wasVisited:   wasVisited,
-			maxElevation: maxElevation,
+	})
+
+	// Cities are only connected to cities on the same landmass and routes on
+	// foot never leave the land, so the routes of different landmasses never
+	// share any regions and can be found in parallel.
+	var landmassCities [][]int
+	landmassToGroup := make(map[int]int)
+	for i, c := range cities {
+		g, ok := landmassToGroup[m.Landmasses[c.ID]]
+		if !ok {
+			g = len(landmassCities)
+			landmassToGroup[m.Landmasses[c.ID]] = g
+			landmassCities = append(landmassCities, nil)
 		}
-		nodeCache[i] = n
-		return n
+		landmassCities[g] = append(landmassCities[g], i)
 	}
 
+	// cityPaths contains the trade routes starting at each city.
+	cityPaths := make([][][]int, len(cities))
+	m.GetExecutor().ParallelFor(len(landmassCities), func(start, end int) {
+		for g := start; g < end; g++ {
+			m.connectTradeRoutes(planner, cities, landmassCities[g], candidates, isCity, linking, cityPaths)
+		}
+	})
+
 	// Paths contains a list of all trade routes represented through
 	// a list of connected regions.
 	//
 	// Note that we still double up if two trade routes happen to
 	// share a common section leading up to a city.
 	var paths [][]int
+	for _, cp := range cityPaths {
+		paths = append(paths, cp...)
+	}
 
-	// TODO: Pair up by import/export of goods and taxes to the capital.
-	sortCityIdx := make([]int, len(cities))
-	for i := range sortCityIdx {
-		sortCityIdx[i] = i
+	log.Println("Done generating trade routes.")
+	return paths, linking
+}
+
+// connectTradeRoutes connects the cities with the given indices (which
+// must be on the same landmass) to their closest candidates and stores the
+// routes starting at each city in cityPaths.
+//
+// The planner is copied, so the incentives to re-use routes only depend on
+// the routes on the same landmass, which allows running this in parallel
+// for different landmasses.
+func (m *Civ) connectTradeRoutes(planner *RoutePlanner, cities []*City, cityIdxs []int, candidates [][]int, isCity map[int]bool, linking [][]int, cityPaths [][][]int) {
+	// visited will store which city pairs have already been visited.
+	visited := make(map[[2]int]bool)
+
+	// visitedPathSeg will store how often path segments (neighboring regions connected by a trade route)
+	// have been used
+	visitedPathSeg := make(map[[2]int]int)
+
+	// used will store how often a region has been used by a trade route.
+	used := make(map[int]int)
+
+	// NOTE: The discounts for re-used segments are unbounded, so we leave
+	// MinCostFactor at 0, which disables the A* heuristic. Otherwise the
+	// search would skip the cheaper re-used routes.
+	p := *planner
+	p.CostFunc = func(u, v int, cost float64) float64 {
+		// Highly incentivize re-using used segments
+		if nvis := visitedPathSeg[getSegment(u, v)]; nvis > 0 {
+			cost /= 8.0 * float64(nvis) * float64(nvis)
+		} else {
+			cost *= 8.0
+		}
+
+		// Heavily incentivize re-using existing roads.
+		if nUsed := used[v]; nUsed > 0 {
+			cost /= 8.0 * float64(nUsed) * float64(nUsed)
+		} else {
+			cost *= 8.0
+		}
+
+		// Bonus if the neighbor is a city.
+		if isCity[v] {
+			cost /= 4.0
+		}
+
+		// Bonus if along coast.
+		for _, nbnb := range m.GetRegNeighbors(v) {
+			if m.Elevation.At(nbnb) <= 0 {
+				cost /= 2.0
+				break
+			}
+		}
+		return cost
 	}
 
 	connectNClosest := 5
-	for i, startC := range cities {
-		start := startC.ID
-		// Sort by distance to start as we try to connect the closest towns first.
-		// NOTE: Wouldn't it make sense to connect the largest cities first?
-		sort.Slice(sortCityIdx, func(j, k int) bool {
-			return m.GetDistance(start, cities[sortCityIdx[j]].ID) < m.GetDistance(start, cities[sortCityIdx[k]].ID)
-		})
-
+	for _, i := range cityIdxs {
+		start := cities[i].ID
 		var connections int
-		for _, j := range sortCityIdx {
+		for _, j := range candidates[i] {
 			if connections >= connectNClosest {
 				break
 			}
-			// We don't want to link a city to itself and we try to avoid double
-			// links (a->b and b->a) as well as we try to only connect towns within
-			// the same territory.
-			if i == j {
-				continue
-			}
-
+			// We try to avoid double links (a->b and b->a).
 			end := cities[j].ID
 			curEdge := getSegment(start, end)
-			if visited[curEdge] ||
-				m.RegionToEmpire[start] != m.RegionToEmpire[end] ||
-				m.Landmasses[start] != m.Landmasses[end] { //  || math.Abs(float64(i-j)) > float64(5)
+			if visited[curEdge] {
 				continue
 			}
 			connections++
@@ -119,16 +153,14 @@ func (m *Civ) GetTradeRoutes() ([][]int, [][]int) {
 			visited[curEdge] = true
 
 			// Attempt to find a path between the two cities.
-			path, _, found := goastar.Path(getTile(start), getTile(end))
+			route, found := p.Route(start, end)
 			if !found {
 				continue
 			}
-			var newPath []int
-			for idx, n := range path {
+			newPath := route.Path
+			for idx, nIdx := range newPath {
 				// Mark the node as used.
-				nti := n.(*TradeTile)
-				nti.SetUsed()
-				nIdx := nti.index
+				used[nIdx]++
 				if idx > 0 {
 					visitedPathSeg[getSegment(newPath[idx-1], nIdx)]++
 				}
@@ -141,107 +173,35 @@ func (m *Civ) GetTradeRoutes() ([][]int, [][]int) {
 				if !isInIntList(linking[nIdx], end) {
 					linking[nIdx] = append(linking[nIdx], end)
 				}
-
-				// Append the region to the path.
-				newPath = append(newPath, nIdx)
 			}
-			paths = append(paths, newPath)
+			cityPaths[i] = append(cityPaths[i], newPath)
 		}
 		log.Println("Done connecting city", i, "of", len(cities))
 	}
-
-	log.Println("Done generating trade routes.")
-	return paths, linking
-}
-
-type TradeTile struct {
-	r            *Civ                   // Reference to the Civ object
-	getTile      func(i int) *TradeTile // Fetch tiles from cache
-	index        int                    // region index
-	used         int                    // number of times this node was used for a trade route
-	steepness    []float64              // cached steepness of all regiones
-	wasVisited   func(i, j int) int     // quick lookup if a segment was already visited
-	isCity       map[int]bool           // quick lookup if an index is a city
-	maxElevation float64
-}
-
-func (n *TradeTile) SetUsed() {
-	n.used++
-}
-
-// PathNeighbors returns the direct neighboring nodes of this node which
-// can be pathed to.
-func (n *TradeTile) PathNeighbors() []goastar.Pather {
-	nbs := make([]goastar.Pather, 0, 6)
-	for _, i := range n.r.GetRegNeighbors(n.index) {
-		nbs = append(nbs, n.getTile(i))
-	}
-	return nbs
 }
 
-// PathNeighborCost calculates the exact movement cost to neighbor nodes.
-func (n *TradeTile) PathNeighborCost(to goastar.Pather) float64 {
-	tot := to.(*TradeTile)
-
-	// Discourage underwater paths.
-	if n.r.Elevation[n.index] <= 0 || n.r.Elevation[tot.index] <= 0 {
-		return math.Inf(1)
-	}
-
-	// TODO: Fix this... this is highly inefficient.
-	nIdx := tot.index
-
-	// Altitude changes come with a cost (downhill is cheaper than uphill)
-	cost := 1.0 + (n.r.Elevation[nIdx]-n.r.Elevation[n.index])/n.maxElevation
-
-	// The steeper the terrain, the more expensive.
-	cost *= 1.0 + n.steepness[nIdx]*n.steepness[nIdx]
-
-	// Highly incentivize re-using used segments
-	if nvis := n.wasVisited(n.index, nIdx); nvis > 0 {
-		cost /= 8.0 * float64(nvis) * float64(nvis)
-	} else {
-		cost *= 8.0
-	}
-
-	// Heavily incentivize re-using existing roads.
-	if nUsed := tot.used; nUsed > 0 {
-		cost /= 8.0 * float64(nUsed) * float64(nUsed)
-	} else {
-		cost *= 8.0
-	}
-
-	// Bonus if the neighbor is a city.
-	if n.isCity[nIdx] {
-		cost /= 4.0
-	}
-
-	// Bonus if along coast.
-	for _, nbnb := range n.r.GetRegNeighbors(nIdx) {
-		if n.r.Elevation[nbnb] <= 0 {
-			cost /= 2.0
-			break
+// getTradeRouteCandidates returns the indices of the cities that the city
+// with the given index might be connected to by a trade route, sorted by
+// distance.
+func (m *Civ) getTradeRouteCandidates(cities []*City, i int) []int {
+	start := cities[i].ID
+	var idxs []int
+	dist := make(map[int]float64)
+	for j, c := range cities {
+		// We don't want to link a city to itself and we try to only connect
+		// towns within the same territory.
+		if i == j ||
+			m.RegionToEmpire[start] != m.RegionToEmpire[c.ID] ||
+			m.Landmasses[start] != m.Landmasses[c.ID] { //  || math.Abs(float64(i-j)) > float64(5)
+			continue
 		}
+		idxs = append(idxs, j)
+		dist[j] = m.GetDistance(start, c.ID)
 	}
-
-	if n.r.IsRegRiver(n.index) && n.r.IsRegRiver(nIdx) {
-		cost *= 0.8 // Bonus if along rivers.
-	} else if n.r.IsRegRiver(n.index) != n.r.IsRegRiver(nIdx) {
-		cost *= 1.4 // Cost of crossing rivers.
-	}
-
-	// Penalty for crossing into a new territory
-	if n.r.RegionToEmpire[n.index] != n.r.RegionToEmpire[nIdx] {
-		cost *= 2.0
-	}
-
-	return cost
-}
-
-// PathEstimatedCost is a heuristic method for estimating movement costs
-// between non-adjacent nodes.
-func (n *TradeTile) PathEstimatedCost(to goastar.Pather) float64 {
-	return n.r.GetDistance(n.index, to.(*TradeTile).index)
+	sort.Slice(idxs, func(a, b int) bool {
+		return dist[idxs[a]] < dist[idxs[b]]
+	})
+	return idxs
 }
 
 func getSegment(a, b int) [2]int {
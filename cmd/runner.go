@@ -4,13 +4,21 @@ import (
 	"flag"
 	"log"
 	"os"
+	"runtime"
 	"runtime/pprof"
 
 	"github.com/Flokey82/genworldvoronoi"
+	"github.com/Flokey82/genworldvoronoi/spheremesh"
+	"github.com/Flokey82/genworldvoronoi/various"
 )
 
 var cpuprofile = flag.String("cpuprofile", "", "write cpu profile to file")
 var memprofile = flag.String("memprofile", "", "write memory profile to this file")
+var workers = flag.Int("workers", 0, "number of workers for parallel work (0 for GOMAXPROCS)")
+var numPoints = flag.Int("num_points", 0, "number of points (0 for the default)")
+var memstats = flag.Bool("memstats", false, "log the heap size of the generated world")
+var float32Storage = flag.Bool("float32", false, "store the bulk terrain layers with float32 precision")
+var mesh = flag.String("mesh", "", "point distribution (icosahedron, healpix or poisson; empty for the Fibonacci sphere)")
 
 func main() {
 	flag.Parse()
@@ -24,12 +32,32 @@ func main() {
 	}
 
 	cfg := genworldvoronoi.NewConfig()
+	cfg.Executor = various.NewExecutor(*workers)
+	if *numPoints > 0 {
+		cfg.GeoConfig.NumPoints = *numPoints
+	}
+	cfg.GeoConfig.Float32Storage = *float32Storage
+	if *mesh != "" {
+		gen, err := spheremesh.NewMeshGenerator(*mesh, 1234)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.GeoConfig.MeshGenerator = gen
+	}
 
 	sp, err := genworldvoronoi.NewMapFromConfig(1234, cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	if *memstats {
+		// Collect garbage first, so only the live heap of the world is reported.
+		runtime.GC()
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		log.Printf("%d regions: heap %d MB (%d MB obtained from the OS)", sp.SphereMesh.NumRegions, ms.HeapAlloc>>20, ms.Sys>>20)
+	}
+
 	sp.GetEmpires()
 	exportPNG := false
 	exportOBJ := false
@@ -3,14 +3,18 @@ package main
 import (
 	"bytes"
 	"compress/gzip"
+	"encoding/json"
 	"flag"
 	"image"
 	"image/png"
 	"log"
 	"net/http"
+	"os"
 	"strconv"
 
 	"github.com/Flokey82/genworldvoronoi"
+	"github.com/Flokey82/genworldvoronoi/geo"
+	"github.com/Flokey82/genworldvoronoi/various"
 	"github.com/gorilla/mux"
 )
 
@@ -25,6 +29,15 @@ var (
 	numVolcanoes            int     = 10
 	jitter                  float64 = 0.0
 	useGlobe                bool    = false
+	adaptiveMesh            bool    = false
+	noiseLayers             string  = ""
+	heightmap               string  = ""
+	heightmapSeaLevel       float64 = 0.5
+	heightmapWidth          int     = 0
+	heightmapHeight         int     = 0
+	landMask                string  = ""
+	numWorkers              int     = 0
+	layersFile              string  = ""
 )
 
 func init() {
@@ -34,6 +47,15 @@ func init() {
 	flag.IntVar(&numVolcanoes, "num_volcanoes", numVolcanoes, "number of volcanoes")
 	flag.BoolVar(&useGlobe, "use_globe", useGlobe, "use 3D globe")
 	flag.Float64Var(&jitter, "jitter", jitter, "jitter")
+	flag.BoolVar(&adaptiveMesh, "adaptive_mesh", adaptiveMesh, "place more points near coasts and mountains")
+	flag.StringVar(&noiseLayers, "noise_layers", noiseLayers, "terrain noise preset (warped, ridged)")
+	flag.StringVar(&heightmap, "heightmap", heightmap, "equirectangular (16 bit grayscale) PNG or raw (little endian float32) heightmap to use instead of plates")
+	flag.Float64Var(&heightmapSeaLevel, "heightmap_sea_level", heightmapSeaLevel, "heightmap value (0.0-1.0 for PNGs, raw value for raw heightmaps) at sea level")
+	flag.IntVar(&heightmapWidth, "heightmap_width", heightmapWidth, "width of the raw heightmap (0 for PNG heightmaps)")
+	flag.IntVar(&heightmapHeight, "heightmap_height", heightmapHeight, "height of the raw heightmap (0 for PNG heightmaps)")
+	flag.StringVar(&landMask, "land_mask", landMask, "equirectangular PNG land mask (white is land) for the heightmap")
+	flag.IntVar(&numWorkers, "workers", numWorkers, "number of workers for parallel work (0 for GOMAXPROCS)")
+	flag.StringVar(&layersFile, "layers", layersFile, "custom data layers to load (exported via /layers_export from a world with the same seed and config)")
 }
 
 func main() {
@@ -47,6 +69,22 @@ func main() {
 	cfg.GeoConfig.NumPoints = numPoints
 	cfg.GeoConfig.NumVolcanoes = numVolcanoes
 	cfg.GeoConfig.Jitter = jitter
+	cfg.GeoConfig.AdaptiveMesh = adaptiveMesh
+	cfg.Executor = various.NewExecutor(numWorkers)
+	if noiseLayers != "" {
+		preset, ok := geo.NoiseLayerPresets[noiseLayers]
+		if !ok {
+			log.Fatalf("unknown noise layer preset %q", noiseLayers)
+		}
+		cfg.GeoConfig.NoiseLayers = preset()
+	}
+	if heightmap != "" {
+		hm, err := loadHeightmap(heightmap, heightmapWidth, heightmapHeight, heightmapSeaLevel, landMask)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.GeoConfig.Heightmap = hm
+	}
 
 	// Initialize the planet.
 	sp, err := genworldvoronoi.NewMapFromConfig(seed, cfg)
@@ -54,6 +92,11 @@ func main() {
 		log.Fatal(err)
 	}
 	worldmap = sp
+	if layersFile != "" {
+		if err := loadLayers(sp, layersFile); err != nil {
+			log.Fatal(err)
+		}
+	}
 
 	// Start the server.
 	router := mux.NewRouter()
@@ -63,6 +106,13 @@ func main() {
 	router.HandleFunc("/terrain3d/{z}/{x}/{y}.terrain", tile3dHandler)
 	router.HandleFunc("/geojson_cities/{z}/{la1}/{lo1}/{la2}/{lo2}", geoJSONCitiesHandler)
 	router.HandleFunc("/geojson_borders/{z}/{la1}/{lo1}/{la2}/{lo2}", geoJSONBorderHandler)
+	router.HandleFunc("/geojson_storms/{z}/{la1}/{lo1}/{la2}/{lo2}", geoJSONStormsHandler)
+	router.HandleFunc("/geojson_features/{z}/{la1}/{lo1}/{la2}/{lo2}", geoJSONFeaturesHandler)
+	router.HandleFunc("/layers", layersHandler)
+	router.HandleFunc("/layers_export", layersExportHandler)
+	router.HandleFunc("/resources", resourcesHandler)
+	router.HandleFunc("/query/nearest/{lat}/{lon}/{k}", queryNearestHandler)
+	router.HandleFunc("/query/radius/{lat}/{lon}/{km}", queryRadiusHandler)
 	if useGlobe {
 		router.PathPrefix("/").Handler(http.FileServer(http.Dir("static_cesium")))
 	} else {
@@ -137,6 +187,21 @@ func geoJSONBorderHandler(res http.ResponseWriter, req *http.Request) {
 	res.Write(data)
 }
 
+func geoJSONStormsHandler(res http.ResponseWriter, req *http.Request) {
+	// Get the tile coordinates and zoom level.
+	tileLa1, tileLo1, tileLa2, tileLo2, tileZ, err := parseBoundingBox(req)
+	if err != nil {
+		panic(err)
+	}
+	data, err := worldmap.GetGeoJSONStormTracks(tileLa1, tileLo1, tileLa2, tileLo2, tileZ)
+	if err != nil {
+		panic(err)
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	res.Write(data)
+}
+
 func tileHandler(res http.ResponseWriter, req *http.Request) {
 	// Get the url parameter 'd'.
 	d := req.URL.Query().Get("d")
@@ -188,6 +253,12 @@ func tileHandler(res http.ResponseWriter, req *http.Request) {
 		aspectshadows = "false"
 	}
 
+	// get the url parameter 'fires'.
+	fires := req.URL.Query().Get("fires")
+	if fires == "" {
+		fires = "false"
+	}
+
 	// Get the tile coordinates and zoom level.
 	vars := mux.Vars(req)
 	tileX, err := strconv.Atoi(vars["x"])
@@ -204,7 +275,7 @@ func tileHandler(res http.ResponseWriter, req *http.Request) {
 	}
 
 	// Get the tile image.
-	img := worldmap.GetTile(tileX, tileY, tileZ, displayMode, vectorMode, rivers == "true", trade == "true", lakes == "true", shadows == "true", aspectshadows == "true")
+	img := worldmap.GetTile(tileX, tileY, tileZ, displayMode, vectorMode, rivers == "true", trade == "true", lakes == "true", shadows == "true", aspectshadows == "true", fires == "true")
 	writeImage(res, &img)
 }
 
@@ -360,3 +431,170 @@ func tileHeightMapHandler(res http.ResponseWriter, req *http.Request) {
 	res.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 	res.Write(data)
 }
+
+func geoJSONFeaturesHandler(res http.ResponseWriter, req *http.Request) {
+	// Get the tile coordinates and zoom level.
+	tileLa1, tileLo1, tileLa2, tileLo2, tileZ, err := parseBoundingBox(req)
+	if err != nil {
+		panic(err)
+	}
+	data, err := worldmap.GetGeoJSONFeatures(tileLa1, tileLo1, tileLa2, tileLo2, tileZ)
+	if err != nil {
+		panic(err)
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	res.Write(data)
+}
+
+// layersHandler returns the custom data layers and their display modes.
+func layersHandler(res http.ResponseWriter, req *http.Request) {
+	type layer struct {
+		Name        string `json:"name"`
+		DisplayMode int    `json:"display_mode"`
+	}
+	layers := []layer{}
+	for i, name := range worldmap.GetLayerNames() {
+		layers = append(layers, layer{
+			Name:        name,
+			DisplayMode: genworldvoronoi.DisplayModeLayers + i,
+		})
+	}
+	data, err := json.Marshal(layers)
+	if err != nil {
+		panic(err)
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	res.Write(data)
+}
+
+// resourcesHandler returns the custom resources and their display modes.
+func resourcesHandler(res http.ResponseWriter, req *http.Request) {
+	type resource struct {
+		Name        string   `json:"name"`
+		Uses        []string `json:"uses"`
+		DisplayMode int      `json:"display_mode"`
+	}
+	resources := []resource{}
+	for i, def := range worldmap.ResourceRegistry.GetCustom() {
+		resources = append(resources, resource{
+			Name:        def.Name,
+			Uses:        def.Uses,
+			DisplayMode: genworldvoronoi.DisplayModeResources + i,
+		})
+	}
+	data, err := json.Marshal(resources)
+	if err != nil {
+		panic(err)
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	res.Write(data)
+}
+
+// parseLatLon parses the lat/lon url parameters.
+func parseLatLon(req *http.Request) (lat, lon float64, err error) {
+	vars := mux.Vars(req)
+	lat, err = strconv.ParseFloat(vars["lat"], 64)
+	if err != nil {
+		return
+	}
+	lon, err = strconv.ParseFloat(vars["lon"], 64)
+	return
+}
+
+// queryNearestHandler returns the region at the given location and the k
+// closest cities and features as GeoJSON.
+func queryNearestHandler(res http.ResponseWriter, req *http.Request) {
+	lat, lon, err := parseLatLon(req)
+	if err != nil {
+		panic(err)
+	}
+	k, err := strconv.Atoi(mux.Vars(req)["k"])
+	if err != nil {
+		panic(err)
+	}
+	data, err := worldmap.GetGeoJSONNearest(lat, lon, k)
+	if err != nil {
+		panic(err)
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	res.Write(data)
+}
+
+// queryRadiusHandler returns the regions within the given radius (in km).
+func queryRadiusHandler(res http.ResponseWriter, req *http.Request) {
+	lat, lon, err := parseLatLon(req)
+	if err != nil {
+		panic(err)
+	}
+	km, err := strconv.ParseFloat(mux.Vars(req)["km"], 64)
+	if err != nil {
+		panic(err)
+	}
+	data, err := json.Marshal(worldmap.Query.RegionsWithinRadius(lat, lon, km))
+	if err != nil {
+		panic(err)
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	res.Write(data)
+}
+
+// layersExportHandler returns the custom data layers in the format read by
+// the -layers flag (see geo.BaseObject.WriteLayers).
+func layersExportHandler(res http.ResponseWriter, req *http.Request) {
+	var buf bytes.Buffer
+	if err := worldmap.WriteLayers(&buf); err != nil {
+		panic(err)
+	}
+	res.Header().Set("Content-Type", "application/octet-stream")
+	res.Header().Set("Content-Disposition", `attachment; filename="layers.bin"`)
+	res.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	res.Write(buf.Bytes())
+}
+
+// loadLayers reads the custom data layers from the given file (see
+// geo.BaseObject.ReadLayers).
+func loadLayers(m *genworldvoronoi.Map, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return m.ReadLayers(f)
+}
+
+// loadHeightmap loads the heightmap and the optional land mask (PNG) from the
+// given files. If width and height are set, the heightmap is read as raw
+// float32 values, otherwise as PNG.
+func loadHeightmap(path string, width, height int, seaLevel float64, maskPath string) (*geo.Heightmap, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var hm *geo.Heightmap
+	if width > 0 || height > 0 {
+		hm, err = geo.ReadHeightmapRaw(f, width, height, seaLevel)
+	} else {
+		hm, err = geo.ReadHeightmapPNG(f, seaLevel)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if maskPath == "" {
+		return hm, nil
+	}
+	fm, err := os.Open(maskPath)
+	if err != nil {
+		return nil, err
+	}
+	defer fm.Close()
+	if hm.LandMask, err = geo.ReadLandMaskPNG(fm); err != nil {
+		return nil, err
+	}
+	return hm, nil
+}
@@ -34,8 +34,19 @@ type CivConfig struct {
 	NumFarmingTowns          int  // Number of generated farming towns
 	NumTradingTowns          int  // Number of generated trading towns
 	NumDesertOasis           int  // Number of generated desert oases
+	NumPortTowns             int  // Number of generated port towns
 	EnableCityAging          bool // Enable city aging
 	EnableOrganizedReligions bool // Enable organized religion generation
+	EnableIndustrialEra      bool // Energy resources (coal, oil, gas, geothermal) contribute to the economy
+
+	// The spread of early settlers can follow the sea level changes of the glacial cycles,
+	// which allows them to cross temporary land bridges.
+	EnableLandBridges   bool  // Use the paleoclimate sea level timeline for the spread of settlements
+	SettlementStartYear int64 // Year (relative to the present) when the spread of settlements starts
+
+	// Earthquakes, volcanic eruptions and floods are simulated as events affecting all regions in their footprint.
+	DisasterEventsPerYear float64 // Average number of disaster events per year (0 to disable)
+	SettlementFireChance  float64 // Daily chance of a settlement starting a wildfire
 
 	// In case of disaster, overpopulation, etc. a percentage of the population might migrate to a new location.
 	MigrationOverpopulationExcessPopulationFactor float64 // Factor of excess population to migrate in case of overpopulation.
@@ -59,8 +70,14 @@ func NewCivConfig() *CivConfig {
 		NumFarmingTowns:          60,
 		NumTradingTowns:          10,
 		NumDesertOasis:           10,
+		NumPortTowns:             20,
 		EnableCityAging:          true,
 		EnableOrganizedReligions: true,
+		EnableIndustrialEra:      false,
+		EnableLandBridges:        false,
+		SettlementStartYear:      -70000,
+		DisasterEventsPerYear:    0.5,
+		SettlementFireChance:     0.001,
 		MigrationOverpopulationExcessPopulationFactor: 1.2,
 		MigrationOverpopulationMinPopulationFactor:    0.1,
 		MigrationToNClosestCities:                     10,
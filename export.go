@@ -49,6 +49,7 @@ func (m *Map) ExportSVG(path string) error {
 	drawResources := true
 	drawMountains := false
 	drawVolcanoes := false
+	drawFeatures := true
 
 	zoom := 3
 	filterPathDist := 20.0
@@ -89,7 +90,14 @@ func (m *Map) ExportSVG(path string) error {
 			"text.city{"+
 			"font-size: 8px;}\n"+
 			"text.capital{"+
-			"font-size: 12px;}\n")
+			"font-size: 12px;}\n"+
+			"text.feature{"+
+			"font-size: 10px;"+
+			"font-style: italic;"+
+			"font-weight: normal;"+
+			"text-anchor: middle;}\n"+
+			"text.feature.water{"+
+			"fill: lightblue;}\n")
 	em := m
 	// Hack to test tile fetching
 	// 113.48673955688815 180 139.02010193037987 225
@@ -114,8 +122,8 @@ func (m *Map) ExportSVG(path string) error {
 		//_, maxFit := minMax(fitScore)
 		//solarRad := m.calcSolarRadiation(172 / 2)
 		//minSol, maxSol := minMax(solarRad)
-		min, max := minMax(m.Elevation)
-		_, maxMois := minMax(m.Moisture)
+		min, max := m.Elevation.MinMax()
+		_, maxMois := m.Moisture.MinMax()
 		out_t := make([]int, 0, 6)
 		for i := 0; i < em.SphereMesh.NumRegions; i++ {
 			rLat := em.LatLon[i][0]
@@ -137,7 +145,7 @@ func (m *Map) ExportSVG(path string) error {
 				x, y := latLonToPixels(em.TriLatLon[j][0], em.TriLatLon[j][1], zoom)
 				path = append(path, [2]float64{x, y})
 			}
-			elev := em.Elevation[i]
+			elev := em.Elevation.At(i)
 			val := (elev - min) / (max - min)
 			//val = (solarRad[i] - minSol) / (maxSol - minSol)
 			//val = cityScore[i] / maxS
@@ -147,14 +155,14 @@ func (m *Map) ExportSVG(path string) error {
 				col = genBlue(val)
 			} else {
 				valElev := elev / max
-				valMois := em.Moisture[i] / maxMois
+				valMois := em.Moisture.At(i) / maxMois
 				col = geo.GetWhittakerModBiomeColor(rLat, valElev, valMois, val)
 			}
 			svg.Path(svgGenD(path), fmt.Sprintf("fill: rgb(%d, %d, %d)", col.R, col.G, col.B), "class=\"terrain\"")
 		}
 	} else {
-		min, max := minMax(m.TriElevation)
-		_, maxMois := minMax(m.TriMoisture)
+		min, max := m.TriElevation.MinMax()
+		_, maxMois := m.TriMoisture.MinMax()
 		for i := 0; i < len(em.SphereMesh.Triangles); i += 3 {
 			// Hacky way to filter paths/triangles that wrap around the entire SVG.
 			triLat := em.TriLatLon[i/3][0]
@@ -163,7 +171,7 @@ func (m *Map) ExportSVG(path string) error {
 			var skip bool
 			var poolCount int
 			for _, j := range em.SphereMesh.Triangles[i : i+3] {
-				if em.Waterpool[j] > 0 {
+				if em.Waterpool.At(j) > 0 {
 					poolCount++
 				}
 				x, y := latLonToPixels(em.LatLon[j][0], em.LatLon[j][1], zoom)
@@ -181,7 +189,7 @@ func (m *Map) ExportSVG(path string) error {
 				x, y := latLonToPixels(em.LatLon[j][0], em.LatLon[j][1], zoom)
 				path = append(path, [2]float64{x, y})
 			}
-			elev := em.TriElevation[i/3]
+			elev := em.TriElevation.At(i / 3)
 			val := (elev - min) / (max - min)
 			var col color.NRGBA
 			if elev <= 0 || poolCount > 2 {
@@ -190,7 +198,7 @@ func (m *Map) ExportSVG(path string) error {
 				valElev := elev / max
 				// Hacky: Modify elevation based on latitude to compensate for colder weather at the poles and warmer weather at the equator.
 				// valElev := math.Max(math.Min((elev/max)+(math.Sqrt(math.Abs(triLat)/90.0)-0.5), max), 0)
-				valMois := em.TriMoisture[i/3] / maxMois
+				valMois := em.TriMoisture.At(i/3) / maxMois
 				col = geo.GetWhittakerModBiomeColor(triLat, valElev, valMois, val)
 			}
 			svg.Path(svgGenD(path), fmt.Sprintf("fill: rgb(%d, %d, %d)", col.R, col.G, col.B), "class=\"terrain\"")
@@ -282,12 +290,12 @@ func (m *Map) ExportSVG(path string) error {
 	// Rivers (based on triangles)
 	if drawRiversB {
 		for i := 0; i < m.SphereMesh.NumSides; i++ {
-			if m.SideFlow[i] < 10000 {
+			if m.SideFlow.At(i) < 10000 {
 				continue
 			}
 			inner_t := m.SphereMesh.S_inner_t(i)
 			outer_t := m.SphereMesh.S_outer_t(i)
-			if m.TriElevation[inner_t] < 0 && m.TriElevation[outer_t] < 0 {
+			if m.TriElevation.At(inner_t) < 0 && m.TriElevation.At(outer_t) < 0 {
 				continue
 			}
 			x1, y1 := latLonToPixels(m.TriLatLon[inner_t][0], m.TriLatLon[inner_t][1], zoom)
@@ -302,7 +310,7 @@ func (m *Map) ExportSVG(path string) error {
 	// Sinks
 	if drawSinks {
 		for r, rdh := range m.Downhill {
-			if rdh < 0 && m.Drainage[r] < 0 && m.Elevation[r] > 0 {
+			if rdh < 0 && m.Drainage[r] < 0 && m.Elevation.At(r) > 0 {
 				drawCircle(m.LatLon[r][0], m.LatLon[r][1], 2, "fill: rgb(0, 255, 0)")
 			}
 		}
@@ -349,7 +357,7 @@ func (m *Map) ExportSVG(path string) error {
 		for _, r := range m.Ocean_r {
 			drawCircle(m.LatLon[r][0], m.LatLon[r][1], 2, "fill: rgb(128, 128, 255)")
 		}
-		for r := 0; r < m.SphereMesh.NumSides; r++ {
+		for r := 0; r < m.SphereMesh.NumRegions; r++ {
 			if m.RegionCompression[r] != 0 {
 				col := genGreen((m.RegionCompression[r] - minComp) / (maxComp - minComp))
 				drawCircle(m.LatLon[r][0], m.LatLon[r][1], 1, fmt.Sprintf("fill: rgb(%d, %d, %d)", col.R, col.R, col.R))
@@ -358,8 +366,9 @@ func (m *Map) ExportSVG(path string) error {
 	}
 
 	if drawFlux {
-		minFlux, maxFlux := minMax(m.Flux)
-		for r, rdh := range m.Flux {
+		minFlux, maxFlux := m.Flux.MinMax()
+		for r := 0; r < m.Flux.Len(); r++ {
+			rdh := m.Flux.At(r)
 			if rdh > 0 {
 				col := genGreen((rdh - minFlux) / (maxFlux - minFlux))
 				col = genGreen(rdh / maxFlux)
@@ -369,8 +378,9 @@ func (m *Map) ExportSVG(path string) error {
 	}
 
 	if drawHumidity {
-		minHumid, maxHumid := minMax(m.Moisture)
-		for r, rdh := range m.Moisture {
+		minHumid, maxHumid := m.Moisture.MinMax()
+		for r := 0; r < m.Moisture.Len(); r++ {
+			rdh := m.Moisture.At(r)
 			if rdh > 0 {
 				col := genGreen((rdh - minHumid) / (maxHumid - minHumid))
 				drawCircle(m.LatLon[r][0], m.LatLon[r][1], 1, fmt.Sprintf("fill: rgb(%d, %d, %d)", col.R, col.R, col.R))
@@ -379,8 +389,9 @@ func (m *Map) ExportSVG(path string) error {
 	}
 
 	if drawRainfall {
-		minRain, maxRain := minMax(m.Rainfall)
-		for r, rdh := range m.Rainfall {
+		minRain, maxRain := m.Rainfall.MinMax()
+		for r := 0; r < m.Rainfall.Len(); r++ {
+			rdh := m.Rainfall.At(r)
 			if rdh > 0 {
 				col := genGreen((rdh - minRain) / (maxRain - minRain))
 				drawCircle(m.LatLon[r][0], m.LatLon[r][1], 1, fmt.Sprintf("fill: rgb(%d, %d, %d)", col.R, col.R, col.R))
@@ -391,7 +402,8 @@ func (m *Map) ExportSVG(path string) error {
 	if drawErosion {
 		er := m.GetErosionRate()
 		minFlux, maxFlux := minMax(er)
-		for r, rdh := range m.Flux {
+		for r := 0; r < m.Flux.Len(); r++ {
+			rdh := m.Flux.At(r)
 			if rdh > 0 {
 				col := genBlue((rdh - minFlux) / (maxFlux - minFlux))
 				drawCircle(m.LatLon[r][0], m.LatLon[r][1], 1, fmt.Sprintf("fill: rgb(%d, %d, %d)", col.R, col.G, col.G))
@@ -402,7 +414,8 @@ func (m *Map) ExportSVG(path string) error {
 	if drawErosion2 {
 		er := m.GetErosionRate2()
 		minFlux, maxFlux := minMax(er)
-		for r, rdh := range m.Flux {
+		for r := 0; r < m.Flux.Len(); r++ {
+			rdh := m.Flux.At(r)
 			if rdh > 0 {
 				col := genBlue((rdh - minFlux) / (maxFlux - minFlux))
 				drawCircle(m.LatLon[r][0], m.LatLon[r][1], 1, fmt.Sprintf("fill: rgb(%d, %d, %d)", col.R, col.G, col.G))
@@ -411,10 +424,10 @@ func (m *Map) ExportSVG(path string) error {
 	}
 
 	if drawAltitude {
-		er := m.Elevation
-		minHeight, maxHeight := minMax(er)
+		minHeight, maxHeight := m.Elevation.MinMax()
 		minHeight = 0
-		for r, rdh := range m.Elevation {
+		for r := 0; r < m.Elevation.Len(); r++ {
+			rdh := m.Elevation.At(r)
 			if rdh > 0 && r%2 == 0 {
 				col := genBlue((rdh - minHeight) / (maxHeight - minHeight))
 				drawCircle(m.LatLon[r][0], m.LatLon[r][1], 1, fmt.Sprintf("fill: rgb(%d, %d, %d)", col.R, col.G, col.G))
@@ -423,9 +436,9 @@ func (m *Map) ExportSVG(path string) error {
 	}
 
 	if drawTemperature {
-		er := m.Elevation
-		_, maxHeight := minMax(er)
-		for r, rdh := range m.Elevation {
+		_, maxHeight := m.Elevation.MinMax()
+		for r := 0; r < m.Elevation.Len(); r++ {
+			rdh := m.Elevation.At(r)
 			if rdh > 0 && r%2 == 0 {
 				t := m.GetRegTemperature(r, maxHeight)
 				col := genBlue((t - geo.MinTemp) / (geo.MaxTemp - geo.MinTemp))
@@ -435,7 +448,8 @@ func (m *Map) ExportSVG(path string) error {
 	}
 
 	if drawBelow {
-		for r, pVal := range m.Elevation {
+		for r := 0; r < m.Elevation.Len(); r++ {
+			pVal := m.Elevation.At(r)
 			if pVal <= 0 {
 				drawCircle(m.LatLon[r][0], m.LatLon[r][1], 2, "fill: rgb(0, 0, 255)")
 			}
@@ -444,7 +458,8 @@ func (m *Map) ExportSVG(path string) error {
 
 	// Water pools
 	if drawPools {
-		for r, pVal := range m.Waterpool {
+		for r := 0; r < m.Waterpool.Len(); r++ {
+			pVal := m.Waterpool.At(r)
 			if pVal > 0 {
 				drawCircle(m.LatLon[r][0], m.LatLon[r][1], 2, "fill: rgb(0, 0, 255)")
 			}
@@ -467,25 +482,51 @@ func (m *Map) ExportSVG(path string) error {
 	}
 
 	if drawResources {
+		// Draw all metals and custom resources.
+		var defs []*geo.ResourceDef
+		for _, def := range m.ResourceRegistry.Defs {
+			if def.Type == geo.ResourceTypeMetal || !def.IsBuiltin() {
+				defs = append(defs, def)
+			}
+		}
 		grad := colorgrad.Rainbow()
-		cols := grad.Colors(uint(geo.ResMaxMetals))
+		cols := grad.Colors(uint(len(defs)))
 
 		// NOTE: This sucks right now.
-		res := m.Metals
 		radius := 1
-		count := make([]int, geo.ResMaxMetals)
-		for i := 0; i < geo.ResMaxMetals; i++ {
+		count := make(map[*geo.ResourceDef]int)
+		defCol := make(map[*geo.ResourceDef]string)
+		for i, def := range defs {
 			cr, cg, cb, _ := cols[i].RGBA()
-			col := fmt.Sprintf("fill: rgb(%d, %d, %d)", cr/(0xffff/255), cg/(0xffff/255), cb/(0xffff/255))
-			for r, t := range res {
-				if t&(1<<i) > 0 {
-					count[i]++
+			if def.Color.A > 0 {
+				cr, cg, cb, _ = def.Color.RGBA()
+			}
+			defCol[def] = fmt.Sprintf("fill: rgb(%d, %d, %d)", cr/(0xffff/255), cg/(0xffff/255), cb/(0xffff/255))
+		}
+		for r := 0; r < m.SphereMesh.NumRegions; r++ {
+			for _, d := range m.Deposits[r] {
+				if col, ok := defCol[d.Def]; ok && d.IsAvailable() {
+					count[d.Def]++
 					drawCircle(m.LatLon[r][0], m.LatLon[r][1], radius, col)
 				}
 			}
 		}
-		for i := 0; i < geo.ResMaxMetals; i++ {
-			log.Printf("Metal %s: %d", geo.MetalToString(i), count[i])
+		for _, def := range defs {
+			log.Printf("Resource %s: %d", def.Name, count[def])
+		}
+	}
+
+	// Geographic features (mountain ranges, seas, ...).
+	if drawFeatures {
+		for _, f := range m.Features {
+			if f.Name == "" {
+				continue
+			}
+			class := "class=\"feature\""
+			if m.Elevation.At(f.Center) <= 0 {
+				class = "class=\"feature water\""
+			}
+			drawText(f.Centroid[0], f.Centroid[1], f.Name, class)
 		}
 	}
 
@@ -513,6 +554,9 @@ func (m *Map) ExportSVG(path string) error {
 			case TownTypeDesertOasis:
 				col = "fill: rgb(55, 0, 255)"
 				radius = 1
+			case TownTypePort:
+				col = "fill: rgb(0, 165, 255)"
+				radius = 2
 			}
 			drawCircle(m.LatLon[r.ID][0], m.LatLon[r.ID][1], radius, col)
 			drawText(m.LatLon[r.ID][0], m.LatLon[r.ID][1], r.Name, class)
@@ -531,7 +575,7 @@ func (m *Map) ExportSVG(path string) error {
 
 	if drawMountains {
 		for r := 0; r < m.SphereMesh.NumRegions; r++ {
-			if m.RegionIsMountain[r] {
+			if m.RegionIsMountain.Has(r) {
 				drawCircle(m.LatLon[r][0], m.LatLon[r][1], 1, "fill: rgb(0, 0, 0)")
 			}
 		}
@@ -539,7 +583,7 @@ func (m *Map) ExportSVG(path string) error {
 
 	if drawVolcanoes {
 		for r := 0; r < m.SphereMesh.NumRegions; r++ {
-			if m.RegionIsVolcano[r] {
+			if m.RegionIsVolcano.Has(r) {
 				drawCircle(m.LatLon[r][0], m.LatLon[r][1], 2, "fill: rgb(235, 52, 155)")
 			}
 		}
@@ -622,22 +666,22 @@ func (m *Map) getImage(drawTerritories, drawSeasonalBiome bool) image.Image {
 	size := sizeFromZoom(zoom)
 	// Create a colored image of the given width and height.
 	img := image.NewNRGBA(image.Rect(0, 0, size, size))
-	min, max := minMax(m.Elevation)
-	_, maxMois := minMax(m.Rainfall)
+	min, max := m.Elevation.MinMax()
+	_, maxMois := m.Rainfall.MinMax()
 	for r := 0; r < m.SphereMesh.NumRegions; r++ {
 		lat := m.LatLon[r][0]
 		lon := m.LatLon[r][1]
 		// log.Println(lat, lon)
 		x, y := latLonToPixels(lat, lon, zoom)
-		val := (m.Elevation[r] - min) / (max - min)
+		val := (m.Elevation.At(r) - min) / (max - min)
 		var col color.NRGBA
-		if elev := m.Elevation[r]; elev <= 0 || m.Waterpool[r] > 0 || m.Flux[r] > 1000 {
+		if elev := m.Elevation.At(r); elev <= 0 || m.Waterpool.At(r) > 0 || m.Flux.At(r) > 1000 {
 			col = genBlue(val)
 		} else {
 			valElev := elev / max
 			// Hacky: Modify elevation based on latitude to compensate for colder weather at the poles and warmer weather at the equator.
 			// valElev := math.Max(math.Min((elev/max)+(math.Sqrt(math.Abs(lat)/90.0)-0.5), max), 0)
-			valMois := m.Rainfall[r] / maxMois
+			valMois := m.Rainfall.At(r) / maxMois
 			if territory[r] != 0 && drawTerritories {
 				cr, cg, cb, _ := cols[terrToColor[territory[r]]].RGBA()
 				col.R = uint8(float64(255) * float64(cr) / float64(0xffff))
@@ -653,25 +697,31 @@ func (m *Map) getImage(drawTerritories, drawSeasonalBiome bool) image.Image {
 			}
 			// col = GetWhittakerModBiomeColor(int(getMeanAnnualTemp(lat)-getTempFalloffFromAltitude(8850*valElev)), int(valMois*45), val)
 		}
+		// Overlay the wildfires in the seasonal animation.
+		var rCol color.Color = col
+		if drawSeasonalBiome {
+			rCol = m.getFireColor(r, col)
+		}
+
 		// Above a certain latitude, we need to draw more pixels since the
 		// distance between regions increases due to the mercator projection.
 		// NOTE: I know this is dumb. Don't judge me.
 		if math.Abs(lat) > 60 {
-			img.Set(int(x)+1, int(y), col)
+			img.Set(int(x)+1, int(y), rCol)
 		}
 		if math.Abs(lat) > 70 {
-			img.Set(int(x)+2, int(y), col)
+			img.Set(int(x)+2, int(y), rCol)
 		}
 		if math.Abs(lat) > 75 {
-			img.Set(int(x)+3, int(y), col)
+			img.Set(int(x)+3, int(y), rCol)
 		}
 		if math.Abs(lat) > 80 {
-			img.Set(int(x)+4, int(y), col)
+			img.Set(int(x)+4, int(y), rCol)
 		}
 		if math.Abs(lat) > 85 {
-			img.Set(int(x)+5, int(y), col)
+			img.Set(int(x)+5, int(y), rCol)
 		}
-		img.Set(int(x), int(y), col)
+		img.Set(int(x), int(y), rCol)
 	}
 	return img
 }
@@ -721,14 +771,14 @@ func (m *Map) ExportOBJ(path string) error {
 
 	// Vertices
 	for i := 0; i < len(m.XYZ); i += 3 {
-		ve := various.ConvToVec3(m.XYZ[i:]).Mul(1.0 + 0.01*(m.Elevation[i/3]+m.Waterpool[i/3]))
+		ve := various.ConvToVec3(m.XYZ[i:]).Mul(1.0 + 0.01*(m.Elevation.At(i/3)+m.Waterpool.At(i/3)))
 		w.WriteString(fmt.Sprintf("v %f %f %f \n", ve.X, ve.Y, ve.Z))
 	}
 
 	// Triangle vertices
 	if drawPlates || drawRivers {
 		for i := 0; i < len(m.TriXYZ); i += 3 {
-			ve := various.ConvToVec3(m.TriXYZ[i:]).Mul(1.03 + 0.01*m.TriElevation[i/3])
+			ve := various.ConvToVec3(m.TriXYZ[i:]).Mul(1.03 + 0.01*m.TriElevation.At(i/3))
 			w.WriteString(fmt.Sprintf("v %f %f %f \n", ve.X, ve.Y, ve.Z))
 		}
 		w.Flush()
@@ -744,10 +794,10 @@ func (m *Map) ExportOBJ(path string) error {
 	// Rivers
 	if drawRivers {
 		for i := 0; i < m.SphereMesh.NumSides; i++ {
-			if m.SideFlow[i] > 1 {
+			if m.SideFlow.At(i) > 1 {
 				inner_t := m.SphereMesh.S_inner_t(i)
 				outer_t := m.SphereMesh.S_outer_t(i)
-				if m.TriElevation[inner_t] < 0 && m.TriElevation[outer_t] < 0 {
+				if m.TriElevation.At(inner_t) < 0 && m.TriElevation.At(outer_t) < 0 {
 					continue
 				}
 				w.WriteString(fmt.Sprintf("l %d %d \n", (len(m.XYZ)/3)+inner_t+1, (len(m.XYZ)/3)+outer_t+1))
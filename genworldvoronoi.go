@@ -4,8 +4,14 @@
 package genworldvoronoi
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/Flokey82/genworldvoronoi/bio"
 	"github.com/Flokey82/genworldvoronoi/geo"
+	"github.com/Flokey82/genworldvoronoi/query"
+	"github.com/Flokey82/genworldvoronoi/spheremesh"
+	"github.com/Flokey82/go_gens/utils"
 )
 
 type Map struct {
@@ -13,8 +19,19 @@ type Map struct {
 	*Civ     // Civilization
 	*bio.Bio // Plants / animals / funghi
 
-	// *TileCache
-	// CoarseMeshes []*SphereMesh // Coarse meshes for each zoom level.
+	TileCache    *TileCache    // Cache of refined terrain patches for high zoom levels
+	Query        *query.Index  // Spatial queries (nearest region, radius search, ...)
+	CoarseMeshes []*CoarseMesh // Coarse meshes for low zoom levels (only for hierarchical meshes)
+}
+
+// numCoarseMeshes is the number of zoom levels with their own (coarse) mesh.
+const numCoarseMeshes = 5
+
+// CoarseMesh is a coarser level of the mesh hierarchy used for drawing low
+// zoom levels.
+type CoarseMesh struct {
+	*spheremesh.SphereMesh
+	Regions []int // Representative region of the global mesh for each region (nil: same IDs)
 }
 
 func NewMapFromConfig(seed int64, cfg *Config) (*Map, error) {
@@ -34,26 +51,20 @@ func NewMapFromConfig(seed int64, cfg *Config) (*Map, error) {
 		Civ: NewCiv(geo, cfg.CivConfig),
 		Bio: bio.NewBio(geo, cfg.BioConfig),
 	}
+
+	// Disasters (also the ones while aging the cities) affect the species.
+	m.Civ.OnDisasterEvent = m.applyDisasterEventToSpecies
+
+	// Changes in the climate affect features, routes, etc.
+	m.Geo.OnClimateChanged = m.Civ.onClimateChanged
 	m.generateMap()
+	m.TileCache = NewTileCache(m.BaseObject)
+	m.Query = query.New(m.SphereMesh, m.Geo.Radius)
 
-	/*
-		m.TileCache = NewTileCache(m.BaseObject)
-
-		// Generate coarse meshes for LOD.
-		m.CoarseMeshes = make([]*SphereMesh, 5)
-		for i := range m.CoarseMeshes {
-			if i == len(m.CoarseMeshes)-1 {
-				m.CoarseMeshes[i] = m.SphereMesh
-				continue
-			}
-			n := 1 << utils.Max(len(m.CoarseMeshes)-i-1, 0)
-			log.Println("Generating coarse mesh for zoom level", i, "with", n, "iterations")
-			mesh, err := m.MakeCoarseSphereMesh(n)
-			if err != nil {
-				return nil, err
-			}
-			m.CoarseMeshes[i] = mesh
-		}*/
+	// Generate coarse meshes for LOD.
+	if err := m.generateCoarseMeshes(); err != nil {
+		return nil, err
+	}
 	return m, nil
 }
 
@@ -67,23 +78,70 @@ func NewMap(seed int64, numPlates, numPoints, numVolcanoes int, jitter float64)
 	return NewMapFromConfig(seed, cfg)
 }
 
-/*
-func (m *Map) getCoarseForZoom(zoom int) (*SphereMesh, int) {
-	n := 1 << utils.Max(len(m.CoarseMeshes)-zoom-1, 0)
-	log.Println("Zoom level", zoom, "has", n, "iterations")
-	return m.CoarseMeshes[utils.Min(zoom, len(m.CoarseMeshes)-1)], n
+// generateCoarseMeshes generates the coarse meshes for the lower zoom levels.
+//
+// This is only possible if the mesh was created by a mesh generator, since
+// then each coarse region is a true ancestor of regions of the global mesh
+// (see spheremesh.CoarseRegions) and we can draw it using the values of one
+// of its descendants.
+func (m *Map) generateCoarseMeshes() error {
+	m.CoarseMeshes = nil
+	if m.SphereMesh.Generator == nil {
+		return nil
+	}
+	coarseMeshes := make([]*CoarseMesh, numCoarseMeshes)
+	for i := range coarseMeshes {
+		if i == len(coarseMeshes)-1 {
+			coarseMeshes[i] = &CoarseMesh{SphereMesh: m.SphereMesh}
+			continue
+		}
+
+		// Each zoom level out quarters the number of regions.
+		n := 1 << utils.Max(2*(len(coarseMeshes)-i-1), 0)
+		mesh, err := m.SphereMesh.MakeCoarseSphereMesh(n)
+		if err != nil {
+			return err
+		}
+
+		// Use the descendant with the lowest ID as representative region,
+		// which is the region itself if the point is kept in finer levels.
+		regions := make([]int, mesh.NumRegions)
+		for r := range regions {
+			regions[r] = -1
+		}
+		ancestors := m.SphereMesh.CoarseRegions(mesh)
+		for r := len(ancestors) - 1; r >= 0; r-- {
+			regions[ancestors[r]] = r
+		}
+		coarseMeshes[i] = &CoarseMesh{
+			SphereMesh: mesh,
+			Regions:    regions,
+		}
+	}
+	m.CoarseMeshes = coarseMeshes
+	return nil
+}
+
+// getCoarseForZoom returns the coarse mesh for the given zoom level or nil
+// if there are no coarse meshes.
+func (m *Map) getCoarseForZoom(zoom int) *CoarseMesh {
+	if len(m.CoarseMeshes) == 0 {
+		return nil
+	}
+	return m.CoarseMeshes[utils.Max(utils.Min(zoom, len(m.CoarseMeshes)-1), 0)]
 }
-*/
 
 func (m *Map) generateMap() {
 	// Build geography / geology / climate.
 	m.GenerateGeology()
 
-	// Build civilization.
-	m.GenerateCivilization()
-
 	// Build plants / animals / funghi.
+	// NOTE: This happens before the civilization, so the species are
+	// affected by the disasters that occur while the cities age.
 	m.GenerateBiology()
+
+	// Build civilization.
+	m.GenerateCivilization()
 }
 
 // Tick advances the map by one tick.
@@ -91,105 +149,24 @@ func (m *Map) Tick() {
 	m.Geo.Tick()
 	m.Bio.Tick()
 	m.Civ.Tick()
-}
-
-/*
-// TileCache is a cache for tiles stored as a quadtree.
-//
-// This cache is used to retrieve tiles for rendering.
-// If a tile is not found in the cache, the closest parent is returned, and
-// the tile is generated in the background.
-type TileCache struct {
-	*TileTree
-}
 
-func NewTileCache(bo *BaseObject) *TileCache {
-	return &TileCache{
-		TileTree: &TileTree{
-			BaseObject: bo,
-		},
-	}
-}
-
-// TileTree is a quadtree of tiles.
-type TileTree struct {
-	Parent     *TileTree
-	Children   [4]*TileTree
-	X, Y, Zoom int
-	*BaseObject
+	// Simulate disaster events, which affect cities, people and species
+	// (see Civ.OnDisasterEvent).
+	m.Civ.tickDisasterEvents(1)
 }
 
-func (t *TileTree) Get(x, y, zoom int) *TileTree {
-	x, y = wrapTileCoordinates(x, y, zoom)
-
-	log.Println("Get tile:", x, y, zoom)
-	// If the tile is not in the quadtree, return.
-	if zoom < t.Zoom {
-		return nil
-	}
-
-	// If the tile is exactly the one requested, return.
-	if zoom == t.Zoom && x == t.X && y == t.Y {
-		log.Println("Found tile in cache:", t.X, t.Y, t.Zoom)
-		return t
+// applyDisasterEventToSpecies applies the given disaster event to all species
+// within its footprint and logs the impact to the history.
+func (m *Map) applyDisasterEventToSpecies(ev *geo.DisasterEvent) {
+	speciesIdx := make(map[*bio.Species]int)
+	for i, s := range m.Bio.Species {
+		speciesIdx[s] = i
 	}
-
-	// Check if the tile is a parent of the tile we are looking for.
-	xAtCurrentTile := x >> uint(zoom-t.Zoom)
-	yAtCurrentTile := y >> uint(zoom-t.Zoom)
-	if xAtCurrentTile != t.X || yAtCurrentTile != t.Y {
-		return nil
-	}
-
-	// Find the points in the child quadtrees.
-	// Get the index of the child that will contain the tile that we are looking for.
-	childIndex := 0
-	xChild := x >> uint(zoom-t.Zoom-1)
-	yChild := y >> uint(zoom-t.Zoom-1)
-
-	if xChild%2 == 1 {
-		childIndex += 1
-	}
-	if yChild%2 == 1 {
-		childIndex += 2
-	}
-
-	// If the child is not nil, find the points in the child.
-	if t.Children[childIndex] != nil {
-		log.Println("Cache hit: Returning child tile", xChild, yChild, zoom+1)
-		return t.Children[childIndex].Get(x, y, zoom)
-	}
-
-	log.Println("Cache miss: Generating tile", xChild, yChild, zoom+1)
-	tbb := newTileBoundingBox(xChild, yChild, t.Zoom+1)
-	la1, lo1, la2, lo2 := tbb.toLatLon()
-	// Make sure we have some padding around the tile.
-	la1 -= 0.1
-	lo1 -= 0.1
-	la2 += 0.1
-	lo2 += 0.1
-	res := t.BaseObject.getBoundingBoxRegions(la1, lo1, la2, lo2)
-	childZoom := t.Zoom + 1
-	var baseObject *BaseObject
-	if childZoom%2 == 1 {
-		ipl, err := t.BaseObject.interpolate(res.Regions)
-		if err != nil {
-			return nil
+	for _, impact := range m.Bio.ApplyDisasterEvent(ev) {
+		msg := fmt.Sprintf("%s was affected by the %s in %d regions", impact.Species.Name, strings.ToLower(ev.Name), impact.Regions)
+		if impact.WipedOut > 0 {
+			msg += fmt.Sprintf(" and wiped out in %d", impact.WipedOut)
 		}
-		baseObject = &ipl.BaseObject
-	} else {
-		baseObject = t.BaseObject
-	}
-
-	// If the child is nil, return the current tile.
-	// TODO: GENERATE THE TILE AND CACHE IT.
-	t.Children[childIndex] = &TileTree{
-		Parent:     t,
-		X:          xChild,
-		Y:          yChild,
-		Zoom:       childZoom,
-		BaseObject: baseObject,
+		m.AddEvent(ev.Name, msg, ObjectReference{ID: speciesIdx[impact.Species], Type: ObjectTypeSpecies})
 	}
-	return t.Children[childIndex].Get(x, y, zoom)
 }
-*/
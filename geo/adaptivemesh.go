@@ -0,0 +1,164 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Flokey82/genworldvoronoi/spheremesh"
+	"github.com/Flokey82/genworldvoronoi/various"
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// Mesh refinement parameters.
+const (
+	meshRefineGradientWeight = 1.0 // Weight of the elevation gradient
+	meshRefineCoastWeight    = 1.0 // Weight of coastal regions
+	meshRefineMountainWeight = 0.5 // Weight of mountain regions
+	meshRefineRiverWeight    = 1.0 // Weight of the (approximated) river flux
+	meshRefineOceanFactor    = 0.1 // Factor applied to the weight of non-coastal ocean regions
+)
+
+// meshTemplate holds the plates of the coarse pass of an adaptive mesh,
+// which are carried over to the refined mesh so that the terrain matches
+// the one that the refinement was based on.
+type meshTemplate struct {
+	parents       []int          // Maps each region of the refined mesh to the coarse region
+	numCoarse     int            // Number of coarse regions
+	plateRegs     []int          // Plate seed regions (coarse)
+	regionToPlate []int          // Region to plate mapping (coarse)
+	plateToVector []vectors.Vec3 // Plate movement vectors (coarse)
+}
+
+// makeAdaptiveMesh generates a sphere mesh with a higher resolution near
+// coastlines, mountains, rivers and steep terrain.
+//
+// A coarse mesh with a fraction of the points (see AdaptiveMeshFraction)
+// is used to generate the plates and elevation (or to sample the heightmap,
+// if one is configured), which determine where we place the remaining
+// points. The regions of the coarse mesh keep their IDs in the refined mesh
+// (see spheremesh.RefineSphere).
+//
+// An error is returned if AdaptiveMeshFraction is not between 0 and 1
+// (exclusive).
+//
+// NOTE: Since the regions are no longer uniform in size, code that depends on
+// the area of a region should use GetRegArea instead of counting regions.
+func makeAdaptiveMesh(seed int64, cfg *GeoConfig) (*spheremesh.SphereMesh, *meshTemplate, error) {
+	if cfg.AdaptiveMeshFraction <= 0 || cfg.AdaptiveMeshFraction >= 1 {
+		return nil, nil, fmt.Errorf("invalid adaptive mesh fraction %v, expected a value between 0 and 1", cfg.AdaptiveMeshFraction)
+	}
+	start := various.StartStage()
+	coarseCfg := *cfg
+	coarseCfg.AdaptiveMesh = false
+	coarseCfg.MeshGenerator = nil // RefineSphere requires the south pole as the last region
+	coarseCfg.NumPoints = int(float64(cfg.NumPoints) * cfg.AdaptiveMeshFraction)
+	coarse, err := NewGeo(seed, &coarseCfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Generate the coarse terrain.
+	if cfg.Heightmap != nil {
+		coarse.assignHeightmapElevation()
+	} else {
+		coarse.generatePlates()
+		coarse.assignOceanPlates()
+		coarse.assignRegionElevation()
+	}
+
+	mesh, parents, err := spheremesh.RefineSphere(coarse.SphereMesh, seed, cfg.NumPoints, coarse.getMeshRefinementWeights())
+	if err != nil {
+		return nil, nil, err
+	}
+	various.LogStage("adaptive mesh", start)
+	return mesh, &meshTemplate{
+		parents:       parents,
+		numCoarse:     coarse.SphereMesh.NumRegions,
+		plateRegs:     coarse.PlateRegs,
+		regionToPlate: coarse.RegionToPlate,
+		plateToVector: coarse.PlateToVector,
+	}, nil
+}
+
+// getMeshRefinementWeights returns the relative importance of each region
+// for the mesh refinement based on the elevation gradient, coastlines,
+// mountains and the river flux (approximated using uniform rainfall).
+func (m *Geo) getMeshRefinementWeights() []float64 {
+	// Approximate the river flux with uniform rainfall on land.
+	m.Rainfall.Fill(0)
+	for r := 0; r < m.Rainfall.Len(); r++ {
+		if m.Elevation.At(r) > 0 {
+			m.Rainfall.Set(r, 1)
+		}
+	}
+	m.AssignDownhill(false)
+	m.assignFlux(true)
+	_, maxFlux := m.Flux.MinMax()
+
+	// Calculate the steepest elevation gradient of each region.
+	gradient := make([]float64, m.SphereMesh.NumRegions)
+	isCoast := make([]bool, m.SphereMesh.NumRegions)
+	outRegs := make([]int, 0, 8)
+	for r := range gradient {
+		for _, nb := range m.SphereMesh.R_circulate_r(outRegs, r) {
+			if dist := m.GetDistance(r, nb); dist > 0 {
+				gradient[r] = math.Max(gradient[r], math.Abs(m.Elevation.At(r)-m.Elevation.At(nb))/dist)
+			}
+			if (m.Elevation.At(r) > 0) != (m.Elevation.At(nb) > 0) {
+				isCoast[r] = true
+			}
+		}
+	}
+	_, maxGradient := minMax(gradient)
+
+	weights := make([]float64, m.SphereMesh.NumRegions)
+	for r := range weights {
+		var w float64
+		if maxGradient > 0 {
+			w += meshRefineGradientWeight * gradient[r] / maxGradient
+		}
+		if isCoast[r] {
+			w += meshRefineCoastWeight
+		}
+		if m.RegionIsMountain.Has(r) {
+			w += meshRefineMountainWeight
+		}
+		if maxFlux > 0 && m.Elevation.At(r) > 0 {
+			w += meshRefineRiverWeight * math.Sqrt(m.Flux.At(r)/maxFlux)
+		}
+		if m.Elevation.At(r) <= 0 && !isCoast[r] {
+			w *= meshRefineOceanFactor
+		}
+		weights[r] = w
+	}
+	return weights
+}
+
+// applyMeshTemplate assigns the plates of the coarse pass to the regions of
+// the refined mesh.
+func (m *Geo) applyMeshTemplate(t *meshTemplate) {
+	numRegions := m.SphereMesh.NumRegions
+
+	// toRefined returns the ID of the coarse region in the refined mesh.
+	// All regions keep their ID except for the south pole.
+	toRefined := func(r int) int {
+		if r == t.numCoarse-1 {
+			return numRegions - 1
+		}
+		return r
+	}
+
+	regPlate := make([]int, numRegions)
+	for r := range regPlate {
+		regPlate[r] = toRefined(t.regionToPlate[t.parents[r]])
+	}
+	plateRegs := make([]int, len(t.plateRegs))
+	plateVectors := make([]vectors.Vec3, numRegions)
+	for i, r := range t.plateRegs {
+		plateRegs[i] = toRefined(r)
+		plateVectors[plateRegs[i]] = t.plateToVector[r]
+	}
+	m.PlateRegs = plateRegs
+	m.RegionToPlate = regPlate
+	m.PlateToVector = plateVectors
+}
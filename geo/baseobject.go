@@ -15,6 +15,12 @@ import (
 	"github.com/Flokey82/go_gens/vectors"
 )
 
+// BaseObject holds the mesh and the terrain values of all regions.
+//
+// The bulk float layers (elevation, moisture, temperatures, ...) are stored
+// with float32 precision if GeoConfig.Float32Storage is set, which roughly
+// halves their memory. See BenchmarkWorldHeap for the memory used by large
+// worlds.
 type BaseObject struct {
 	Seed                   int64        // Seed for random number generators
 	Rand                   *rand.Rand   // Rand initialized with above seed
@@ -22,61 +28,76 @@ type BaseObject struct {
 	*spheremesh.SphereMesh              // Triangle mesh containing the sphere information
 
 	// Elevation related stuff
-	Elevation         []float64       // Point / region elevation
-	RegionCompression map[int]float64 // Point / region compression factor
+	Elevation         various.Floats // Point / region elevation
+	RegionCompression []float64      // Point / region compression factor (0 if not on a plate boundary)
 
 	// Derived elevation related stuff
-	Downhill         []int        // Point / region mapping to its lowest neighbor
-	Landmasses       []int        // Point / region mapping of regions that are part of the same landmass
-	LandmassSize     map[int]int  // Landmass ID to size mapping
-	RegionIsMountain map[int]bool // Point / region is a mountain
-	RegionIsVolcano  map[int]bool // Point / region is a volcano
+	Downhill         []int          // Point / region mapping to its lowest neighbor
+	Landmasses       []int          // Point / region mapping of regions that are part of the same landmass
+	LandmassSize     map[int]int    // Landmass ID to size mapping
+	RegionIsMountain various.Bitset // Point / region is a mountain
+	RegionIsVolcano  various.Bitset // Point / region is a volcano
 
 	// Moisture related stuff
-	Moisture          []float64    // Point / region moisture
-	Rainfall          []float64    // Point / region rainfall
-	Flux              []float64    // Point / region hydrology: throughflow of rainfall
-	Waterbodies       []int        // Point / region mapping of pool to waterbody ID
-	WaterbodySize     map[int]int  // Waterbody ID to size mapping
-	LakeSize          map[int]int  // Lake ID to size mapping
-	RegionIsWaterfall map[int]bool // Point / region is a waterfall
+	Moisture          various.Floats // Point / region moisture
+	Rainfall          various.Floats // Point / region rainfall
+	Flux              various.Floats // Point / region hydrology: throughflow of rainfall
+	Waterbodies       []int          // Point / region mapping of pool to waterbody ID
+	WaterbodySize     map[int]int    // Waterbody ID to size mapping
+	LakeSize          map[int]int    // Lake ID to size mapping
+	RegionIsWaterfall various.Bitset // Point / region is a waterfall
 
 	// Temperature related stuff
-	OceanTemperature []float64   // Ocean temperatures (yearly average)
-	AirTemperature   []float64   // Air temperatures (yearly average)
-	BiomeRegions     []int       // Point / region mapping of regions with the same biome
-	BiomeRegionSize  map[int]int // Biome region ID to size mapping
+	OceanTemperature various.Floats // Ocean temperatures (yearly average)
+	AirTemperature   various.Floats // Air temperatures (yearly average)
+	BiomeRegions     []int          // Point / region mapping of regions with the same biome
+	BiomeRegionSize  map[int]int    // Biome region ID to size mapping
+
+	// Ocean related stuff
+	OceanSalinity  various.Floats // Ocean salinity (psu, yearly average)
+	OceanUpwelling various.Floats // Ocean upwelling intensity (0.0-1.0)
+	CoastalFog     various.Floats // Coastal fog frequency over land (0.0-1.0)
 
 	// Triangle stuff (purely derived from regions)
-	TriElevation []float64 // Triangle elevation
-	TriMoisture  []float64 // Triangle moisture
+	TriElevation various.Floats // Triangle elevation
+	TriMoisture  various.Floats // Triangle moisture
 
 	// Currently unused:
-	TriPool         []float64 // Triangle water pool depth
-	TriFlow         []float64 // Triangle flow intensity (rainfall)
-	TriDownflowSide []int     // Triangle mapping to side through which water flows downhill.
-	OrderTri        []int     // Triangles in uphill order of elevation.
-	SideFlow        []float64 // Flow intensity through sides
+	TriPool         various.Floats // Triangle water pool depth
+	TriFlow         various.Floats // Triangle flow intensity (rainfall)
+	TriDownflowSide []int          // Triangle mapping to side through which water flows downhill.
+	OrderTri        []int          // Triangles in uphill order of elevation.
+	SideFlow        various.Floats // Flow intensity through sides
 
 	// Currently unused:
-	Waterpool []float64 // Point / region hydrology: water pool depth
-	Drainage  []int     // Point / region mapping of pool to its drainage region
+	Waterpool various.Floats // Point / region hydrology: water pool depth
+	Drainage  []int          // Point / region mapping of pool to its drainage region
+
+	// Custom data layers (see RegisterLayer)
+	layers     map[string]LayerAccessor // Layer name to layer mapping
+	layerNames []string                 // Layer names in order of registration
+
+	executor *various.Executor // Executor for parallel work (nil: various.DefaultExecutor)
 }
 
-func newBaseObject(seed int64, mesh *spheremesh.SphereMesh) *BaseObject {
+func newBaseObject(seed int64, mesh *spheremesh.SphereMesh, lowPrecision bool, executor *various.Executor) *BaseObject {
 	return &BaseObject{
 		Seed:              seed,
+		executor:          executor,
 		Rand:              rand.New(rand.NewSource(seed)),
 		noise:             noise.NewNoise(6, 2.0/3.0, seed),
 		SphereMesh:        mesh,
-		Elevation:         make([]float64, mesh.NumRegions),
-		RegionCompression: make(map[int]float64),
-		Moisture:          make([]float64, mesh.NumRegions),
-		Flux:              make([]float64, mesh.NumRegions),
-		Waterpool:         make([]float64, mesh.NumRegions),
-		Rainfall:          make([]float64, mesh.NumRegions),
-		OceanTemperature:  make([]float64, mesh.NumRegions),
-		AirTemperature:    make([]float64, mesh.NumRegions),
+		Elevation:         various.NewFloats(mesh.NumRegions, lowPrecision),
+		RegionCompression: make([]float64, mesh.NumRegions),
+		Moisture:          various.NewFloats(mesh.NumRegions, lowPrecision),
+		Flux:              various.NewFloats(mesh.NumRegions, lowPrecision),
+		Waterpool:         various.NewFloats(mesh.NumRegions, lowPrecision),
+		Rainfall:          various.NewFloats(mesh.NumRegions, lowPrecision),
+		OceanTemperature:  various.NewFloats(mesh.NumRegions, lowPrecision),
+		AirTemperature:    various.NewFloats(mesh.NumRegions, lowPrecision),
+		OceanSalinity:     various.NewFloats(mesh.NumRegions, lowPrecision),
+		OceanUpwelling:    various.NewFloats(mesh.NumRegions, lowPrecision),
+		CoastalFog:        various.NewFloats(mesh.NumRegions, lowPrecision),
 		Downhill:          make([]int, mesh.NumRegions),
 		Drainage:          make([]int, mesh.NumRegions),
 		Waterbodies:       make([]int, mesh.NumRegions),
@@ -86,17 +107,25 @@ func newBaseObject(seed int64, mesh *spheremesh.SphereMesh) *BaseObject {
 		Landmasses:        make([]int, mesh.NumRegions),
 		LandmassSize:      make(map[int]int),
 		LakeSize:          make(map[int]int),
-		RegionIsMountain:  make(map[int]bool),
-		RegionIsVolcano:   make(map[int]bool),
-		RegionIsWaterfall: make(map[int]bool),
-		TriPool:           make([]float64, mesh.NumTriangles),
-		TriElevation:      make([]float64, mesh.NumTriangles),
-		TriMoisture:       make([]float64, mesh.NumTriangles),
+		RegionIsMountain:  various.NewBitset(mesh.NumRegions),
+		RegionIsVolcano:   various.NewBitset(mesh.NumRegions),
+		RegionIsWaterfall: various.NewBitset(mesh.NumRegions),
+		TriPool:           various.NewFloats(mesh.NumTriangles, lowPrecision),
+		TriElevation:      various.NewFloats(mesh.NumTriangles, lowPrecision),
+		TriMoisture:       various.NewFloats(mesh.NumTriangles, lowPrecision),
 		TriDownflowSide:   make([]int, mesh.NumTriangles),
 		OrderTri:          make([]int, mesh.NumTriangles),
-		TriFlow:           make([]float64, mesh.NumTriangles),
-		SideFlow:          make([]float64, mesh.NumSides),
+		TriFlow:           various.NewFloats(mesh.NumTriangles, lowPrecision),
+		SideFlow:          various.NewFloats(mesh.NumSides, lowPrecision),
+	}
+}
+
+// GetExecutor returns the executor used for parallel work on this object.
+func (m *BaseObject) GetExecutor() *various.Executor {
+	if m.executor == nil {
+		return various.DefaultExecutor
 	}
+	return m.executor
 }
 
 // ResetRand resets the random number generator to its initial state.
@@ -186,9 +215,9 @@ func (m *BaseObject) assignTriValues() {
 		r1 := mesh.S_begin_r(s0)
 		r2 := mesh.S_begin_r(s0 + 1)
 		r3 := mesh.S_begin_r(s0 + 2)
-		tPool[t] = (rPool[r1] + rPool[r2] + rPool[r3]) / 3
-		tElevation[t] = (rElevation[r1] + rElevation[r2] + rElevation[r3]) / 3
-		tMoisture[t] = (rMoisture[r1] + rMoisture[r2] + rMoisture[r3]) / 3
+		tPool.Set(t, (rPool.At(r1)+rPool.At(r2)+rPool.At(r3))/3)
+		tElevation.Set(t, (rElevation.At(r1)+rElevation.At(r2)+rElevation.At(r3))/3)
+		tMoisture.Set(t, (rMoisture.At(r1)+rMoisture.At(r2)+rMoisture.At(r3))/3)
 	}
 
 	// This averages out rainfall to calculate moisture for triangles.
@@ -198,13 +227,10 @@ func (m *BaseObject) assignTriValues() {
 		for i := 0; i < 3; i++ {
 			s := 3*t + i
 			r := mesh.S_begin_r(s)
-			moisture += m.Rainfall[r] / 3
+			moisture += m.Rainfall.At(r) / 3
 		}
-		tMoisture[t] = moisture
+		tMoisture.Set(t, moisture)
 	}
-	m.TriElevation = tElevation
-	m.TriPool = tPool
-	m.TriMoisture = tMoisture
 }
 
 // AssignDownhill will populate r_downhill with a mapping of region to lowest neighbor region.
@@ -227,14 +253,14 @@ func (m *BaseObject) GetDownhill(usePool bool) []int {
 		outReg := make([]int, 0, 8)
 		for r := start; r < end; r++ {
 			lowestRegion := -1
-			lowestElevation := m.Elevation[r]
+			lowestElevation := m.Elevation.At(r)
 			if usePool {
-				lowestElevation += m.Waterpool[r]
+				lowestElevation += m.Waterpool.At(r)
 			}
 			for _, nbReg := range mesh.R_circulate_r(outReg, r) {
-				elev := m.Elevation[nbReg]
+				elev := m.Elevation.At(nbReg)
 				if usePool {
-					elev += m.Waterpool[nbReg]
+					elev += m.Waterpool.At(nbReg)
 				}
 				if elev < lowestElevation {
 					lowestElevation = elev
@@ -248,7 +274,7 @@ func (m *BaseObject) GetDownhill(usePool bool) []int {
 	useGoRoutines := true
 	if useGoRoutines {
 		// Use goroutines to process the regions in chunks.
-		various.KickOffChunkWorkers(mesh.NumRegions, chunkProcessor)
+		m.GetExecutor().ParallelFor(mesh.NumRegions, chunkProcessor)
 	} else {
 		// Process the regions in a single chunk.
 		chunkProcessor(0, mesh.NumRegions)
@@ -278,12 +304,12 @@ func (m *BaseObject) AssignDownflow() {
 
 	// Part 1: ocean triangles get downslope assigned to the lowest neighbor.
 	for t := 0; t < numTriangles; t++ {
-		if m.TriElevation[t] < 0 {
+		if m.TriElevation.At(t) < 0 {
 			bestSide := -1
-			bestElevation := m.TriElevation[t]
+			bestElevation := m.TriElevation.At(t)
 			for j := 0; j < 3; j++ {
 				side := 3*t + j
-				elevation := m.TriElevation[mesh.S_outer_t(side)]
+				elevation := m.TriElevation.At(mesh.S_outer_t(side))
 				if elevation < bestElevation {
 					bestSide = side
 					bestElevation = elevation
@@ -294,7 +320,7 @@ func (m *BaseObject) AssignDownflow() {
 			m.TriDownflowSide[t] = bestSide
 			heap.Push(&queue, &QueueEntry{
 				Destination: t,
-				Score:       m.TriElevation[t],
+				Score:       m.TriElevation.At(t),
 				Index:       t,
 			})
 		}
@@ -306,13 +332,13 @@ func (m *BaseObject) AssignDownflow() {
 		for j := 0; j < 3; j++ {
 			s := 3*current_t + j
 			neighbor_t := mesh.S_outer_t(s) // uphill from current_t
-			if m.TriDownflowSide[neighbor_t] == -999 && m.TriElevation[neighbor_t] >= 0.0 {
+			if m.TriDownflowSide[neighbor_t] == -999 && m.TriElevation.At(neighbor_t) >= 0.0 {
 				m.TriDownflowSide[neighbor_t] = mesh.S_opposite_s(s)
 				m.OrderTri[queueIn] = neighbor_t
 				queueIn++
 				heap.Push(&queue, &QueueEntry{
 					Destination: neighbor_t,
-					Score:       m.TriElevation[neighbor_t],
+					Score:       m.TriElevation.At(neighbor_t),
 				})
 			}
 		}
@@ -334,9 +360,9 @@ func (m *BaseObject) GetRegNeighbors(r int) []int {
 func (m *BaseObject) GetLowestRegNeighbor(r int) int {
 	lowestReg := -1
 	lowestElev := 999.0
-	rElev := m.Elevation[r]
+	rElev := m.Elevation.At(r)
 	for _, nbReg := range m.GetRegNeighbors(r) {
-		elev := m.Elevation[nbReg]
+		elev := m.Elevation.At(nbReg)
 		if elev < lowestElev && elev < rElev {
 			lowestElev = elev
 			lowestReg = nbReg
@@ -411,6 +437,29 @@ func (m *BaseObject) GetRegArea(r int) float64 {
 	return area
 }
 
+// GetRegAreas returns the surface area of all regions on a unit sphere.
+func (m *BaseObject) GetRegAreas() []float64 {
+	areas := make([]float64, m.SphereMesh.NumRegions)
+	m.GetExecutor().ParallelFor(len(areas), func(start, end int) {
+		for r := start; r < end; r++ {
+			areas[r] = m.GetRegArea(r)
+		}
+	})
+	return areas
+}
+
+// getAreaByID returns the summed up surface area (on a unit sphere) of the
+// regions per ID (e.g. landmass or waterbody ID), ignoring negative IDs.
+func getAreaByID(ids []int, areas []float64) map[int]float64 {
+	idArea := make(map[int]float64)
+	for r, id := range ids {
+		if id >= 0 {
+			idArea[id] += areas[r]
+		}
+	}
+	return idArea
+}
+
 // GetSlope returns the region slope by averaging the slopes of the triangles
 // around a given region.
 //
@@ -446,7 +495,7 @@ func (m *BaseObject) GetSlope() []float64 {
 	useGoRoutines := true
 	if useGoRoutines {
 		// Use goroutines to process the regions in chunks.
-		various.KickOffChunkWorkers(m.SphereMesh.NumRegions, chunkProcessor)
+		m.GetExecutor().ParallelFor(m.SphereMesh.NumRegions, chunkProcessor)
 	} else {
 		// Process all regions in a single chunk.
 		chunkProcessor(0, m.SphereMesh.NumRegions)
@@ -490,7 +539,7 @@ func (m *BaseObject) GetSteepness() []float64 {
 			// steepness = angle * 2 / Pi
 
 			// Calculate height difference between r and dh[r].
-			hDiff := m.Elevation[r] - m.Elevation[dhReg]
+			hDiff := m.Elevation.At(r) - m.Elevation.At(dhReg)
 
 			// Great arc distance between the lat/lon coordinates of r and dh[r].
 			regLatLon := m.LatLon[r]
@@ -505,7 +554,7 @@ func (m *BaseObject) GetSteepness() []float64 {
 	useGoRoutines := true
 	// Use go routines to process a chunk of regions at a time.
 	if useGoRoutines {
-		various.KickOffChunkWorkers(m.SphereMesh.NumRegions, chunkProcessor)
+		m.GetExecutor().ParallelFor(m.SphereMesh.NumRegions, chunkProcessor)
 	} else {
 		chunkProcessor(0, m.SphereMesh.NumRegions)
 	}
@@ -555,10 +604,10 @@ func (m *BaseObject) regPolySlopeVec3(outRegs []int, i int) vectors.Vec3 {
 		// and elevation, then rotate the vector around the axis.
 		current := various.ConvToVec3(m.XYZ[r*3:]).
 			Rotate(axis, angle).
-			Mul(1 + 0.1*m.Elevation[r])
+			Mul(1 + 0.1*m.Elevation.At(r))
 		next := various.ConvToVec3(m.XYZ[jNext*3:]).
 			Rotate(axis, angle).
-			Mul(1 + 0.1*m.Elevation[jNext])
+			Mul(1 + 0.1*m.Elevation.At(jNext))
 		normal.X += (current.Z - next.Z) * (current.Y + next.Y)
 		normal.Y += (current.Y - next.Y) * (current.X + next.X)
 		normal.Z += (current.X - next.X) * (current.Z + next.Z)
@@ -658,9 +707,9 @@ func (m *BaseObject) RegTriNormal(t int, nbs []int) vectors.Vec3 {
 	p1 := various.ConvToVec3(m.XYZ[nbs[1]*3:])
 	p2 := various.ConvToVec3(m.XYZ[nbs[2]*3:])
 
-	p0 = p0.Rotate(axis, angle).Mul(1 + 0.05*m.Elevation[nbs[0]])
-	p1 = p1.Rotate(axis, angle).Mul(1 + 0.05*m.Elevation[nbs[1]])
-	p2 = p2.Rotate(axis, angle).Mul(1 + 0.05*m.Elevation[nbs[2]])
+	p0 = p0.Rotate(axis, angle).Mul(1 + 0.05*m.Elevation.At(nbs[0]))
+	p1 = p1.Rotate(axis, angle).Mul(1 + 0.05*m.Elevation.At(nbs[1]))
+	p2 = p2.Rotate(axis, angle).Mul(1 + 0.05*m.Elevation.At(nbs[2]))
 
 	// Calculate the normal.
 	return p1.Sub(p0).Cross(p2.Sub(p0)).Normalize()
@@ -673,13 +722,17 @@ func (m *BaseObject) GetSinks(skipSinksBelowSea, usePool bool) []int {
 	// Identify sinks above sea level.
 	var regSinks []int
 	for r, lowestReg := range m.GetDownhill(usePool) {
-		if lowestReg == -1 && (!skipSinksBelowSea || m.Elevation[r] > 0) { // && m.r_drainage[r] < 0
+		if lowestReg == -1 && (!skipSinksBelowSea || m.Elevation.At(r) > 0) { // && m.r_drainage[r] < 0
 			regSinks = append(regSinks, r)
 		}
 	}
 	return regSinks
 }
 
+// minFloat32Epsilon is the smallest elevation difference that FillSinks uses
+// if the elevation is stored with float32 precision (a few ulps at 1.0).
+const minFloat32Epsilon = 1.0 / (1 << 21)
+
 // FillSinks is an implementation of the algorithm described in
 // https://www.researchgate.net/publication/240407597_A_fast_simple_and_versatile_algorithm_to_fill_the_depressions_of_digital_elevation_models
 // and a partial port of the implementation in:
@@ -693,6 +746,9 @@ func (m *BaseObject) GetSinks(skipSinksBelowSea, usePool bool) []int {
 // in very artificially looking rivers. It lacks some kind of variation like
 // noise. It's very fast and less destructive than my other, home-grown algorithm.
 // Maybe it's worth to combine the two in some way?
+//
+// NOTE: If the elevation is stored with float32 precision, the epsilon is at
+// least minFloat32Epsilon, so the filled slopes don't turn into flats.
 func (m *BaseObject) FillSinks(randEpsilon bool) []float64 {
 	// Reset the RNG.
 	m.ResetRand()
@@ -702,10 +758,10 @@ func (m *BaseObject) FillSinks(randEpsilon bool) []float64 {
 	baseEpsilon := 1.0 / (float64(mesh.NumRegions) * 1000.0)
 	newHeight := make([]float64, mesh.NumRegions)
 	for i := range newHeight {
-		if m.Elevation[i] <= 0 {
+		if m.Elevation.At(i) <= 0 {
 			// Set the elevation at or below sea level to the current
 			// elevation.
-			newHeight[i] = m.Elevation[i]
+			newHeight[i] = m.Elevation.At(i)
 		} else {
 			// Set the elevation above sea level to infinity.
 			newHeight[i] = inf
@@ -715,7 +771,7 @@ func (m *BaseObject) FillSinks(randEpsilon bool) []float64 {
 	// Loop until no more changes are made.
 	var epsilon float64
 	outReg := make([]int, 0, 8)
-	outPermRegs := make([]int, 0, len(m.Elevation))
+	outPermRegs := make([]int, 0, m.Elevation.Len())
 	for {
 		if randEpsilon {
 			// Variation.
@@ -727,15 +783,18 @@ func (m *BaseObject) FillSinks(randEpsilon bool) []float64 {
 			//
 			// NOTE: I've decided to use m.rand.Float64() instead of noise.
 			epsilon = baseEpsilon * m.Rand.Float64()
+			if m.Elevation.LowPrecision() {
+				epsilon = math.Max(epsilon, minFloat32Epsilon)
+			}
 		}
 		changed := false
 
 		// By shuffling the order in which we parse regions,
 		// we ensure a more natural look.
-		for _, r := range m.randPerm(outPermRegs, len(m.Elevation)) {
+		for _, r := range m.randPerm(outPermRegs, m.Elevation.Len()) {
 			// Skip all regions that have the same elevation as in
 			// the current heightmap.
-			if newHeight[r] == m.Elevation[r] {
+			if newHeight[r] == m.Elevation.At(r) {
 				continue
 			}
 
@@ -752,8 +811,8 @@ func (m *BaseObject) FillSinks(randEpsilon bool) []float64 {
 				// the coast, comparing each region with the processed / set
 				// neighbors (that aren't set to infinity) in the new heightmap
 				// until we run out of regions that need change.
-				if m.Elevation[r] >= newHeight[nb]+epsilon {
-					newHeight[r] = m.Elevation[r]
+				if m.Elevation.At(r) >= newHeight[nb]+epsilon {
+					newHeight[r] = m.Elevation.At(r)
 					changed = true
 					break
 				}
@@ -774,7 +833,7 @@ func (m *BaseObject) FillSinks(randEpsilon bool) []float64 {
 				//
 				// TODO: Simplify this comment word salad.
 				oh := newHeight[nb] + epsilon
-				if newHeight[r] > oh && oh > m.Elevation[r] {
+				if newHeight[r] > oh && oh > m.Elevation.At(r) {
 					newHeight[r] = oh
 					changed = true
 				}
@@ -789,10 +848,49 @@ func (m *BaseObject) FillSinks(randEpsilon bool) []float64 {
 
 // AssignDistanceField calculates the distance from any point in seedRegs to all other points, but
 // don't go past any point in stopReg.
+//
+// NOTE: The distance is measured in graph hops, which depends on the resolution of the mesh.
+// Use Geo.AssignGeodesicDistanceField for the distance in km.
 func (m *BaseObject) AssignDistanceField(seedRegs []int, stopReg map[int]bool) []float64 {
+	return m.AssignDistanceFieldFunc(seedRegs, func(r int) bool {
+		return stopReg[r]
+	})
+}
+
+// AssignDistanceFieldFunc is the same as AssignDistanceField, but the stop
+// regions are given as a function (e.g. various.Bitset.Has), so we don't have
+// to build a map.
+func (m *BaseObject) AssignDistanceFieldFunc(seedRegs []int, isStop func(r int) bool) []float64 {
 	// Reset the random number generator.
 	m.ResetRand()
+	return m.assignDistanceField(m.Rand, seedRegs, isStop)
+}
 
+// AssignDistanceFields calculates a distance field (see AssignDistanceField)
+// for each of the given seed region slices in parallel. If isStop is nil,
+// there are no stop regions.
+//
+// Each field uses its own random number generator seeded with the seed of the
+// world, so the results are identical to separate calls of
+// AssignDistanceField. The random number generator of the world is reset
+// afterwards.
+func (m *BaseObject) AssignDistanceFields(seedRegs [][]int, isStop func(r int) bool) [][]float64 {
+	if isStop == nil {
+		isStop = func(r int) bool { return false }
+	}
+	fields := make([][]float64, len(seedRegs))
+	m.GetExecutor().ParallelFor(len(seedRegs), func(start, end int) {
+		for i := start; i < end; i++ {
+			fields[i] = m.assignDistanceField(rand.New(rand.NewSource(m.Seed)), seedRegs[i], isStop)
+		}
+	})
+	m.ResetRand()
+	return fields
+}
+
+// assignDistanceField calculates the distance field using the given random
+// number generator (see AssignDistanceFieldFunc).
+func (m *BaseObject) assignDistanceField(rnd *rand.Rand, seedRegs []int, isStop func(r int) bool) []float64 {
 	inf := math.Inf(0)
 	mesh := m.SphereMesh
 	numRegions := mesh.NumRegions
@@ -817,11 +915,11 @@ func (m *BaseObject) AssignDistanceField(seedRegs []int, stopReg map[int]bool) [
 	// Random search adapted from breadth first search.
 	// TODO: Improve the queue. Currently this is growing unchecked.
 	for queueOut := 0; queueOut < len(queue); queueOut++ {
-		pos := queueOut + m.Rand.Intn(len(queue)-queueOut)
+		pos := queueOut + rnd.Intn(len(queue)-queueOut)
 		currentReg := queue[pos]
 		queue[pos] = queue[queueOut]
 		for _, nbReg := range mesh.R_circulate_r(outRegs, currentReg) {
-			if !math.IsInf(regDistance[nbReg], 0) || stopReg[nbReg] {
+			if !math.IsInf(regDistance[nbReg], 0) || isStop(nbReg) {
 				continue
 			}
 
@@ -907,6 +1005,7 @@ func (m *BaseObject) UpdateDistanceField(regDistance []float64, seedRegs []int,
 
 type Interpolated struct {
 	NumRegions int
+	Parents    [][2]int // Regions of the original object each region was derived from
 	BaseObject
 }
 
@@ -916,46 +1015,61 @@ func (m *BaseObject) Interpolate(regions []int) (*Interpolated, error) {
 	var ipl Interpolated
 	ipl.Seed = m.Seed
 	ipl.Rand = rand.New(rand.NewSource(m.Seed))
+	ipl.executor = m.executor
 
 	// Increase the resolution by one octave.
 	ipl.noise = m.noise.PlusOneOctave()
 
+	// Use the same precision for the values as the original object.
+	lowPrecision := m.Elevation.LowPrecision()
+	ipl.Elevation = various.NewFloats(0, lowPrecision)
+	ipl.Moisture = various.NewFloats(0, lowPrecision)
+	ipl.Rainfall = various.NewFloats(0, lowPrecision)
+	ipl.Flux = various.NewFloats(0, lowPrecision)
+	ipl.Waterpool = various.NewFloats(0, lowPrecision)
+	ipl.OceanTemperature = various.NewFloats(0, lowPrecision)
+	ipl.AirTemperature = various.NewFloats(0, lowPrecision)
+	ipl.OceanSalinity = various.NewFloats(0, lowPrecision)
+	ipl.OceanUpwelling = various.NewFloats(0, lowPrecision)
+	ipl.CoastalFog = various.NewFloats(0, lowPrecision)
+
 	// Get all points within bounds.
 	seen := make(map[[2]int]bool)
 
 	// Carry over mountains, volcanoes and compression.
-	regionIsMountain := make(map[int]bool)
-	regionIsVolcano := make(map[int]bool)
-	regionIsWaterfall := make(map[int]bool)
-	regionCompression := make(map[int]float64)
+	// NOTE: regionCompression holds one value per interpolated region.
+	var mountains, volcanoes, waterfalls []int
+	var regionCompression []float64
 	outRegs := make([]int, 0, 6)
 
 	mesh := m.SphereMesh
 	var xyz []float64
 	for _, r := range regions {
-		if m.RegionIsMountain[r] {
-			regionIsMountain[ipl.NumRegions] = true
+		if m.RegionIsMountain.Has(r) {
+			mountains = append(mountains, ipl.NumRegions)
 		}
-		if m.RegionIsVolcano[r] {
-			regionIsVolcano[ipl.NumRegions] = true
+		if m.RegionIsVolcano.Has(r) {
+			volcanoes = append(volcanoes, ipl.NumRegions)
 		}
-		if m.RegionIsWaterfall[r] {
-			regionIsWaterfall[ipl.NumRegions] = true
-		}
-		if m.RegionCompression[r] != 0 {
-			regionCompression[ipl.NumRegions] = m.RegionCompression[r]
+		if m.RegionIsWaterfall.Has(r) {
+			waterfalls = append(waterfalls, ipl.NumRegions)
 		}
+		regionCompression = append(regionCompression, m.RegionCompression[r])
 
 		ipl.NumRegions++
+		ipl.Parents = append(ipl.Parents, [2]int{r, r})
 		rxyz := m.XYZ[r*3 : (r*3)+3]
 		xyz = append(xyz, rxyz...)
-		ipl.Moisture = append(ipl.Moisture, m.Moisture[r])
-		ipl.Rainfall = append(ipl.Rainfall, m.Rainfall[r])
-		ipl.Flux = append(ipl.Flux, m.Flux[r])
-		ipl.Waterpool = append(ipl.Waterpool, m.Waterpool[r])
-		ipl.Elevation = append(ipl.Elevation, m.Elevation[r])
-		ipl.OceanTemperature = append(ipl.OceanTemperature, m.OceanTemperature[r])
-		ipl.AirTemperature = append(ipl.AirTemperature, m.AirTemperature[r])
+		ipl.Moisture.Append(m.Moisture.At(r))
+		ipl.Rainfall.Append(m.Rainfall.At(r))
+		ipl.Flux.Append(m.Flux.At(r))
+		ipl.Waterpool.Append(m.Waterpool.At(r))
+		ipl.Elevation.Append(m.Elevation.At(r))
+		ipl.OceanTemperature.Append(m.OceanTemperature.At(r))
+		ipl.AirTemperature.Append(m.AirTemperature.At(r))
+		ipl.OceanSalinity.Append(m.OceanSalinity.At(r))
+		ipl.OceanUpwelling.Append(m.OceanUpwelling.At(r))
+		ipl.CoastalFog.Append(m.CoastalFog.At(r))
 
 		// Circulate_r all points and add midpoints.
 		for _, nbReg := range mesh.R_circulate_r(outRegs, r) {
@@ -982,28 +1096,37 @@ func (m *BaseObject) Interpolate(regions []int) (*Interpolated, error) {
 			}).Normalize()
 			xyz = append(xyz, mid.X, mid.Y, mid.Z)
 			ipl.NumRegions++
+			ipl.Parents = append(ipl.Parents, [2]int{r, nbReg})
 
 			// Calculate diff and use noise to add variation.
 			nvl := (ipl.noise.Eval3(mid.X, mid.Y, mid.Z) + 1) / 2
-			diffElevation := m.Elevation[nbReg] - m.Elevation[r]
-			diffMoisture := m.Moisture[nbReg] - m.Moisture[r]
-			diffRainfall := m.Rainfall[nbReg] - m.Rainfall[r]
-			diffFlux := m.Flux[nbReg] - m.Flux[r]
-			diffPool := m.Waterpool[nbReg] - m.Waterpool[r]
-			diffOceanTemp := m.OceanTemperature[nbReg] - m.OceanTemperature[r]
-			diffAirTemp := m.AirTemperature[nbReg] - m.AirTemperature[r]
+			diffElevation := m.Elevation.At(nbReg) - m.Elevation.At(r)
+			diffMoisture := m.Moisture.At(nbReg) - m.Moisture.At(r)
+			diffRainfall := m.Rainfall.At(nbReg) - m.Rainfall.At(r)
+			diffFlux := m.Flux.At(nbReg) - m.Flux.At(r)
+			diffPool := m.Waterpool.At(nbReg) - m.Waterpool.At(r)
+			diffOceanTemp := m.OceanTemperature.At(nbReg) - m.OceanTemperature.At(r)
+			diffAirTemp := m.AirTemperature.At(nbReg) - m.AirTemperature.At(r)
+			diffSalinity := m.OceanSalinity.At(nbReg) - m.OceanSalinity.At(r)
+			diffUpwelling := m.OceanUpwelling.At(nbReg) - m.OceanUpwelling.At(r)
+			diffFog := m.CoastalFog.At(nbReg) - m.CoastalFog.At(r)
 
 			// TODO: Add some better variation with the water pool and stuff.
 			// TODO: Add flood fill, downhill and flux?
-			// TODO: Average compression values?
-
-			ipl.Elevation = append(ipl.Elevation, m.Elevation[r]+(diffElevation*nvl))
-			ipl.Moisture = append(ipl.Moisture, m.Moisture[r]+(diffMoisture*nvl))
-			ipl.Rainfall = append(ipl.Rainfall, m.Rainfall[r]+(diffRainfall*nvl))
-			ipl.Flux = append(ipl.Flux, m.Flux[r]+(diffFlux*nvl))
-			ipl.Waterpool = append(ipl.Waterpool, m.Waterpool[r]+(diffPool*nvl))
-			ipl.OceanTemperature = append(ipl.OceanTemperature, m.OceanTemperature[r]+(diffOceanTemp*nvl))
-			ipl.AirTemperature = append(ipl.AirTemperature, m.AirTemperature[r]+(diffAirTemp*nvl))
+
+			// The compression of the midpoint is the average of its parents.
+			regionCompression = append(regionCompression, (m.RegionCompression[r]+m.RegionCompression[nbReg])/2)
+
+			ipl.Elevation.Append(m.Elevation.At(r) + (diffElevation * nvl))
+			ipl.Moisture.Append(m.Moisture.At(r) + (diffMoisture * nvl))
+			ipl.Rainfall.Append(m.Rainfall.At(r) + (diffRainfall * nvl))
+			ipl.Flux.Append(m.Flux.At(r) + (diffFlux * nvl))
+			ipl.Waterpool.Append(m.Waterpool.At(r) + (diffPool * nvl))
+			ipl.OceanTemperature.Append(m.OceanTemperature.At(r) + (diffOceanTemp * nvl))
+			ipl.AirTemperature.Append(m.AirTemperature.At(r) + (diffAirTemp * nvl))
+			ipl.OceanSalinity.Append(m.OceanSalinity.At(r) + (diffSalinity * nvl))
+			ipl.OceanUpwelling.Append(m.OceanUpwelling.At(r) + (diffUpwelling * nvl))
+			ipl.CoastalFog.Append(m.CoastalFog.At(r) + (diffFog * nvl))
 		}
 	}
 
@@ -1027,22 +1150,34 @@ func (m *BaseObject) Interpolate(regions []int) (*Interpolated, error) {
 	ipl.TriQuadTree = spheremesh.NewQuadTreeFromLatLon(ipl.SphereMesh.TriLatLon)
 
 	// Assign all the values.
-	ipl.RegionIsMountain = regionIsMountain
-	ipl.RegionIsVolcano = regionIsVolcano
-	ipl.RegionIsWaterfall = regionIsWaterfall
+	ipl.RegionIsMountain = various.NewBitset(sphere.NumRegions)
+	for _, r := range mountains {
+		ipl.RegionIsMountain.Set(r)
+	}
+	ipl.RegionIsVolcano = various.NewBitset(sphere.NumRegions)
+	for _, r := range volcanoes {
+		ipl.RegionIsVolcano.Set(r)
+	}
+	ipl.RegionIsWaterfall = various.NewBitset(sphere.NumRegions)
+	for _, r := range waterfalls {
+		ipl.RegionIsWaterfall.Set(r)
+	}
 	ipl.RegionCompression = regionCompression
-	ipl.TriPool = make([]float64, sphere.NumTriangles)
-	ipl.TriElevation = make([]float64, sphere.NumTriangles)
-	ipl.TriMoisture = make([]float64, sphere.NumTriangles)
+	ipl.TriPool = various.NewFloats(sphere.NumTriangles, lowPrecision)
+	ipl.TriElevation = various.NewFloats(sphere.NumTriangles, lowPrecision)
+	ipl.TriMoisture = various.NewFloats(sphere.NumTriangles, lowPrecision)
 	ipl.TriDownflowSide = make([]int, sphere.NumTriangles)
 	ipl.OrderTri = make([]int, sphere.NumTriangles)
-	ipl.TriFlow = make([]float64, sphere.NumTriangles)
-	ipl.SideFlow = make([]float64, sphere.NumSides)
+	ipl.TriFlow = various.NewFloats(sphere.NumTriangles, lowPrecision)
+	ipl.SideFlow = various.NewFloats(sphere.NumSides, lowPrecision)
 	ipl.AssignDownhill(true)
 	ipl.assignTriValues()
 	ipl.AssignDownflow()
 	ipl.AssignFlow()
 
+	// Carry over the custom data layers.
+	m.interpolateLayers(&ipl.BaseObject, ipl.Parents)
+
 	return &ipl, nil
 }
 
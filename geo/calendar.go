@@ -1,44 +1,55 @@
 package geo
 
-import "time"
-
-// TODO: Create struct that tracks date and time.
+// Calendar tracks the current year and day of the year.
+//
+// NOTE: Since the planet might have a different number of days per year
+// than earth, we can't use time.Time here.
 type Calendar struct {
-	t time.Time
+	year        int64
+	day         int // Day of the year, starting at 0
+	daysPerYear int
 }
 
-func NewCalendar() *Calendar {
+// NewCalendar returns a new calendar with the given (positive) number of days
+// per year.
+func NewCalendar(daysPerYear int) *Calendar {
 	return &Calendar{
-		t: time.Unix(0, 0),
+		year:        1970,
+		daysPerYear: daysPerYear,
 	}
 }
 
-// GetDayOfYear returns the current day of the year.
+// GetDayOfYear returns the current day of the year (starting at 1).
 func (c *Calendar) GetDayOfYear() int {
-	return c.t.YearDay()
+	return c.day + 1
 }
 
 // Tick advances the calendar by one day.
 func (c *Calendar) Tick() {
-	c.t = c.t.Add(time.Hour * 24)
+	c.day++
+	if c.day >= c.daysPerYear {
+		c.day = 0
+		c.year++
+	}
 }
 
 // TickYear advances the calendar by one year.
 func (c *Calendar) TickYear() {
-	c.t = c.t.AddDate(1, 0, 0)
+	c.year++
 }
 
 // SetYear sets the year of the calendar.
 func (c *Calendar) SetYear(year int64) {
-	c.t = time.Date(int(year), 1, 1, 0, 0, 0, 0, time.UTC)
+	c.year = year
+	c.day = 0
 }
 
 // GetYear returns the current year.
 func (c *Calendar) GetYear() int64 {
-	return int64(c.t.Year())
+	return c.year
 }
 
 // GetYearProgress returns the progress of the current year in 0.0-1.0.
 func (c *Calendar) GetYearProgress() float64 {
-	return float64(c.t.YearDay()) / float64(365)
+	return float64(c.GetDayOfYear()) / float64(c.daysPerYear)
 }
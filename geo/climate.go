@@ -9,24 +9,24 @@ import (
 
 // GetAzgaarRegionBiome returns the biome for a given region as per Azgaar's map generator.
 func (m *Geo) GetAzgaarRegionBiome(r int, elev, maxElev float64) int {
-	return genbiome.GetAzgaarBiome(int(20.0*m.Moisture[r]), int(m.GetRegTemperature(r, maxElev)), int(elev*100))
+	return genbiome.GetAzgaarBiome(int(20.0*m.Moisture.At(r)), int(m.GetRegTemperature(r, maxElev)), int(elev*100))
 }
 
 // GetRegWhittakerModBiomeFunc returns a function that returns the Whittaker biome
 // for a given region.
 func (m *Geo) GetRegWhittakerModBiomeFunc() func(r int) int {
-	_, maxElev := minMax(m.Elevation)
-	_, maxMois := minMax(m.Moisture)
+	_, maxElev := m.Elevation.MinMax()
+	_, maxMois := m.Moisture.MinMax()
 	return func(r int) int {
-		valElev := m.Elevation[r] / maxElev
-		valMois := m.Moisture[r] / maxMois
+		valElev := m.Elevation.At(r) / maxElev
+		valMois := m.Moisture.At(r) / maxMois
 		regLat := m.LatLon[r][0]
-		return getWhittakerModBiome(regLat, valElev, valMois)
+		return m.getWhittakerModBiome(regLat, valElev, valMois)
 	}
 }
 
-func getWhittakerModBiome(latitude, elevation, moisture float64) int {
-	return genbiome.GetWhittakerModBiome(int(GetMeanAnnualTemp(latitude)-GetTempFalloffFromAltitude(MaxAltitudeFactor*elevation)), int(moisture*MaxPrecipitation))
+func (m *Geo) getWhittakerModBiome(latitude, elevation, moisture float64) int {
+	return genbiome.GetWhittakerModBiome(int(m.getMeanAnnualTemp(latitude)-GetTempFalloffFromAltitude(MaxAltitudeFactor*elevation)), int(moisture*MaxPrecipitation))
 }
 
 func GetWhittakerModBiomeColor(latitude, elevation, moisture, intensity float64) color.NRGBA {
@@ -52,7 +52,7 @@ func (m *Geo) identifyBiomeRegions() []int {
 	biomeToRegs := initRegionSlice(m.SphereMesh.NumRegions)
 	// Set all ocean regions to -2.
 	for r := range biomeToRegs {
-		if m.Elevation[r] <= 0.0 {
+		if m.Elevation.At(r) <= 0.0 {
 			biomeToRegs[r] = -2
 		}
 	}
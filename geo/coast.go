@@ -0,0 +1,223 @@
+package geo
+
+import "math"
+
+// Coast types describing the geomorphology of coastal land regions.
+const (
+	CoastTypeNone = iota
+	CoastTypeCliff
+	CoastTypeBeach
+	CoastTypeDelta
+	CoastTypeEstuary
+	CoastTypeFjord
+	CoastTypeMangrove
+	CoastTypeReef
+	CoastTypeMarsh
+)
+
+// CoastTypeToString returns the name of the given coast type.
+func CoastTypeToString(t int) string {
+	switch t {
+	case CoastTypeCliff:
+		return "cliff"
+	case CoastTypeBeach:
+		return "beach"
+	case CoastTypeDelta:
+		return "delta"
+	case CoastTypeEstuary:
+		return "estuary"
+	case CoastTypeFjord:
+		return "fjord"
+	case CoastTypeMangrove:
+		return "mangrove"
+	case CoastTypeReef:
+		return "reef"
+	case CoastTypeMarsh:
+		return "marsh"
+	default:
+		return ""
+	}
+}
+
+// CoastTypeHarborQuality returns the suitability (0.0-1.0) of the given coast
+// type for a harbor.
+func CoastTypeHarborQuality(t int) float64 {
+	switch t {
+	case CoastTypeEstuary:
+		return 1.0 // Sheltered, deep water and access to the hinterland.
+	case CoastTypeFjord:
+		return 0.9 // Sheltered and deep, but little hinterland.
+	case CoastTypeDelta:
+		return 0.7 // Access to the river, but shifting channels.
+	case CoastTypeBeach:
+		return 0.5
+	case CoastTypeMarsh, CoastTypeMangrove:
+		return 0.3 // Shallow and hard to build on.
+	case CoastTypeReef:
+		return 0.2 // Treacherous waters.
+	case CoastTypeCliff:
+		return 0.1 // Hardly any landing spots.
+	default:
+		return 0
+	}
+}
+
+// assignCoastTypes classifies all coastal land regions by their geomorphology
+// based on the slope, sediment load, river mouths, temperature, tidal range
+// and wave exposure.
+//
+// NOTE: Coastline_r only contains the coastlines produced by the tectonic
+// plate collisions, so we use all land regions neighboring the ocean instead.
+func (m *Geo) assignCoastTypes() {
+	coastTypes := make([]int, m.SphereMesh.NumRegions)
+	steepness := m.GetSteepness()
+	exposure := m.GetWaveExposure()
+	tidalRange := m.GetTidalRange()
+	_, maxElev := m.Elevation.MinMax()
+	_, maxFlux := m.Flux.MinMax()
+	if maxFlux == 0 {
+		maxFlux = 1
+	}
+
+	// Since the steepness depends on the scale of the elevation, we normalize
+	// it using the steepest coastal region.
+	var maxSteep float64
+	for r := 0; r < m.SphereMesh.NumRegions; r++ {
+		if m.isRegCoastalLand(r) {
+			maxSteep = math.Max(maxSteep, steepness[r])
+		}
+	}
+	if maxSteep == 0 {
+		maxSteep = 1
+	}
+
+	for r := 0; r < m.SphereMesh.NumRegions; r++ {
+		if !m.isRegCoastalLand(r) {
+			continue
+		}
+		steep := steepness[r] / maxSteep
+		sediment := math.Sqrt(m.Flux.At(r) / maxFlux)
+		temp := m.GetRegTemperature(r, maxElev)
+		absLat := math.Abs(m.LatLon[r][0])
+		isMouth := m.IsRegRiver(r) && m.Downhill[r] >= 0 && m.Elevation.At(m.Downhill[r]) <= 0
+
+		// Find the warmest neighboring ocean region and the strongest
+		// upwelling (for reefs).
+		var oceanTemp, upwelling float64
+		for _, nb := range m.GetRegNeighbors(r) {
+			if m.Elevation.At(nb) <= 0 {
+				oceanTemp = math.Max(oceanTemp, m.OceanTemperature.At(nb))
+				upwelling = math.Max(upwelling, m.OceanUpwelling.At(nb))
+			}
+		}
+
+		switch {
+		case isMouth && sediment > 0.3 && tidalRange[r] < 0.5 && exposure[r] < 0.5:
+			// Lots of sediment and little to wash it away.
+			coastTypes[r] = CoastTypeDelta
+		case isMouth:
+			// Tides and waves flush the sediment out to sea.
+			coastTypes[r] = CoastTypeEstuary
+		case steep > 0.5 && temp < 5 && absLat > 45:
+			// Glacially carved valleys flooded by the sea.
+			coastTypes[r] = CoastTypeFjord
+		case steep > 0.5 || (steep > 0.3 && exposure[r] > 0.7):
+			coastTypes[r] = CoastTypeCliff
+		case temp > 20 && exposure[r] < 0.3 && (tidalRange[r] > 0.4 || sediment > 0.1):
+			// Sheltered, muddy tropical coasts.
+			coastTypes[r] = CoastTypeMangrove
+		case oceanTemp > 20 && sediment < 0.1 && upwelling < 0.3:
+			// Corals need warm, clear and nutrient poor water.
+			coastTypes[r] = CoastTypeReef
+		case exposure[r] < 0.3 && steep < 0.2:
+			coastTypes[r] = CoastTypeMarsh
+		default:
+			coastTypes[r] = CoastTypeBeach
+		}
+	}
+	m.RegionToCoastType = coastTypes
+}
+
+// isRegCoastalLand returns true if the region is a land region next to the
+// ocean (or any other body of water below sea level).
+func (m *Geo) isRegCoastalLand(r int) bool {
+	if m.Elevation.At(r) <= 0 {
+		return false
+	}
+	for _, nb := range m.GetRegNeighbors(r) {
+		if m.Elevation.At(nb) <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// GetWaveExposure returns the wave exposure (0.0-1.0) of all coastal land
+// regions, which is based on the fetch, the distance the wind travels over
+// open water before reaching the coast.
+func (m *Geo) GetWaveExposure() []float64 {
+	// Maximum number of regions we trace the wind upwind.
+	const maxFetch = 20
+
+	exposure := make([]float64, m.SphereMesh.NumRegions)
+	outRegs := make([]int, 0, 8)
+	for r := 0; r < m.SphereMesh.NumRegions; r++ {
+		if !m.isRegCoastalLand(r) {
+			continue
+		}
+
+		// Follow the wind upwind until we hit land again.
+		var fetch int
+		cur := r
+		for fetch < maxFetch {
+			prev := m.getPreviousNeighbor(outRegs, cur, m.RegionToWindVec[cur])
+			if prev < 0 || prev == cur || m.Elevation.At(prev) > 0 {
+				break
+			}
+			cur = prev
+			fetch++
+		}
+		exposure[r] = float64(fetch) / maxFetch
+	}
+	return exposure
+}
+
+// GetTidalRange returns the relative tidal range (0.0-1.0) of all coastal
+// land regions.
+//
+// Tides are amplified in embayments, where the water is funneled into a
+// narrowing basin, so we use the fraction of land surrounding the adjacent
+// water regions as an indicator.
+func (m *Geo) GetTidalRange() []float64 {
+	tidalRange := make([]float64, m.SphereMesh.NumRegions)
+	for r := 0; r < m.SphereMesh.NumRegions; r++ {
+		if !m.isRegCoastalLand(r) {
+			continue
+		}
+		var numLand, numTotal int
+		for _, nb := range m.GetRegNeighbors(r) {
+			if m.Elevation.At(nb) > 0 {
+				continue
+			}
+			for _, nb2 := range m.GetRegNeighbors(nb) {
+				if m.Elevation.At(nb2) > 0 {
+					numLand++
+				}
+				numTotal++
+			}
+		}
+		if numTotal > 0 {
+			tidalRange[r] = float64(numLand) / float64(numTotal)
+		}
+	}
+	return tidalRange
+}
+
+// GetRegHarborQuality returns the suitability (0.0-1.0) of the given region
+// for a harbor based on its coast type.
+func (m *Geo) GetRegHarborQuality(reg int) float64 {
+	if reg < 0 || reg >= len(m.RegionToCoastType) {
+		return 0
+	}
+	return CoastTypeHarborQuality(m.RegionToCoastType[reg])
+}
@@ -1,21 +1,42 @@
 package geo
 
+import (
+	"github.com/Flokey82/genworldvoronoi/noise"
+	"github.com/Flokey82/genworldvoronoi/spheremesh"
+	"github.com/Flokey82/genworldvoronoi/various"
+)
+
 // GeoConfig is a struct that holds all configuration options for the geography / geology / climate generation.
 type GeoConfig struct {
-	NumPlates               int     // Number of generated plates
-	OceanPlatesFraction     float64 // Fraction of ocean plates
-	OceanPlatesAltSelection bool    // Use alternative selection of ocean plates
-	NumVolcanoes            int     // Number of generated volcanoes
-	NumPoints               int     // Number of generated points / regions
-	TectonicFalloff         bool    // Use square falloff to make mountains more peaky and flatlands more flat.
-	NormalizeElevation      bool    // Normalize elevation to 0-1 range
-	MultiplyNoise           bool    // Multiply noise instead of adding
-	Jitter                  float64 // Jitter factor (randomness in point distribution)
+	*PlanetConfig                                    // Physical properties of the planet
+	NumPlates               int                      // Number of generated plates
+	OceanPlatesFraction     float64                  // Fraction of ocean plates
+	OceanPlatesAltSelection bool                     // Use alternative selection of ocean plates
+	NumVolcanoes            int                      // Number of generated volcanoes
+	NumPoints               int                      // Number of generated points / regions
+	TectonicFalloff         bool                     // Use square falloff to make mountains more peaky and flatlands more flat.
+	NormalizeElevation      bool                     // Normalize elevation to 0-1 range
+	MultiplyNoise           bool                     // Multiply noise instead of adding
+	Jitter                  float64                  // Jitter factor (randomness in point distribution)
+	MeshGenerator           spheremesh.MeshGenerator // Hierarchical point distribution (nil: Fibonacci sphere, ignored for adaptive meshes)
+	NumStormTracks          int                      // Number of generated (historical) tropical cyclone tracks
+	CycloneMinSeaTemp       float64                  // Minimum ocean temperature (°C) for tropical cyclones to spawn
+	TemperatureOffset       float64                  // Global temperature offset (°C) relative to the present day climate
+	SeaLevelOffset          float64                  // Sea level change (m) relative to the generated sea level
+	ResourceRegistry        *ResourceRegistry        // Registry of (built-in and custom) natural resources
+	LightningIgnitionChance float64                  // Daily chance per region of a lightning strike that might ignite a wildfire
+	AdaptiveMesh            bool                     // Place more points near coastlines, mountains and rivers
+	AdaptiveMeshFraction    float64                  // Fraction of the points used for the coarse pass of the adaptive mesh
+	NoiseLayers             []noise.Layer            // Noise layers applied to the elevation (nil: single fBm layer, see MultiplyNoise)
+	Heightmap               *Heightmap               // Imported terrain used instead of tectonic plates (nil: generate terrain)
+	Float32Storage          bool                     // Store the bulk float layers (elevation, moisture, ...) with float32 precision to save memory
+	Executor                *various.Executor        // Executor for parallel work (nil: various.DefaultExecutor)
 }
 
 // NewGeoConfig returns a new config for geography / geology / climate generation.
 func NewGeoConfig() *GeoConfig {
 	return &GeoConfig{
+		PlanetConfig:            NewPlanetConfig(),
 		NumPoints:               400000,
 		NumPlates:               25,
 		OceanPlatesFraction:     0.5,
@@ -25,5 +46,18 @@ func NewGeoConfig() *GeoConfig {
 		NormalizeElevation:      true,
 		MultiplyNoise:           true,
 		Jitter:                  0.0,
+		MeshGenerator:           nil,
+		NumStormTracks:          200,
+		CycloneMinSeaTemp:       26.0,
+		TemperatureOffset:       0.0,
+		SeaLevelOffset:          0.0,
+		ResourceRegistry:        NewResourceRegistry(),
+		LightningIgnitionChance: 0.00001,
+		AdaptiveMesh:            false,
+		AdaptiveMeshFraction:    0.3,
+		NoiseLayers:             nil,
+		Heightmap:               nil,
+		Float32Storage:          false,
+		Executor:                various.NewExecutor(0),
 	}
 }
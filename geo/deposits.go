@@ -0,0 +1,205 @@
+package geo
+
+import (
+	"math"
+	"math/bits"
+)
+
+// Base quantity of a deposit per resource type (in arbitrary units).
+const (
+	depositBaseQuantityMetal   = 1000.0
+	depositBaseQuantityGem     = 100.0
+	depositBaseQuantityStone   = 10000.0
+	depositBaseQuantityVarious = 5000.0
+	depositBaseQuantityWood    = 5000.0
+)
+
+// Deposit is a natural resource deposit in a region.
+type Deposit struct {
+	Def        *ResourceDef // Resource definition
+	Region     int          // Region where the deposit is located
+	Type       int          // Resource type (ResourceTypeMetal, ResourceTypeGem, ...)
+	Resource   byte         // Resource flag (e.g. ResMetIron, 0 for custom resources)
+	Quantity   float64      // Remaining quantity
+	Initial    float64      // Initial quantity
+	Quality    float64      // Quality grade (0.0-1.0)
+	Difficulty float64      // Extraction difficulty (0.0-1.0)
+	Discovered bool         // True if the deposit has been discovered
+	Suspended  bool         // True while the deposit is unavailable (e.g. burned wood)
+}
+
+// Name returns the name of the resource of the deposit.
+func (d *Deposit) Name() string {
+	return d.Def.Name
+}
+
+// ResourceID returns the index of the resource flag (e.g. 0 for ResMetIron).
+// NOTE: This is only meaningful for built-in resources.
+func (d *Deposit) ResourceID() int {
+	return bits.TrailingZeros8(d.Resource)
+}
+
+// Remaining returns the remaining fraction (0.0-1.0) of the deposit.
+func (d *Deposit) Remaining() float64 {
+	if d.Initial <= 0 {
+		return 0
+	}
+	return d.Quantity / d.Initial
+}
+
+// IsDepleted returns true if the deposit has been exhausted.
+func (d *Deposit) IsDepleted() bool {
+	return d.Quantity <= 0
+}
+
+// IsAvailable returns true if the deposit is neither depleted nor suspended.
+func (d *Deposit) IsAvailable() bool {
+	return !d.IsDepleted() && !d.Suspended
+}
+
+// Value returns the value of the deposit, taking into account the value of
+// the resource, the quality and the remaining quantity. Suspended deposits
+// have no value.
+func (d *Deposit) Value() float64 {
+	if d.Suspended {
+		return 0
+	}
+	return d.Def.Value * (0.5 + d.Quality/2) * d.Remaining()
+}
+
+// Extract extracts up to the given amount from the deposit and returns the
+// actually extracted amount. Difficult deposits yield less per effort.
+func (d *Deposit) Extract(amount float64) float64 {
+	amount *= 1 - d.Difficulty/2
+	if amount > d.Quantity {
+		amount = d.Quantity
+	}
+	d.Quantity -= amount
+	return amount
+}
+
+// GetDeposits returns all deposits in the given region.
+func (res *Resources) GetDeposits(r int) []*Deposit {
+	return res.Deposits[r]
+}
+
+// GetDepositsOfType returns all deposits of the given resource type in the
+// given region.
+func (res *Resources) GetDepositsOfType(r, resourceType int) []*Deposit {
+	var deps []*Deposit
+	for _, d := range res.Deposits[r] {
+		if d.Type == resourceType {
+			deps = append(deps, d)
+		}
+	}
+	return deps
+}
+
+// HasDepositsOfType returns true if there are any available deposits of the
+// given resource type in the given region.
+func (res *Resources) HasDepositsOfType(r, resourceType int) bool {
+	for _, d := range res.Deposits[r] {
+		if d.Type == resourceType && d.IsAvailable() {
+			return true
+		}
+	}
+	return false
+}
+
+// GetDepositValue returns the sum of the value of all available deposits
+// in the given region.
+func (res *Resources) GetDepositValue(r int) float64 {
+	var sum float64
+	for _, d := range res.Deposits[r] {
+		sum += d.Value()
+	}
+	return sum
+}
+
+// RemoveDepleted removes the flag of a depleted deposit from the resource
+// bitmask of its region (unless there is another deposit of the same
+// resource), so that the region no longer counts as having the resource.
+func (res *Resources) RemoveDepleted(d *Deposit) {
+	for _, o := range res.Deposits[d.Region] {
+		if o != d && o.Def == d.Def && !o.IsDepleted() {
+			return
+		}
+	}
+	switch d.Type {
+	case ResourceTypeMetal:
+		res.Metals[d.Region] &^= d.Resource
+	case ResourceTypeGem:
+		res.Gems[d.Region] &^= d.Resource
+	case ResourceTypeStone:
+		res.Stones[d.Region] &^= d.Resource
+	case ResourceTypeVarious:
+		res.Various[d.Region] &^= d.Resource
+	case ResourceTypeWood:
+		res.Wood[d.Region] &^= d.Resource
+	}
+}
+
+// assignDeposits generates a deposit for each resource flag set in the
+// resource bitmasks and each placed custom resource with a random quantity
+// and quality, and an extraction difficulty depending on the terrain.
+//
+// Surface resources like stone, wood and fish are discovered from the start,
+// while ores and gems need to be discovered by the miners.
+func (m *Geo) assignDeposits() {
+	m.ResetRand()
+	steepness := m.GetSteepness()
+	_, maxElev := m.Elevation.MinMax()
+	if maxElev <= 0 {
+		maxElev = 1
+	}
+
+	deposits := make(map[int][]*Deposit)
+	addDeposit := func(r int, def *ResourceDef) {
+		quantity := def.Quantity * (0.5 + m.Rand.Float64())
+		difficulty := 0.5*steepness[r] + 0.5*math.Max(0, m.Elevation.At(r)/maxElev)
+		deposits[r] = append(deposits[r], &Deposit{
+			Def:        def,
+			Region:     r,
+			Type:       def.Type,
+			Resource:   def.Flag,
+			Quantity:   quantity,
+			Initial:    quantity,
+			Quality:    m.Rand.Float64(),
+			Difficulty: math.Min(1, difficulty),
+			Discovered: def.Type != ResourceTypeMetal && def.Type != ResourceTypeGem,
+		})
+	}
+
+	// Built-in resources.
+	for _, def := range m.ResourceRegistry.Defs {
+		if !def.IsBuiltin() {
+			continue
+		}
+		var masks []byte
+		switch def.Type {
+		case ResourceTypeMetal:
+			masks = m.Metals
+		case ResourceTypeGem:
+			masks = m.Gems
+		case ResourceTypeStone:
+			masks = m.Stones
+		case ResourceTypeVarious:
+			masks = m.Various
+		case ResourceTypeWood:
+			masks = m.Wood
+		}
+		for r, mask := range masks {
+			if mask&def.Flag != 0 {
+				addDeposit(r, def)
+			}
+		}
+	}
+
+	// Custom resources.
+	for r := 0; r < m.SphereMesh.NumRegions; r++ {
+		for _, def := range m.Custom[r] {
+			addDeposit(r, def)
+		}
+	}
+	m.Deposits = deposits
+}
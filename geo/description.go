@@ -15,9 +15,9 @@ func (m *Geo) GenerateRegPropertyDescription(p RegProperty) string {
 	str += " is covered by " + genbiome.WhittakerModBiomeToString(p.Biome) + ".\n"
 
 	// Add info on the potential dangers of the region.
-	if p.DistanceToVolcano < 3 {
+	if p.DistanceToVolcano < DistanceNearby {
 		if p.Danger.Volcano > 0.2 {
-			if p.DistanceToVolcano < 1 {
+			if p.DistanceToVolcano == 0 {
 				str += " It's location on a volcano"
 			} else {
 				str += " The proximity to a volcano"
@@ -29,16 +29,16 @@ func (m *Geo) GenerateRegPropertyDescription(p RegProperty) string {
 			}
 			str += ". "
 		} else {
-			if p.DistanceToVolcano < 1 {
+			if p.DistanceToVolcano == 0 {
 				str += " It is located on a volcano"
 			} else {
 				str += " It is close to a volcano"
 			}
 			str += ". "
 		}
-	} else if p.DistanceToMountain < 3 {
+	} else if p.DistanceToMountain < DistanceNearby {
 		if p.Danger.RockSlide > 0.2 {
-			if p.DistanceToMountain < 1 {
+			if p.DistanceToMountain == 0 {
 				str += " The exposed location on a mountain"
 			} else {
 				str += " The proximity to a mountain"
@@ -50,9 +50,9 @@ func (m *Geo) GenerateRegPropertyDescription(p RegProperty) string {
 			}
 			str += ". "
 		}
-	} else if p.DistanceToFaultline < 3 {
+	} else if p.DistanceToFaultline < DistanceNearby {
 		if p.Danger.Earthquake > 0.2 {
-			if p.DistanceToFaultline < 1 {
+			if p.DistanceToFaultline == 0 {
 				str += " The exposed location on a faultline"
 			} else {
 				str += " The proximity to a faultline"
@@ -65,7 +65,7 @@ func (m *Geo) GenerateRegPropertyDescription(p RegProperty) string {
 			str += ". "
 		}
 	}
-	if p.DistanceToRiver < 1 {
+	if p.DistanceToRiver < DistanceAdjacent {
 		str += " The nearby river provides access to fresh water"
 		if p.Danger.Flood > 0.2 {
 			if p.Danger.Flood > 0.5 {
@@ -79,6 +79,32 @@ func (m *Geo) GenerateRegPropertyDescription(p RegProperty) string {
 		}
 		str += ". \n"
 	}
+	switch p.CoastType {
+	case CoastTypeCliff:
+		str += " Steep cliffs drop off into the sea.\n"
+	case CoastTypeBeach:
+		str += " The coast is lined with sandy beaches.\n"
+	case CoastTypeDelta:
+		str += " The river splits into countless channels as it enters the sea.\n"
+	case CoastTypeEstuary:
+		str += " The river widens into a sheltered estuary.\n"
+	case CoastTypeFjord:
+		str += " A deep fjord cuts into the land.\n"
+	case CoastTypeMangrove:
+		str += " Dense mangroves line the muddy shore.\n"
+	case CoastTypeReef:
+		str += " Coral reefs guard the coast.\n"
+	case CoastTypeMarsh:
+		str += " Salt marshes stretch along the shore.\n"
+	}
+	if p.Danger.Cyclone > 0.2 {
+		if p.Danger.Cyclone > 0.5 {
+			str += " Tropical cyclones regularly ravage the region during the storm season."
+		} else {
+			str += " Now and then, a tropical cyclone passes through the region."
+		}
+		str += "\n"
+	}
 	return str
 }
 
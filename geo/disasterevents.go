@@ -0,0 +1,355 @@
+package geo
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Footprints of disaster events.
+const (
+	earthquakeRuptureLength = 150.0 // Length (km) of the rupture along the fault line for a magnitude of 1.0
+	earthquakeMinRadius     = 40.0  // Radius (km) of the shaking around the rupture for a magnitude of 0.0
+	earthquakeRadius        = 220.0 // Additional radius (km) of the shaking for a magnitude of 1.0
+	volcanoMinRadius        = 40.0  // Radius (km) of the lava and pyroclastic flows for a magnitude of 0.0
+	volcanoRadius           = 70.0  // Additional radius (km) of the flows for a magnitude of 1.0
+	volcanoFlowFalloff      = 35.0  // Distance (km) at which the intensity of the flows is halved
+	volcanoMinPlumeLength   = 100.0 // Length (km) of the ash plume for a magnitude of 0.0
+	volcanoPlumeLength      = 430.0 // Additional length (km) of the ash plume for a magnitude of 1.0
+	volcanoPlumeDecay       = 0.005 // Fraction of the ash plume intensity lost per km
+)
+
+// DisasterEvent is a disaster that occurred at a specific location and affects
+// all regions within its spatial footprint.
+type DisasterEvent struct {
+	Disaster                   // Type of disaster
+	Origin     int             // Region where the disaster originated (epicenter, volcano, ...)
+	Magnitude  float64         // Relative magnitude (0.0-1.0)
+	Regions    map[int]float64 // Affected regions mapped to the local intensity (0.0-1.0)
+	TravelTime map[int]float64 // Arrival time (h) in the affected regions (if applicable, e.g. tsunamis)
+}
+
+// GetIntensity returns the intensity (0.0-1.0) of the disaster in the given
+// region (0 if the region is not affected).
+func (e *DisasterEvent) GetIntensity(r int) float64 {
+	return e.Regions[r]
+}
+
+// GetRegions returns all affected regions in ascending order.
+func (e *DisasterEvent) GetRegions() []int {
+	regs := make([]int, 0, len(e.Regions))
+	for r := range e.Regions {
+		regs = append(regs, r)
+	}
+	sort.Ints(regs)
+	return regs
+}
+
+// addRegion adds the region to the footprint, keeping the highest intensity
+// if the region is already affected.
+func (e *DisasterEvent) addRegion(r int, intensity float64) {
+	if intensity > e.Regions[r] {
+		e.Regions[r] = intensity
+	}
+}
+
+// newDisasterEvent returns a new disaster event of the given type.
+func newDisasterEvent(dis Disaster, origin int, magnitude float64) *DisasterEvent {
+	return &DisasterEvent{
+		Disaster:  dis,
+		Origin:    origin,
+		Magnitude: magnitude,
+		Regions:   make(map[int]float64),
+	}
+}
+
+// GetEarthquakeEvent returns an earthquake originating in the given region.
+//
+// The rupture follows the fault line (regions under compression) for a length
+// (km) that depends on the magnitude, and the shaking affects all regions
+// within a radius (km) around the rupture, decreasing with the distance.
+func (m *Geo) GetEarthquakeEvent(origin int, magnitude float64) *DisasterEvent {
+	ev := newDisasterEvent(DisEarthquake, origin, magnitude)
+
+	// Follow the fault line.
+	ruptureLength := earthquakeRuptureLength * magnitude
+	rupture := m.getRegionsWithinKm([]int{origin}, ruptureLength, func(r int) bool {
+		return m.RegionCompression[r] != 0
+	})
+	seeds := make([]int, 0, len(rupture))
+	for r := range rupture {
+		seeds = append(seeds, r)
+	}
+	sort.Ints(seeds)
+
+	// The shaking decreases with the distance from the rupture.
+	radius := earthquakeMinRadius + earthquakeRadius*magnitude
+	for r, d := range m.getRegionsWithinKm(seeds, radius, nil) {
+		ev.addRegion(r, (0.3+0.7*magnitude)*(1-d/radius))
+	}
+	return ev
+}
+
+// GetVolcanoEvent returns a volcanic eruption of the given volcano.
+//
+// Lava flows and pyroclastic flows devastate the immediate vicinity of the
+// volcano, while the ash plume is carried downwind (following the wind
+// vectors), widening and thinning out with the distance.
+func (m *Geo) GetVolcanoEvent(volcano int, magnitude float64) *DisasterEvent {
+	ev := newDisasterEvent(DisVolcano, volcano, magnitude)
+
+	// Lava and pyroclastic flows.
+	radius := volcanoMinRadius + volcanoRadius*magnitude
+	for r, d := range m.getRegionsWithinKm([]int{volcano}, radius, nil) {
+		ev.addRegion(r, 1/(1+d/volcanoFlowFalloff))
+	}
+
+	// Ash plume.
+	outRegs := make([]int, 0, 8)
+	plumeLength := volcanoMinPlumeLength + volcanoPlumeLength*magnitude
+	intensity := 0.5 + magnitude/2
+	cur := volcano
+	var dist float64
+	for dist < plumeLength && intensity > 0.05 {
+		next := m.GetClosestNeighbor(outRegs, cur, m.RegionToWindVec[cur])
+		if next < 0 || next == cur {
+			break
+		}
+		km := m.DistToKm(m.GetDistance(cur, next))
+		intensity *= math.Pow(1-volcanoPlumeDecay, km)
+		ev.addRegion(next, intensity)
+
+		// The plume widens as it travels downwind.
+		if dist >= plumeLength/3 {
+			for _, nb := range m.SphereMesh.R_circulate_r(outRegs, next) {
+				ev.addRegion(nb, intensity/2)
+			}
+		}
+		dist += km
+		cur = next
+	}
+	return ev
+}
+
+// GetFloodEvent returns a flood originating in the given (river) region.
+//
+// The flood wave propagates downstream along the river until it reaches the
+// sea or dissipates, and inundates the adjacent low-lying regions (the flood
+// plain) along the way.
+func (m *Geo) GetFloodEvent(origin int, magnitude float64) *DisasterEvent {
+	ev := newDisasterEvent(DisFlood, origin, magnitude)
+
+	// Regions that are at most this much higher than the river are flooded.
+	floodDepth := m.metersToElevation(2 + 8*magnitude)
+
+	outRegs := make([]int, 0, 8)
+	intensity := 0.5 + magnitude/2
+	seen := make(map[int]bool)
+	for cur := origin; cur >= 0 && !seen[cur] && m.Elevation.At(cur) > 0 && intensity > 0.05; cur = m.Downhill[cur] {
+		seen[cur] = true
+		ev.addRegion(cur, intensity)
+
+		// Flood plain.
+		for _, nb := range m.SphereMesh.R_circulate_r(outRegs, cur) {
+			if m.Elevation.At(nb) > 0 && m.Elevation.At(nb)-m.Elevation.At(cur) < floodDepth {
+				ev.addRegion(nb, intensity/2)
+			}
+		}
+
+		// The flood wave dissipates unless the river carries more water.
+		if !m.IsRegBigRiver(cur) {
+			intensity *= 0.8
+		} else {
+			intensity *= 0.95
+		}
+	}
+	return ev
+}
+
+// GetDisasterEventFunc returns a function that generates a random disaster
+// event (earthquake, volcanic eruption or flood) at a random location, where
+// the origin is chosen based on the chance of the disaster in each region
+// (see GetGeoDisasterFunc). The magnitude is random, with small disasters
+// being far more common than big ones.
+//
+// The function returns the disaster event followed by the events it triggered:
+// - Undersea earthquakes near subduction zones and eruptions of coastal
+// volcanoes might cause a tsunami.
+// - Earthquakes and heavy rain (floods) trigger landslides on steep slopes.
+//
+// NOTE: The function returns nil if no disaster is possible on the map.
+func (m *Geo) GetDisasterEventFunc() func(rnd *rand.Rand) []*DisasterEvent {
+	earthquakeChance := m.GetEarthquakeChance()
+	floodChance := m.GetFloodChance()
+	steepness := m.GetSteepness()
+	landslideChance := m.getLandslideChance(steepness)
+	distSubduction := m.AssignGeodesicDistanceField(m.getSubductionZones(), nil, nil).Distance
+
+	earthquakes := newRegionPicker(m.SphereMesh.NumRegions, func(r int) float64 {
+		if m.RegionCompression[r] == 0 {
+			return 0
+		}
+		return earthquakeChance[r]
+	})
+	floods := newRegionPicker(m.SphereMesh.NumRegions, func(r int) float64 {
+		if m.Elevation.At(r) <= 0 || !m.IsRegRiver(r) {
+			return 0
+		}
+		return floodChance[r]
+	})
+	volcanoes := newRegionPicker(m.SphereMesh.NumRegions, func(r int) float64 {
+		if !m.RegionIsVolcano.Has(r) {
+			return 0
+		}
+		return 1
+	})
+
+	type eventType struct {
+		Disaster
+		*regionPicker
+		gen func(r int, magnitude float64) *DisasterEvent
+	}
+	var types []eventType
+	for _, t := range []eventType{
+		{DisEarthquake, earthquakes, m.GetEarthquakeEvent},
+		{DisFlood, floods, m.GetFloodEvent},
+		{DisVolcano, volcanoes, m.GetVolcanoEvent},
+	} {
+		if t.total() > 0 {
+			types = append(types, t)
+		}
+	}
+
+	// getTsunamiOrigin returns the ocean region where the given event causes
+	// a tsunami (or -1 if it doesn't).
+	outRegs := make([]int, 0, 8)
+	getTsunamiOrigin := func(ev *DisasterEvent) int {
+		if ev.Magnitude < tsunamiMinMagnitude {
+			return -1
+		}
+		switch ev.Disaster {
+		case DisEarthquake:
+			// Megathrust earthquakes at subduction zones displace the sea floor.
+			if m.Elevation.At(ev.Origin) <= 0 && distSubduction[ev.Origin] <= tsunamiMaxSubduction {
+				return ev.Origin
+			}
+		case DisVolcano:
+			// Pyroclastic flows and flank collapses of coastal volcanoes.
+			for _, nb := range m.SphereMesh.R_circulate_r(outRegs, ev.Origin) {
+				if m.Elevation.At(nb) <= 0 {
+					return nb
+				}
+			}
+		}
+		return -1
+	}
+
+	return func(rnd *rand.Rand) []*DisasterEvent {
+		if len(types) == 0 {
+			return nil
+		}
+
+		// Pick the type of the disaster given their respective probabilities.
+		var sum float64
+		for _, t := range types {
+			sum += t.Probability
+		}
+		t := types[len(types)-1]
+		x := rnd.Float64() * sum
+		for _, tt := range types {
+			x -= tt.Probability
+			if x <= 0 {
+				t = tt
+				break
+			}
+		}
+
+		// Small disasters are more common than big ones.
+		magnitude := rnd.Float64()
+		ev := t.gen(t.pick(rnd), magnitude*magnitude)
+		events := []*DisasterEvent{ev}
+
+		// Add the triggered tsunamis and landslides.
+		if r := getTsunamiOrigin(ev); r >= 0 {
+			events = append(events, m.GetTsunamiEvent(r, ev.Magnitude))
+		}
+		if ev.Disaster == DisEarthquake || ev.Disaster == DisFlood {
+			events = append(events, m.getTriggeredLandslides(landslideChance, steepness, ev, rnd)...)
+		}
+		return events
+	}
+}
+
+// getRegionsWithinHops returns all regions within the given number of graph
+// hops from the seed regions, mapped to their distance (in hops).
+// If 'allowed' is not nil, only regions for which it returns true are visited
+// (the seed regions are always included).
+func (m *Geo) getRegionsWithinHops(seeds []int, maxHops int, allowed func(r int) bool) map[int]int {
+	dist := make(map[int]int)
+	queue := make([]int, 0, len(seeds))
+	for _, r := range seeds {
+		if _, ok := dist[r]; !ok {
+			dist[r] = 0
+			queue = append(queue, r)
+		}
+	}
+	outRegs := make([]int, 0, 8)
+	for len(queue) > 0 {
+		r := queue[0]
+		queue = queue[1:]
+		if dist[r] >= maxHops {
+			continue
+		}
+		for _, nb := range m.SphereMesh.R_circulate_r(outRegs, r) {
+			if _, ok := dist[nb]; ok {
+				continue
+			}
+			if allowed != nil && !allowed(nb) {
+				continue
+			}
+			dist[nb] = dist[r] + 1
+			queue = append(queue, nb)
+		}
+	}
+	return dist
+}
+
+// regionPicker picks random regions weighted by a fitness value.
+type regionPicker struct {
+	regs []int     // Regions with a weight > 0
+	cum  []float64 // Cumulative weights
+}
+
+// newRegionPicker returns a new picker for the given weight function.
+func newRegionPicker(numRegions int, weight func(r int) float64) *regionPicker {
+	p := &regionPicker{}
+	var sum float64
+	for r := 0; r < numRegions; r++ {
+		if w := weight(r); w > 0 {
+			sum += w
+			p.regs = append(p.regs, r)
+			p.cum = append(p.cum, sum)
+		}
+	}
+	return p
+}
+
+// total returns the sum of all weights.
+func (p *regionPicker) total() float64 {
+	if len(p.cum) == 0 {
+		return 0
+	}
+	return p.cum[len(p.cum)-1]
+}
+
+// pick returns a random region (or -1 if there are no regions).
+func (p *regionPicker) pick(rnd *rand.Rand) int {
+	if len(p.regs) == 0 {
+		return -1
+	}
+	i := sort.SearchFloat64s(p.cum, rnd.Float64()*p.total())
+	if i >= len(p.regs) {
+		i = len(p.regs) - 1
+	}
+	return p.regs[i]
+}
@@ -4,6 +4,8 @@ import (
 	"math"
 	"math/rand"
 	"sort"
+
+	"github.com/Flokey82/genworldvoronoi/various"
 )
 
 // Disaster represents a Disaster that can occur in a region.
@@ -40,6 +42,9 @@ var (
 	DisVolcano    = Disaster{"Volcanic Eruption", 0.4, 0.6}
 	DisPlague     = Disaster{"Plague", 0.2, 0.8}
 	DisSandstorm  = Disaster{"Sandstorm", 0.9, 0.1}
+	DisCyclone    = Disaster{"Tropical Cyclone", 0.8, 0.2}
+	DisTsunami    = Disaster{"Tsunami", 0.3, 0.5}
+	DisLandslide  = Disaster{"Landslide", 0.8, 0.15}
 )
 
 func RandDisaster(dis []Disaster) Disaster {
@@ -77,6 +82,7 @@ type GeoDisasterChance struct {
 	Flood      float64 // 0.0-1.0
 	Volcano    float64 // 0.0-1.0
 	RockSlide  float64 // 0.0-1.0
+	Cyclone    float64 // 0.0-1.0
 }
 
 func (c GeoDisasterChance) GetDisasters() []Disaster {
@@ -94,6 +100,9 @@ func (c GeoDisasterChance) GetDisasters() []Disaster {
 	if c.RockSlide > 0.0001 {
 		ds = append(ds, DisRockslide.adjustProbability(c.RockSlide))
 	}
+	if c.Cyclone > 0.0001 {
+		ds = append(ds, DisCyclone.adjustProbability(c.Cyclone))
+	}
 	return ds
 }
 
@@ -103,15 +112,17 @@ func (m *Geo) GetGeoDisasterFunc() func(int) GeoDisasterChance {
 	floodChance := m.GetFloodChance()
 	volcanoEruptionChance := m.GetVolcanoEruptionChance()
 	rockSlideAvalancheChance := m.GetRockSlideAvalancheChance()
+	cycloneChance := m.GetCycloneChance()
 	return func(reg int) GeoDisasterChance {
 		// Get the chance of a disaster in this region.
 		// NOTE: This is a very simple way of combining the chances.
-		// TODO: Add chance of tropical storms, wildfires, etc.
+		// TODO: Add chance of wildfires, etc.
 		return GeoDisasterChance{
 			Earthquake: earthquakeChance[reg],
 			Flood:      floodChance[reg],
 			Volcano:    volcanoEruptionChance[reg],
 			RockSlide:  rockSlideAvalancheChance[reg],
+			Cyclone:    cycloneChance[reg],
 		}
 	}
 }
@@ -131,13 +142,13 @@ func (m *Geo) GetEarthquakeChance() []float64 {
 func (m *Geo) GetFloodChance() []float64 {
 	// Now get the chance of flood for each region.
 	floodChance := make([]float64, m.SphereMesh.NumRegions)
-	_, maxFlux := minMax(m.Flux)
+	_, maxFlux := m.Flux.MinMax()
 	steepness := m.GetSteepness()
 	for r := 0; r < m.SphereMesh.NumRegions; r++ {
 		// We use the flux of water and the steepness in the region
 		// to determine the chance of a flood.
 		// NOTE: This should also apply to lakes.
-		floodChance[r] = (1 - steepness[r]) * m.Flux[r] / maxFlux
+		floodChance[r] = (1 - steepness[r]) * m.Flux.At(r) / maxFlux
 	}
 
 	// Normalize the flood chance.
@@ -156,7 +167,7 @@ func (m *Geo) GetRockSlideAvalancheChance() []float64 {
 	return m.getDownhillDisaster(m.RegionIsMountain, 0.1)
 }
 
-func (m *Geo) getDownhillDisaster(origins map[int]bool, steepnessLimit float64) []float64 {
+func (m *Geo) getDownhillDisaster(origins various.Bitset, steepnessLimit float64) []float64 {
 	steepness := m.GetSteepness()
 	downhill := m.GetDownhill(true)
 
@@ -164,14 +175,14 @@ func (m *Geo) getDownhillDisaster(origins map[int]bool, steepnessLimit float64)
 	// flat or we reach the ocean.
 	chance := make([]float64, m.SphereMesh.NumRegions)
 	for r := 0; r < m.SphereMesh.NumRegions; r++ {
-		if !origins[r] {
+		if !origins.Has(r) {
 			continue
 		}
 
 		// Go downhill until the steepness is too low or we reach the ocean.
 		rdh := r
 		danger := 1.0
-		for rdh != -1 && steepness[rdh] > steepnessLimit && m.Elevation[rdh] > 0 {
+		for rdh != -1 && steepness[rdh] > steepnessLimit && m.Elevation.At(rdh) > 0 {
 			// Add the danger of the region to the chance of being affected by a
 			// downhill disaster.
 			chance[rdh] += danger
@@ -188,15 +199,15 @@ func (m *Geo) getDisasterFunc() func(r int) []Disaster {
 
 	// distRegion := math.Sqrt(4 * math.Pi / float64(m.mesh.NumRegions))
 	// biomeFunc := m.getRegWhittakerModBiomeFunc()
-	_, maxElev := minMax(m.Elevation)
+	_, maxElev := m.Elevation.MinMax()
 	var volcanoes, mountains, faultlines []int
 	isBigRiver := make(map[int]bool)
 	isFireDanger := make(map[int]bool)
 	for r := 0; r < m.SphereMesh.NumRegions; r++ {
-		if m.RegionIsMountain[r] {
+		if m.RegionIsMountain.Has(r) {
 			mountains = append(mountains, r)
 		}
-		if m.RegionIsVolcano[r] {
+		if m.RegionIsVolcano.Has(r) {
 			volcanoes = append(volcanoes, r)
 		}
 		if m.RegionCompression[r] > 0 {
@@ -208,17 +219,16 @@ func (m *Geo) getDisasterFunc() func(r int) []Disaster {
 		// Determine if there is danger of fire by checking if the region is
 		// hot and relatively dry while still having vegetation.
 		temp := m.GetRegTemperature(r, maxElev)
-		if temp > 25 && m.Moisture[r] < 0.2 {
+		if temp > 25 && m.Moisture.At(r) < 0.2 {
 			isFireDanger[r] = true
 		}
 	}
 
-	// Get distance field from volanoes.
-	distVolcanoes := m.AssignDistanceField(mountains, make(map[int]bool))
-	// Get distance field from fault lines.
-	distMountains := m.AssignDistanceField(mountains, make(map[int]bool))
-	// Get distance field from mountains.
-	distFaultlines := m.AssignDistanceField(faultlines, make(map[int]bool))
+	// Get distance fields from volcanoes, mountains and fault lines.
+	dists := m.AssignDistanceFields([][]int{volcanoes, mountains, faultlines}, nil)
+	distVolcanoes := dists[0]
+	distMountains := dists[1]
+	distFaultlines := dists[2]
 
 	// TODO: Instead, introduce a new property of disasters that determines
 	// how likely they are to occur. Then, we can take in account how far
@@ -0,0 +1,167 @@
+package geo
+
+import (
+	"container/heap"
+	"math"
+)
+
+// DistanceField holds the (weighted) geodesic distance of all regions to
+// the nearest seed region and the seed region each region is closest to.
+type DistanceField struct {
+	Distance []float64 // Distance to the nearest seed region in km (+Inf if unreachable)
+	Label    []int     // Nearest seed region (-1 if unreachable)
+}
+
+// EdgeCostFunc returns the cost of traversing the edge from region 'u' to
+// the neighbor region 'v' for the seed region 'o', given the length 'dist'
+// of the edge in km. A negative cost means that the edge is impassable.
+type EdgeCostFunc func(o, u, v int, dist float64) float64
+
+// AssignGeodesicDistanceField calculates the geodesic distance (in km) from
+// the closest region in seedRegs to all other regions using Dijkstra's
+// algorithm, without expanding into any region in stopReg.
+//
+// Unlike AssignDistanceField, which counts graph hops, the result does not
+// depend on the resolution of the mesh and the random number generator is
+// left untouched.
+//
+// If 'cost' is nil, the cost of an edge is its length.
+func (m *Geo) AssignGeodesicDistanceField(seedRegs []int, stopReg map[int]bool, cost EdgeCostFunc) *DistanceField {
+	return m.AssignGeodesicDistanceFieldFunc(seedRegs, func(r int) bool {
+		return stopReg[r]
+	}, cost)
+}
+
+// AssignGeodesicDistanceFieldFunc is the same as AssignGeodesicDistanceField,
+// but the stop regions are given as a function (e.g. various.Bitset.Has), so
+// we don't have to build a map.
+func (m *Geo) AssignGeodesicDistanceFieldFunc(seedRegs []int, isStop func(r int) bool, cost EdgeCostFunc) *DistanceField {
+	inf := math.Inf(0)
+	df := &DistanceField{
+		Distance: make([]float64, m.SphereMesh.NumRegions),
+		Label:    initRegionSlice(m.SphereMesh.NumRegions),
+	}
+	for r := range df.Distance {
+		df.Distance[r] = inf
+	}
+	m.updateGeodesicDistanceField(df, seedRegs, isStop, cost)
+	return df
+}
+
+// UpdateGeodesicDistanceField updates the given distance field with the new
+// seed regions (see AssignGeodesicDistanceField).
+//
+// NOTE: Removed seed regions are not taken into account. If a seed region
+// has disappeared, the distance field needs to be re-calculated.
+func (m *Geo) UpdateGeodesicDistanceField(df *DistanceField, seedRegs []int, stopReg map[int]bool, cost EdgeCostFunc) *DistanceField {
+	m.updateGeodesicDistanceField(df, seedRegs, func(r int) bool {
+		return stopReg[r]
+	}, cost)
+	return df
+}
+
+func (m *Geo) updateGeodesicDistanceField(df *DistanceField, seedRegs []int, isStop func(r int) bool, cost EdgeCostFunc) {
+	var queue AscPriorityQueue
+	heap.Init(&queue)
+	for _, r := range seedRegs {
+		// Skip seed regions that are already part of the distance field.
+		if df.Distance[r] == 0 && df.Label[r] == r {
+			continue
+		}
+		df.Distance[r] = 0
+		df.Label[r] = r
+		heap.Push(&queue, &QueueEntry{
+			Score:       0,
+			Origin:      r,
+			Destination: r,
+		})
+	}
+
+	// Allocate a slice for the output of mesh.R_circulate_r.
+	outRegs := make([]int, 0, 8)
+	for queue.Len() > 0 {
+		u := heap.Pop(&queue).(*QueueEntry)
+
+		// Skip stale entries that have been superseded by a shorter path.
+		if u.Score > df.Distance[u.Destination] || df.Label[u.Destination] != u.Origin {
+			continue
+		}
+		for _, v := range m.SphereMesh.R_circulate_r(outRegs, u.Destination) {
+			if isStop(v) {
+				continue
+			}
+			edgeCost := m.DistToKm(m.GetDistance(u.Destination, v))
+			if cost != nil {
+				edgeCost = cost(u.Origin, u.Destination, v, edgeCost)
+				if edgeCost < 0 {
+					continue
+				}
+			}
+			newDist := u.Score + edgeCost
+			if newDist >= df.Distance[v] {
+				continue
+			}
+			df.Distance[v] = newDist
+			df.Label[v] = u.Origin
+			heap.Push(&queue, &QueueEntry{
+				Score:       newDist,
+				Origin:      u.Origin,
+				Destination: v,
+			})
+		}
+	}
+}
+
+// getRegionsWithinKm returns all regions within the given geodesic distance
+// (km) from the seed regions, mapped to their distance (km).
+//
+// This is the same search as AssignGeodesicDistanceField, but it stops at
+// the given distance, so it only visits the regions close to the seeds.
+// If 'allowed' is not nil, only regions for which it returns true are visited
+// (the seed regions are always included).
+func (m *Geo) getRegionsWithinKm(seeds []int, maxKm float64, allowed func(r int) bool) map[int]float64 {
+	dist := make(map[int]float64)
+	var queue AscPriorityQueue
+	heap.Init(&queue)
+	for _, r := range seeds {
+		if _, ok := dist[r]; ok {
+			continue
+		}
+		dist[r] = 0
+		heap.Push(&queue, &QueueEntry{
+			Score:       0,
+			Origin:      r,
+			Destination: r,
+		})
+	}
+
+	// Allocate a slice for the output of mesh.R_circulate_r.
+	outRegs := make([]int, 0, 8)
+	for queue.Len() > 0 {
+		u := heap.Pop(&queue).(*QueueEntry)
+
+		// Skip stale entries that have been superseded by a shorter path.
+		if u.Score > dist[u.Destination] {
+			continue
+		}
+		for _, v := range m.SphereMesh.R_circulate_r(outRegs, u.Destination) {
+			if allowed != nil && !allowed(v) {
+				continue
+			}
+			newDist := u.Score + m.DistToKm(m.GetDistance(u.Destination, v))
+			if newDist > maxKm {
+				continue
+			}
+			if d, ok := dist[v]; ok && newDist >= d {
+				continue
+			}
+			dist[v] = newDist
+			heap.Push(&queue, &QueueEntry{
+				Score:       newDist,
+				Origin:      u.Origin,
+				Destination: v,
+			})
+		}
+	}
+	return dist
+}
@@ -0,0 +1,116 @@
+package geo
+
+import (
+	"math"
+
+	"github.com/Flokey82/genbiome"
+)
+
+// Relative energy value of the energy resources.
+const (
+	energyValueCoal       = 0.6
+	energyValueOil        = 1.0
+	energyValueGas        = 0.8
+	energyValueGeothermal = 0.5
+)
+
+// Energy resource distances (km).
+const (
+	energyGeothermalVolcanoKm    = 115.0 // Geothermal sites around volcanoes
+	energyGeothermalSubductionKm = 80.0  // Geothermal sites above subduction zones
+	energyGeothermalFalloffKm    = 40.0  // Distance at which the geothermal value is halved
+	energyCoastalBasinKm         = 380.0 // Oil and gas in sedimentary basins close to the coast
+)
+
+// placeEnergy places coal, oil, natural gas and geothermal sites and
+// calculates the energy resource layer (0.0-1.0) of all regions.
+//
+// - Coal forms from the buried swamps and forests of sedimentary basins.
+// - Oil and gas form from marine sediments, so we find them on continental
+// shelves and in sedimentary basins close to the (ancient) coast.
+// - Gas also forms in deeply buried coal beds.
+// - Geothermal energy is available near volcanoes and subduction zones.
+//
+// NOTE: Since we don't have any information about the past, we use the
+// current biomes and a noise function to approximate the ancient environment.
+func (m *Geo) placeEnergy(g *geology) {
+	biomeFunc := m.GetRegWhittakerModBiomeFunc()
+	steepness := m.GetSteepness()
+
+	// The noise functions approximate the ancient swamps/forests and the
+	// (marine) source rocks of the basins.
+	fnSwamp := m.fbmNoiseCustom(2, 1, 4, 4, 4, 0, 0, 0)
+	fnSource := m.fbmNoiseCustom(2, 1, 3, 3, 3, 5, 5, 5)
+
+	// Calculate the distance (km) to the ocean so we know which basins were close
+	// to the coast (and flooded by shallow seas).
+	var oceanRegs []int
+	for r := 0; r < m.SphereMesh.NumRegions; r++ {
+		if m.Elevation.At(r) <= 0 {
+			oceanRegs = append(oceanRegs, r)
+		}
+	}
+	distOcean := m.AssignGeodesicDistanceField(oceanRegs, nil, nil).Distance
+
+	energy := make([]float64, m.SphereMesh.NumRegions)
+	for r := 0; r < m.SphereMesh.NumRegions; r++ {
+		// Reset the energy flags.
+		m.Various[r] &^= ResVarCoal | ResVarOil | ResVarGas | ResVarGeothermal
+
+		elev := m.Elevation.At(r)
+		isSedimentary := m.RegionToLithology[r] == LithologySedimentary
+		isShelf := elev <= 0 && elev > -0.1
+		var val float64
+
+		// Coal.
+		if isSedimentary && elev > 0 && steepness[r] < 0.3 {
+			swamp := fnSwamp(r)
+			switch biomeFunc(r) {
+			case genbiome.WhittakerModBiomeHotSwamp,
+				genbiome.WhittakerModBiomeWetlands,
+				genbiome.WhittakerModBiomeTemperateRainforest,
+				genbiome.WhittakerModBiomeTropicalRainforest:
+				swamp += 0.2 // The climate was probably wet for a long time.
+			}
+			if swamp > 0.3 {
+				m.Various[r] |= ResVarCoal
+				val += energyValueCoal * math.Min(1, swamp)
+
+				// Deeply buried coal beds also produce gas.
+				if swamp > 0.6 {
+					m.Various[r] |= ResVarGas
+					val += energyValueGas / 2
+				}
+			}
+		}
+
+		// Oil and gas.
+		if isSedimentary && (isShelf || (elev > 0 && distOcean[r] <= energyCoastalBasinKm)) {
+			source := fnSource(r)
+			if source > 0.3 {
+				m.Various[r] |= ResVarOil
+				val += energyValueOil * math.Min(1, source)
+			}
+			if source > 0.5 || source < -0.5 {
+				// The deeper (hotter) source rocks produce gas.
+				m.Various[r] |= ResVarGas
+				val += energyValueGas * math.Min(1, math.Abs(source))
+			}
+		}
+
+		// Geothermal energy.
+		if elev > 0 && (g.distVolcano[r] <= energyGeothermalVolcanoKm || g.distSubduction[r] <= energyGeothermalSubductionKm) {
+			m.Various[r] |= ResVarGeothermal
+			val += energyValueGeothermal / (1 + math.Min(g.distVolcano[r], g.distSubduction[r])/energyGeothermalFalloffKm)
+		}
+		energy[r] = val
+	}
+
+	// Normalize the energy layer.
+	if _, maxEnergy := minMax(energy); maxEnergy > 0 {
+		for r := range energy {
+			energy[r] /= maxEnergy
+		}
+	}
+	m.Energy = energy
+}
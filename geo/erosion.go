@@ -27,7 +27,7 @@ func (m *Geo) Erode(amount float64) []float64 {
 			// negative height diff to fill the sinks during the erosion steps?
 			continue
 		}
-		dhDiff[r] = m.Elevation[r] - m.Elevation[dhr]
+		dhDiff[r] = m.Elevation.At(r) - m.Elevation.At(dhr)
 	}
 
 	// This will hold our new heightmap.
@@ -51,7 +51,7 @@ func (m *Geo) Erode(amount float64) []float64 {
 	// Calculate the new heightmap by applying the erosion rates we have calculated.
 	for r, e := range er {
 		// We can at most erode amount*dhDiff[r].
-		newh[r] = m.Elevation[r] - amount*dhDiff[r]*(e/maxr)
+		newh[r] = m.Elevation.At(r) - amount*dhDiff[r]*(e/maxr)
 	}
 	return newh
 }
@@ -84,7 +84,7 @@ func (m *Geo) GetErosionRate() []float64 {
 	newh := make([]float64, m.SphereMesh.NumRegions)
 
 	// Get the max height value so we can normalize the elevation values.
-	_, maxH := minMax(m.Elevation) // TODO: Cache somewhere?
+	_, maxH := m.Elevation.MinMax() // TODO: Cache somewhere?
 
 	// erodeRegion sets the erosion rate for the given region and
 	// traverses the neighbor graph up to the remaining depth (rem).
@@ -92,7 +92,7 @@ func (m *Geo) GetErosionRate() []float64 {
 
 	erodeRegion = func(out_r []int, r, rem int, toErode float64) {
 		// If we have erosion below sea level, skip this region.
-		if erodeOnlyAboveSealevel && m.Elevation[r] < 0 {
+		if erodeOnlyAboveSealevel && m.Elevation.At(r) < 0 {
 			return
 		}
 
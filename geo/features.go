@@ -0,0 +1,310 @@
+package geo
+
+import (
+	"math"
+	"sort"
+
+	"github.com/Flokey82/genbiome"
+	"github.com/Flokey82/genworldvoronoi/various"
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// Feature extraction parameters.
+//
+// NOTE: Since the regions are not uniform in size (see makeAdaptiveMesh), the
+// sizes are areas instead of region counts.
+const (
+	featureMinMountainRange = 4000.0  // Minimum area of a mountain range in km²
+	featureMountainGap      = 2       // Maximum gap (graph hops) between the mountains of a range
+	featureMinSea           = 12500.0 // Minimum area of a sea in km²
+	featureMinOcean         = 0.04    // Waterbodies above this fraction of the surface are oceans
+	featureEnclosureRadius  = 3       // Radius (graph hops) used to calculate the enclosure of the water
+	featureMinEnclosure     = 0.55    // Minimum fraction of land around a bay or gulf
+	featureMaxBay           = 25000.0 // Embayments above this area in km² are gulfs
+	featureMinEmbayment     = 2500.0  // Minimum area of a bay or gulf in km²
+	featureMinContinent     = 0.02    // Landmasses above this fraction of the surface are continents
+	featureMinIsland        = 4000.0  // Minimum area of an island in km²
+	featureMinBiomeRegion   = 12500.0 // Minimum area of a named desert or forest in km²
+	featureMinSwamp         = 6000.0  // Minimum area of a named swamp in km²
+)
+
+// Feature is a geographic feature like a mountain range, a sea or an island.
+type Feature struct {
+	ID       int        // Index in Geo.Features
+	Type     string     // Feature type (FeatureTypeMountainRange, ...)
+	Name     string     // Name of the feature (empty until named)
+	Regions  []int      // Regions that make up the feature
+	Center   int        // Region of the feature closest to the centroid
+	Centroid [2]float64 // Lat/lon of the centroid
+}
+
+// String returns the name of the feature or its type if it has no name.
+func (f *Feature) String() string {
+	if f.Name == "" {
+		return f.Type
+	}
+	return f.Name
+}
+
+// GetFeaturesAt returns all features that contain the given region.
+func (m *Geo) GetFeaturesAt(r int) []*Feature {
+	var features []*Feature
+	for _, f := range m.Features {
+		for _, fr := range f.Regions {
+			if fr == r {
+				features = append(features, f)
+				break
+			}
+		}
+	}
+	return features
+}
+
+// GetFeaturesOfType returns all features of the given type.
+func (m *Geo) GetFeaturesOfType(t string) []*Feature {
+	var features []*Feature
+	for _, f := range m.Features {
+		if f.Type == t {
+			features = append(features, f)
+		}
+	}
+	return features
+}
+
+// assignFeatures identifies the geographic features of the map.
+//
+// - Mountains are clustered into mountain ranges.
+// - Small waterbodies are seas, large ones oceans. Enclosed coastal waters
+// are bays or gulfs (depending on their size), and narrow passages between
+// landmasses are straits.
+// - Connected desert, forest and swamp biome regions are deserts, forests and
+// swamps.
+// - Large landmasses are continents, small ones islands.
+//
+// NOTE: Features can overlap, for example a bay is also part of an ocean.
+func (m *Geo) assignFeatures() {
+	areas := m.GetRegAreas()
+	surface := 4 * math.Pi
+
+	// getArea returns the area of the given regions in km².
+	getArea := func(regs []int) float64 {
+		var area float64
+		for _, r := range regs {
+			area += areas[r]
+		}
+		return m.AreaToKm2(area)
+	}
+
+	var features []*Feature
+	addFeature := func(t string, regs []int) {
+		f := &Feature{
+			ID:      len(features),
+			Type:    t,
+			Regions: regs,
+		}
+		m.assignFeatureCentroid(f)
+		features = append(features, f)
+	}
+
+	// Mountain ranges.
+	var mountains []int
+	for r := 0; r < m.SphereMesh.NumRegions; r++ {
+		if m.RegionIsMountain.Has(r) {
+			mountains = append(mountains, r)
+		}
+	}
+	for _, regs := range m.getRegionClusters(mountains, featureMountainGap) {
+		if getArea(regs) >= featureMinMountainRange {
+			addFeature(FeatureTypeMountainRange, regs)
+		}
+	}
+
+	// Oceans and seas.
+	waterbodies := make(map[int][]int)
+	var wbIDs []int
+	for r, wb := range m.Waterbodies {
+		if wb < 0 || m.Elevation.At(r) > 0 {
+			continue
+		}
+		if _, ok := waterbodies[wb]; !ok {
+			wbIDs = append(wbIDs, wb)
+		}
+		waterbodies[wb] = append(waterbodies[wb], r)
+	}
+	// NOTE: This uses the same threshold as GetRegionFeatureTypeFunc.
+	for _, wb := range wbIDs {
+		regs := waterbodies[wb]
+		if area := getArea(regs); area > m.AreaToKm2(featureMinOcean*surface) {
+			addFeature(FeatureTypeOcean, regs)
+		} else if area >= featureMinSea {
+			addFeature(FeatureTypeSea, regs)
+		}
+	}
+
+	// Bays and gulfs are enclosed by land, straits connect two seas between
+	// two landmasses.
+	var embayments, straits []int
+	outRegs := make([]int, 0, 8)
+	for r := 0; r < m.SphereMesh.NumRegions; r++ {
+		if m.Elevation.At(r) > 0 {
+			continue
+		}
+		var isCoastal bool
+		landmasses := make(map[int]bool)
+		for _, nb := range m.SphereMesh.R_circulate_r(outRegs, r) {
+			if m.Elevation.At(nb) > 0 {
+				isCoastal = true
+				landmasses[m.Landmasses[nb]] = true
+			}
+		}
+		if !isCoastal {
+			continue
+		}
+		if len(landmasses) > 1 {
+			straits = append(straits, r)
+		}
+		var numLand int
+		nearby := m.getRegionsWithinHops([]int{r}, featureEnclosureRadius, nil)
+		for nb := range nearby {
+			if m.Elevation.At(nb) > 0 {
+				numLand++
+			}
+		}
+		if float64(numLand)/float64(len(nearby)) >= featureMinEnclosure {
+			embayments = append(embayments, r)
+		}
+	}
+	for _, regs := range m.getRegionClusters(embayments, 1) {
+		if area := getArea(regs); area > featureMaxBay {
+			addFeature(FeatureTypeGulf, regs)
+		} else if area >= featureMinEmbayment {
+			addFeature(FeatureTypeBay, regs)
+		}
+	}
+	for _, regs := range m.getRegionClusters(straits, 1) {
+		addFeature(FeatureTypeStrait, regs)
+	}
+
+	// Deserts, forests and swamps.
+	biomeFunc := m.GetRegWhittakerModBiomeFunc()
+	biomeRegions := make(map[int][]int)
+	var brIDs []int
+	for r, br := range m.BiomeRegions {
+		if br < 0 {
+			continue
+		}
+		if _, ok := biomeRegions[br]; !ok {
+			brIDs = append(brIDs, br)
+		}
+		biomeRegions[br] = append(biomeRegions[br], r)
+	}
+	for _, br := range brIDs {
+		regs := biomeRegions[br]
+		switch biomeFunc(br) {
+		case genbiome.WhittakerModBiomeSubtropicalDesert,
+			genbiome.WhittakerModBiomeColdDesert:
+			if getArea(regs) >= featureMinBiomeRegion {
+				addFeature(FeatureTypeDesert, regs)
+			}
+		case genbiome.WhittakerModBiomeBorealForestTaiga,
+			genbiome.WhittakerModBiomeTemperateRainforest,
+			genbiome.WhittakerModBiomeTemperateSeasonalForest,
+			genbiome.WhittakerModBiomeTropicalRainforest,
+			genbiome.WhittakerModBiomeTropicalSeasonalForest:
+			if getArea(regs) >= featureMinBiomeRegion {
+				addFeature(FeatureTypeForest, regs)
+			}
+		case genbiome.WhittakerModBiomeHotSwamp,
+			genbiome.WhittakerModBiomeWetlands:
+			if getArea(regs) >= featureMinSwamp {
+				addFeature(FeatureTypeSwamp, regs)
+			}
+		}
+	}
+
+	// Continents and islands.
+	landmasses := make(map[int][]int)
+	var lmIDs []int
+	for r, lm := range m.Landmasses {
+		if lm < 0 {
+			continue
+		}
+		if _, ok := landmasses[lm]; !ok {
+			lmIDs = append(lmIDs, lm)
+		}
+		landmasses[lm] = append(landmasses[lm], r)
+	}
+	for _, lm := range lmIDs {
+		regs := landmasses[lm]
+		if area := getArea(regs); area >= m.AreaToKm2(featureMinContinent*surface) {
+			addFeature(FeatureTypeContinent, regs)
+		} else if area >= featureMinIsland {
+			addFeature(FeatureTypeIsle, regs)
+		}
+	}
+	m.Features = features
+}
+
+// assignFeatureCentroid calculates the centroid of the feature and the region
+// of the feature closest to it.
+func (m *Geo) assignFeatureCentroid(f *Feature) {
+	var sum vectors.Vec3
+	for _, r := range f.Regions {
+		sum = vectors.Add3(sum, various.ConvToVec3(m.XYZ[3*r:3*r+3]))
+	}
+	centroid := sum.Normalize()
+	lat, lon := various.LatLonFromVec3(centroid, 1.0)
+	f.Centroid = [2]float64{lat, lon}
+
+	// The region with the largest dot product is the closest to the centroid.
+	bestDot := math.Inf(-1)
+	for _, r := range f.Regions {
+		if dot := vectors.Dot3(centroid, various.ConvToVec3(m.XYZ[3*r:3*r+3])); dot > bestDot {
+			bestDot = dot
+			f.Center = r
+		}
+	}
+}
+
+// getRegionClusters groups the given regions into clusters of regions that
+// are at most 'maxGap' graph hops apart.
+func (m *Geo) getRegionClusters(regs []int, maxGap int) [][]int {
+	isMember := make(map[int]bool)
+	for _, r := range regs {
+		isMember[r] = true
+	}
+	seen := make(map[int]bool)
+	var clusters [][]int
+	for _, r := range regs {
+		if seen[r] {
+			continue
+		}
+		seen[r] = true
+		cluster := []int{r}
+		for i := 0; i < len(cluster); i++ {
+			for _, nb := range m.getRegionClusterNeighbors(cluster[i], maxGap) {
+				if isMember[nb] && !seen[nb] {
+					seen[nb] = true
+					cluster = append(cluster, nb)
+				}
+			}
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters
+}
+
+// getRegionClusterNeighbors returns the regions within 'maxGap' graph hops of
+// the given region in ascending order.
+func (m *Geo) getRegionClusterNeighbors(r, maxGap int) []int {
+	if maxGap <= 1 {
+		return m.SphereMesh.R_circulate_r(nil, r)
+	}
+	nearby := m.getRegionsWithinHops([]int{r}, maxGap, nil)
+	regs := make([]int, 0, len(nearby))
+	for nb := range nearby {
+		regs = append(regs, nb)
+	}
+	sort.Ints(regs)
+	return regs
+}
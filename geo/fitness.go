@@ -2,8 +2,6 @@ package geo
 
 import (
 	"math"
-
-	"github.com/Flokey82/genworldvoronoi/various"
 )
 
 type fitCache struct {
@@ -50,14 +48,14 @@ func (m *Civ) getFitCaches() *fitCaches {
 // terrain close to water.
 func (m *Geo) GetFitnessProximityToWater() func(int) float64 {
 	var seedWater []int
-	for r := range m.Elevation {
+	for r := 0; r < m.Elevation.Len(); r++ {
 		if m.IsRegLakeOrWaterBody(r) || m.IsRegBigRiver(r) {
 			seedWater = append(seedWater, r)
 		}
 	}
 
 	// Make sure we normalize the distance field so that the highest value is 1.
-	distWater := m.AssignDistanceField(seedWater, m.RegionIsMountain)
+	distWater := m.AssignDistanceFieldFunc(seedWater, m.RegionIsMountain.Has)
 	_, maxDist := minMax(distWater)
 	return func(r int) float64 {
 		if m.IsRegLakeOrWaterBody(r) || distWater[r] < 0 {
@@ -77,10 +75,10 @@ func (m *Geo) GetFitnessSteepMountains() func(int) float64 {
 	seedMountains := m.Mountain_r
 	distMountains := m.AssignDistanceField(seedMountains, make(map[int]bool))
 	return func(r int) float64 {
-		if m.Elevation[r] <= 0 {
+		if m.Elevation.At(r) <= 0 {
 			return -1.0
 		}
-		chance := steepness[r] * math.Sqrt(m.Elevation[r])
+		chance := steepness[r] * math.Sqrt(m.Elevation.At(r))
 		chance /= (distMountains[r] + 1) / 2
 		return chance
 	}
@@ -102,7 +100,7 @@ func (m *Geo) GetFitnessInlandValleys() func(int) float64 {
 	seedAll = append(seedAll, seedOceans...)
 	distAll := m.AssignDistanceField(seedAll, make(map[int]bool))
 	return func(r int) float64 {
-		if m.Elevation[r] <= 0 {
+		if m.Elevation.At(r) <= 0 {
 			return -1.0
 		}
 		chance := 1 - steepness[r]
@@ -115,21 +113,21 @@ func (m *Geo) GetFitnessArableLand() func(int) float64 {
 	// Prefer flat terrain with reasonable precipitation and at
 	// lower altitudes.
 	steepness := m.GetSteepness()
-	_, maxElev := minMax(m.Elevation)
-	_, maxRain := minMax(m.Rainfall)
-	_, maxFlux := minMax(m.Flux)
+	_, maxElev := m.Elevation.MinMax()
+	_, maxRain := m.Rainfall.MinMax()
+	_, maxFlux := m.Flux.MinMax()
 	return func(r int) float64 {
 		temp := m.GetRegTemperature(r, maxElev)
-		if m.Elevation[r] <= 0 {
+		if m.Elevation.At(r) <= 0 {
 			return -1.0
 		}
-		irrigation := math.Max(m.Rainfall[r]/maxRain, m.Flux[r]/maxFlux)
+		irrigation := math.Max(m.Rainfall.At(r)/maxRain, m.Flux.At(r)/maxFlux)
 		if irrigation < 0.1 || temp <= 0 {
 			return 0
 		}
 		chance := 1 - steepness[r]
 		chance *= irrigation
-		chance *= 1 - (m.Elevation[r]/maxElev)*(m.Elevation[r]/maxElev)
+		chance *= 1 - (m.Elevation.At(r)/maxElev)*(m.Elevation.At(r)/maxElev)
 		return chance
 	}
 }
@@ -137,9 +135,9 @@ func (m *Geo) GetFitnessArableLand() func(int) float64 {
 // GetFitnessClimate returns a fitness function that returns high
 // scores for regions with high rainfall high temperatures, and alternatively high flux.
 func (m *Geo) GetFitnessClimate() func(int) float64 {
-	_, maxRain := minMax(m.Rainfall)
-	_, maxElev := minMax(m.Elevation)
-	_, maxFlux := minMax(m.Flux)
+	_, maxRain := m.Rainfall.MinMax()
+	_, maxElev := m.Elevation.MinMax()
+	_, maxFlux := m.Flux.MinMax()
 
 	return func(r int) float64 {
 		temp := m.GetRegTemperature(r, maxElev)
@@ -147,8 +145,8 @@ func (m *Geo) GetFitnessClimate() func(int) float64 {
 			return 0.1
 		}
 		scoreTemp := math.Sqrt(temp / MaxTemp)
-		scoreRain := m.Rainfall[r] / maxRain
-		scoreFlux := math.Sqrt(m.Flux[r] / maxFlux)
+		scoreRain := m.Rainfall.At(r) / maxRain
+		scoreFlux := math.Sqrt(m.Flux.At(r) / maxFlux)
 		return 0.1 + 0.9*(scoreTemp*(scoreFlux+scoreRain)/2)
 	}
 }
@@ -160,8 +158,9 @@ func (m *Geo) GetFitnessClimate() func(int) float64 {
 // - 'distSeedFunc' returns a number of regions from which we maximize the distance when
 // calculating the fitness score.
 func (m *Geo) CalcFitnessScore(sf func(int) float64, distSeedFunc func() []int) []float64 {
-	// Get distance to other seed regions returned by the distSeedFunc.
-	return m.CalcFitnessScoreWithDistanceField(sf, m.AssignDistanceField(distSeedFunc(), make(map[int]bool)))
+	// Get the geodesic distance to other seed regions returned by the distSeedFunc.
+	df := m.AssignGeodesicDistanceField(distSeedFunc(), nil, nil)
+	return m.CalcFitnessScoreWithDistanceField(sf, df.Distance)
 }
 
 func (m *Geo) CalcFitnessScoreWithDistanceField(sf func(int) float64, regDistanceC []float64) []float64 {
@@ -198,7 +197,7 @@ func (m *Geo) CalcFitnessScoreWithDistanceField(sf func(int) float64, regDistanc
 
 	useGoRoutines := true
 	if useGoRoutines {
-		various.KickOffChunkWorkers(m.SphereMesh.NumRegions, chunkProcessor)
+		m.GetExecutor().ParallelFor(m.SphereMesh.NumRegions, chunkProcessor)
 	} else {
 		chunkProcessor(0, m.SphereMesh.NumRegions)
 	}
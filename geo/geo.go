@@ -1,10 +1,8 @@
 package geo
 
 import (
-	"log"
 	"math"
 	"sort"
-	"time"
 
 	"github.com/Flokey82/genworldvoronoi/spheremesh"
 	"github.com/Flokey82/genworldvoronoi/various"
@@ -22,119 +20,163 @@ type Geo struct {
 	RegionToWindVec      [][2]float64   // Point / region wind vector
 	RegionToWindVecLocal [][2]float64   // Point / region wind vector (local)
 	RegionToOceanVec     [][2]float64   // Point / region ocean current vector
+	RegionToDeepOceanVec [][2]float64   // Point / region deep ocean (thermohaline) return flow vector
 	RegionToPlate        []int          // Point / region to plate mapping
 	Ocean_r              []int          // Ocean regions
 	Mountain_r           []int          // Mountain regions
 	Coastline_r          []int          // Coastline regions
+	RegionToCoastType    []int          // Point / region coast type (CoastTypeNone if not coastal)
+	RegionToLithology    []int          // Point / region bedrock type
 	AvgInsolation        []float64      // Average daily insolation values
+	StormTracks          []*StormTrack  // Historical tropical cyclone tracks
+	Features             []*Feature     // Geographic features (mountain ranges, seas, islands, ...)
+	FireIntensity        []float64      // Point / region wildfire intensity (0.0-1.0, 0 if not burning)
+	Fuel                 []float64      // Point / region vegetation (fuel) available for wildfires
+	FuelCapacity         []float64      // Point / region vegetation (fuel) when fully grown
+	burnedWood           map[int]byte   // Wood resources lost to wildfires until the vegetation regrows
+	elevationPerMeter    float64        // Elevation units per m (see metersToElevation)
 	QuadGeom             *QuadGeometry  // Quad geometry generated from the mesh (?)
+	meshTemplate         *meshTemplate  // Plates of the coarse pass (if we use an adaptive mesh)
+
+	// OnClimateChanged is called after the climate chain has been re-run
+	// (see ApplyClimate), e.g. to name new features or to discard anything
+	// derived from the old terrain and climate.
+	OnClimateChanged func()
 }
 
 func NewGeo(seed int64, cfg *GeoConfig) (*Geo, error) {
 	if cfg == nil {
 		cfg = NewGeoConfig()
 	}
-	result, err := spheremesh.MakeSphere(seed, cfg.NumPoints, cfg.Jitter)
+	if cfg.PlanetConfig == nil {
+		cfg.PlanetConfig = NewPlanetConfig()
+	}
+	if err := cfg.PlanetConfig.validate(); err != nil {
+		return nil, err
+	}
+	if cfg.ResourceRegistry == nil {
+		cfg.ResourceRegistry = NewResourceRegistry()
+	}
+	var result *spheremesh.SphereMesh
+	var tmpl *meshTemplate
+	var err error
+	if cfg.AdaptiveMesh {
+		result, tmpl, err = makeAdaptiveMesh(seed, cfg)
+	} else if cfg.MeshGenerator != nil {
+		result, err = spheremesh.MakeHierarchicalSphere(cfg.MeshGenerator, cfg.NumPoints)
+	} else {
+		result, err = spheremesh.MakeSphere(seed, cfg.NumPoints, cfg.Jitter)
+	}
 	if err != nil {
 		return nil, err
 	}
 	return &Geo{
 		GeoConfig:            cfg,
-		Calendar:             NewCalendar(),
+		Calendar:             NewCalendar(cfg.DaysPerYear),
 		PlateIsOcean:         make(map[int]bool),
-		BaseObject:           newBaseObject(seed, result),
+		BaseObject:           newBaseObject(seed, result, cfg.Float32Storage, cfg.Executor),
 		Resources:            newResources(result.NumRegions),
 		RegionToWindVec:      make([][2]float64, result.NumRegions),
 		RegionToWindVecLocal: make([][2]float64, result.NumRegions),
 		RegionToOceanVec:     make([][2]float64, result.NumRegions),
+		RegionToDeepOceanVec: make([][2]float64, result.NumRegions),
+		RegionToCoastType:    make([]int, result.NumRegions),
+		RegionToLithology:    make([]int, result.NumRegions),
 		QuadGeom:             NewQuadGeometry(result.TriangleMesh),
+		meshTemplate:         tmpl,
 	}, nil
 }
 
 func (m *Geo) GenerateGeology() {
-	// Generate tectonic plates.
-	start := time.Now()
-	m.generatePlates()
-	m.assignOceanPlates()
-	log.Println("Done plates in ", time.Since(start).String())
+	start := various.StartStage()
+	if m.Heightmap != nil {
+		// Sample the elevation from the imported heightmap.
+		m.assignHeightmapElevation()
+		various.LogStage("heightmap elevation", start)
+	} else {
+		// Generate tectonic plates.
+		m.generatePlates()
+		m.assignOceanPlates()
+		various.LogStage("plates", start)
+
+		// Calculate elevation.
+		start = various.StartStage()
+		m.assignRegionElevation()
+		various.LogStage("elevation", start)
+	}
 
-	// Calculate elevation.
-	start = time.Now()
-	m.assignRegionElevation()
-	log.Println("Done elevation in ", time.Since(start).String())
+	// Assign the bedrock types.
+	// NOTE: The geology is also used for placing resources further down.
+	start = various.StartStage()
+	geology := m.getGeology()
+	m.assignLithology(geology)
+	various.LogStage("lithology", start)
 
 	// Calculate wind vectors.
-	start = time.Now()
+	start = various.StartStage()
 	m.assignWindVectors()
-	log.Println("Done wind vectors in ", time.Since(start).String())
+	various.LogStage("wind vectors", start)
 
 	// Assign rainfall, moisture.
-	start = time.Now()
+	start = various.StartStage()
 	m.assignRainfallBasic()
 	// m.assignRainfall(1, moistTransferIndirect, moistOrderWind)
 	// m.assignFlux()
-	log.Println("Done rainfall in ", time.Since(start).String())
+	various.LogStage("rainfall", start)
 
 	// Hydrology (based on regions) - EXPERIMENTAL
-	start = time.Now()
+	start = various.StartStage()
 	// m.assignHydrologyWithFlooding()
 	m.assignHydrology()
 	// m.getRivers(9000.1)
 	// m.r_elevation = m.rErode(0.05)
-	log.Println("Done hydrology in ", time.Since(start).String())
+	various.LogStage("hydrology", start)
 
 	// Now that water is assigned, we can make note of waterbodies.
 	// NOTE: Lake sizes are assigned in assignHydrology etc.
-	start = time.Now()
+	start = various.StartStage()
 	m.assignWaterbodies()
-	log.Println("Done waterbodies in ", time.Since(start).String())
+	various.LogStage("waterbodies", start)
 
 	// Note waterfalls.
-	start = time.Now()
+	start = various.StartStage()
 	m.assignWaterfalls()
-	log.Println("Done waterfalls in ", time.Since(start).String())
+	various.LogStage("waterfalls", start)
 
 	// Place resources
-	start = time.Now()
-	m.placeResources()
-	log.Println("Done placing resources in ", time.Since(start).String())
+	start = various.StartStage()
+	m.placeResources(geology)
+	various.LogStage("placing resources", start)
 
 	// Hydrology (based on triangles)
 	// Amit's hydrology code.
-	start = time.Now()
+	start = various.StartStage()
 	m.assignTriValues()
 	// m.assignDownflow()
 	// m.assignFlow()
-	log.Println("Done triangles in ", time.Since(start).String())
+	various.LogStage("triangles", start)
 
 	// Quad geometry update.
 	// This is really only useful for rendering the map but we don't
 	// really use this right now.
-	start = time.Now()
+	start = various.StartStage()
 	m.QuadGeom.setMap(m.SphereMesh.TriangleMesh, m)
-	log.Println("Done quadgeom in ", time.Since(start).String())
+	various.LogStage("quadgeom", start)
 
 	// Identify continents / landmasses.
-	start = time.Now()
+	start = various.StartStage()
 	m.assignLandmasses()
-	log.Println("Done identify landmasses in ", time.Since(start).String())
-
-	// Update the biome regions.
-	// This will be interesting to determine place names, impact on
-	// pathfinding (navigating around difficult terrain), etc.
-	start = time.Now()
-	m.assignBiomeRegions()
-	log.Println("Done biome regions in ", time.Since(start).String())
+	various.LogStage("identify landmasses", start)
 
 	// Assign ocean currents.
-	start = time.Now()
+	start = various.StartStage()
 	// m.assignOceanCurrents()
 	m.assignOceanCurrents3()
-	log.Println("Done ocean currents in ", time.Since(start).String())
+	various.LogStage("ocean currents", start)
 
 	// Hacky: Generate temperatures.
 	transportTemp := true
-	start = time.Now()
+	start = various.StartStage()
 	// TODO: Do iterative steps since the water temperature will influence
 	// the air temperature and vice versa.
 	m.initRegionWaterTemperature()
@@ -143,17 +185,60 @@ func (m *Geo) GenerateGeology() {
 		m.transportRegionWaterTemperature()
 		m.assignRegionAirTemperature()
 	}
-	log.Println("Done temperatures in ", time.Since(start).String())
+	various.LogStage("temperatures", start)
+
+	// Salinity, upwelling and the deep ocean return flow.
+	// NOTE: Upwelling of cold water leads to coastal fog and aridity, so
+	// this has to happen before we assign the biome regions.
+	start = various.StartStage()
+	m.assignOceanModel()
+	various.LogStage("ocean model", start)
+
+	// Classify the coastlines.
+	start = various.StartStage()
+	m.assignCoastTypes()
+	various.LogStage("coast types", start)
+
+	// Generate the resource deposits.
+	// NOTE: This has to happen after the ocean model, since it places fish.
+	start = various.StartStage()
+	m.assignDeposits()
+	various.LogStage("deposits", start)
+
+	// Update the biome regions.
+	// This will be interesting to determine place names, impact on
+	// pathfinding (navigating around difficult terrain), etc.
+	start = various.StartStage()
+	m.assignBiomeRegions()
+	various.LogStage("biome regions", start)
+
+	// Identify the geographic features.
+	start = various.StartStage()
+	m.assignFeatures()
+	various.LogStage("features", start)
+
+	// Generate historical storm tracks.
+	start = various.StartStage()
+	m.assignStormTracks()
+	various.LogStage("storm tracks", start)
+
+	// Vegetation (fuel) for wildfires.
+	start = various.StartStage()
+	m.assignFuel()
+	various.LogStage("wildfire fuel", start)
 
 	// Average daily insolation. (currently with a set day of year)
-	start = time.Now()
+	start = various.StartStage()
 	m.AvgInsolation = m.GetAverageInsolation(90)
-	log.Println("Done insolation in ", time.Since(start).String())
+	various.LogStage("insolation", start)
 }
 
 func (m *Geo) Tick() {
 	// Advance the calendar.
 	m.Calendar.Tick()
+
+	// Burn and spread the wildfires.
+	m.tickWildfires()
 }
 
 // GetCustomContour returns a countour by tracing the region borders determined
@@ -0,0 +1,123 @@
+package geo
+
+import (
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"testing"
+)
+
+// benchSeed is the seed used for all benchmarks.
+const benchSeed = 1234
+
+// benchSizes are the numbers of points the benchmarks are run with.
+var benchSizes = []int{10000, 50000, 100000}
+
+func TestMain(m *testing.M) {
+	// Silence the progress logs of the generation.
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// newBenchGeo returns a new geography with the given number of points.
+func newBenchGeo(b *testing.B, numPoints int) *Geo {
+	cfg := NewGeoConfig()
+	cfg.NumPoints = numPoints
+	g, err := NewGeo(benchSeed, cfg)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return g
+}
+
+// benchGeos caches the generated geographies used by the stage benchmarks.
+var benchGeos = make(map[int]*Geo)
+
+// getBenchGeo returns a generated geography with the given number of points.
+func getBenchGeo(b *testing.B, numPoints int) *Geo {
+	if g, ok := benchGeos[numPoints]; ok {
+		return g
+	}
+	g := newBenchGeo(b, numPoints)
+	g.GenerateGeology()
+	benchGeos[numPoints] = g
+	return g
+}
+
+func BenchmarkGenerateGeology(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				g := newBenchGeo(b, n)
+				b.StartTimer()
+				g.GenerateGeology()
+			}
+		})
+	}
+}
+
+// benchStages are the stages of GenerateGeology. Each stage is re-run on a
+// fully generated geography.
+//
+// Stages that modify the input of other stages (e.g. the elevation) are
+// marked as mutating and run on their own, freshly generated geography, so
+// the shared geography used by the other stages stays untouched.
+var benchStages = []struct {
+	name     string
+	mutating bool
+	fn       func(g *Geo)
+}{
+	{"Plates", true, func(g *Geo) { g.generatePlates(); g.assignOceanPlates() }},
+	{"Elevation", true, func(g *Geo) { g.assignRegionElevation() }},
+	{"Lithology", false, func(g *Geo) { g.assignLithology(g.getGeology()) }},
+	{"WindVectors", false, func(g *Geo) { g.assignWindVectors() }},
+	{"Rainfall", true, func(g *Geo) { g.assignRainfallBasic() }},
+	{"Hydrology", true, func(g *Geo) { g.assignHydrology() }},
+	{"Waterbodies", false, func(g *Geo) { g.assignWaterbodies() }},
+	{"Waterfalls", false, func(g *Geo) { g.assignWaterfalls() }},
+	{"Resources", false, func(g *Geo) { g.placeResources(g.getGeology()) }},
+	{"Triangles", false, func(g *Geo) { g.assignTriValues() }},
+	{"QuadGeom", false, func(g *Geo) { g.QuadGeom.setMap(g.SphereMesh.TriangleMesh, g) }},
+	{"Landmasses", false, func(g *Geo) { g.assignLandmasses() }},
+	{"OceanCurrents", false, func(g *Geo) { g.assignOceanCurrents3() }},
+	{"Temperatures", true, func(g *Geo) {
+		g.initRegionWaterTemperature()
+		g.initRegionAirTemperature()
+		g.transportRegionWaterTemperature()
+		g.assignRegionAirTemperature()
+	}},
+	{"OceanModel", true, func(g *Geo) { g.assignOceanModel() }},
+	{"CoastTypes", false, func(g *Geo) { g.assignCoastTypes() }},
+	{"Deposits", false, func(g *Geo) { g.assignDeposits() }},
+	{"BiomeRegions", false, func(g *Geo) { g.assignBiomeRegions() }},
+	{"Features", false, func(g *Geo) { g.assignFeatures() }},
+	{"StormTracks", false, func(g *Geo) { g.assignStormTracks() }},
+	{"Fuel", false, func(g *Geo) { g.assignFuel() }},
+	{"Insolation", false, func(g *Geo) { g.AvgInsolation = g.GetAverageInsolation(90) }},
+}
+
+func BenchmarkGeologyStages(b *testing.B) {
+	for _, st := range benchStages {
+		b.Run(st.name, func(b *testing.B) {
+			for _, n := range benchSizes {
+				b.Run(strconv.Itoa(n), func(b *testing.B) {
+					var g *Geo
+					if st.mutating {
+						g = newBenchGeo(b, n)
+						g.GenerateGeology()
+					} else {
+						g = getBenchGeo(b, n)
+					}
+					b.ReportAllocs()
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						st.fn(g)
+					}
+				})
+			}
+		})
+	}
+}
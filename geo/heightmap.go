@@ -0,0 +1,198 @@
+package geo
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/Flokey82/go_gens/utils"
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// Heightmap import parameters.
+const (
+	heightmapMountainElevation = 0.6   // Minimum (normalized) elevation of mountains
+	heightmapMinElevation      = 0.001 // Minimum absolute elevation of regions forced above or below sea level by the land mask
+)
+
+// Heightmap is an equirectangular elevation grid that can be used instead of
+// the tectonic plates to generate the terrain (see GeoConfig.Heightmap).
+//
+// The first row is the north pole, the last row the south pole, the first
+// column is at 180°W and the last column at 180°E.
+type Heightmap struct {
+	Width    int        // Number of columns
+	Height   int        // Number of rows
+	Values   []float64  // Row-major elevation values
+	SeaLevel float64    // Value at sea level
+	LandMask *Heightmap // Optional land mask (values above 0.5 are land)
+}
+
+// NewHeightmap returns a new heightmap with the given dimensions and values.
+func NewHeightmap(width, height int, values []float64, seaLevel float64) (*Heightmap, error) {
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("invalid heightmap dimensions")
+	}
+	if len(values) != width*height {
+		return nil, errors.New("number of values does not match heightmap dimensions")
+	}
+	return &Heightmap{
+		Width:    width,
+		Height:   height,
+		Values:   values,
+		SeaLevel: seaLevel,
+	}, nil
+}
+
+// ReadHeightmapPNG reads a grayscale (preferably 16 bit) PNG heightmap. The
+// values are scaled to 0.0-1.0, so the sea level should be given in the same
+// range.
+func ReadHeightmapPNG(r io.Reader, seaLevel float64) (*Heightmap, error) {
+	img, err := png.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	return heightmapFromImage(img, seaLevel)
+}
+
+// ReadLandMaskPNG reads a land mask from a PNG, where white (or bright) pixels
+// are land and black (or dark) pixels are water.
+func ReadLandMaskPNG(r io.Reader) (*Heightmap, error) {
+	return ReadHeightmapPNG(r, 0.5)
+}
+
+// ReadHeightmapRaw reads a raw grid of little endian float32 values with the
+// given dimensions (e.g. elevation in meters with the sea level at 0).
+func ReadHeightmapRaw(r io.Reader, width, height int, seaLevel float64) (*Heightmap, error) {
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("invalid heightmap dimensions")
+	}
+	raw := make([]float32, width*height)
+	if err := binary.Read(r, binary.LittleEndian, raw); err != nil {
+		return nil, err
+	}
+	values := make([]float64, len(raw))
+	for i, v := range raw {
+		values[i] = float64(v)
+	}
+	return NewHeightmap(width, height, values, seaLevel)
+}
+
+func heightmapFromImage(img image.Image, seaLevel float64) (*Heightmap, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	values := make([]float64, 0, width*height)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.Gray16Model.Convert(img.At(x, y)).(color.Gray16)
+			values = append(values, float64(c.Y)/math.MaxUint16)
+		}
+	}
+	return NewHeightmap(width, height, values, seaLevel)
+}
+
+// Sample returns the bilinearly interpolated value at the given lat/lon
+// (in degrees).
+func (h *Heightmap) Sample(lat, lon float64) float64 {
+	// Convert lat/lon to (continuous) pixel coordinates.
+	x := (lon+180)/360*float64(h.Width) - 0.5
+	y := (90-lat)/180*float64(h.Height) - 0.5
+	y = math.Max(0, math.Min(float64(h.Height-1), y))
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	fx, fy := x-float64(x0), y-float64(y0)
+	y1 := utils.Min(y0+1, h.Height-1)
+
+	// The longitude wraps around.
+	x1 := (x0 + 1) % h.Width
+	x0 = (x0%h.Width + h.Width) % h.Width
+	v00 := h.Values[y0*h.Width+x0]
+	v10 := h.Values[y0*h.Width+x1]
+	v01 := h.Values[y1*h.Width+x0]
+	v11 := h.Values[y1*h.Width+x1]
+	return (v00*(1-fx)+v10*fx)*(1-fy) + (v01*(1-fx)+v11*fx)*fy
+}
+
+// assignHeightmapElevation samples the elevation of all regions from the
+// configured heightmap instead of generating the terrain from tectonic
+// plates.
+//
+// The elevation is normalized to -1.0 - 1.0 with 0 at the sea level of the
+// heightmap, and the land mask (if any) decides which regions are land.
+//
+// Since there are no plates, all regions are assigned to a single plate
+// without any compression (so there are no faultlines or volcanoes), and
+// the mountains are the regions above heightmapMountainElevation.
+func (m *Geo) assignHeightmapElevation() {
+	h := m.Heightmap
+	numRegions := m.SphereMesh.NumRegions
+	for r := 0; r < numRegions; r++ {
+		m.Elevation.Set(r, h.Sample(m.LatLon[r][0], m.LatLon[r][1])-h.SeaLevel)
+	}
+
+	// Normalize the elevation values to the range -1.0 - 1.0
+	minElevation, maxElevation := m.Elevation.MinMax()
+	for r := 0; r < numRegions; r++ {
+		if m.Elevation.At(r) < 0 && minElevation < 0 {
+			m.Elevation.Set(r, m.Elevation.At(r)/math.Abs(minElevation))
+		} else if m.Elevation.At(r) > 0 && maxElevation > 0 {
+			m.Elevation.Set(r, m.Elevation.At(r)/maxElevation)
+		}
+	}
+
+	// Apply the land mask.
+	if h.LandMask != nil {
+		for r := 0; r < numRegions; r++ {
+			isLand := h.LandMask.Sample(m.LatLon[r][0], m.LatLon[r][1]) > h.LandMask.SeaLevel
+			if isLand && m.Elevation.At(r) <= 0 {
+				m.Elevation.Set(r, heightmapMinElevation)
+			} else if !isLand && m.Elevation.At(r) > 0 {
+				m.Elevation.Set(r, -heightmapMinElevation)
+			}
+		}
+	}
+
+	// Apply the configured sea level change (e.g. for an ice age).
+	m.assignElevationScale()
+	m.shiftSeaLevel(m.SeaLevelOffset)
+
+	// All regions belong to a single plate.
+	m.PlateRegs = []int{0}
+	m.RegionToPlate = make([]int, numRegions)
+	m.PlateToVector = make([]vectors.Vec3, numRegions)
+	m.PlateIsOcean = make(map[int]bool)
+	m.RegionCompression = make([]float64, numRegions)
+
+	// Identify the mountains, coastlines and coastal waters.
+	m.Mountain_r, m.Coastline_r, m.Ocean_r = nil, nil, nil
+	outRegs := make([]int, 0, 8)
+	for r := 0; r < numRegions; r++ {
+		if m.Elevation.At(r) >= heightmapMountainElevation {
+			m.Mountain_r = append(m.Mountain_r, r)
+		}
+		for _, nb := range m.SphereMesh.R_circulate_r(outRegs, r) {
+			if (m.Elevation.At(r) > 0) == (m.Elevation.At(nb) > 0) {
+				continue
+			}
+			if m.Elevation.At(r) > 0 {
+				m.Coastline_r = append(m.Coastline_r, r)
+			} else {
+				m.Ocean_r = append(m.Ocean_r, r)
+			}
+			break
+		}
+	}
+
+	// Sort the mountains by elevation (like the tectonic mountains are sorted
+	// by compression).
+	sort.Slice(m.Mountain_r, func(i, j int) bool {
+		return m.Elevation.At(m.Mountain_r[i]) > m.Elevation.At(m.Mountain_r[j])
+	})
+	for _, r := range m.Mountain_r {
+		m.RegionIsMountain.Set(r)
+	}
+}
@@ -29,22 +29,22 @@ Loop:
 			if !m.IsRegBelowOrAtSeaLevelOrPool(r) {
 				continue Loop
 			}
-			if m.Elevation[nb] < lowest {
-				lowest = m.Elevation[nb]
+			if m.Elevation.At(nb) < lowest {
+				lowest = m.Elevation.At(nb)
 			}
 		}
-		m.Elevation[r] = lowest
+		m.Elevation.Set(r, lowest)
 	}
 
 	// Start off by filling sinks.
-	m.Elevation = m.FillSinks(true)
+	m.Elevation.SetValues(m.FillSinks(true))
 
 	// Try to flood all sinks.
 	var attempts int
 	for {
 		// Abort if we have no more sinks or ran out of attempts.
 		if attempts > maxAttempts {
-			m.Elevation = m.FillSinks(true)
+			m.Elevation.SetValues(m.FillSinks(true))
 
 			// Regenerate winds.
 			// m.assignWindVectors()
@@ -67,12 +67,12 @@ Loop:
 		}
 
 		// Reset pools.
-		for i := range m.Waterpool {
-			m.Waterpool[i] = 0
+		for i := 0; i < m.Waterpool.Len(); i++ {
+			m.Waterpool.Set(i, 0)
 		}
 
 		// Fill sinks.
-		m.Elevation = m.FillSinks(true)
+		m.Elevation.SetValues(m.FillSinks(true))
 
 		// Regenerate winds.
 		// m.assignWindVectors()
@@ -87,7 +87,7 @@ Loop:
 		m.assignFlux(false)
 
 		// Erode a little.
-		m.Elevation = m.Erode(erosionAmount) // NOTE: Flux would change as downhill values would change.
+		m.Elevation.SetValues(m.Erode(erosionAmount)) // NOTE: Flux would change as downhill values would change.
 	}
 
 	// TODO: Move this somewhere else.
@@ -116,7 +116,7 @@ func (m *Geo) assignHydrologyWithFlooding() {
 
 		// Abort if we have no more sinks or ran out of attempts.
 		if len(r_sinks) == 0 || attempts > maxAttempts {
-			m.Elevation = m.FillSinks(true)
+			m.Elevation.SetValues(m.FillSinks(true))
 			// Regenerate downhill.
 			m.BaseObject.AssignDownhill(true)
 
@@ -141,8 +141,8 @@ func (m *Geo) assignHydrologyWithFlooding() {
 		}
 
 		// Reset pools.
-		for i := range m.Waterpool {
-			m.Waterpool[i] = 0
+		for i := 0; i < m.Waterpool.Len(); i++ {
+			m.Waterpool.Set(i, 0)
 		}
 
 		// Erode a little.
@@ -159,7 +159,7 @@ func (m *Geo) assignHydrologyWithFlooding() {
 
 		// Start from lowest sink.
 		sort.Slice(r_sinks, func(i, j int) bool {
-			return m.Elevation[r_sinks[i]] < m.Elevation[r_sinks[j]]
+			return m.Elevation.At(r_sinks[i]) < m.Elevation.At(r_sinks[j])
 		})
 
 		// Flood sink up to lowest neighbor + epsilon.
@@ -169,9 +169,9 @@ func (m *Geo) assignHydrologyWithFlooding() {
 			//}
 			switch floodVariant {
 			case FloodVariant1:
-				m.floodV1(r, m.Flux[r])
+				m.floodV1(r, m.Flux.At(r))
 			case FloodVariant2:
-				m.floodV2(r, m.Flux[r])
+				m.floodV2(r, m.Flux.At(r))
 			}
 		}
 
@@ -199,7 +199,7 @@ func (m *BaseObject) floodSinks() []float64 {
 	// - Get the elevation from the fill sinks algorithm.
 	filledSinks := m.FillSinks(false)
 
-	newHeight := make([]float64, len(m.Elevation))
+	newHeight := make([]float64, m.Elevation.Len())
 	copy(newHeight, filledSinks)
 
 	// Compare the unaltered elevation of the lake regions
@@ -208,15 +208,15 @@ func (m *BaseObject) floodSinks() []float64 {
 	//
 	// NOTE: The surface of the lakes would not level if used
 	// unaltered due to the way the fill sinks algorithm works.
-	pool := make([]float64, len(m.Elevation))
+	pool := make([]float64, m.Elevation.Len())
 	for i, v := range filledSinks {
-		pool[i] = v - m.Elevation[i]
+		pool[i] = v - m.Elevation.At(i)
 	}
 
 	// Sort the regions by their filled elevation in ascending order.
 	// This way we avoid picking a high region as the seed region.
 	// The seed region is the region that is used to represent the lake.
-	sortedRegs := make([]int, len(m.Elevation))
+	sortedRegs := make([]int, m.Elevation.Len())
 	for i := range sortedRegs {
 		sortedRegs[i] = i
 	}
@@ -227,10 +227,10 @@ func (m *BaseObject) floodSinks() []float64 {
 	outRegs := make([]int, 0, 8)
 
 	// drainage holds mapping of region to drainage region.
-	drainage := initRegionSlice(len(m.Elevation))
+	drainage := initRegionSlice(m.Elevation.Len())
 
 	// poolIDs olds mapping of region to pool ID.
-	poolIDs := initRegionSlice(len(m.Elevation))
+	poolIDs := initRegionSlice(m.Elevation.Len())
 
 	// poolIDToLowestReg holds mapping of pool ID to lowest region
 	// (for normalizing/leveling the water surface).
@@ -311,8 +311,8 @@ func (m *BaseObject) floodSinks() []float64 {
 			if rID != seed {
 				continue
 			}
-			sumPrecip += m.Rainfall[reg]
-			sumFlux += m.Flux[reg]
+			sumPrecip += m.Rainfall.At(reg)
+			sumFlux += m.Flux.At(reg)
 		}
 
 		// Fill up the elevation of the lake regions instead of the water pool if:
@@ -341,7 +341,7 @@ func (m *BaseObject) floodSinks() []float64 {
 					continue
 				}
 
-				if filledSinks[lowestReg] < m.Elevation[reg] {
+				if filledSinks[lowestReg] < m.Elevation.At(reg) {
 					// Use the actual elevation of the region if it is higher
 					// than the lowest lake region.
 					pool[reg] = 0
@@ -356,8 +356,8 @@ func (m *BaseObject) floodSinks() []float64 {
 				} else {
 					// If the the actual elevation of the region is higher than the
 					// lowest lake region, we fill up the water pool to the actual
-					pool[reg] = (m.Elevation[lowestReg] + pool[lowestReg]) - m.Elevation[reg]
-					newHeight[reg] = m.Elevation[reg]
+					pool[reg] = (m.Elevation.At(lowestReg) + pool[lowestReg]) - m.Elevation.At(reg)
+					newHeight[reg] = m.Elevation.At(reg)
 
 					// Set the drainage of the lake regions to the drainage region
 					// which is the lowest region of the lake neighbors.
@@ -367,9 +367,9 @@ func (m *BaseObject) floodSinks() []float64 {
 			}
 		}
 	}
-	m.Elevation = newHeight
+	m.Elevation.SetValues(newHeight)
 	m.Drainage = drainage
-	m.Waterpool = pool
+	m.Waterpool.SetValues(pool)
 	return pool
 }
 
@@ -389,7 +389,7 @@ func (m *Geo) floodV1(r int, dVol float64) {
 		drainage     = 0.01
 	)
 
-	plane := m.Elevation[r] + m.Waterpool[r]
+	plane := m.Elevation.At(r) + m.Waterpool.At(r)
 	initialplane := plane
 
 	// Floodset contains all regions that are part of a floodplain.
@@ -411,14 +411,14 @@ func (m *Geo) floodV1(r int, dVol float64) {
 		tried[i] = true
 
 		// Wall / Boundary
-		currHeight := m.Elevation[i] + m.Waterpool[i]
+		currHeight := m.Elevation.At(i) + m.Waterpool.At(i)
 		if plane < currHeight {
 			return
 		}
 
 		// Drainage Point
 		if initialplane > currHeight {
-			if !drainfound || currHeight < m.Waterpool[drain]+m.Elevation[drain] {
+			if !drainfound || currHeight < m.Waterpool.At(drain)+m.Elevation.At(drain) {
 				// No Drain yet or lower drain.
 				drain = i
 			}
@@ -436,7 +436,7 @@ func (m *Geo) floodV1(r int, dVol float64) {
 		// NOTE: The regions are sorted in ascending order, so the first
 		// region in the list will be the lowest one.
 		sort.Slice(nbs, func(si, sj int) bool {
-			return m.Elevation[nbs[si]]+m.Waterpool[nbs[si]] < m.Elevation[nbs[sj]]+m.Waterpool[nbs[sj]]
+			return m.Elevation.At(nbs[si])+m.Waterpool.At(nbs[si]) < m.Elevation.At(nbs[sj])+m.Waterpool.At(nbs[sj])
 		})
 
 		// Expand floodset by attempting to fill all neighbors.
@@ -464,15 +464,15 @@ func (m *Geo) floodV1(r int, dVol float64) {
 		// Drainage Point
 		if drainfound {
 			// Set the New Waterlevel (Slowly)
-			plane = (1.0-drainage)*initialplane + drainage*(m.Elevation[drain]+m.Waterpool[drain])
+			plane = (1.0-drainage)*initialplane + drainage*(m.Elevation.At(drain)+m.Waterpool.At(drain))
 
 			// Compute the New Height
 			for _, s := range set {
-				if plane > m.Elevation[s] {
-					m.Waterpool[s] = plane - m.Elevation[s]
+				if plane > m.Elevation.At(s) {
+					m.Waterpool.Set(s, plane-m.Elevation.At(s))
 					m.Drainage[s] = drain
 				} else {
-					m.Waterpool[s] = 0.0
+					m.Waterpool.Set(s, 0.0)
 					m.Drainage[s] = -1
 				}
 			}
@@ -487,14 +487,14 @@ func (m *Geo) floodV1(r int, dVol float64) {
 		// gives up the total missing volume required for a full flood.
 		var totalVol float64
 		for _, s := range set {
-			totalVol += volumeFactor * (plane - (m.Elevation[s] + m.Waterpool[s]))
+			totalVol += volumeFactor * (plane - (m.Elevation.At(s) + m.Waterpool.At(s)))
 		}
 		// log.Println("totalVol", totalVol, "dVol", dVol, "setLen", len(set))
 		// We can fill the volume of the sink.
 		if totalVol <= dVol && initialplane < plane {
 			// Raise water level to plane height.
 			for _, s := range set {
-				m.Waterpool[s] = plane - m.Elevation[s]
+				m.Waterpool.Set(s, plane-m.Elevation.At(s))
 			}
 
 			// Adjust flux Volume
@@ -550,7 +550,7 @@ func (m *Geo) floodV2(r int, dVol float64) bool {
 		tried[i] = true
 
 		// Wall / Boundary
-		currHeight := m.Elevation[i] + m.Waterpool[i]
+		currHeight := m.Elevation.At(i) + m.Waterpool.At(i)
 		if plane < currHeight {
 			boundary[i] = currHeight
 			return true
@@ -559,7 +559,7 @@ func (m *Geo) floodV2(r int, dVol float64) bool {
 		// Drainage Point
 		if currHeight < plane {
 			// No Drain yet
-			if !drainfound || currHeight < m.Waterpool[drain]+m.Elevation[drain] {
+			if !drainfound || currHeight < m.Waterpool.At(drain)+m.Elevation.At(drain) {
 				drain = i
 			}
 			drainfound = true
@@ -570,7 +570,7 @@ func (m *Geo) floodV2(r int, dVol float64) bool {
 		floodset = append(floodset, i)
 		nbs := m.GetRegNeighbors(i)
 		sort.Slice(nbs, func(si, sj int) bool {
-			return m.Elevation[nbs[si]]+m.Waterpool[nbs[si]] < m.Elevation[nbs[sj]]+m.Waterpool[nbs[sj]]
+			return m.Elevation.At(nbs[si])+m.Waterpool.At(nbs[si]) < m.Elevation.At(nbs[sj])+m.Waterpool.At(nbs[sj])
 		})
 		for _, nbReg := range nbs {
 			if !findset(nbReg, plane) {
@@ -581,7 +581,7 @@ func (m *Geo) floodV2(r int, dVol float64) bool {
 					} else {
 						newDrain = i
 					}
-					if drainedFrom == -1 || m.Elevation[newDrain]+m.Waterpool[newDrain] < m.Elevation[drainedFrom]+m.Waterpool[drainedFrom] {
+					if drainedFrom == -1 || m.Elevation.At(newDrain)+m.Waterpool.At(newDrain) < m.Elevation.At(drainedFrom)+m.Waterpool.At(drainedFrom) {
 						drainedFrom = newDrain
 					}
 				}
@@ -591,7 +591,7 @@ func (m *Geo) floodV2(r int, dVol float64) bool {
 		return true
 	}
 
-	plane := m.Waterpool[r] + m.Elevation[r]
+	plane := m.Waterpool.At(r) + m.Elevation.At(r)
 	minboundFirst := r
 	minboundSecond := plane
 	for dVol > minVol && findset(r, plane) {
@@ -615,7 +615,7 @@ func (m *Geo) floodV2(r int, dVol float64) bool {
 		}
 
 		for _, s := range floodset {
-			m.Waterpool[s] = plane - m.Elevation[s]
+			m.Waterpool.Set(s, plane-m.Elevation.At(s))
 			if s != drainedFrom {
 				m.Drainage[s] = drainedFrom // WROOOOONG?????
 			}
@@ -631,23 +631,23 @@ func (m *Geo) floodV2(r int, dVol float64) bool {
 			var lowbound func(i int)
 			lowbound = func(i int) {
 				// Out-Of-Bounds
-				if i < 0 || m.Waterpool[i] == 0 {
+				if i < 0 || m.Waterpool.At(i) == 0 {
 					return
 				}
 				// Below Drain Height
-				if m.Elevation[i]+m.Waterpool[i] < m.Elevation[drain]+m.Waterpool[drain] {
+				if m.Elevation.At(i)+m.Waterpool.At(i) < m.Elevation.At(drain)+m.Waterpool.At(drain) {
 					return
 				}
 				// Higher than Plane (we want lower)
-				if m.Elevation[i]+m.Waterpool[i] >= plane {
+				if m.Elevation.At(i)+m.Waterpool.At(i) >= plane {
 					return
 				}
-				plane = m.Elevation[i] + m.Waterpool[i]
+				plane = m.Elevation.At(i) + m.Waterpool.At(i)
 			}
 
 			nbs := m.GetRegNeighbors(drain)
 			sort.Slice(nbs, func(si, sj int) bool {
-				return m.Elevation[nbs[si]]+m.Waterpool[nbs[si]] < m.Elevation[nbs[sj]]+m.Waterpool[nbs[sj]]
+				return m.Elevation.At(nbs[si])+m.Waterpool.At(nbs[si]) < m.Elevation.At(nbs[sj])+m.Waterpool.At(nbs[sj])
 			})
 
 			// Fill Neighbors
@@ -663,26 +663,26 @@ func (m *Geo) floodV2(r int, dVol float64) bool {
 		// Water-Level to Plane-Height
 		for _, s := range floodset {
 			// volume += ((plane > h[ind])?(h[ind] + p[ind] - plane):p[ind])/volumeFactor;
-			if plane > m.Elevation[s] {
-				m.Waterpool[s] = plane - m.Elevation[s]
+			if plane > m.Elevation.At(s) {
+				m.Waterpool.Set(s, plane-m.Elevation.At(s))
 				if s != drainedFrom {
 					m.Drainage[s] = drainedFrom
 				}
 			} else {
-				m.Waterpool[s] = 0.0
+				m.Waterpool.Set(s, 0.0)
 				m.Drainage[s] = -1
 			}
 		}
 
 		for bfirst := range boundary {
 			// volume += ((plane > h[ind])?(h[ind] + p[ind] - plane):p[ind])/volumeFactor;
-			if plane > m.Elevation[bfirst] {
-				m.Waterpool[bfirst] = plane - m.Elevation[bfirst]
+			if plane > m.Elevation.At(bfirst) {
+				m.Waterpool.Set(bfirst, plane-m.Elevation.At(bfirst))
 				if bfirst != drainedFrom {
 					m.Drainage[bfirst] = drainedFrom
 				}
 			} else {
-				m.Waterpool[bfirst] = 0.0
+				m.Waterpool.Set(bfirst, 0.0)
 				m.Drainage[bfirst] = -1
 			}
 		}
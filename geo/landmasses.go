@@ -24,7 +24,7 @@ func (m *Geo) IdentifyLandmasses() []int {
 	landMasses := initRegionSlice(m.SphereMesh.NumRegions)
 	for r := range landMasses {
 		// Skip everything that is ocean.
-		if m.Elevation[r] <= 0 {
+		if m.Elevation.At(r) <= 0 {
 			landMasses[r] = -2
 		}
 	}
@@ -0,0 +1,110 @@
+package geo
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Landslide parameters.
+const (
+	landslideMinSteepness   = 0.05 // Landslides come to a halt on flatter terrain
+	landslideTriggerChance  = 0.1  // Chance of a landslide per region at full hazard and trigger intensity
+	landslideMaxPerTrigger  = 10   // Maximum number of landslides triggered by a single event
+	landslideMaxRunoutSteps = 20   // Maximum length (graph hops) of a landslide
+)
+
+// GetLandslideChance returns the landslide hazard (0.0-1.0) of all regions.
+//
+// Landslides happen on steep slopes, especially if the ground is soaked with
+// water, and in seismically active areas where earthquakes trigger them.
+// Rockslides and avalanches below mountains (see GetRockSlideAvalancheChance)
+// are taken into account as well.
+func (m *Geo) GetLandslideChance() []float64 {
+	return m.getLandslideChance(m.GetSteepness())
+}
+
+// getLandslideChance returns the landslide hazard of all regions based on the
+// given steepness of each region (see GetLandslideChance).
+func (m *Geo) getLandslideChance(steepness []float64) []float64 {
+	earthquakeChance := m.GetEarthquakeChance()
+	rockSlideChance := m.GetRockSlideAvalancheChance()
+	_, maxRain := m.Rainfall.MinMax()
+	if maxRain <= 0 {
+		maxRain = 1
+	}
+
+	chance := make([]float64, m.SphereMesh.NumRegions)
+	for r := 0; r < m.SphereMesh.NumRegions; r++ {
+		if m.Elevation.At(r) <= 0 {
+			continue
+		}
+		wetness := m.Rainfall.At(r) / maxRain
+		val := steepness[r] * (0.5 + 0.5*wetness) * (1 + earthquakeChance[r]) / 2
+		chance[r] = math.Max(val, math.Min(1, rockSlideChance[r])*steepness[r])
+	}
+
+	// Normalize the landslide chance.
+	if _, maxChance := minMax(chance); maxChance > 0 {
+		for r := range chance {
+			chance[r] /= maxChance
+		}
+	}
+	return chance
+}
+
+// GetLandslideEvent returns a landslide originating in the given region.
+// The slide runs downhill until the terrain flattens out or it reaches the
+// sea, and spreads its debris at the foot of the slope.
+//
+// NOTE: 'steepness' is the steepness of all regions (see GetSteepness), which
+// the caller can re-use for multiple landslides.
+func (m *Geo) GetLandslideEvent(origin int, magnitude float64, steepness []float64) *DisasterEvent {
+	ev := newDisasterEvent(DisLandslide, origin, magnitude)
+
+	outRegs := make([]int, 0, 8)
+	intensity := 0.5 + magnitude/2
+	cur := origin
+	for i := 0; i < landslideMaxRunoutSteps && cur >= 0 && m.Elevation.At(cur) > 0; i++ {
+		ev.addRegion(cur, intensity)
+		if steepness[cur] < landslideMinSteepness {
+			// Debris fan.
+			for _, nb := range m.SphereMesh.R_circulate_r(outRegs, cur) {
+				if m.Elevation.At(nb) > 0 {
+					ev.addRegion(nb, intensity/2)
+				}
+			}
+			break
+		}
+		intensity *= 0.9
+		if m.Downhill[cur] == cur {
+			break
+		}
+		cur = m.Downhill[cur]
+	}
+	return ev
+}
+
+// GetTriggeredLandslides returns the landslides triggered by the given event,
+// for example the shaking of an earthquake or the heavy rain that causes a
+// flood.
+func (m *Geo) GetTriggeredLandslides(trigger *DisasterEvent, rnd *rand.Rand) []*DisasterEvent {
+	steepness := m.GetSteepness()
+	return m.getTriggeredLandslides(m.getLandslideChance(steepness), steepness, trigger, rnd)
+}
+
+// getTriggeredLandslides returns the landslides triggered by the given event
+// based on the given landslide chance and steepness of each region.
+func (m *Geo) getTriggeredLandslides(chance, steepness []float64, trigger *DisasterEvent, rnd *rand.Rand) []*DisasterEvent {
+	var slides []*DisasterEvent
+	for _, r := range trigger.GetRegions() {
+		if len(slides) >= landslideMaxPerTrigger {
+			break
+		}
+		intensity := trigger.GetIntensity(r)
+		if m.Elevation.At(r) <= 0 || rnd.Float64() >= landslideTriggerChance*chance[r]*intensity {
+			continue
+		}
+		slides = append(slides, m.GetLandslideEvent(r, chance[r]*intensity, steepness))
+	}
+	return slides
+}
@@ -0,0 +1,212 @@
+package geo
+
+import (
+	"image/color"
+	"io"
+	"log"
+	"math"
+)
+
+// LayerValue is the type constraint for the values of custom data layers.
+// Only fixed-size types are allowed so that the layers can be serialized.
+type LayerValue interface {
+	bool | int8 | int16 | int32 | int64 | uint8 | uint16 | uint32 | uint64 | float32 | float64
+}
+
+// Layer interpolation modes (see Layer.Interpolation).
+const (
+	LayerInterpolateNearest = iota // Use the value of the closest original region (IDs, flags, ...)
+	LayerInterpolateLinear         // Average the values of the original regions (numeric values only)
+)
+
+// Layer is a custom per-region data layer (e.g. quest markers, fog-of-war or
+// ownership), which is registered on a BaseObject using RegisterLayer.
+type Layer[T LayerValue] struct {
+	Name          string        // Name of the layer
+	Values        []T           // Point / region values
+	Interpolation int           // How values are interpolated on refined meshes (LayerInterpolateNearest, ...)
+	Ramp          []color.Color // Color ramp used for rendering (nil for the default ramp)
+}
+
+// GetName returns the name of the layer.
+func (l *Layer[T]) GetName() string {
+	return l.Name
+}
+
+// GetValue returns the value of the given region.
+func (l *Layer[T]) GetValue(r int) any {
+	return l.Values[r]
+}
+
+// GetFloat returns the value of the given region as float64 (true is 1).
+func (l *Layer[T]) GetFloat(r int) float64 {
+	return layerValueToFloat(l.Values[r])
+}
+
+// GetColorRamp returns the color ramp used for rendering the layer.
+func (l *Layer[T]) GetColorRamp() []color.Color {
+	return l.Ramp
+}
+
+// interpolate returns a copy of the layer for a mesh derived from the one
+// of the layer, where 'parents' holds for each new region the two regions
+// it was derived from (see Interpolated.Parents).
+func (l *Layer[T]) interpolate(parents [][2]int) LayerAccessor {
+	values := make([]T, len(parents))
+	for i, p := range parents {
+		if l.Interpolation == LayerInterpolateLinear && p[0] != p[1] {
+			avg := (layerValueToFloat(l.Values[p[0]]) + layerValueToFloat(l.Values[p[1]])) / 2
+			values[i] = layerValueFromFloat[T](avg)
+		} else {
+			values[i] = l.Values[p[0]]
+		}
+	}
+	return &Layer[T]{
+		Name:          l.Name,
+		Values:        values,
+		Interpolation: l.Interpolation,
+		Ramp:          l.Ramp,
+	}
+}
+
+// LayerAccessor provides type-agnostic access to a custom data layer.
+type LayerAccessor interface {
+	GetName() string             // Name of the layer
+	GetValue(r int) any          // Value of the region
+	GetFloat(r int) float64      // Value of the region as float64 (for rendering)
+	GetColorRamp() []color.Color // Color ramp used for rendering (nil for the default ramp)
+	interpolate([][2]int) LayerAccessor
+	numValues() int
+	writeTo(io.Writer) error
+}
+
+// RegisterLayer registers a new custom data layer with the given name on the
+// given object and returns it. If a layer with the same name and type
+// already exists (e.g. read via ReadLayers), it is returned instead. A
+// layer with the same name but a different type is replaced.
+func RegisterLayer[T LayerValue](m *BaseObject, name string) *Layer[T] {
+	if l, ok := m.layers[name]; ok {
+		if tl, ok := l.(*Layer[T]); ok {
+			return tl
+		}
+		log.Printf("replacing layer %q", name)
+	} else {
+		m.layerNames = append(m.layerNames, name)
+	}
+	l := &Layer[T]{
+		Name:   name,
+		Values: make([]T, m.SphereMesh.NumRegions),
+	}
+	if m.layers == nil {
+		m.layers = make(map[string]LayerAccessor)
+	}
+	m.layers[name] = l
+	return l
+}
+
+// GetLayer returns the custom data layer with the given name and type.
+func GetLayer[T LayerValue](m *BaseObject, name string) (*Layer[T], bool) {
+	l, ok := m.layers[name].(*Layer[T])
+	return l, ok
+}
+
+// GetLayer returns the custom data layer with the given name (or nil).
+func (m *BaseObject) GetLayer(name string) LayerAccessor {
+	return m.layers[name]
+}
+
+// GetLayerNames returns the names of all custom data layers in order of
+// registration.
+func (m *BaseObject) GetLayerNames() []string {
+	return m.layerNames
+}
+
+// GetRegLayerValues returns the values of all custom data layers for the
+// given region (nil if there are no layers).
+func (m *BaseObject) GetRegLayerValues(r int) map[string]any {
+	if len(m.layerNames) == 0 {
+		return nil
+	}
+	values := make(map[string]any, len(m.layerNames))
+	for _, name := range m.layerNames {
+		values[name] = m.layers[name].GetValue(r)
+	}
+	return values
+}
+
+// interpolateLayers copies all custom data layers to the given object derived
+// from this one (see Interpolated.Parents).
+func (m *BaseObject) interpolateLayers(dst *BaseObject, parents [][2]int) {
+	if len(m.layerNames) == 0 {
+		return
+	}
+	dst.layers = make(map[string]LayerAccessor, len(m.layerNames))
+	dst.layerNames = append([]string(nil), m.layerNames...)
+	for _, name := range m.layerNames {
+		dst.layers[name] = m.layers[name].interpolate(parents)
+	}
+}
+
+// layerValueToFloat converts a layer value to float64.
+func layerValueToFloat[T LayerValue](v T) float64 {
+	switch val := any(v).(type) {
+	case bool:
+		if val {
+			return 1
+		}
+		return 0
+	case int8:
+		return float64(val)
+	case int16:
+		return float64(val)
+	case int32:
+		return float64(val)
+	case int64:
+		return float64(val)
+	case uint8:
+		return float64(val)
+	case uint16:
+		return float64(val)
+	case uint32:
+		return float64(val)
+	case uint64:
+		return float64(val)
+	case float32:
+		return float64(val)
+	case float64:
+		return val
+	}
+	return 0
+}
+
+// layerValueFromFloat converts a float64 to a layer value (rounding to the
+// nearest integer for integer types).
+func layerValueFromFloat[T LayerValue](f float64) T {
+	var v T
+	var res any
+	switch any(v).(type) {
+	case bool:
+		res = f >= 0.5
+	case int8:
+		res = int8(math.Round(f))
+	case int16:
+		res = int16(math.Round(f))
+	case int32:
+		res = int32(math.Round(f))
+	case int64:
+		res = int64(math.Round(f))
+	case uint8:
+		res = uint8(math.Round(f))
+	case uint16:
+		res = uint16(math.Round(f))
+	case uint32:
+		res = uint32(math.Round(f))
+	case uint64:
+		res = uint64(math.Round(f))
+	case float32:
+		res = float32(f)
+	case float64:
+		res = f
+	}
+	return res.(T)
+}
@@ -0,0 +1,210 @@
+package geo
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+)
+
+// Layer value kinds used to identify the type of a serialized layer.
+const (
+	layerKindBool uint8 = iota
+	layerKindInt8
+	layerKindInt16
+	layerKindInt32
+	layerKindInt64
+	layerKindUint8
+	layerKindUint16
+	layerKindUint32
+	layerKindUint64
+	layerKindFloat32
+	layerKindFloat64
+)
+
+// WriteLayers writes all custom data layers to the given writer.
+func (m *BaseObject) WriteLayers(w io.Writer) error {
+	if err := binary.Write(w, binary.LittleEndian, int64(len(m.layerNames))); err != nil {
+		return err
+	}
+	for _, name := range m.layerNames {
+		if err := m.layers[name].writeTo(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadLayers reads the custom data layers from the given reader and adds them
+// to the object, replacing existing layers with the same name.
+//
+// NOTE: The layers need to be written by an object with the same mesh.
+func (m *BaseObject) ReadLayers(r io.Reader) error {
+	var num int64
+	if err := binary.Read(r, binary.LittleEndian, &num); err != nil {
+		return err
+	}
+	for i := int64(0); i < num; i++ {
+		l, err := readLayer(r)
+		if err != nil {
+			return err
+		}
+		if l.numValues() != m.SphereMesh.NumRegions {
+			return fmt.Errorf("layer %q has %d values, expected %d", l.GetName(), l.numValues(), m.SphereMesh.NumRegions)
+		}
+		if _, ok := m.layers[l.GetName()]; !ok {
+			m.layerNames = append(m.layerNames, l.GetName())
+		}
+		if m.layers == nil {
+			m.layers = make(map[string]LayerAccessor)
+		}
+		m.layers[l.GetName()] = l
+	}
+	return nil
+}
+
+func (l *Layer[T]) numValues() int {
+	return len(l.Values)
+}
+
+func (l *Layer[T]) writeTo(w io.Writer) error {
+	// Write the name.
+	if err := binary.Write(w, binary.LittleEndian, int64(len(l.Name))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, l.Name); err != nil {
+		return err
+	}
+
+	// Write the value kind and the interpolation mode.
+	if err := binary.Write(w, binary.LittleEndian, getLayerKind[T]()); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, int64(l.Interpolation)); err != nil {
+		return err
+	}
+
+	// Write the color ramp.
+	if err := binary.Write(w, binary.LittleEndian, int64(len(l.Ramp))); err != nil {
+		return err
+	}
+	for _, c := range l.Ramp {
+		if err := binary.Write(w, binary.LittleEndian, color.NRGBAModel.Convert(c).(color.NRGBA)); err != nil {
+			return err
+		}
+	}
+
+	// Write the values.
+	if err := binary.Write(w, binary.LittleEndian, int64(len(l.Values))); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, l.Values)
+}
+
+func readLayer(r io.Reader) (LayerAccessor, error) {
+	// Read the name.
+	var nameLen int64
+	if err := binary.Read(r, binary.LittleEndian, &nameLen); err != nil {
+		return nil, err
+	}
+	name := make([]byte, nameLen)
+	if _, err := io.ReadFull(r, name); err != nil {
+		return nil, err
+	}
+
+	// Read the value kind and create the layer with the matching type.
+	var kind uint8
+	if err := binary.Read(r, binary.LittleEndian, &kind); err != nil {
+		return nil, err
+	}
+	switch kind {
+	case layerKindBool:
+		return readLayerValues[bool](r, string(name))
+	case layerKindInt8:
+		return readLayerValues[int8](r, string(name))
+	case layerKindInt16:
+		return readLayerValues[int16](r, string(name))
+	case layerKindInt32:
+		return readLayerValues[int32](r, string(name))
+	case layerKindInt64:
+		return readLayerValues[int64](r, string(name))
+	case layerKindUint8:
+		return readLayerValues[uint8](r, string(name))
+	case layerKindUint16:
+		return readLayerValues[uint16](r, string(name))
+	case layerKindUint32:
+		return readLayerValues[uint32](r, string(name))
+	case layerKindUint64:
+		return readLayerValues[uint64](r, string(name))
+	case layerKindFloat32:
+		return readLayerValues[float32](r, string(name))
+	case layerKindFloat64:
+		return readLayerValues[float64](r, string(name))
+	}
+	return nil, errors.New("unknown layer value kind")
+}
+
+// readLayerValues reads the remaining fields of a layer (see writeTo).
+func readLayerValues[T LayerValue](r io.Reader, name string) (*Layer[T], error) {
+	l := &Layer[T]{Name: name}
+
+	// Read the interpolation mode.
+	var interpolation int64
+	if err := binary.Read(r, binary.LittleEndian, &interpolation); err != nil {
+		return nil, err
+	}
+	l.Interpolation = int(interpolation)
+
+	// Read the color ramp.
+	var numColors int64
+	if err := binary.Read(r, binary.LittleEndian, &numColors); err != nil {
+		return nil, err
+	}
+	for i := int64(0); i < numColors; i++ {
+		var c color.NRGBA
+		if err := binary.Read(r, binary.LittleEndian, &c); err != nil {
+			return nil, err
+		}
+		l.Ramp = append(l.Ramp, c)
+	}
+
+	// Read the values.
+	var numValues int64
+	if err := binary.Read(r, binary.LittleEndian, &numValues); err != nil {
+		return nil, err
+	}
+	l.Values = make([]T, numValues)
+	if err := binary.Read(r, binary.LittleEndian, l.Values); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// getLayerKind returns the value kind of layers of the given type.
+func getLayerKind[T LayerValue]() uint8 {
+	var v T
+	switch any(v).(type) {
+	case bool:
+		return layerKindBool
+	case int8:
+		return layerKindInt8
+	case int16:
+		return layerKindInt16
+	case int32:
+		return layerKindInt32
+	case int64:
+		return layerKindInt64
+	case uint8:
+		return layerKindUint8
+	case uint16:
+		return layerKindUint16
+	case uint32:
+		return layerKindUint32
+	case uint64:
+		return layerKindUint64
+	case float32:
+		return layerKindFloat32
+	}
+	return layerKindFloat64
+}
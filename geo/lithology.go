@@ -0,0 +1,157 @@
+package geo
+
+import "math"
+
+// Lithology (bedrock) types.
+const (
+	LithologySedimentary = iota // Basins, lowlands and continental shelves (sandstone, limestone, shale)
+	LithologyMetamorphic        // Compressed mountain belts (marble, slate, schist)
+	LithologyIgneous            // Intrusive (plutonic) rock like granite, e.g. in volcanic arcs
+	LithologyVolcanic           // Extrusive rock like basalt, near volcanoes and the ocean floor
+	LithologyMax
+)
+
+// Lithology distances (km) to the plate boundaries and volcanoes.
+const (
+	lithologyVolcanicKm    = 80.0  // Volcanic rock around volcanoes
+	lithologyArcVolcanicKm = 40.0  // Volcanic rock of the volcanic arc above subduction zones
+	lithologyArcIgneousKm  = 190.0 // Igneous rock (batholiths) of the volcanic arc above subduction zones
+	lithologyMetamorphicKm = 115.0 // Metamorphic rock around mountains (collision zones)
+)
+
+// LithologyToString returns the name of the given lithology type.
+func LithologyToString(l int) string {
+	switch l {
+	case LithologySedimentary:
+		return "sedimentary"
+	case LithologyMetamorphic:
+		return "metamorphic"
+	case LithologyIgneous:
+		return "igneous"
+	case LithologyVolcanic:
+		return "volcanic"
+	default:
+		return "unknown"
+	}
+}
+
+// geology holds the distance fields that we use for both, the lithology and
+// the resource placement.
+type geology struct {
+	distVolcano    []float64 // Distance (km) to the nearest volcano
+	distSubduction []float64 // Distance (km) to the nearest subduction zone
+	distMountain   []float64 // Distance (km) to the nearest mountain (collision zone)
+	compression    []float64 // Propagated (normalized) compression
+}
+
+// getGeology calculates the (geodesic) distance fields from volcanoes,
+// subduction zones and mountains, so the geology doesn't depend on the
+// resolution of the mesh.
+func (m *Geo) getGeology() *geology {
+	var volcanoes, mountains []int
+	for r := 0; r < m.SphereMesh.NumRegions; r++ {
+		if m.RegionIsVolcano.Has(r) {
+			volcanoes = append(volcanoes, r)
+		}
+		if m.RegionIsMountain.Has(r) {
+			mountains = append(mountains, r)
+		}
+	}
+
+	// Calculate the distance fields in parallel.
+	seeds := [][]int{volcanoes, m.getSubductionZones(), mountains}
+	dists := make([][]float64, len(seeds))
+	m.GetExecutor().ParallelFor(len(seeds), func(start, end int) {
+		for i := start; i < end; i++ {
+			dists[i] = m.AssignGeodesicDistanceField(seeds[i], nil, nil).Distance
+		}
+	})
+	return &geology{
+		distVolcano:    dists[0],
+		distSubduction: dists[1],
+		distMountain:   dists[2],
+		compression:    m.PropagateCompression(m.RegionCompression),
+	}
+}
+
+// getSubductionZones returns all regions where an ocean plate is pushed
+// underneath a continental plate.
+func (m *Geo) getSubductionZones() []int {
+	var regs []int
+	outRegs := make([]int, 0, 8)
+	for r := 0; r < m.SphereMesh.NumRegions; r++ {
+		if m.RegionCompression[r] <= 0 {
+			continue
+		}
+		plate := m.RegionToPlate[r]
+		for _, nb := range m.SphereMesh.R_circulate_r(outRegs, r) {
+			nbPlate := m.RegionToPlate[nb]
+			if nbPlate != plate && m.PlateIsOcean[nbPlate] != m.PlateIsOcean[plate] {
+				regs = append(regs, r)
+				break
+			}
+		}
+	}
+	return regs
+}
+
+// assignLithology assigns the bedrock type to each region based on the plate
+// tectonics.
+//
+// - Volcanic rock is found near volcanoes and on the deep ocean floor.
+// - Igneous (intrusive) rock forms the roots of volcanic arcs above subduction
+// zones, and is exposed in the cores of old, eroded highlands.
+// - Metamorphic rock is found in compressed mountain belts.
+// - Sedimentary rock fills the basins, lowlands and continental shelves.
+func (m *Geo) assignLithology(g *geology) {
+	fn := m.fbmNoiseCustom(2, 1, 3, 3, 3, 0, 0, 0)
+	lithology := make([]int, m.SphereMesh.NumRegions)
+	for r := 0; r < m.SphereMesh.NumRegions; r++ {
+		elev := m.Elevation.At(r)
+		switch {
+		case g.distVolcano[r] <= lithologyVolcanicKm:
+			lithology[r] = LithologyVolcanic
+		case elev < -0.1:
+			// The deep ocean floor is made up of basalt.
+			lithology[r] = LithologyVolcanic
+		case elev <= 0:
+			// The continental shelves are covered by sediments.
+			lithology[r] = LithologySedimentary
+		case g.distSubduction[r] <= lithologyArcVolcanicKm:
+			lithology[r] = LithologyVolcanic
+		case g.distSubduction[r] <= lithologyArcIgneousKm:
+			// The batholiths of the volcanic arc.
+			lithology[r] = LithologyIgneous
+		case g.distMountain[r] <= lithologyMetamorphicKm || math.Abs(g.compression[r]) > 0.5:
+			lithology[r] = LithologyMetamorphic
+		case elev > 0.3 && fn(r) > 0.2:
+			// Exposed shields (the eroded cores of ancient mountains).
+			lithology[r] = LithologyIgneous
+		default:
+			lithology[r] = LithologySedimentary
+		}
+	}
+	m.RegionToLithology = lithology
+}
+
+// GetRegLithology returns the lithology type of the given region.
+func (m *Geo) GetRegLithology(r int) int {
+	if r < 0 || r >= len(m.RegionToLithology) {
+		return LithologySedimentary
+	}
+	return m.RegionToLithology[r]
+}
+
+// isRegLithologyNear returns true if the region or any of its neighbors has
+// the given lithology.
+func (m *Geo) isRegLithologyNear(outRegs []int, r, lithology int) bool {
+	if m.RegionToLithology[r] == lithology {
+		return true
+	}
+	for _, nb := range m.SphereMesh.R_circulate_r(outRegs, r) {
+		if m.RegionToLithology[nb] == lithology {
+			return true
+		}
+	}
+	return false
+}
@@ -39,8 +39,8 @@ func (qg *QuadGeometry) setMap(mesh *spheremesh.TriangleMesh, m *Geo) {
 	regMoisture := m.Moisture
 	numRegions := mesh.NumRegions
 	for r := 0; r < numRegions; r++ {
-		tm[p] = regElevation[r]
-		tm[p+1] = regMoisture[r]
+		tm[p] = regElevation.At(r)
+		tm[p+1] = regMoisture.At(r)
 		p += 2
 	}
 
@@ -48,8 +48,8 @@ func (qg *QuadGeometry) setMap(mesh *spheremesh.TriangleMesh, m *Geo) {
 	triMoisture := m.TriMoisture
 	numTriangles := mesh.NumTriangles
 	for t := 0; t < numTriangles; t++ {
-		tm[p] = triElevation[t]
-		tm[p+1] = triMoisture[t]
+		tm[p] = triElevation.At(t)
+		tm[p+1] = triMoisture.At(t)
 		p += 2
 	}
 
@@ -69,8 +69,8 @@ func (qg *QuadGeometry) setMap(mesh *spheremesh.TriangleMesh, m *Geo) {
 		// a quadrilateral. This is usually a nuisance but in this
 		// case it's a feature. See the explanation here
 		// https://www.redblobgames.com/x/1725-procedural-elevation/#rendering
-		coast := regElevation[r1] < 0.0 || regElevation[r2] < 0.0
-		if coast || sideFlow[side] > 0 || sideFlow[oppositeSide] > 0 {
+		coast := regElevation.At(r1) < 0.0 || regElevation.At(r2) < 0.0
+		if coast || sideFlow.At(side) > 0 || sideFlow.At(oppositeSide) > 0 {
 			// It's a coastal or river edge, forming a valley
 			idxs[i] = r1
 			idxs[i+1] = numRegions + t2
@@ -1,5 +1,60 @@
 package geo
 
+import "github.com/Flokey82/genworldvoronoi/noise"
+
+// NoiseLayerPresets are the available presets for GeoConfig.NoiseLayers.
+var NoiseLayerPresets = map[string]func() []noise.Layer{
+	"warped": NoiseLayersWarped,
+	"ridged": NoiseLayersRidged,
+}
+
+// NoiseLayersWarped returns noise layers with domain warped fBm noise, which
+// results in more irregular, swirly coastlines than the default noise.
+func NoiseLayersWarped() []noise.Layer {
+	return []noise.Layer{{
+		Type:        noise.LayerTypeFBM,
+		Blend:       noise.BlendMultiply,
+		Octaves:     6,
+		Persistence: 2.0 / 3.0,
+		Frequency:   1,
+		Amplitude:   1,
+		Warp:        0.4,
+	}}
+}
+
+// NoiseLayersRidged returns noise layers with warped coastlines, sharp
+// ridgelines on land and uneven continental shelves.
+func NoiseLayersRidged() []noise.Layer {
+	return []noise.Layer{{
+		// Base noise (see NoiseLayersWarped).
+		Type:        noise.LayerTypeFBM,
+		Blend:       noise.BlendMultiply,
+		Octaves:     6,
+		Persistence: 2.0 / 3.0,
+		Frequency:   1,
+		Amplitude:   1,
+		Warp:        0.3,
+	}, {
+		// Ridgelines on land.
+		Type:        noise.LayerTypeRidged,
+		Blend:       noise.BlendMultiply,
+		Mask:        noise.MaskPositive,
+		Octaves:     5,
+		Persistence: 0.5,
+		Frequency:   2,
+		Amplitude:   0.6,
+	}, {
+		// Shelves and basins in the ocean.
+		Type:        noise.LayerTypeBillow,
+		Blend:       noise.BlendMultiply,
+		Mask:        noise.MaskNegative,
+		Octaves:     4,
+		Persistence: 0.5,
+		Frequency:   3,
+		Amplitude:   0.5,
+	}}
+}
+
 // fbmNoiseCustom returns a function that returns the 'fractal bownian motion'-ish noise value for a given region.
 func (m *Geo) fbmNoiseCustom(octaves int, persistence, mx, my, mz, dx, dy, dz float64) func(int) float64 {
 	// https://thebookofshaders.com/13/
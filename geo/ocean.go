@@ -0,0 +1,309 @@
+package geo
+
+import (
+	"math"
+
+	"github.com/Flokey82/genworldvoronoi/various"
+)
+
+// Salinity (in psu) of the ocean.
+const (
+	oceanBaseSalinity = 35.0 // Average salinity of the ocean
+	oceanMinSalinity  = 0.0  // Fresh water
+	oceanMaxSalinity  = 42.0 // Enclosed seas with high evaporation
+)
+
+// assignOceanModel extends the surface ocean currents with salinity, a coarse
+// thermohaline (deep water) return flow and coastal upwelling.
+//
+// Upwelling zones bring cold, nutrient rich water to the surface, which
+// results in rich fishing grounds and cool, foggy but arid coastlines.
+func (m *Geo) assignOceanModel() {
+	m.assignOceanSalinity()
+	distSink := m.assignDeepOceanCurrents()
+	m.assignOceanUpwelling(distSink)
+	m.assignCoastalFog()
+	m.placeFish()
+}
+
+// assignOceanSalinity calculates the salinity of all ocean regions.
+//
+// Evaporation (based on the ocean temperature) increases the salinity while
+// rainfall and river inflow at river mouths decrease it. The salinity is then
+// transported along the ocean currents.
+func (m *Geo) assignOceanSalinity() {
+	// Calculate the fresh water inflow from rivers into the ocean.
+	freshWater := make([]float64, m.SphereMesh.NumRegions)
+	for r, dh := range m.Downhill {
+		if m.Elevation.At(r) > 0 && dh >= 0 && m.Elevation.At(dh) <= 0 {
+			freshWater[dh] += m.Flux.At(r)
+		}
+	}
+	_, maxFresh := minMax(freshWater)
+	if maxFresh == 0 {
+		maxFresh = 1
+	}
+
+	salinity := make([]float64, m.SphereMesh.NumRegions)
+	for r := 0; r < m.SphereMesh.NumRegions; r++ {
+		if m.Elevation.At(r) > 0 {
+			continue
+		}
+		// Warm water evaporates more, leaving the salt behind.
+		evaporation := math.Max(0, m.OceanTemperature.At(r)) / 30
+		salinity[r] = oceanBaseSalinity + 4*(evaporation-m.Rainfall.At(r)) - 10*math.Sqrt(freshWater[r]/maxFresh)
+	}
+
+	// Mix the salinity with the neighbors and transport it along the currents.
+	const numSteps = 5
+	outRegs := make([]int, 0, 8)
+	newSalinity := make([]float64, m.SphereMesh.NumRegions)
+	for step := 0; step < numSteps; step++ {
+		for r := range salinity {
+			if m.Elevation.At(r) > 0 {
+				continue
+			}
+			sum := salinity[r]
+			count := 1.0
+			for _, nb := range m.SphereMesh.R_circulate_r(outRegs, r) {
+				if m.Elevation.At(nb) <= 0 {
+					sum += salinity[nb]
+					count++
+				}
+			}
+			newSalinity[r] = 0.5*salinity[r] + 0.25*sum/count
+
+			// Pull in the salinity from upstream.
+			if pr := m.getPreviousNeighbor(outRegs, r, m.RegionToOceanVec[r]); pr >= 0 && m.Elevation.At(pr) <= 0 {
+				newSalinity[r] += 0.25 * salinity[pr]
+			} else {
+				newSalinity[r] += 0.25 * salinity[r]
+			}
+		}
+		salinity, newSalinity = newSalinity, salinity
+	}
+
+	for r := range salinity {
+		salinity[r] = math.Max(oceanMinSalinity, math.Min(oceanMaxSalinity, salinity[r]))
+	}
+	m.OceanSalinity.SetValues(salinity)
+}
+
+// assignDeepOceanCurrents calculates a coarse thermohaline return flow.
+//
+// Cold and salty (dense) water sinks in the high latitudes and flows along
+// the ocean floor away from these downwelling zones until it eventually
+// resurfaces far away from its origin.
+//
+// The returned slice contains the distance of each ocean region from the
+// nearest downwelling zone (or nil if there are none).
+func (m *Geo) assignDeepOceanCurrents() []float64 {
+	// Calculate the density of the ocean water (roughly).
+	// The colder and saltier the water, the denser it is.
+	density := make([]float64, m.SphereMesh.NumRegions)
+	var oceanDensity []float64
+	stopRegs := make(map[int]bool)
+	for r := 0; r < m.SphereMesh.NumRegions; r++ {
+		if m.Elevation.At(r) > 0 {
+			stopRegs[r] = true
+			continue
+		}
+		density[r] = m.OceanSalinity.At(r) - 0.2*m.OceanTemperature.At(r)
+		oceanDensity = append(oceanDensity, density[r])
+	}
+	if len(oceanDensity) == 0 {
+		return nil
+	}
+
+	// Deep water forms in the high latitudes where the water is densest.
+	minDensity, maxDensity := minMax(oceanDensity)
+	threshold := minDensity + 0.8*(maxDensity-minDensity)
+	var sinkRegs []int
+	for r, d := range density {
+		if !stopRegs[r] && math.Abs(m.LatLon[r][0]) > 50 && d >= threshold {
+			sinkRegs = append(sinkRegs, r)
+		}
+	}
+	if len(sinkRegs) == 0 {
+		return nil
+	}
+
+	// The deep water flows away from the downwelling zones.
+	distSink := m.AssignDistanceField(sinkRegs, stopRegs)
+	outRegs := make([]int, 0, 8)
+	deepVec := make([][2]float64, m.SphereMesh.NumRegions)
+	for r, d := range distSink {
+		if math.IsInf(d, 0) {
+			continue
+		}
+		bestR := -1
+		bestDist := d
+		for _, nb := range m.SphereMesh.R_circulate_r(outRegs, r) {
+			if !math.IsInf(distSink[nb], 0) && distSink[nb] > bestDist {
+				bestDist = distSink[nb]
+				bestR = nb
+			}
+		}
+		if bestR < 0 {
+			continue
+		}
+		rLatLon := m.LatLon[r]
+		nbLatLon := m.LatLon[bestR]
+		vec := various.CalcVecFromLatLong(rLatLon[0], rLatLon[1], nbLatLon[0], nbLatLon[1])
+		deepVec[r] = normalizeOrZero2(vec)
+	}
+	m.RegionToDeepOceanVec = deepVec
+	return distSink
+}
+
+// assignOceanUpwelling calculates the upwelling intensity of all ocean regions.
+//
+// Coastal upwelling occurs where the surface water is pushed away from the
+// shore, either by the ocean currents or by Ekman transport of the wind
+// (90° to the right of the wind in the northern hemisphere, to the left in
+// the southern hemisphere). The deep water return flow also resurfaces
+// (very weakly) in the open ocean far away from the downwelling zones, which
+// are given by the distance field 'distSink'.
+func (m *Geo) assignOceanUpwelling(distSink []float64) {
+	upwelling := make([]float64, m.SphereMesh.NumRegions)
+	outRegs := make([]int, 0, 8)
+	for r := 0; r < m.SphereMesh.NumRegions; r++ {
+		if m.Elevation.At(r) > 0 {
+			continue
+		}
+
+		// Calculate the offshore direction by summing up the vectors pointing
+		// from all neighboring land regions to this region.
+		var offshore [2]float64
+		rLatLon := m.LatLon[r]
+		for _, nb := range m.SphereMesh.R_circulate_r(outRegs, r) {
+			if m.Elevation.At(nb) <= 0 {
+				continue
+			}
+			nbLatLon := m.LatLon[nb]
+			vec := various.CalcVecFromLatLong(nbLatLon[0], nbLatLon[1], rLatLon[0], rLatLon[1])
+			offshore = various.Add2(offshore, normalizeOrZero2(vec))
+		}
+		if various.Len2(offshore) == 0 {
+			continue
+		}
+
+		// Ekman transport deflects the surface water relative to the wind.
+		ekmanAngle := -math.Pi / 2
+		if rLatLon[0] < 0 {
+			ekmanAngle = math.Pi / 2
+		}
+		ekman := various.Rotate2(normalizeOrZero2(m.RegionToWindVec[r]), ekmanAngle)
+		surface := various.Add2(normalizeOrZero2(m.RegionToOceanVec[r]), ekman)
+		if various.Len2(surface) == 0 {
+			continue
+		}
+		upwelling[r] = math.Max(0, various.Dot2(various.Normalize2(surface), various.Normalize2(offshore)))
+	}
+
+	// Spread the upwelling a bit out to sea.
+	newUpwelling := make([]float64, m.SphereMesh.NumRegions)
+	copy(newUpwelling, upwelling)
+	for r, up := range upwelling {
+		if up == 0 {
+			continue
+		}
+		for _, nb := range m.SphereMesh.R_circulate_r(outRegs, r) {
+			if m.Elevation.At(nb) <= 0 {
+				newUpwelling[nb] = math.Max(newUpwelling[nb], up/2)
+			}
+		}
+	}
+
+	// Add the resurfacing deep water far away from the downwelling zones.
+	if distSink != nil {
+		var maxDist float64
+		for _, d := range distSink {
+			if !math.IsInf(d, 0) {
+				maxDist = math.Max(maxDist, d)
+			}
+		}
+		if maxDist > 0 {
+			for r, d := range distSink {
+				if !math.IsInf(d, 0) {
+					newUpwelling[r] = math.Min(1, newUpwelling[r]+0.2*d/maxDist)
+				}
+			}
+		}
+	}
+
+	// Upwelling brings up cold water from the depths.
+	for r, up := range newUpwelling {
+		m.OceanTemperature.Add(r, -5*up)
+	}
+	m.OceanUpwelling.SetValues(newUpwelling)
+}
+
+// assignCoastalFog calculates the fog frequency of coastal land regions next
+// to upwelling zones.
+//
+// The cold water cools the air above it, which results in frequent fog but
+// suppresses rainfall, so we also reduce the moisture of the affected regions.
+func (m *Geo) assignCoastalFog() {
+	fog := make([]float64, m.SphereMesh.NumRegions)
+	outRegs := make([]int, 0, 8)
+	for r := 0; r < m.SphereMesh.NumRegions; r++ {
+		if m.Elevation.At(r) <= 0 {
+			continue
+		}
+		for _, nb := range m.SphereMesh.R_circulate_r(outRegs, r) {
+			if m.Elevation.At(nb) <= 0 {
+				fog[r] = math.Max(fog[r], m.OceanUpwelling.At(nb))
+			}
+		}
+	}
+
+	// The fog creeps a bit further inland.
+	newFog := make([]float64, m.SphereMesh.NumRegions)
+	copy(newFog, fog)
+	for r, f := range fog {
+		if f == 0 {
+			continue
+		}
+		for _, nb := range m.SphereMesh.R_circulate_r(outRegs, r) {
+			if m.Elevation.At(nb) > 0 {
+				newFog[nb] = math.Max(newFog[nb], f/2)
+			}
+		}
+	}
+
+	// Apply the aridity.
+	for r, f := range newFog {
+		m.Moisture.Set(r, m.Moisture.At(r)*(1-0.5*f))
+	}
+	m.CoastalFog.SetValues(newFog)
+}
+
+// placeFish marks coastal land regions next to nutrient rich upwelling zones
+// as having access to rich fishing grounds.
+func (m *Geo) placeFish() {
+	const minUpwelling = 0.3
+	outRegs := make([]int, 0, 8)
+	for r := 0; r < m.SphereMesh.NumRegions; r++ {
+		// Reset the flag in case the climate (or coastline) has changed.
+		m.Various[r] &^= ResVarFish
+		if m.Elevation.At(r) <= 0 {
+			continue
+		}
+		for _, nb := range m.SphereMesh.R_circulate_r(outRegs, r) {
+			if m.Elevation.At(nb) <= 0 && m.OceanUpwelling.At(nb) >= minUpwelling {
+				m.Various[r] |= ResVarFish
+				break
+			}
+		}
+	}
+}
+
+// normalizeOrZero2 returns the normalized vector or a zero vector if the
+// given vector has no length.
+func normalizeOrZero2(v [2]float64) [2]float64 {
+	if various.Len2(v) == 0 {
+		return [2]float64{}
+	}
+	return various.Normalize2(v)
+}
@@ -0,0 +1,249 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Flokey82/genworldvoronoi/various"
+)
+
+// Glacial cycle parameters.
+//
+// The glacial cycles are driven by the Milankovitch cycles, the variations in
+// eccentricity (~100k years), obliquity (~41k years) and precession (~23k
+// years) of the orbit of the planet. During the last glacial maximum, the
+// global mean temperature was about 6 °C lower and the sea level about 120 m
+// lower than today.
+const (
+	glacialPeriodEccentricity = 100000.0 // in years
+	glacialPeriodObliquity    = 41000.0  // in years
+	glacialPeriodPrecession   = 23000.0  // in years
+	glacialMaxTempDrop        = 6.0      // in °C
+	glacialMaxSeaLevelDrop    = 120.0    // in m
+)
+
+// ClimateState is the global climate at a given point in time.
+type ClimateState struct {
+	Year              int64   // Year relative to the present (negative for the past)
+	GlacialIndex      float64 // 0.0 for interglacial to 1.0 for a glacial maximum
+	TemperatureOffset float64 // Global temperature offset (°C) relative to the present
+	SeaLevelOffset    float64 // Sea level change (m) relative to the present
+}
+
+// GetPaleoclimate returns the (approximate) global climate at the given year
+// relative to the present.
+func GetPaleoclimate(year int64) ClimateState {
+	// The glacial index is a weighted sum of the orbital cycles, where each
+	// cycle is 0.0 at the present (interglacial) and 1.0 at its peak.
+	cycle := func(period float64) float64 {
+		return 0.5 - 0.5*math.Cos(2*math.Pi*float64(year)/period)
+	}
+	idx := 0.6*cycle(glacialPeriodEccentricity) +
+		0.25*cycle(glacialPeriodObliquity) +
+		0.15*cycle(glacialPeriodPrecession)
+	return ClimateState{
+		Year:              year,
+		GlacialIndex:      idx,
+		TemperatureOffset: -glacialMaxTempDrop * idx,
+		SeaLevelOffset:    -glacialMaxSeaLevelDrop * idx,
+	}
+}
+
+// GetPaleoclimateTimeline returns the global climate from 'startYear' to
+// 'endYear' (relative to the present) in steps of 'step' years.
+func GetPaleoclimateTimeline(startYear, endYear, step int64) []ClimateState {
+	if step <= 0 {
+		return nil
+	}
+	var timeline []ClimateState
+	for year := startYear; year <= endYear; year += step {
+		timeline = append(timeline, GetPaleoclimate(year))
+	}
+	return timeline
+}
+
+// ApplyClimate changes the global temperature offset (°C) and the sea level
+// (m, relative to the generated sea level) and re-runs the climate chain.
+//
+// Regions that are flooded or emerge from the sea (land bridges) are reflected
+// in the updated landmasses, waterbodies and biome regions.
+//
+// NOTE: Rivers are re-routed, but the terrain is not eroded again, so that
+// going back to the original climate restores the original map (mostly).
+func (m *Geo) ApplyClimate(tempOffset, seaLevel float64) {
+	m.shiftSeaLevel(seaLevel - m.SeaLevelOffset)
+	m.SeaLevelOffset = seaLevel
+	m.TemperatureOffset = tempOffset
+	m.regenerateClimate()
+}
+
+// ApplyClimateState applies the given climate state (see ApplyClimate).
+func (m *Geo) ApplyClimateState(state ClimateState) {
+	m.ApplyClimate(state.TemperatureOffset, state.SeaLevelOffset)
+}
+
+// SimulatePaleoclimate applies the climate of each year from 'startYear' to
+// 'endYear' (relative to the present) in steps of 'step' years and calls 'fn'
+// after each step, which allows the caller to record the changes in the
+// climate, biomes or landmasses over time. The original climate is restored
+// afterwards.
+//
+// NOTE: Each step re-runs the full climate chain, so the steps should be
+// in the order of millennia.
+func (m *Geo) SimulatePaleoclimate(startYear, endYear, step int64, fn func(state ClimateState)) {
+	origTemp, origSeaLevel := m.TemperatureOffset, m.SeaLevelOffset
+	for _, state := range GetPaleoclimateTimeline(startYear, endYear, step) {
+		start := various.StartStage()
+		m.ApplyClimateState(state)
+		various.LogStage(fmt.Sprintf("paleoclimate of year %d", state.Year), start)
+		if fn != nil {
+			fn(state)
+		}
+	}
+	m.ApplyClimate(origTemp, origSeaLevel)
+}
+
+// GetLandBridges returns all regions that are currently below sea level but
+// would be land if the sea level changed by 'seaLevel' (m), for example
+// during an ice age.
+func (m *Geo) GetLandBridges(seaLevel float64) []int {
+	isLand := m.GetRegIsLandAtSeaLevelFunc()
+	var regs []int
+	for r := 0; r < m.Elevation.Len(); r++ {
+		if m.Elevation.At(r) <= 0 && isLand(r, seaLevel) {
+			regs = append(regs, r)
+		}
+	}
+	return regs
+}
+
+// GetRegIsLandAtSeaLevelFunc returns a function that returns true if the
+// given region would be above sea level if the sea level changed by
+// 'seaLevel' (m).
+func (m *Geo) GetRegIsLandAtSeaLevelFunc() func(r int, seaLevel float64) bool {
+	scale := m.metersToElevation(1)
+	return func(r int, seaLevel float64) bool {
+		return m.Elevation.At(r) > seaLevel*scale
+	}
+}
+
+// shiftSeaLevel raises (or lowers) the sea level by the given change in m by
+// shifting the elevation of all regions.
+func (m *Geo) shiftSeaLevel(change float64) {
+	if change == 0 {
+		return
+	}
+	delta := m.metersToElevation(change)
+	for r := 0; r < m.Elevation.Len(); r++ {
+		m.Elevation.Add(r, -delta)
+	}
+}
+
+// metersToElevation converts the given height in m to the elevation scale
+// of the map.
+//
+// NOTE: The scale is fixed once the elevation has been generated (see
+// assignElevationScale), so shifting the sea level (which changes the
+// maximum elevation) back and forth restores the original elevation.
+func (m *Geo) metersToElevation(meters float64) float64 {
+	if m.elevationPerMeter > 0 {
+		return meters * m.elevationPerMeter
+	}
+	return meters * m.getElevationScale()
+}
+
+// assignElevationScale fixes the scale used by metersToElevation based on
+// the generated elevation.
+func (m *Geo) assignElevationScale() {
+	m.elevationPerMeter = m.getElevationScale()
+}
+
+// getElevationScale returns the elevation units per m based on the current
+// maximum elevation.
+func (m *Geo) getElevationScale() float64 {
+	_, maxElev := m.Elevation.MinMax()
+	if maxElev <= 0 {
+		maxElev = 1
+	}
+	return maxElev / MaxAltitudeFactor
+}
+
+// regenerateClimate re-runs the climate chain (wind, rainfall, rivers,
+// temperature, ocean, coasts and biomes) after a change in the sea level or
+// the global temperature, and refreshes everything that depends on it
+// (forests and deposits, features, storm tracks and wildfire fuel).
+// Afterwards OnClimateChanged is called (if set).
+//
+// NOTE: The deposits are generated from scratch, so any extraction (see
+// Deposit.Extract) is lost. The features are re-identified, but keep their
+// names if they still exist (see reassignFeatures).
+func (m *Geo) regenerateClimate() {
+	m.assignWindVectors()
+	m.assignRainfallBasic()
+	m.AssignDownhill(true)
+	m.assignFlux(true)
+	m.assignWaterbodies()
+	m.assignTriValues()
+	m.assignLandmasses()
+	m.assignOceanCurrents3()
+	m.initRegionWaterTemperature()
+	m.initRegionAirTemperature()
+	m.transportRegionWaterTemperature()
+	m.assignRegionAirTemperature()
+	m.assignOceanModel()
+	m.assignCoastTypes()
+	m.placeForests()
+	m.assignDeposits()
+	m.assignBiomeRegions()
+	m.reassignFeatures()
+	m.assignStormTracks()
+	m.assignFuel()
+	if m.OnClimateChanged != nil {
+		m.OnClimateChanged()
+	}
+}
+
+// reassignFeatures re-identifies the geographic features after a change in
+// the climate. Each new feature keeps the name of the named old feature of
+// the same type it shares the most regions with, so seas and islands that
+// merely changed their shape keep their names.
+func (m *Geo) reassignFeatures() {
+	oldFeatures := m.Features
+	m.assignFeatures()
+
+	// Map the regions to the named old features.
+	regToOld := make(map[int][]*Feature)
+	for _, f := range oldFeatures {
+		if f.Name == "" {
+			continue
+		}
+		for _, r := range f.Regions {
+			regToOld[r] = append(regToOld[r], f)
+		}
+	}
+
+	// Each old name is only passed on to a single new feature.
+	used := make(map[*Feature]bool)
+	for _, f := range m.Features {
+		overlap := make(map[*Feature]int)
+		for _, r := range f.Regions {
+			for _, o := range regToOld[r] {
+				if o.Type == f.Type && !used[o] {
+					overlap[o]++
+				}
+			}
+		}
+
+		// NOTE: Old features are iterated in order to keep the result deterministic.
+		var best *Feature
+		for _, o := range oldFeatures {
+			if overlap[o] > 0 && (best == nil || overlap[o] > overlap[best]) {
+				best = o
+			}
+		}
+		if best != nil {
+			f.Name = best.Name
+			used[best] = true
+		}
+	}
+}
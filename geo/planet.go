@@ -0,0 +1,143 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// PlanetConfig holds the physical properties of the planet, which influence
+// the insolation, seasons, temperature, calendar and distances.
+type PlanetConfig struct {
+	Radius           float64 // Radius of the planet in km
+	AxialTilt        float64 // Axial tilt (obliquity) in degrees
+	Eccentricity     float64 // Orbital eccentricity (0.0 is a perfect circle)
+	DaysPerYear      int     // Number of (local) days per year
+	HoursPerDay      float64 // Number of hours per (local) day
+	SeaLevelFraction float64 // Fraction of the surface below sea level (0.0 to keep the generated sea level)
+}
+
+// Earth constants.
+const (
+	earthRadius       = 6371.0 // in km
+	earthAxialTilt    = 23.44  // in degrees
+	earthEccentricity = 0.0167
+	earthDaysPerYear  = 365
+	earthHoursPerDay  = 24.0
+)
+
+// NewPlanetConfig returns a new planet config with earth-like defaults.
+func NewPlanetConfig() *PlanetConfig {
+	return &PlanetConfig{
+		Radius:           earthRadius,
+		AxialTilt:        earthAxialTilt,
+		Eccentricity:     earthEccentricity,
+		DaysPerYear:      earthDaysPerYear,
+		HoursPerDay:      earthHoursPerDay,
+		SeaLevelFraction: 0,
+	}
+}
+
+// validate fills in the earth defaults for all properties that are required
+// to be positive (radius, days per year and hours per day) but are not, so a
+// partially filled config can be used. An error is returned if the
+// eccentricity or the sea level fraction are out of range.
+func (p *PlanetConfig) validate() error {
+	if p.Radius <= 0 {
+		p.Radius = earthRadius
+	}
+	if p.DaysPerYear <= 0 {
+		p.DaysPerYear = earthDaysPerYear
+	}
+	if p.HoursPerDay <= 0 {
+		p.HoursPerDay = earthHoursPerDay
+	}
+	if p.Eccentricity < 0 || p.Eccentricity >= 1 {
+		return fmt.Errorf("invalid eccentricity %v, expected a value between 0 and 1", p.Eccentricity)
+	}
+	if p.SeaLevelFraction < 0 || p.SeaLevelFraction >= 1 {
+		return fmt.Errorf("invalid sea level fraction %v, expected a value between 0 and 1", p.SeaLevelFraction)
+	}
+	return nil
+}
+
+// earthPlanet is used by functions that do not have access to a planet config.
+var earthPlanet = NewPlanetConfig()
+
+// Circumference returns the circumference of the planet in km.
+func (p *PlanetConfig) Circumference() float64 {
+	return 2 * math.Pi * p.Radius
+}
+
+// SurfaceArea returns the surface area of the planet in km².
+func (p *PlanetConfig) SurfaceArea() float64 {
+	return 4 * math.Pi * p.Radius * p.Radius
+}
+
+// DistToKm converts a distance on the unit sphere (in radians) to km.
+func (p *PlanetConfig) DistToKm(dist float64) float64 {
+	return dist * p.Radius
+}
+
+// AreaToKm2 converts an area on the unit sphere to km².
+func (p *PlanetConfig) AreaToKm2(area float64) float64 {
+	return area * p.Radius * p.Radius
+}
+
+// toEarthDayOfYear converts the given day of the year to the equivalent day
+// of the year on earth, which allows us to use earth based constants like the
+// day of the equinoxes and solstices.
+func (p *PlanetConfig) toEarthDayOfYear(dayOfYear int) int {
+	if p.DaysPerYear == earthDaysPerYear {
+		return dayOfYear
+	}
+	return dayOfYear * earthDaysPerYear / p.DaysPerYear
+}
+
+// fractionalYear returns the progress of the year in radians for the given
+// day of the year and hour of the day.
+func (p *PlanetConfig) fractionalYear(dayOfYear int, hour float64) float64 {
+	return (2.0 * math.Pi / float64(p.DaysPerYear)) * (float64(dayOfYear) - 1.0 + (hour / p.HoursPerDay))
+}
+
+// tiltFactor returns the ratio of the axial tilt of the planet compared to
+// the axial tilt of earth, which we use to scale earth based approximations.
+func (p *PlanetConfig) tiltFactor() float64 {
+	return p.AxialTilt / earthAxialTilt
+}
+
+// adjustSeaLevel shifts the elevation of all regions so that the given
+// fraction of the surface is below (or at) sea level.
+//
+// NOTE: Since the regions are not uniform in size (see makeAdaptiveMesh), we
+// sum up the area of the regions instead of counting them.
+func (m *Geo) adjustSeaLevel(fraction float64) {
+	if fraction <= 0 || fraction >= 1 {
+		return
+	}
+	areas := m.GetRegAreas()
+	var totalArea float64
+	sorted := make([]int, m.Elevation.Len())
+	for r := range sorted {
+		sorted[r] = r
+		totalArea += areas[r]
+	}
+	sort.Slice(sorted, func(a, b int) bool {
+		return m.Elevation.At(sorted[a]) < m.Elevation.At(sorted[b])
+	})
+
+	// Find the elevation at which the given fraction of the surface is
+	// below (or at) sea level.
+	seaLevel := m.Elevation.At(sorted[len(sorted)-1])
+	var area float64
+	for _, r := range sorted {
+		area += areas[r]
+		if area >= fraction*totalArea {
+			seaLevel = m.Elevation.At(r)
+			break
+		}
+	}
+	for r := 0; r < m.Elevation.Len(); r++ {
+		m.Elevation.Add(r, -seaLevel)
+	}
+}
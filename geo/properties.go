@@ -6,41 +6,56 @@ import (
 	"github.com/Flokey82/genworldvoronoi/various"
 )
 
+// regPropMaxIsland is the maximum area of a landmass in km² for its regions
+// to be considered on an island (see RegProperty.OnIsland).
+const regPropMaxIsland = 20000.0
+
 type RegProperty struct {
 	ID                  int
 	Elevation           float64 // 0.0-1.0
 	Steepness           float64 // 0.0-1.0
 	Biome               int     // biome of the region
-	DistanceToCoast     float64 // graph distance to the nearest coast
-	DistanceToMountain  float64 // graph distance to the nearest mountain
-	DistanceToRiver     float64 // graph distance to the nearest river
-	DistanceToVolcano   float64 // graph distance to the nearest volcano
-	DistanceToFaultline float64 // graph distance to the nearest faultline
+	DistanceToCoast     float64 // distance to the nearest coast in km
+	DistanceToMountain  float64 // distance to the nearest mountain in km
+	DistanceToRiver     float64 // distance to the nearest river in km
+	DistanceToVolcano   float64 // distance to the nearest volcano in km
+	DistanceToFaultline float64 // distance to the nearest faultline in km
 	Temperature         float64 // in °C
 	Rainfall            float64 // in dm
 	Danger              GeoDisasterChance
-	HasWaterfall        bool // true if the region has a waterfall
-	IsValley            bool // true if the region is a valley
-	OnIsland            bool // true if the region is on an island
+	HasWaterfall        bool           // true if the region has a waterfall
+	IsValley            bool           // true if the region is a valley
+	OnIsland            bool           // true if the region is on an island
+	CoastType           int            // coast type of the region (CoastTypeNone if not coastal)
+	Lithology           int            // bedrock type of the region
+	Layers              map[string]any // values of the custom data layers (see RegisterLayer)
 }
 
+// Distance thresholds (in km) used to describe the proximity of a region
+// to geographic features (see RegProperty).
+const (
+	DistanceAdjacent = 50.0  // Features closer than this are adjacent to the region
+	DistanceNearby   = 100.0 // Features closer than this are nearby
+)
+
 // GetRegPropertyFunc returns a function that returns the properties of a region.
 // NOTE: This is probably a very greedy function.
 func (m *Geo) GetRegPropertyFunc() func(int) RegProperty {
-	// TODO: Add chance of tropical storms, wildfires, etc.
+	// TODO: Add chance of wildfires, etc.
 	disasterFunc := m.GetGeoDisasterFunc()
 	steepness := m.GetSteepness()
 	inlandValleyFunc := m.GetFitnessInlandValleys()
 	biomeFunc := m.GetRegWhittakerModBiomeFunc()
-	_, maxElev := minMax(m.Elevation)
+	_, maxElev := m.Elevation.MinMax()
+	landmassArea := getAreaByID(m.Landmasses, m.GetRegAreas())
 	var oceanRegs, volcanoRegs, riverRegs, faultlineRegs []int
 	stopOcean := make(map[int]bool)
 	for r := 0; r < m.SphereMesh.NumRegions; r++ {
-		if m.Elevation[r] <= 0 {
+		if m.Elevation.At(r) <= 0 {
 			oceanRegs = append(oceanRegs, r)
 			stopOcean[r] = true
 		}
-		if m.RegionIsVolcano[r] {
+		if m.RegionIsVolcano.Has(r) {
 			volcanoRegs = append(volcanoRegs, r)
 		}
 		if m.IsRegBigRiver(r) {
@@ -50,11 +65,26 @@ func (m *Geo) GetRegPropertyFunc() func(int) RegProperty {
 			faultlineRegs = append(faultlineRegs, r)
 		}
 	}
-	distOcean := m.AssignDistanceField(oceanRegs, m.RegionIsMountain)
-	distMountain := m.AssignDistanceField(m.Mountain_r, stopOcean)
-	distVolcano := m.AssignDistanceField(volcanoRegs, stopOcean)
-	distRiver := m.AssignDistanceField(riverRegs, stopOcean)
-	distFaultline := m.AssignDistanceField(faultlineRegs, stopOcean)
+
+	// Calculate the distance fields in parallel.
+	isOcean := func(r int) bool { return stopOcean[r] }
+	distTasks := []struct {
+		seedRegs []int
+		isStop   func(r int) bool
+	}{
+		{oceanRegs, m.RegionIsMountain.Has},
+		{m.Mountain_r, isOcean},
+		{volcanoRegs, isOcean},
+		{riverRegs, isOcean},
+		{faultlineRegs, isOcean},
+	}
+	dists := make([][]float64, len(distTasks))
+	m.GetExecutor().ParallelFor(len(distTasks), func(start, end int) {
+		for i := start; i < end; i++ {
+			dists[i] = m.AssignGeodesicDistanceFieldFunc(distTasks[i].seedRegs, distTasks[i].isStop, nil).Distance
+		}
+	})
+	distOcean, distMountain, distVolcano, distRiver, distFaultline := dists[0], dists[1], dists[2], dists[3], dists[4]
 	return func(id int) RegProperty {
 		// Make sure that we do not have more than 2 neighbours that has a lower elevation.
 		// ... because a valley should be surrounded by mountains.
@@ -62,7 +92,7 @@ func (m *Geo) GetRegPropertyFunc() func(int) RegProperty {
 		if isValley {
 			var count int
 			for _, n := range m.GetRegNeighbors(id) {
-				if m.Elevation[n] > m.Elevation[id] {
+				if m.Elevation.At(n) > m.Elevation.At(id) {
 					continue
 				}
 				count++
@@ -75,7 +105,7 @@ func (m *Geo) GetRegPropertyFunc() func(int) RegProperty {
 
 		return RegProperty{
 			ID:                  id,
-			Elevation:           m.Elevation[id],
+			Elevation:           m.Elevation.At(id),
 			Steepness:           steepness[id],
 			Biome:               biomeFunc(id),
 			DistanceToCoast:     distOcean[id],
@@ -84,11 +114,14 @@ func (m *Geo) GetRegPropertyFunc() func(int) RegProperty {
 			DistanceToVolcano:   distVolcano[id],
 			DistanceToFaultline: distFaultline[id],
 			Temperature:         m.GetRegTemperature(id, maxElev),
-			Rainfall:            m.Rainfall[id],
+			Rainfall:            m.Rainfall.At(id),
 			Danger:              disasterFunc(id),
-			HasWaterfall:        m.RegionIsWaterfall[id],
+			HasWaterfall:        m.RegionIsWaterfall.Has(id),
 			IsValley:            isValley,
-			OnIsland:            m.LandmassSize[m.Landmasses[id]] < 15, // TODO: This should use actual geographical area.
+			OnIsland:            m.Landmasses[id] >= 0 && m.AreaToKm2(landmassArea[m.Landmasses[id]]) < regPropMaxIsland,
+			CoastType:           m.RegionToCoastType[id],
+			Lithology:           m.RegionToLithology[id],
+			Layers:              m.GetRegLayerValues(id),
 		}
 	}
 }
@@ -101,29 +134,49 @@ const (
 	FeatureTypeGulf      = "gulf"
 	FeatureTypeIsle      = "isle"
 	FeatureTypeContinent = "continent"
+
+	// Named geographic features (see Geo.Features).
+	FeatureTypeMountainRange = "mountain range"
+	FeatureTypeBay           = "bay"
+	FeatureTypeStrait        = "strait"
+	FeatureTypeDesert        = "desert"
+	FeatureTypeForest        = "forest"
+	FeatureTypeSwamp         = "swamp"
 )
 
 // GetRegionFeatureTypeFunc returns a function that returns the feature type of
-// a given region.
+// a given region. Coastal land regions return their coast type (cliff, beach,
+// delta, ... see CoastTypeToString).
+//
+// NOTE: Since the regions are not uniform in size (see makeAdaptiveMesh), the
+// type depends on the area of the waterbody or landmass (as a fraction of the
+// surface) instead of the number of regions.
 func (m *Geo) GetRegionFeatureTypeFunc() func(int) string {
+	areas := m.GetRegAreas()
+	waterbodyArea := getAreaByID(m.Waterbodies, areas)
+	isOnIsle := m.getRegIsOnIsleFunc(areas)
+	surface := 4 * math.Pi
 	return func(i int) string {
 		if i < 0 {
 			return ""
 		}
 		if waterbodyID := m.Waterbodies[i]; waterbodyID >= 0 {
-			switch wbSize := m.WaterbodySize[waterbodyID]; {
-			case wbSize > m.SphereMesh.NumRegions/25:
+			switch wbArea := waterbodyArea[waterbodyID]; {
+			case wbArea > featureMinOcean*surface:
 				return FeatureTypeOcean
-			case wbSize > m.SphereMesh.NumRegions/100:
+			case wbArea > surface/100:
 				return FeatureTypeSea
-			case wbSize > m.SphereMesh.NumRegions/500:
+			case wbArea > surface/500:
 				return FeatureTypeGulf
 			default:
 				return FeatureTypeLake
 			}
 		}
-		if landmassID := m.Landmasses[i]; landmassID >= 0 {
-			if m.LandmassSize[landmassID] < m.SphereMesh.NumRegions/100 {
+		if coastType := m.RegionToCoastType[i]; coastType != CoastTypeNone {
+			return CoastTypeToString(coastType)
+		}
+		if m.Landmasses[i] >= 0 {
+			if isOnIsle(i) {
 				return FeatureTypeIsle
 			}
 			return FeatureTypeContinent
@@ -132,16 +185,42 @@ func (m *Geo) GetRegionFeatureTypeFunc() func(int) string {
 	}
 }
 
+// GetRegIsOnIsleFunc returns a function that returns true if the given region
+// is on an isle, which is a landmass smaller than 1% of the surface.
+func (m *Geo) GetRegIsOnIsleFunc() func(int) bool {
+	return m.getRegIsOnIsleFunc(m.GetRegAreas())
+}
+
+// getRegIsOnIsleFunc is the same as GetRegIsOnIsleFunc, but uses the given
+// areas of all regions (see GetRegAreas).
+func (m *Geo) getRegIsOnIsleFunc(areas []float64) func(int) bool {
+	landmassArea := getAreaByID(m.Landmasses, areas)
+	surface := 4 * math.Pi
+	return func(i int) bool {
+		lm := m.Landmasses[i]
+		return lm >= 0 && landmassArea[lm] < surface/100
+	}
+}
+
+// minHarborQuality is the minimum harbor quality of a coast for it to be
+// usable as a haven.
+const minHarborQuality = 0.2
+
 // GetRegHaven returns the closest neighbor region that is a water cell, which
 // can be used as a haven, and returns the number of water neighbors, indicating
 // the harbor size.
 //
-// If no haven is found, -1 is returned.
+// If no haven is found (or the coast is unsuitable for a harbor), -1 is returned.
 func (m *Geo) GetRegHaven(reg int) (int, int) {
+	// Coasts like cliffs have hardly any suitable landing spots.
+	if m.RegionToCoastType[reg] != CoastTypeNone && m.GetRegHarborQuality(reg) < minHarborQuality {
+		return -1, 0
+	}
+
 	// get all neighbors that are below or at sea level.
 	water := make([]int, 0, 8)
 	for _, nb := range m.GetRegNeighbors(reg) {
-		if m.Elevation[nb] <= 0.0 {
+		if m.Elevation.At(nb) <= 0.0 {
 			water = append(water, nb)
 		}
 	}
@@ -194,7 +273,8 @@ func (m *Geo) GetRegCellTypes() []int {
 	var oceanRegs, landRegs []int
 	stop_land := make(map[int]bool)
 	stop_ocean := make(map[int]bool)
-	for r, elev := range m.Elevation {
+	for r := 0; r < m.Elevation.Len(); r++ {
+		elev := m.Elevation.At(r)
 		if elev <= 0.0 {
 			oceanRegs = append(oceanRegs, r)
 			stop_ocean[r] = true
@@ -209,7 +289,7 @@ func (m *Geo) GetRegCellTypes() []int {
 	cellType := make([]int, m.SphereMesh.NumRegions)
 	for i := range cellType {
 		// Is it water?
-		if m.Elevation[i] <= 0.0 {
+		if m.Elevation.At(i) <= 0.0 {
 			// Figure out if it has a land neighbor.
 			// If so, it is -1 (water near coast)
 			if regDistanceLand[i] <= 1 {
@@ -230,7 +310,7 @@ func (m *Geo) GetRegCellTypes() []int {
 }
 
 func (m *BaseObject) IsRegBelowOrAtSeaLevelOrPool(r int) bool {
-	return m.Elevation[r] <= 0 || m.Waterpool[r] > 0
+	return m.Elevation.At(r) <= 0 || m.Waterpool.At(r) > 0
 }
 
 func (m *BaseObject) IsRegLakeOrWaterBody(r int) bool {
@@ -242,13 +322,13 @@ func (m *BaseObject) IsRegWaterBody(r int) bool {
 }
 
 func (m *BaseObject) IsRegLake(r int) bool {
-	return m.Drainage[r] >= 0 || m.Waterpool[r] > 0
+	return m.Drainage[r] >= 0 || m.Waterpool.At(r) > 0
 }
 
 func (m *BaseObject) IsRegRiver(r int) bool {
-	return m.Flux[r] > m.Rainfall[r]
+	return m.Flux.At(r) > m.Rainfall.At(r)
 }
 
 func (m *BaseObject) IsRegBigRiver(r int) bool {
-	return m.Flux[r] > m.Rainfall[r]*2
+	return m.Flux.At(r) > m.Rainfall.At(r)*2
 }
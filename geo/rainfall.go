@@ -38,7 +38,7 @@ func (m *Geo) assignRainfall(numSteps, transferMode, sortOrder int) {
 	var seaRegs, landRegs []int
 	isSea := make([]bool, m.SphereMesh.NumRegions)
 	for r := 0; r < m.SphereMesh.NumRegions; r++ {
-		if m.Elevation[r] < 0 {
+		if m.Elevation.At(r) < 0 {
 			isSea[r] = true
 			seaRegs = append(seaRegs, r)
 		} else {
@@ -56,7 +56,7 @@ func (m *Geo) assignRainfall(numSteps, transferMode, sortOrder int) {
 		regDistanceSea := m.AssignDistanceField(seaRegs, make(map[int]bool))
 		sort.Slice(distOrderRegs, func(a, b int) bool {
 			if regDistanceSea[distOrderRegs[a]] == regDistanceSea[distOrderRegs[b]] {
-				return m.Elevation[distOrderRegs[a]] < m.Elevation[distOrderRegs[b]]
+				return m.Elevation.At(distOrderRegs[a]) < m.Elevation.At(distOrderRegs[b])
 			}
 			return regDistanceSea[distOrderRegs[a]] < regDistanceSea[distOrderRegs[b]]
 		})
@@ -69,10 +69,10 @@ func (m *Geo) assignRainfall(numSteps, transferMode, sortOrder int) {
 
 	// 1.3. Get wind vector for every region
 	regWindVec := m.RegionToWindVec
-	_, maxH := minMax(m.Elevation)
+	_, maxH := m.Elevation.MinMax()
 
 	calcRainfall := func(r int, humidity float64) float64 {
-		regElev := m.Elevation[r]
+		regElev := m.Elevation.At(r)
 		if regElev < 0 {
 			regElev = 0 // Set to sea-level
 		}
@@ -90,23 +90,25 @@ func (m *Geo) assignRainfall(numSteps, transferMode, sortOrder int) {
 		// 2. Assign initial moisture of 1.0 to all regions below or at sea level or replenish
 		// moisture through evaporation if our moisture is below 0.
 		for _, r := range seaRegs {
-			if m.Moisture[r] < 1.0 {
-				m.Moisture[r] = 1.0
+			if m.Moisture.At(r) < 1.0 {
+				m.Moisture.Set(r, 1.0)
 			}
 			// m.r_rainfall[r] += biomesParam.raininess * m.r_moisture[r]
 		}
 
 		// Rivers should experience some evaporation.
-		for r, fluxval := range m.Flux {
-			if m.Moisture[r] < fluxval && m.Moisture[r] < 1.0 {
-				m.Moisture[r] = 1.0 // TODO: Should depend on available water.
+		for r := 0; r < m.Flux.Len(); r++ {
+			fluxval := m.Flux.At(r)
+			if m.Moisture.At(r) < fluxval && m.Moisture.At(r) < 1.0 {
+				m.Moisture.Set(r, 1.0) // TODO: Should depend on available water.
 			}
 		}
 
 		// Water pools should experience some evaporation.
-		for r, poolval := range m.Waterpool {
-			if poolval > 0 && m.Moisture[r] < 1.0 {
-				m.Moisture[r] = 1.0 // TODO: Should depend on available water.
+		for r := 0; r < m.Waterpool.Len(); r++ {
+			poolval := m.Waterpool.At(r)
+			if poolval > 0 && m.Moisture.At(r) < 1.0 {
+				m.Moisture.Set(r, 1.0) // TODO: Should depend on available water.
 			}
 		}
 		// m.interpolateRainfallMoisture(1)
@@ -150,8 +152,8 @@ func (m *Geo) assignRainfall(numSteps, transferMode, sortOrder int) {
 					if dotV > 0 {
 						// Only positive dot products mean that we lie within 90°, so 'in wind direction'.
 						count++
-						humidity := m.Moisture[nbReg] + m.Moisture[r]*dotV
-						rainfall := m.Rainfall[nbReg] // + biomesParam.raininess*m.r_moisture[r]*dotV
+						humidity := m.Moisture.At(nbReg) + m.Moisture.At(r)*dotV
+						rainfall := m.Rainfall.At(nbReg) // + biomesParam.raininess*m.r_moisture[r]*dotV
 						orographicRainfall := calcRainfall(nbReg, humidity)
 						if orographicRainfall > 0.0 {
 							rainfall += biomesParam.raininess * orographicRainfall
@@ -161,8 +163,8 @@ func (m *Geo) assignRainfall(numSteps, transferMode, sortOrder int) {
 						// WARNING: The humidity calculation is off.
 						// humidity = math.Min(humidity, 1.0)
 						// rainfall = math.Min(rainfall, 1.0)
-						m.Rainfall[nbReg] = rainfall
-						m.Moisture[nbReg] = humidity
+						m.Rainfall.Set(nbReg, rainfall)
+						m.Moisture.Set(nbReg, humidity)
 					}
 				}
 			}
@@ -202,30 +204,30 @@ func (m *Geo) assignRainfall(numSteps, transferMode, sortOrder int) {
 					if dotV > 0 {
 						// Only positive dot products mean that we lie within 90°, so 'in wind direction'.
 						count++
-						sum += m.Moisture[nbReg] * dotV
+						sum += m.Moisture.At(nbReg) * dotV
 					}
 				}
 
 				var humidity, rainfall float64
-				humidity = m.Moisture[r]
+				humidity = m.Moisture.At(r)
 				if count > 0 {
 					// TODO: Calculate max humidity at current altitude, temperature, rain off the rest.
 					// WARNING: The humidity calculation is off.
 					humidity = math.Min(humidity+sum, 1.0) // / float64(count)
 					rainfall = math.Min(rainfall+biomesParam.raininess*sum, 1.0)
 				}
-				if m.Elevation[r] <= 0.0 {
+				if m.Elevation.At(r) <= 0.0 {
 					// evaporation := biomesParam.evaporation * (-m.r_elevation[r])
 					// humidity = evaporation
-					humidity = m.Moisture[r]
+					humidity = m.Moisture.At(r)
 				}
 				orographicRainfall := calcRainfall(r, humidity)
 				if orographicRainfall > 0.0 {
 					rainfall += biomesParam.raininess * orographicRainfall
 					humidity -= orographicRainfall
 				}
-				m.Rainfall[r] = rainfall
-				m.Moisture[r] = humidity
+				m.Rainfall.Set(r, rainfall)
+				m.Moisture.Set(r, humidity)
 			}
 		}
 
@@ -255,9 +257,9 @@ func (m *Geo) assignRainfallBasic() {
 	stepsTransport := 2     // Number of moisture transport steps to perform.
 	stepsInterpolation := 2 // Number of interpolation steps to perform on the moisture and rainfall.
 
-	_, maxFlux := minMax(m.Flux)
-	_, maxPool := minMax(m.Waterpool)
-	minElev, maxElev := minMax(m.Elevation)
+	_, maxFlux := m.Flux.MinMax()
+	_, maxPool := m.Waterpool.MinMax()
+	minElev, maxElev := m.Elevation.MinMax()
 	if minElev == 0 {
 		minElev = 1
 	}
@@ -269,7 +271,7 @@ func (m *Geo) assignRainfallBasic() {
 
 	// calcRainfall returns the amount of rain shed given the region and humidity.
 	calcRainfall := func(r int, humidity float64) float64 {
-		elev := m.Elevation[r]
+		elev := m.Elevation.At(r)
 		if elev < 0 {
 			elev = 0 // Set to sea-level
 		}
@@ -283,18 +285,20 @@ func (m *Geo) assignRainfallBasic() {
 	// Evaporation.
 	// 1. Assign initial moisture of 1.0 to all regions below or at sea level or replenish
 	// moisture through evaporation if our moisture is below 0.
-	for r, h := range m.Elevation {
+	for r := 0; r < m.Elevation.Len(); r++ {
+		h := m.Elevation.At(r)
 		if h <= 0 {
-			m.Moisture[r] = math.Max(m.Moisture[r], humidityFromSea)
+			m.Moisture.Set(r, math.Max(m.Moisture.At(r), humidityFromSea))
 		}
 	}
 
 	// Rivers should experience some evaporation.
 	if evaporateRivers {
-		for r, fluxval := range m.Flux {
+		for r := 0; r < m.Flux.Len(); r++ {
+			fluxval := m.Flux.At(r)
 			if m.IsRegBigRiver(r) {
 				evaporation := humidityFromRiver * fluxval / maxFlux
-				m.Moisture[r] = math.Max(m.Moisture[r], evaporation)
+				m.Moisture.Set(r, math.Max(m.Moisture.At(r), evaporation))
 			}
 		}
 	}
@@ -304,10 +308,11 @@ func (m *Geo) assignRainfallBasic() {
 	// NOTE: Currently this is not used since flood algorithms are deactivated so
 	// the value for water pools is always 0.
 	if evaporatePools {
-		for r, poolval := range m.Waterpool {
+		for r := 0; r < m.Waterpool.Len(); r++ {
+			poolval := m.Waterpool.At(r)
 			if poolval > 0 {
 				evaporation := humidityFromPool * poolval / maxPool
-				m.Moisture[r] = math.Max(m.Moisture[r], evaporation)
+				m.Moisture.Set(r, math.Max(m.Moisture.At(r), evaporation))
 			}
 		}
 	}
@@ -365,7 +370,7 @@ func (m *Geo) assignRainfallBasic() {
 
 	useGoRoutines := true
 	if useGoRoutines {
-		various.KickOffChunkWorkers(len(dotToNeighbors), dotChunkProcessor)
+		m.GetExecutor().ParallelFor(len(dotToNeighbors), dotChunkProcessor)
 	} else {
 		dotChunkProcessor(0, len(dotToNeighbors))
 	}
@@ -383,26 +388,26 @@ func (m *Geo) assignRainfallBasic() {
 
 				// Check if the neighbor region is up-wind (that the wind blows from neighbor_r to r) / dotV is positive.
 				if dotV > 0.0 {
-					humidity += m.Moisture[nbReg] * dotV
+					humidity += m.Moisture.At(nbReg) * dotV
 				}
 			}
 
 			// Evaporation.
-			if m.Elevation[r] <= 0 {
-				evaporation := biomesParam.evaporation * humidityFromSea * m.Elevation[r] / minElev
+			if m.Elevation.At(r) <= 0 {
+				evaporation := biomesParam.evaporation * humidityFromSea * m.Elevation.At(r) / minElev
 				humidity = math.Max(humidity, evaporation)
 			} else if evaporateRivers && m.IsRegBigRiver(r) {
-				evaporation := biomesParam.evaporation * humidityFromRiver * m.Flux[r] / maxFlux
+				evaporation := biomesParam.evaporation * humidityFromRiver * m.Flux.At(r) / maxFlux
 				humidity = math.Max(humidity, evaporation)
-			} else if evaporatePools && m.Waterpool[r] > 0 {
-				evaporation := biomesParam.evaporation * humidityFromPool * m.Waterpool[r] / maxPool
+			} else if evaporatePools && m.Waterpool.At(r) > 0 {
+				evaporation := biomesParam.evaporation * humidityFromPool * m.Waterpool.At(r) / maxPool
 				humidity = math.Max(humidity, evaporation)
 			}
 
 			// Calculate orographic rainfall caused by elevation changes.
 			rainfall := biomesParam.raininess * calcRainfall(r, humidity)
-			m.Rainfall[r] = rainfall
-			m.Moisture[r] = humidity - rainfall
+			m.Rainfall.Set(r, rainfall)
+			m.Moisture.Set(r, humidity-rainfall)
 		}
 	}
 
@@ -411,29 +416,32 @@ func (m *Geo) assignRainfallBasic() {
 }
 
 func (m *Geo) interpolateRainfallMoisture(interpolationSteps int) {
-	outRegs := make([]int, 0, 8)
 	for i := 0; i < interpolationSteps; i++ {
 		regMoistureInterpol := make([]float64, m.SphereMesh.NumRegions)
 		regRainfallInterpol := make([]float64, m.SphereMesh.NumRegions)
-		for r := range regMoistureInterpol {
-			rMoist := m.Moisture[r]
-			rRain := m.Rainfall[r]
-			var count int
-			for _, nbReg := range m.SphereMesh.R_circulate_r(outRegs, r) {
-				// Gravity! Water moves downwards.
-				// This is not super-accurate since you'd have to take
-				// in account how steep the slope is etc.
-				if m.Elevation[r] >= m.Elevation[nbReg] {
-					continue
+		chunkProcessor := func(start, end int) {
+			outRegs := make([]int, 0, 8)
+			for r := start; r < end; r++ {
+				rMoist := m.Moisture.At(r)
+				rRain := m.Rainfall.At(r)
+				var count int
+				for _, nbReg := range m.SphereMesh.R_circulate_r(outRegs, r) {
+					// Gravity! Water moves downwards.
+					// This is not super-accurate since you'd have to take
+					// in account how steep the slope is etc.
+					if m.Elevation.At(r) >= m.Elevation.At(nbReg) {
+						continue
+					}
+					rMoist += m.Moisture.At(nbReg)
+					rRain += m.Rainfall.At(nbReg)
+					count++
 				}
-				rMoist += m.Moisture[nbReg]
-				rRain += m.Rainfall[nbReg]
-				count++
+				regMoistureInterpol[r] = rMoist / float64(count+1)
+				regRainfallInterpol[r] = rRain / float64(count+1)
 			}
-			regMoistureInterpol[r] = rMoist / float64(count+1)
-			regRainfallInterpol[r] = rRain / float64(count+1)
 		}
-		m.Moisture = regMoistureInterpol
-		m.Rainfall = regRainfallInterpol
+		m.GetExecutor().ParallelFor(m.SphereMesh.NumRegions, chunkProcessor)
+		m.Moisture.SetValues(regMoistureInterpol)
+		m.Rainfall.SetValues(regRainfallInterpol)
 	}
 }
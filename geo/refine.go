@@ -0,0 +1,116 @@
+package geo
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/Flokey82/geoquad"
+)
+
+// Refine returns a refined copy of the given lat/lon window (see Interpolate)
+// with a higher resolution and one more octave of noise.
+//
+// The hydrology is re-run on the refined regions, which will add smaller
+// tributaries, while the rivers of the original object are kept intact (see
+// assignRefinedHydrology).
+//
+// NOTE: The window should have some margin around the area we are interested
+// in, since the regions at the border of the window lack neighbors.
+func (m *BaseObject) Refine(la1, lo1, la2, lo2 float64) (*Interpolated, error) {
+	var regions []int
+	for _, qd := range m.RegQuadTree.FindPointsInRect(geoquad.Rect{
+		MinLat: la1,
+		MaxLat: la2,
+		MinLon: lo1,
+		MaxLon: lo2,
+	}) {
+		regions = append(regions, qd.Data.(int))
+	}
+	if len(regions) < 3 {
+		return nil, errors.New("not enough regions within bounds")
+	}
+
+	// Sort the regions to get the same result for the same window.
+	sort.Ints(regions)
+	ipl, err := m.Interpolate(regions)
+	if err != nil {
+		return nil, err
+	}
+	ipl.assignRefinedHydrology(m)
+	return ipl, nil
+}
+
+// assignRefinedHydrology re-calculates the downhill neighbors and the flux of
+// the refined regions consistent with the rivers of the given original object.
+//
+// - Original regions drain through the refined region on the edge towards
+// their original downhill neighbor, so the rivers follow the same course.
+// - The flux is accumulated from the local rainfall (scaled by the smaller
+// region size) to get the local streams and tributaries.
+// - Regions along the original rivers carry at least the original flux.
+func (ipl *Interpolated) assignRefinedHydrology(orig *BaseObject) {
+	// Look up the refined regions by their parent regions.
+	origToReg := make(map[int]int)
+	edgeToReg := make(map[[2]int]int)
+	for i, p := range ipl.Parents {
+		if p[0] == p[1] {
+			origToReg[p[0]] = i
+		} else {
+			edgeToReg[p] = i
+		}
+	}
+	getEdgeReg := func(a, b int) (int, bool) {
+		if r, ok := edgeToReg[[2]int{a, b}]; ok {
+			return r, true
+		}
+		r, ok := edgeToReg[[2]int{b, a}]
+		return r, ok
+	}
+
+	// Make the refined regions follow the original rivers.
+	ipl.AssignDownhill(true)
+	origFlux := make([]float64, ipl.NumRegions)
+	for r, i := range origToReg {
+		origFlux[i] = orig.Flux.At(r)
+		dh := orig.Downhill[r]
+		if dh < 0 {
+			continue
+		}
+		mid, ok := getEdgeReg(r, dh)
+		if !ok {
+			continue
+		}
+		ipl.Downhill[i] = mid
+		origFlux[mid] = orig.Flux.At(r)
+		if j, ok := origToReg[dh]; ok {
+			ipl.Downhill[mid] = j
+		}
+	}
+
+	// Accumulate the local rainfall, highest elevation first.
+	scale := float64(len(origToReg)) / float64(ipl.NumRegions)
+	flux := make([]float64, ipl.NumRegions)
+	idxs := make([]int, ipl.NumRegions)
+	for i := range flux {
+		if ipl.Elevation.At(i) >= 0 {
+			flux[i] = ipl.Rainfall.At(i) * scale
+		}
+		idxs[i] = i
+	}
+	sort.Slice(idxs, func(a, b int) bool {
+		return ipl.Elevation.At(idxs[a]) > ipl.Elevation.At(idxs[b])
+	})
+	for _, r := range idxs {
+		if ipl.Elevation.At(r) < 0 || ipl.Downhill[r] < 0 {
+			continue
+		}
+		flux[ipl.Downhill[r]] += flux[r]
+	}
+
+	// Keep the original rivers.
+	for i := range flux {
+		flux[i] = math.Max(flux[i], origFlux[i])
+	}
+	ipl.Flux.SetValues(flux)
+}
@@ -0,0 +1,145 @@
+package geo
+
+import (
+	"image/color"
+	"log"
+)
+
+// ResourceDef describes a natural resource, either one of the built-in
+// resources (metals, gems, stones, wood, various) or a custom resource
+// registered by the application (e.g. fantasy minerals, spices or dyes).
+type ResourceDef struct {
+	ID       int                            // Index in the registry (assigned on registration)
+	Name     string                         // Name of the resource
+	Type     int                            // Resource type (ResourceTypeMetal, ...), determines who extracts it
+	Flag     byte                           // Flag in the resource bitmasks (built-in resources only)
+	Value    float64                        // Relative value (1.0 is cheap, 8.0 is very valuable)
+	Uses     []string                       // What the resource is used for (e.g. "weapons", "dye", "trade")
+	Chance   float64                        // Chance of a deposit in a region with a fitness of 1.0 (custom resources only)
+	Quantity float64                        // Base quantity of a deposit
+	Color    color.NRGBA                    // Color used for rendering (zero value for the default color)
+	Fitness  func(m *Geo) func(int) float64 // Placement fitness (0.0-1.0) of a region (custom resources only)
+}
+
+// IsBuiltin returns true if the resource is one of the built-in resources,
+// which are stored in the resource bitmasks.
+func (d *ResourceDef) IsBuiltin() bool {
+	return d.Flag != 0
+}
+
+// ResourceRegistry holds all known resources.
+type ResourceRegistry struct {
+	Defs   []*ResourceDef          // All registered resources
+	byName map[string]*ResourceDef // Name to resource mapping
+}
+
+// NewResourceRegistry returns a new registry containing all built-in resources.
+func NewResourceRegistry() *ResourceRegistry {
+	reg := &ResourceRegistry{
+		byName: make(map[string]*ResourceDef),
+	}
+	addBuiltin := func(resourceType, num int, toString func(int) string, quantity float64, uses ...string) {
+		for i := 0; i < num; i++ {
+			reg.Register(&ResourceDef{
+				Name:     toString(i),
+				Type:     resourceType,
+				Flag:     1 << i,
+				Value:    float64(i + 1),
+				Uses:     uses,
+				Quantity: quantity / float64(i+1), // Rarer resources come in smaller quantities.
+			})
+		}
+	}
+	addBuiltin(ResourceTypeMetal, ResMaxMetals, MetalToString, depositBaseQuantityMetal, "tools", "weapons", "trade")
+	addBuiltin(ResourceTypeGem, ResMaxGems, GemToString, depositBaseQuantityGem, "jewelry", "trade")
+	addBuiltin(ResourceTypeStone, ResMaxStones, StoneToString, depositBaseQuantityStone, "construction")
+	addBuiltin(ResourceTypeVarious, ResMaxVarious, VariousToString, depositBaseQuantityVarious, "trade")
+	addBuiltin(ResourceTypeWood, ResMaxWoods, WoodToString, depositBaseQuantityWood, "construction", "fuel")
+	return reg
+}
+
+// Register adds a resource to the registry and returns it with its assigned ID.
+// If a resource with the same name already exists, it is replaced.
+//
+// NOTE: A replaced built-in resource keeps its resource type and flag, since
+// the built-in resources are placed using the resource bitmasks. Only the
+// value, uses, quantity, color, etc. can be changed.
+func (reg *ResourceRegistry) Register(def *ResourceDef) *ResourceDef {
+	if old, ok := reg.byName[def.Name]; ok {
+		log.Printf("replacing resource %q", def.Name)
+		if old.IsBuiltin() {
+			def.Type = old.Type
+			def.Flag = old.Flag
+		}
+		def.ID = old.ID
+		reg.Defs[old.ID] = def
+	} else {
+		def.ID = len(reg.Defs)
+		reg.Defs = append(reg.Defs, def)
+	}
+	reg.byName[def.Name] = def
+	return def
+}
+
+// Get returns the resource with the given ID (or nil).
+func (reg *ResourceRegistry) Get(id int) *ResourceDef {
+	if id < 0 || id >= len(reg.Defs) {
+		return nil
+	}
+	return reg.Defs[id]
+}
+
+// GetByName returns the resource with the given name (or nil).
+func (reg *ResourceRegistry) GetByName(name string) *ResourceDef {
+	return reg.byName[name]
+}
+
+// GetByType returns all resources of the given resource type.
+func (reg *ResourceRegistry) GetByType(resourceType int) []*ResourceDef {
+	var defs []*ResourceDef
+	for _, def := range reg.Defs {
+		if def.Type == resourceType {
+			defs = append(defs, def)
+		}
+	}
+	return defs
+}
+
+// GetBuiltin returns the built-in resource with the given type and flag (or nil).
+func (reg *ResourceRegistry) GetBuiltin(resourceType int, flag byte) *ResourceDef {
+	for _, def := range reg.Defs {
+		if def.Type == resourceType && def.Flag == flag {
+			return def
+		}
+	}
+	return nil
+}
+
+// GetCustom returns all custom (non built-in) resources.
+func (reg *ResourceRegistry) GetCustom() []*ResourceDef {
+	var defs []*ResourceDef
+	for _, def := range reg.Defs {
+		if !def.IsBuiltin() {
+			defs = append(defs, def)
+		}
+	}
+	return defs
+}
+
+// placeCustomResources places all custom resources based on their fitness
+// functions.
+func (m *Geo) placeCustomResources() {
+	custom := make(map[int][]*ResourceDef)
+	for _, def := range m.ResourceRegistry.GetCustom() {
+		if def.Fitness == nil {
+			continue
+		}
+		fn := def.Fitness(m)
+		for r := 0; r < m.SphereMesh.NumRegions; r++ {
+			if f := fn(r); f > 0 && m.Rand.Float64() < def.Chance*f {
+				custom[r] = append(custom[r], def)
+			}
+		}
+	}
+	m.Custom = custom
+}
@@ -1,11 +1,6 @@
 package geo
 
-import (
-	"log"
-	"math"
-
-	"github.com/Flokey82/genbiome"
-)
+import "github.com/Flokey82/genbiome"
 
 // SumResources returns the sum of the resource flag IDs in the byte.
 // This is a convenience function for determining the approximate
@@ -27,6 +22,8 @@ const (
 	ResourceTypeMetal = iota
 	ResourceTypeGem
 	ResourceTypeStone
+	ResourceTypeVarious
+	ResourceTypeWood
 )
 
 // getRegsWithResource returns the regions that have the specified resource.
@@ -40,6 +37,10 @@ func (m *Geo) getRegsWithResource(resource byte, resourceType int) []int {
 		search = m.Gems
 	case ResourceTypeStone:
 		search = m.Stones
+	case ResourceTypeVarious:
+		search = m.Various
+	case ResourceTypeWood:
+		search = m.Wood
 	}
 
 	// Find the regions that have the specified resource.
@@ -54,20 +55,27 @@ func (m *Geo) getRegsWithResource(resource byte, resourceType int) []int {
 
 // Resources maps regions to natural resources.
 type Resources struct {
-	Metals  []byte // Metal ores
-	Gems    []byte // Gemstones
-	Stones  []byte // Rocks or minerals
-	Various []byte // Other resources
-	Wood    []byte // Wood
+	Metals  []byte    // Metal ores
+	Gems    []byte    // Gemstones
+	Stones  []byte    // Rocks or minerals
+	Various []byte    // Other resources
+	Wood    []byte    // Wood
+	Energy  []float64 // Energy resource potential (0.0-1.0)
+
+	Custom   map[int][]*ResourceDef // Region to custom resources (see ResourceRegistry)
+	Deposits map[int][]*Deposit     // Region to resource deposits (quantity, quality, ...)
 }
 
 func newResources(size int) *Resources {
 	return &Resources{
-		Metals:  make([]byte, size),
-		Gems:    make([]byte, size),
-		Stones:  make([]byte, size),
-		Various: make([]byte, size),
-		Wood:    make([]byte, size),
+		Metals:   make([]byte, size),
+		Gems:     make([]byte, size),
+		Stones:   make([]byte, size),
+		Various:  make([]byte, size),
+		Wood:     make([]byte, size),
+		Energy:   make([]float64, size),
+		Custom:   make(map[int][]*ResourceDef),
+		Deposits: make(map[int][]*Deposit),
 	}
 }
 
@@ -84,21 +92,19 @@ func (m *Geo) resourceFitness() []float64 {
 	return fitness
 }
 
-func (m *Geo) placeResources() {
-	// NOTE: This currently sucks.
-	// TODO: Use fitness function instead or in addition.
-
+// placeResources places all resources. The geology (bedrock, volcanoes,
+// subduction zones and mountain belts) determines where we can find ores,
+// gems and quarry stone.
+func (m *Geo) placeResources(g *geology) {
 	// Place metals.
-	// Metals can be found mainly in mountains, so steepness
-	// will be an indicator along with the distance from the
-	// mountain seed points.
-	m.placeMetals()
+	// Metal ores are found mainly in the igneous and metamorphic rocks of
+	// volcanic arcs and mountain belts, but also in sedimentary basins.
+	m.placeMetals(g)
 
 	// Place gemstones.
-	// Gemstones can be found mainly in inland valleys, so
-	// distance from the coastlines, mountains, and oceans
-	// will be an indicator.
-	m.placeGems()
+	// Gemstones form under heat and pressure in metamorphic rock, in granite
+	// pegmatites, in cavities of volcanic rock, and in kimberlite pipes.
+	m.placeGems(g)
 
 	// Place forests.
 	// Forests can be found mainly in valleys, so steepness
@@ -107,13 +113,19 @@ func (m *Geo) placeResources() {
 	m.placeForests()
 
 	// Place potential quarry sites.
-	// Potential quarry sites can be found mainly in mountains,
-	m.placeStones()
+	// The type of quarry stone depends on the bedrock.
+	m.placeStones(g)
+
+	// Place other resources.
+	// Magical handwavium... and clay, and salt, and stuff.
+	m.placeVarious(g)
+
+	// Place coal, oil, gas and geothermal sites.
+	// This also calculates the energy resource layer.
+	m.placeEnergy(g)
 
-	// Place energy sources and other resources.
-	// Oil, coal, and natural gas, as well as geothermal energy
-	// and magical handwavium... and clay, and salt, and stuff.
-	m.placeVarious()
+	// Place custom resources registered by the application.
+	m.placeCustomResources()
 
 	// Place arable land.
 	// Arable land can be found mainly in valleys, so steepness
@@ -155,22 +167,27 @@ func MetalToString(metalID int) string {
 	}
 }
 
-func (m *Geo) placeMetals() {
-	steepness := m.GetSteepness()
-	// distMountains, _, _, _ := m.findCollisions()
-
+func (m *Geo) placeMetals(g *geology) {
+	// Chance of finding a metal in a region with suitable geology.
 	// https://www.reddit.com/r/worldbuilding/comments/kbmnd6/a_guide_to_placing_resources_on_fictional_worlds/
 	const (
-		chancePlatinum = 0.005
-		chanceGold     = chancePlatinum + 0.020
-		chanceSilver   = chanceGold + 0.040
-		chanceCopper   = chanceSilver + 0.06
-		chanceLead     = chanceCopper + 0.07
-		chanceTin      = chanceLead + 0.1
-		chanceIron     = chanceTin + 0.4
+		chancePlatinum = 0.02
+		chanceGold     = 0.04
+		chanceSilver   = 0.06
+		chanceCopper   = 0.3
+		chanceLead     = 0.1
+		chanceTin      = 0.1
+		chanceIron     = 0.2
+	)
+
+	// Distances (km) to the plate boundaries.
+	const (
+		maxOphioliteDist = 115.0 // Ophiolites (platinum) are found close to the mountain belts
+		maxLeadDist      = 300.0 // Lead is found at the edges of the basins next to the mountain belts
 	)
+
+	// The noise clusters the deposits into mineral provinces.
 	fn := m.fbmNoiseCustom(2, 1, 2, 2, 2, 0, 0, 0)
-	fm := m.GetFitnessSteepMountains()
 
 	// NOTE: By encoding the resources as bit flags, we can easily
 	// determine the value of a region given the assumption that
@@ -179,67 +196,87 @@ func (m *Geo) placeMetals() {
 	//
 	// I feel pretty clever about this one, but it's not realistic.
 	m.ResetRand()
-	metals := make([]byte, len(steepness))
-
-	// TODO: Use noise intersection instead of rand.
+	metals := make([]byte, m.SphereMesh.NumRegions)
 	for r := 0; r < m.SphereMesh.NumRegions; r++ {
-		if fm(r) > 0.9 {
-			switch rv := math.Abs(m.Rand.NormFloat64() * fn(r)); {
-			case rv < chancePlatinum:
-				metals[r] |= ResMetPlatinum
-			case rv < chanceGold:
+		if m.Elevation.At(r) <= 0 {
+			continue
+		}
+		province := 0.5 + 0.5*fn(r)
+		chance := func(c float64) bool {
+			return m.Rand.Float64() < c*province
+		}
+
+		switch m.RegionToLithology[r] {
+		case LithologyIgneous:
+			if g.distSubduction[r] <= lithologyArcIgneousKm {
+				// Porphyry copper belts form above subduction zones and
+				// often contain gold and silver as well.
+				if chance(chanceCopper) {
+					metals[r] |= ResMetCopper
+				}
+				if chance(chanceGold) {
+					metals[r] |= ResMetGold
+				}
+				if chance(chanceSilver) {
+					metals[r] |= ResMetSilver
+				}
+			} else if chance(chanceTin) {
+				// Tin is found in granites and their pegmatites.
+				metals[r] |= ResMetTin
+			}
+		case LithologyMetamorphic:
+			// Hydrothermal veins in the mountain belts.
+			if chance(chanceGold) {
 				metals[r] |= ResMetGold
-			case rv < chanceSilver:
+			}
+			if chance(chanceSilver) {
 				metals[r] |= ResMetSilver
-			case rv < chanceCopper:
-				metals[r] |= ResMetCopper
-			case rv < chanceLead:
+			}
+			if chance(chanceLead) {
 				metals[r] |= ResMetLead
-			case rv < chanceTin:
-				metals[r] |= ResMetTin
-			case rv < chanceIron:
+			}
+			if chance(chanceIron / 2) {
 				metals[r] |= ResMetIron
 			}
-		}
-	}
-	m.Metals = metals
-
-	// This attempts some weird variation of:
-	// https://www.redblobgames.com/x/1736-resource-placement/
-	/*
-		nA := m.fbm_noise2(5, 0.5, 5, 5, 5, 0, 0, 0)
-		nB := m.fbm_noise2(7, 0.5, 5, 5, 5, 0, 0, 0)
-		resources := make([]byte, len(steepness))
-		for r := range steepness {
-			noiseVal := (nA(r) + nB(r) + m.r_elevation[r]) / 3
-			if m.getIntersection(noiseVal, 0.75, 0.01) {
-				resources[r] |= ResMetPlatinum
+		case LithologyVolcanic:
+			// Ophiolites (slices of ocean floor pushed onto land) contain
+			// platinum, volcanic vents deposit massive copper sulfides.
+			if g.distMountain[r] <= maxOphioliteDist && chance(chancePlatinum) {
+				metals[r] |= ResMetPlatinum
 			}
-			//chance /= float64(distMountains[r])
-		}
-
-		nC := m.fbm_noise2(2, 0.5, 5, 5, 5, 0, 0, 0)
-		nD := m.fbm_noise2(7, 0.5, 5, 5, 5, 0, 0, 0)
-		for r := range steepness {
-			noiseVal := (nC(r) + nD(r) + m.r_elevation[r]) / 3
-			if m.getIntersection(noiseVal, 0.75, 0.02) {
-				resources[r] |= ResMetGold
+			if chance(chanceCopper / 3) {
+				metals[r] |= ResMetCopper
 			}
-			//chance /= float64(distMountains[r])
-		}
-
-		nC = m.fbm_noise2(2, 0.5, 1, 1, 1, 0, 0, 0)
-		nD = m.fbm_noise2(5, 0.1, 1, 1, 1, 0, 0, 0)
-		for r := range steepness {
-			noiseVal := (-1*(nC(r)+nD(r)) + m.r_elevation[r]) / 3
-			if m.getIntersection(noiseVal, 0.52, 0.07) {
-				resources[r] |= ResMetIron
+		case LithologySedimentary:
+			// Banded iron formations and bog iron.
+			if chance(chanceIron) {
+				metals[r] |= ResMetIron
+			}
+			// Lead (and zinc) is deposited in the carbonates at the edges
+			// of the basins.
+			if g.distMountain[r] <= maxLeadDist && chance(chanceLead) {
+				metals[r] |= ResMetLead
 			}
-			//chance /= float64(distMountains[r])
 		}
-	*/
+	}
 
-	//m.r_metals = resources
+	// Gold is eroded from the veins and concentrated in placer deposits
+	// along the rivers downstream.
+	const maxPlacerDist = 5
+	var goldRegs []int
+	for r, met := range metals {
+		if met&ResMetGold != 0 {
+			goldRegs = append(goldRegs, r)
+		}
+	}
+	for _, r := range goldRegs {
+		dh := m.Downhill[r]
+		for i := 0; i < maxPlacerDist && dh >= 0 && m.Elevation.At(dh) > 0 && m.IsRegRiver(dh); i++ {
+			metals[dh] |= ResMetGold
+			dh = m.Downhill[dh]
+		}
+	}
+	m.Metals = metals
 }
 
 // Gemstone resource flags starting with the cheapest gem.
@@ -273,39 +310,56 @@ func GemToString(gemsID int) string {
 	}
 }
 
-func (m *Geo) placeGems() {
-	steepness := m.GetSteepness()
+func (m *Geo) placeGems(g *geology) {
+	// Chance of finding a gemstone in a region with suitable geology.
 	const (
-		chanceDiamond  = 0.005
-		chanceRuby     = chanceDiamond + 0.025
-		chanceEmerald  = chanceRuby + 0.04
-		chanceSapphire = chanceEmerald + 0.05
-		chanceTopaz    = chanceSapphire + 0.06
-		chanceAmethyst = chanceTopaz + 0.1
+		chanceDiamond  = 0.01
+		chanceRuby     = 0.02
+		chanceEmerald  = 0.03
+		chanceSapphire = 0.03
+		chanceTopaz    = 0.05
+		chanceAmethyst = 0.08
 		// chanceQuartz   = 0.75 // Usually goes hand in hand with gold?
 		// chanceFlint    = 0.9
 	)
 
-	gems := make([]byte, len(steepness))
+	// Diamonds are only found far away (km) from the plate boundaries.
+	const minDiamondDist = 380.0
+
+	outRegs := make([]int, 0, 8)
+	gems := make([]byte, m.SphereMesh.NumRegions)
 	for r := 0; r < m.SphereMesh.NumRegions; r++ {
-		if steepness[r] > 0.9 && m.Elevation[r] > 0.5 {
-			switch rv := math.Abs(m.Rand.NormFloat64()); {
-			case rv < chanceDiamond:
-				gems[r] |= ResGemDiamond
-			case rv < chanceRuby:
+		if m.Elevation.At(r) <= 0 {
+			continue
+		}
+		switch m.RegionToLithology[r] {
+		case LithologyMetamorphic:
+			// Corundum (ruby and sapphire) forms in metamorphosed limestone.
+			if m.Rand.Float64() < chanceRuby {
 				gems[r] |= ResGemRuby
-			case rv < chanceEmerald:
-				gems[r] |= ResGemEmerald
-			case rv < chanceSapphire:
+			}
+			if m.Rand.Float64() < chanceSapphire {
 				gems[r] |= ResGemSapphire
-			case rv < chanceTopaz:
+			}
+			// Emeralds form where granite pegmatites intrude into
+			// metamorphic rock.
+			if m.isRegLithologyNear(outRegs, r, LithologyIgneous) && m.Rand.Float64() < chanceEmerald {
+				gems[r] |= ResGemEmerald
+			}
+		case LithologyIgneous:
+			// Topaz is found in granite pegmatites.
+			if m.Rand.Float64() < chanceTopaz {
 				gems[r] |= ResGemTopaz
-			case rv < chanceAmethyst:
+			}
+			// Diamonds are brought up by kimberlite pipes in old, stable
+			// shields far away from the plate boundaries.
+			if g.distMountain[r] > minDiamondDist && g.distSubduction[r] > minDiamondDist && m.Rand.Float64() < chanceDiamond {
+				gems[r] |= ResGemDiamond
+			}
+		case LithologyVolcanic:
+			// Amethyst geodes form in the cavities of volcanic rock.
+			if m.Rand.Float64() < chanceAmethyst {
 				gems[r] |= ResGemAmethyst
-				// case rv < chanceQuartz:
-				//	gems[r] |= ResGemQuartz
-				// case rv < chanceFlint:
-				//	gems[r] |= ResGemFlint
 			}
 		}
 	}
@@ -325,7 +379,7 @@ const (
 	ResStoObsidian
 )
 
-const ResMaxStones = 7
+const ResMaxStones = 8
 
 func StoneToString(stoneID int) string {
 	switch 1 << stoneID {
@@ -350,9 +404,7 @@ func StoneToString(stoneID int) string {
 	}
 }
 
-func (m *Geo) placeStones() {
-	log.Println("placing stones is not implemented")
-
+func (m *Geo) placeStones(g *geology) {
 	// Chalk:
 	// Ancient Chalk beds formed on the floor of ancient seas.
 	//
@@ -393,98 +445,74 @@ func (m *Geo) placeStones() {
 	// Slate is formed when shale is subjected to intense heat and pressure. Slate
 	// will be placed near mountain ranges.
 
+	// Obsidian is only found close (km) to volcanoes.
+	const maxObsidianDist = 80.0
+
 	// Initialize the stone map.
 	stones := make([]byte, m.SphereMesh.NumRegions)
 
 	biomeFunc := m.GetRegWhittakerModBiomeFunc()
 	steepness := m.GetSteepness()
 
-	// Generate a distance field for volcanoes, mountains, and faultlines.
-	var volcanoes, mountains, faultlines []int
-	stopSea := make(map[int]bool)
+	// Check which regions are beaches.
 	isBeach := make(map[int]bool)
-
-	out_r := make([]int, 0, 8)
+	outRegs := make([]int, 0, 8)
 	for r := 0; r < m.SphereMesh.NumRegions; r++ {
-		if m.RegionIsVolcano[r] {
-			volcanoes = append(volcanoes, r)
-		}
-		if m.RegionIsMountain[r] {
-			mountains = append(mountains, r)
-		}
-		if math.Abs(m.RegionCompression[r]) > 0.1 {
-			faultlines = append(faultlines, r)
+		if m.Elevation.At(r) <= 0.0 {
+			continue
 		}
-		if m.Elevation[r] <= 0.0 {
-			stopSea[r] = true
-		} else {
-			// Check if the region is a beach.
-			for _, n := range m.R_circulate_r(out_r, r) {
-				if m.Elevation[n] <= 0.0 {
-					isBeach[r] = true
-					break
-				}
+		for _, n := range m.SphereMesh.R_circulate_r(outRegs, r) {
+			if m.Elevation.At(n) <= 0.0 {
+				isBeach[r] = true
+				break
 			}
 		}
 	}
 
-	distVolcanoes := m.AssignDistanceField(volcanoes, stopSea)
-	distMountains := m.AssignDistanceField(mountains, stopSea)
-	distFaultlines := m.AssignDistanceField(faultlines, stopSea)
-
 	// Loop through all the regions and place stones based on the region's
-	// properties.
+	// bedrock and properties.
 	for r := 0; r < m.SphereMesh.NumRegions; r++ {
 		// Skip water regions.
-		if m.Elevation[r] <= 0.0 {
+		if m.Elevation.At(r) <= 0.0 {
 			continue
 		}
 
-		// Get the region's biome.
-		biome := biomeFunc(r)
-
-		// Check if we have sandstone (beach, or desert).
-		if biome == genbiome.WhittakerModBiomeSubtropicalDesert || isBeach[r] {
-			stones[r] |= ResStoSandstone
-		}
+		switch m.RegionToLithology[r] {
+		case LithologySedimentary:
+			// Check if we have sandstone (beach, or desert).
+			if biomeFunc(r) == genbiome.WhittakerModBiomeSubtropicalDesert || isBeach[r] {
+				stones[r] |= ResStoSandstone
+			}
 
-		// Chalk and limestone.
-		if biome == genbiome.WhittakerModBiomeTemperateGrassland && steepness[r] > 0.1 {
-			// If we are close to mountains, we have marble.
-			if distMountains[r] < 2 {
+			// Chalk and limestone (ancient sea floors) are exposed in hilly terrain.
+			if steepness[r] > 0.1 {
+				if m.Rainfall.At(r) > 0.5 {
+					// Check if we have chalk (wetter, hilly terrain)
+					stones[r] |= ResStoChalk
+				} else if !m.IsRegRiver(r) && !m.IsRegLakeOrWaterBody(r) {
+					// Check if we have limestone (dryer, hilly terrain)
+					stones[r] |= ResStoLimestone
+				}
+			}
+		case LithologyMetamorphic:
+			// Limestone in contact with the metamorphic zone becomes marble,
+			// shale further within becomes slate.
+			if m.isRegLithologyNear(outRegs, r, LithologySedimentary) {
 				stones[r] |= ResStoMarble
-			} else if !m.IsRegRiver(r) && !m.IsRegLakeOrWaterBody(r) {
-				// Check if we have limestone (dryer, hilly grassland)
-				stones[r] |= ResStoLimestone
-			} else if m.Rainfall[r] > 0.5 {
-				// Check if we have chalk (wetter, hilly grassland)
-				stones[r] |= ResStoChalk
+			} else if steepness[r] > 0.2 || g.distMountain[r] > 0 {
+				stones[r] |= ResStoSlate
 			}
-		}
-
-		// Obsidian, and basalt.
-		// For these stones, we need to check if we are near a volcano or faultline.
-		if distVolcanoes[r] < 2 || distFaultlines[r] < 2 {
-			// Check if we have obsidian (near a volcano).
-			if distVolcanoes[r] < 2 {
+		case LithologyIgneous:
+			stones[r] |= ResStoGranite
+		case LithologyVolcanic:
+			// Obsidian survives only near volcanoes in dry regions, otherwise
+			// we have basalt.
+			if g.distVolcano[r] < maxObsidianDist && m.Rainfall.At(r) < 0.3 {
 				stones[r] |= ResStoObsidian
-			}
-
-			// Check if we have basalt (near a faultline).
-			if distFaultlines[r] < 2 {
+			} else {
 				stones[r] |= ResStoBasalt
 			}
 		}
-
-		// Check if we have granite (near a mountain and faultline).
-		if distMountains[r] < 3 && distFaultlines[r] < 2 {
-			stones[r] |= ResStoGranite
-		} else if steepness[r] > 0.2 && distMountains[r] > 2 && distMountains[r] < 5 {
-			// Slate.
-			// For slate, we need to check if we are near a mountain range or if the region
-			// is steep.
-			stones[r] |= ResStoSlate
-		}
 	}
 
 	// Assign the stone map.
@@ -498,9 +526,11 @@ const (
 	ResVarCoal
 	ResVarOil
 	ResVarGas
+	ResVarFish
+	ResVarGeothermal
 )
 
-const ResMaxVarious = 6
+const ResMaxVarious = 8
 
 func VariousToString(v int) string {
 	switch 1 << v {
@@ -516,38 +546,43 @@ func VariousToString(v int) string {
 		return "oil"
 	case ResVarGas:
 		return "gas"
+	case ResVarFish:
+		return "fish"
+	case ResVarGeothermal:
+		return "geothermal"
 	default:
 		return "unknown"
 	}
 }
 
-func (m *Geo) placeVarious() {
+func (m *Geo) placeVarious(g *geology) {
+	// Sulfur is only found close (km) to volcanoes.
+	const maxSulfurDist = 40.0
+
 	varRes := make([]byte, m.SphereMesh.NumRegions)
 	biomeFunc := m.GetRegWhittakerModBiomeFunc()
 	steepness := m.GetSteepness()
+	fn := m.fbmNoiseCustom(2, 1, 4, 4, 4, 0, 0, 0)
 	for r := 0; r < m.SphereMesh.NumRegions; r++ {
-		if m.Elevation[r] <= 0.0 {
+		if m.Elevation.At(r) <= 0.0 {
 			continue
 		}
 		biome := biomeFunc(r)
+		lithology := m.RegionToLithology[r]
 
-		if m.RegionIsVolcano[r] {
+		// Sulfur is deposited around volcanic vents.
+		if g.distVolcano[r] <= maxSulfurDist {
 			varRes[r] |= ResVarSulfur
 		}
 
-		if m.RegionIsMountain[r] {
-			varRes[r] |= ResVarCoal
+		// Salt (evaporites) is left behind by dried up seas in arid basins.
+		if lithology == LithologySedimentary && biome == genbiome.WhittakerModBiomeSubtropicalDesert && fn(r) < -0.2 {
+			varRes[r] |= ResVarSalt
 		}
 
 		if m.IsRegRiver(r) && steepness[r] > 0.1 && steepness[r] < 0.3 {
 			varRes[r] |= ResVarClay
 		}
-
-		if biome == genbiome.WhittakerModBiomeHotSwamp {
-			varRes[r] |= ResVarGas
-		}
-
-		// TODO: Salt, oil, coal.
 	}
 	m.Various = varRes
 }
@@ -598,7 +633,7 @@ func (m *Geo) placeForests() {
 
 	wood := make([]byte, m.SphereMesh.NumRegions)
 	for r := 0; r < m.SphereMesh.NumRegions; r++ {
-		if m.Elevation[r] <= 0.0 {
+		if m.Elevation.At(r) <= 0.0 {
 			continue
 		}
 
@@ -20,7 +20,92 @@ const (
 // NOTE: This is based on mewo2's terrain generation code
 // See: https://github.com/mewo2/terrain
 func (m *Geo) assignFlux(skipBelowSea bool) {
-	m.Flux = m.getFlux(skipBelowSea)
+	m.Flux.SetValues(m.getFlux(skipBelowSea))
+}
+
+// fluxMinParallelRegions is the minimum number of regions of a step in
+// accumulateFlux to be processed in parallel.
+const fluxMinParallelRegions = 4096
+
+// accumulateFlux adds the flux of each region to its downhill neighbor,
+// starting with the regions furthest upstream.
+//
+// Instead of sorting all regions by elevation, each region collects the flux
+// of its upstream neighbors once all of them are done. This way we process
+// the regions in steps, where all regions of a step can be processed in
+// parallel. The result only depends on the mesh, not the parallelism.
+func (m *Geo) accumulateFlux(regFlux []float64, skipBelowSea bool) {
+	numRegions := m.SphereMesh.NumRegions
+
+	// passesFlux returns true if the region passes its flux to the downhill
+	// neighbor, which is not the case if we are below sea level or there is
+	// no downhill neighbor where the water could flow to.
+	// NOTE: In this case we allow water to flow to sea level.
+	passesFlux := func(r int) bool {
+		return m.Downhill[r] >= 0 && !(m.Elevation.At(r) < 0 && skipBelowSea)
+	}
+
+	// Collect the upstream neighbors of each region.
+	// The upstream neighbors of region r are upstream[upStart[r]:upStart[r+1]].
+	upStart := make([]int, numRegions+1)
+	for r := 0; r < numRegions; r++ {
+		if passesFlux(r) {
+			upStart[m.Downhill[r]+1]++
+		}
+	}
+	for r := 0; r < numRegions; r++ {
+		upStart[r+1] += upStart[r]
+	}
+	upstream := make([]int, upStart[numRegions])
+	next := make([]int, numRegions)
+	copy(next, upStart)
+	for r := 0; r < numRegions; r++ {
+		if passesFlux(r) {
+			d := m.Downhill[r]
+			upstream[next[d]] = r
+			next[d]++
+		}
+	}
+
+	// Start with all regions without upstream neighbors.
+	remaining := make([]int, numRegions)
+	var step []int
+	for r := 0; r < numRegions; r++ {
+		remaining[r] = upStart[r+1] - upStart[r]
+		if remaining[r] == 0 {
+			step = append(step, r)
+		}
+	}
+	collectFlux := func(start, end int) {
+		for _, r := range step[start:end] {
+			for _, u := range upstream[upStart[r]:upStart[r+1]] {
+				regFlux[r] += regFlux[u]
+			}
+		}
+	}
+	for len(step) > 0 {
+		// All upstream neighbors of the regions in this step are done.
+		if len(step) >= fluxMinParallelRegions {
+			m.GetExecutor().ParallelFor(len(step), collectFlux)
+		} else {
+			collectFlux(0, len(step))
+		}
+
+		// The downhill neighbors are next once all their upstream neighbors
+		// are done.
+		nextStep := next[:0]
+		for _, r := range step {
+			if !passesFlux(r) {
+				continue
+			}
+			d := m.Downhill[r]
+			remaining[d]--
+			if remaining[d] == 0 {
+				nextStep = append(nextStep, d)
+			}
+		}
+		step, next = nextStep, step
+	}
 }
 
 // getFlux calculates and returns the water flux values for each region.
@@ -31,35 +116,16 @@ func (m *Geo) getFlux(skipBelowSea bool) []float64 {
 	// Initialize flux values with r_rainfall.
 	regFlux := make([]float64, m.SphereMesh.NumRegions)
 	for i := 0; i < m.SphereMesh.NumRegions; i++ {
-		if m.Elevation[i] >= 0 || !skipBelowSea {
-			regFlux[i] = m.Rainfall[i]
+		if m.Elevation.At(i) >= 0 || !skipBelowSea {
+			regFlux[i] = m.Rainfall.At(i)
 		}
 	}
 
 	switch variant {
 	case FluxVolVariantBasic:
-		// This is most basic flux calculation.
-		// Sort regions by elevation in descending order.
-		idxs := make([]int, len(regFlux))
-		for i := range regFlux {
-			idxs[i] = i
-		}
-		sort.Slice(idxs, func(a, b int) bool {
-			return m.Elevation[idxs[a]] > m.Elevation[idxs[b]]
-		})
-
-		// Highest elevation first.
-		for _, r := range idxs {
-			// Skip calculation if we are below sea level or there is no downhill
-			// neighbor where the water could flow to.
-			// NOTE: In this case we allow water to flow to sea level.
-			if (m.Elevation[r] < 0 && skipBelowSea) || m.Downhill[r] < 0 {
-				continue
-			}
-
-			// Add the flux of the region to the downhill neighbor.
-			regFlux[m.Downhill[r]] += regFlux[r]
-		}
+		// This is most basic flux calculation, where each region passes its
+		// flux on to its downhill neighbor.
+		m.accumulateFlux(regFlux, skipBelowSea)
 	case FluxVolVariantBasicWithDrains:
 		// Basic variant copying the flux to the downhill neighbor or the drainage.
 		// Initialize map for identifying drains and populate initial state of sorted index.
@@ -75,14 +141,14 @@ func (m *Geo) getFlux(skipBelowSea bool) []float64 {
 
 		// Sort index array.
 		sort.Slice(idxs, func(a, b int) bool {
-			return (m.Elevation[idxs[b]] + m.Waterpool[idxs[b]]) < (m.Elevation[idxs[a]] + m.Waterpool[idxs[a]])
+			return (m.Elevation.At(idxs[b]) + m.Waterpool.At(idxs[b])) < (m.Elevation.At(idxs[a]) + m.Waterpool.At(idxs[a]))
 		})
 
 		// Copy flux to known drainage point or next lowest neighbor.
 		for _, j := range idxs {
 			// Do not copy flux if we are below sea level.
 			// NOTE: In this case we allow water to flow to sea level.
-			if m.Elevation[j] < 0 && skipBelowSea {
+			if m.Elevation.At(j) < 0 && skipBelowSea {
 				continue
 			}
 
@@ -112,7 +178,7 @@ func (m *Geo) getFlux(skipBelowSea bool) []float64 {
 			}
 			for drain != -1 {
 				// NOTE: In this case we allow water to flow to sea level.
-				if m.Elevation[drain] < 0 && skipBelowSea {
+				if m.Elevation.At(drain) < 0 && skipBelowSea {
 					break
 				}
 				regFluxTmp[drain] += fl
@@ -164,7 +230,7 @@ func (m *Geo) getFlux(skipBelowSea bool) []float64 {
 				// If we couldn't find a region to drain into, or if
 				// we are below sea level, stop here.
 				// NOTE: In this case we allow water to flow to sea level.
-				if r < 0 || m.Elevation[r] < 0 && skipBelowSea {
+				if r < 0 || m.Elevation.At(r) < 0 && skipBelowSea {
 					break
 				}
 				// Abort if we have already visited r to avoid circular
@@ -199,9 +265,7 @@ func (m *BaseObject) AssignFlow() {
 	sideFlow := m.SideFlow
 
 	// Clear all existing water flux values.
-	for i := range sideFlow {
-		sideFlow[i] = 0
-	}
+	sideFlow.Fill(0)
 
 	triFlow := m.TriFlow
 	triElevation := m.TriElevation
@@ -211,10 +275,10 @@ func (m *BaseObject) AssignFlow() {
 	// half of its moisture squared as its initial state.
 	numTriangles := m.SphereMesh.NumTriangles
 	for t := 0; t < numTriangles; t++ {
-		if triElevation[t] >= 0.0 {
-			triFlow[t] = 0.5 * triMoisture[t] * triMoisture[t]
+		if triElevation.At(t) >= 0.0 {
+			triFlow.Set(t, 0.5*triMoisture.At(t)*triMoisture.At(t))
 		} else {
-			triFlow[t] = 0
+			triFlow.Set(t, 0)
 		}
 	}
 
@@ -229,30 +293,27 @@ func (m *BaseObject) AssignFlow() {
 		flowSide := triDownflowSide[tributaryTri]
 		if flowSide >= 0 {
 			trunkTri := (halfedges[flowSide] / 3)
-			triFlow[trunkTri] += triFlow[tributaryTri]
-			sideFlow[flowSide] += triFlow[tributaryTri] // TODO: isn't s_flow[flow_s] === t_flow[?]
-			if triElevation[trunkTri] > triElevation[tributaryTri] {
-				triElevation[trunkTri] = triElevation[tributaryTri]
+			triFlow.Add(trunkTri, triFlow.At(tributaryTri))
+			sideFlow.Add(flowSide, triFlow.At(tributaryTri)) // TODO: isn't s_flow[flow_s] === t_flow[?]
+			if triElevation.At(trunkTri) > triElevation.At(tributaryTri) {
+				triElevation.Set(trunkTri, triElevation.At(tributaryTri))
 			}
 		}
 	}
-	m.TriFlow = triFlow
-	m.SideFlow = sideFlow
-	m.TriElevation = triElevation
 }
 
 // assignWaterfalls finds regions that carry a river and are steep enough to be a waterfall.
 func (m *BaseObject) assignWaterfalls() {
 	steepness := m.GetSteepness()
-	wfRegs := make(map[int]bool)
+	wfRegs := various.NewBitset(m.SphereMesh.NumRegions)
 	for i, s := range steepness {
-		if m.Elevation[i] <= 0.0 {
+		if m.Elevation.At(i) <= 0.0 {
 			continue
 		}
 		// 1.0 is the maximum steepness (90 degrees), so
 		// everything above 0.9 (81 degrees) is a waterfall.
 		if s > 0.9 && m.IsRegBigRiver(i) {
-			wfRegs[i] = true
+			wfRegs.Set(i)
 		}
 		// TODO:
 		// - Also note that the downhill region is a waterfall?
@@ -319,7 +380,7 @@ func (m *BaseObject) getRiverSegments(limit float64) [][2]int {
 	// Adjust the limit to be a fraction of the max flux.
 	// This will save us a lot of cycles when comparing
 	// flux values to the limit.
-	_, maxFlux := minMax(flux)
+	_, maxFlux := flux.MinMax()
 	limit *= maxFlux
 
 	// Find all link segments that have a high enough flux value.
@@ -327,7 +388,7 @@ func (m *BaseObject) getRiverSegments(limit float64) [][2]int {
 	for r := 0; r < m.SphereMesh.NumRegions; r++ {
 		// Skip all regions that are sinks / have no downhill neighbor or
 		// regions below sea level.
-		if dh[r] < 0 || m.Elevation[r] < 0 {
+		if dh[r] < 0 || m.Elevation.At(r) < 0 {
 			continue
 		}
 
@@ -336,13 +397,13 @@ func (m *BaseObject) getRiverSegments(limit float64) [][2]int {
 		// water influx.
 		// NOTE: Rivers need at least one contributor region and would therefore have a flux
 		// value that is higher than the rainfall in the region.
-		if flux[r] <= m.Rainfall[r] || flux[dh[r]] <= m.Rainfall[dh[r]] {
+		if flux.At(r) <= m.Rainfall.At(r) || flux.At(dh[r]) <= m.Rainfall.At(dh[r]) {
 			continue
 		}
 
 		// NOTE: Right now we skip segments if both flux values are
 		// below the limit.
-		if flux[r] >= limit && flux[dh[r]] >= limit {
+		if flux.At(r) >= limit && flux.At(dh[r]) >= limit {
 			// NOTE: The river segment always flows from seg[0] to seg[1].
 			links = append(links, [2]int{r, dh[r]})
 		}
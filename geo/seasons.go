@@ -27,35 +27,40 @@ const (
 
 // GetSeason returns the season for the current day of the year and the given
 // latitude.
+//
+// NOTE: The equinox and solstice days above are based on an earth year, so we
+// convert the day of the year to the equivalent day on earth.
 func (m *Geo) GetSeason(lat float64) int {
+	day := m.toEarthDayOfYear(m.GetDayOfYear())
+
 	// If we are in the northern hemisphere, the seasons are "normal".
 	if lat > 0 {
-		if m.GetDayOfYear() < SpringEquinoxDayOfYear {
+		if day < SpringEquinoxDayOfYear {
 			return SeasonWinter
 		}
-		if m.GetDayOfYear() < SummerSolsticeDayOfYear {
+		if day < SummerSolsticeDayOfYear {
 			return SeasonSpring
 		}
-		if m.GetDayOfYear() < AutumnEquinoxDayOfYear {
+		if day < AutumnEquinoxDayOfYear {
 			return SeasonSummer
 		}
-		if m.GetDayOfYear() < WinterSolsticeDayOfYear {
+		if day < WinterSolsticeDayOfYear {
 			return SeasonAutumn
 		}
 		return SeasonWinter
 	}
 
 	// If we are in the southern hemisphere, the seasons are reversed.
-	if m.GetDayOfYear() < SpringEquinoxDayOfYear {
+	if day < SpringEquinoxDayOfYear {
 		return SeasonSummer
 	}
-	if m.GetDayOfYear() < SummerSolsticeDayOfYear {
+	if day < SummerSolsticeDayOfYear {
 		return SeasonAutumn
 	}
-	if m.GetDayOfYear() < AutumnEquinoxDayOfYear {
+	if day < AutumnEquinoxDayOfYear {
 		return SeasonWinter
 	}
-	if m.GetDayOfYear() < WinterSolsticeDayOfYear {
+	if day < WinterSolsticeDayOfYear {
 		return SeasonSpring
 	}
 	return SeasonSummer
@@ -65,16 +70,16 @@ func (m *Geo) GetSeason(lat float64) int {
 // given latitude, longitude, altitude, day of the year and hour of the day
 // where the hour is the true hour of the location, not the hour according to
 // a timezone.
-func calculateSunVectorWithoutTrueSolarTime(lat, lon, altitude float64, day int, hour float64) vectors.Vec3 {
-	elevation, azimuth := calculateSunPositionWithoutTrueSolarTime(lat, lon, altitude, day, hour)
+func (p *PlanetConfig) calculateSunVectorWithoutTrueSolarTime(lat, lon, altitude float64, day int, hour float64) vectors.Vec3 {
+	elevation, azimuth := p.calculateSunPositionWithoutTrueSolarTime(lat, lon, altitude, day, hour)
 	return sunVectorFromElevationAndAzimuth(elevation, azimuth)
 }
 
 // calculateSunVector calculates the sun vector for the given latitude,
 // longitude, altitude, day of the year and hour of the day.
 // The hour is based on the timezone of the location.
-func calculateSunVector(lat, lon, altitude float64, day int, hour float64) vectors.Vec3 {
-	elevation, azimuth := calculateSunPosition(lat, lon, altitude, day, hour)
+func (p *PlanetConfig) calculateSunVector(lat, lon, altitude float64, day int, hour float64) vectors.Vec3 {
+	elevation, azimuth := p.calculateSunPosition(lat, lon, altitude, day, hour)
 	return sunVectorFromElevationAndAzimuth(elevation, azimuth)
 }
 
@@ -100,17 +105,20 @@ func sunVectorFromElevationAndAzimuth(elevation, azimuth float64) vectors.Vec3 {
 }
 
 // TODO: Merge this with the calculateSunPosition function.
-func calculateSunPositionWithoutTrueSolarTime(latitude, longitude, altitude float64, dayOfYear int, hour float64) (elevation, azimuth float64) {
+func (p *PlanetConfig) calculateSunPositionWithoutTrueSolarTime(latitude, longitude, altitude float64, dayOfYear int, hour float64) (elevation, azimuth float64) {
 	// See: https://gml.noaa.gov/grad/solcalc/solareqns.PDF
 	latRad := latitude * math.Pi / 180.0
 
 	// Calculate the fractional year in radians.
 	// We ignore leap yers for now.
-	fractionalYear := (2.0 * math.Pi / 365.0) * (float64(dayOfYear) - 1.0 + (hour / 24.0))
+	fractionalYear := p.fractionalYear(dayOfYear, hour)
 
 	// Calculate the declination of the sun.
 	// See http://en.wikipedia.org/wiki/Position_of_the_Sun
+	// NOTE: This approximation is fitted to earth, so we scale it by the
+	// axial tilt of the planet.
 	declination := 0.006918 - (0.399912 * math.Cos(fractionalYear)) + (0.070257 * math.Sin(fractionalYear)) - (0.006758 * math.Cos(2.0*fractionalYear)) + (0.000907 * math.Sin(2.0*fractionalYear)) - (0.002697 * math.Cos(3.0*fractionalYear)) + (0.00148 * math.Sin(3.0*fractionalYear))
+	declination *= p.tiltFactor()
 
 	// where eqtime is in minutes, longitude is in degrees (positive to the east of the Prime Meridian),
 	// timezone is in hours from UTC (U.S. Mountain Standard Time = –7 hours).
@@ -119,7 +127,7 @@ func calculateSunPositionWithoutTrueSolarTime(latitude, longitude, altitude floa
 	trueSolarTime := hour*60.0 + mn + sc/60
 
 	// The hour angle is then calculated from the true solar time.
-	ha := trueSolarTime/4.0 - 180.0
+	ha := 360.0*trueSolarTime/(p.HoursPerDay*60.0) - 180.0
 	// The solar zenith angle (phi) can then be found from the hour angle (ha), latitude (lat) and solar
 	// declination (decl) using the following equation:
 
@@ -144,7 +152,7 @@ func calculateSunPositionWithoutTrueSolarTime(latitude, longitude, altitude floa
 	return elevation, azimuth
 }
 
-func calculateSunPosition(latitude, longitude, altitude float64, dayOfYear int, hour float64) (elevation, azimuth float64) {
+func (p *PlanetConfig) calculateSunPosition(latitude, longitude, altitude float64, dayOfYear int, hour float64) (elevation, azimuth float64) {
 	// See: https://gml.noaa.gov/grad/solcalc/solareqns.PDF
 
 	latRad := latitude * math.Pi / 180.0
@@ -152,7 +160,7 @@ func calculateSunPosition(latitude, longitude, altitude float64, dayOfYear int,
 
 	// Calculate the fractional year in radians.
 	// We ignore leap yers for now.
-	fractionalYear := (2.0 * math.Pi / 365.0) * (float64(dayOfYear) - 1.0 + (hour / 24.0))
+	fractionalYear := p.fractionalYear(dayOfYear, hour)
 
 	// Estimate the equation of time.
 	// See http://en.wikipedia.org/wiki/Equation_of_time
@@ -160,7 +168,10 @@ func calculateSunPosition(latitude, longitude, altitude float64, dayOfYear int,
 
 	// Calculate the declination of the sun.
 	// See http://en.wikipedia.org/wiki/Position_of_the_Sun
+	// NOTE: This approximation is fitted to earth, so we scale it by the
+	// axial tilt of the planet.
 	declination := 0.006918 - (0.399912 * math.Cos(fractionalYear)) + (0.070257 * math.Sin(fractionalYear)) - (0.006758 * math.Cos(2.0*fractionalYear)) + (0.000907 * math.Sin(2.0*fractionalYear)) - (0.002697 * math.Cos(3.0*fractionalYear)) + (0.00148 * math.Sin(3.0*fractionalYear))
+	declination *= p.tiltFactor()
 
 	// Calculate the timezone coarsely by longitude.
 	timeZone := int(math.Floor(longitude/15.0 + 0.5))
@@ -176,7 +187,7 @@ func calculateSunPosition(latitude, longitude, altitude float64, dayOfYear int,
 	trueSolarTime := hour*60.0 + mn + sc/60 + timeOffset
 
 	// The hour angle is then calculated from the true solar time.
-	ha := trueSolarTime/4.0 - 180.0
+	ha := 360.0*trueSolarTime/(p.HoursPerDay*60.0) - 180.0
 	// The solar zenith angle (phi) can then be found from the hour angle (ha), latitude (lat) and solar
 	// declination (decl) using the following equation:
 
@@ -224,7 +235,7 @@ func (m *Geo) GetAverageInsolation(day int) []float64 {
 			// Calculate average insolation for the given day and latitude.
 			if enableInsolation {
 				// Get the base insolation value for the given latitude.
-				insolation = CalcSolarRadiation(various.DegToRad(lat), day)
+				insolation = m.SolarRadiation(various.DegToRad(lat), day)
 			}
 
 			strength := 1.0
@@ -234,7 +245,7 @@ func (m *Geo) GetAverageInsolation(day int) []float64 {
 			// TODO: We should move this to the hourly insolation calculation.
 			if enableNormals {
 				normVec := m.regPolySlopeVec3(nbs, i)
-				sunVec := calculateSunVectorWithoutTrueSolarTime(lat, m.LatLon[i][1], 0, day, fixedNormalsDayHour).Normalize()
+				sunVec := m.calculateSunVectorWithoutTrueSolarTime(lat, m.LatLon[i][1], 0, day, fixedNormalsDayHour).Normalize()
 
 				// If the vectors are exactly opposite, the insolation is 1.0.
 				// If the vectors are exactly the same, the insolation is 0.0.
@@ -251,7 +262,7 @@ func (m *Geo) GetAverageInsolation(day int) []float64 {
 			if enableHourlyInsolation {
 				var sum int
 				var count int
-				for hour := 0.0; hour < 24; hour += 0.25 {
+				for hour := 0.0; hour < m.HoursPerDay; hour += 0.25 {
 					// TODO: The darkness of the shadow would depend on the distance
 					// between the location and the point that blocks the sun.
 
@@ -277,7 +288,7 @@ func (m *Geo) GetAverageInsolation(day int) []float64 {
 		}
 	}
 	if useGoRoutines {
-		various.KickOffChunkWorkers(m.SphereMesh.NumRegions, chunkProcessor)
+		m.GetExecutor().ParallelFor(m.SphereMesh.NumRegions, chunkProcessor)
 	} else {
 		chunkProcessor(0, m.SphereMesh.NumRegions)
 	}
@@ -344,7 +355,7 @@ func (m *Geo) TriangulateElevation(lat, lon float64, outTri, outRegs []int) floa
 		}
 	}
 
-	return calcHeightMercator(m.LatLon[regs[0]], m.LatLon[regs[1]], m.LatLon[regs[2]], latlon, m.Elevation[regs[0]], m.Elevation[regs[1]], m.Elevation[regs[2]])
+	return calcHeightMercator(m.LatLon[regs[0]], m.LatLon[regs[1]], m.LatLon[regs[2]], latlon, m.Elevation.At(regs[0]), m.Elevation.At(regs[1]), m.Elevation.At(regs[2]))
 }
 
 func wrapLat(latitude float64) float64 {
@@ -379,7 +390,7 @@ func (m *Geo) GetInsolationShadowDistance(region, day int, hour float64, outTri,
 	elevMultiplier := 1.0
 
 	// Calculate sun position.
-	elevation, azimuth := calculateSunPositionWithoutTrueSolarTime(lat, lon, 0, day, hour)
+	elevation, azimuth := m.calculateSunPositionWithoutTrueSolarTime(lat, lon, 0, day, hour)
 
 	// If the sun is below the horizon, there is no insolation.
 	if elevation < 0 {
@@ -417,7 +428,7 @@ func (m *Geo) GetInsolationShadowDistance(region, day int, hour float64, outTri,
 			log.Printf("%d: Lat: %f, Lon: %f", i, lat2, lon2)
 		}
 
-		height1 := m.Elevation[region]
+		height1 := m.Elevation.At(region)
 		if height1 < 0 {
 			height1 = 0
 		}
@@ -459,7 +470,7 @@ func (m *Geo) GetInsolationShadowDistance(region, day int, hour float64, outTri,
 // GetSolarRadiation returns the solar radiation for the current day of the year
 // and the given latitude.
 func (m *Geo) GetSolarRadiation(lat float64) float64 {
-	return CalcSolarRadiation(various.DegToRad(lat), m.GetDayOfYear())
+	return m.SolarRadiation(various.DegToRad(lat), m.GetDayOfYear())
 }
 
 // calcMinMaxTemperature calculates the minimum and maximum temperature for
@@ -481,12 +492,12 @@ func (m *Geo) GetMinMaxTemperature(lat float64) (min, max float64) {
 
 func (m *Geo) GetMinMaxTemperatureOfDay(lat float64, dayOfYear int) (min, max float64) {
 	// Get yearly average temperature for the given latitude.
-	tmp := GetMeanAnnualTemp(lat)
+	tmp := m.getMeanAnnualTemp(lat)
 	// TODO: Compensate for altitude.
 
 	// Now get the average day and night duration for the given latitude.
-	dayLen := calcDaylightHoursByLatitudeAndDayOfYear(various.DegToRad(lat), dayOfYear)
-	nightLen := 24.0 - dayLen
+	dayLen := m.calcDaylightHoursByLatitudeAndDayOfYear(various.DegToRad(lat), dayOfYear)
+	nightLen := m.HoursPerDay - dayLen
 
 	// Given the mean temperature and the day and night duration, we can
 	// calculate the minimum and maximum temperature for the current day.
@@ -533,29 +544,35 @@ func (m *Geo) GetMinMaxTemperatureOfDay(lat float64, dayOfYear int) (min, max fl
 func (m *Geo) CalcSolarRadiation(dayOfYear int) []float64 {
 	res := make([]float64, m.SphereMesh.NumRegions)
 	for i := range res {
-		res[i] = CalcSolarRadiation(various.DegToRad(m.LatLon[i][0]), dayOfYear)
+		res[i] = m.SolarRadiation(various.DegToRad(m.LatLon[i][0]), dayOfYear)
 	}
 	return res
 }
 
-// Calculate incoming solar (or shortwave) radiation, *Rs* (radiation hitting
-// a horizontal plane after scattering by the atmosphere) from latitude, and
-// day of year.
+// CalcSolarRadiation calculates the incoming solar radiation on an earth-like
+// planet. See PlanetConfig.SolarRadiation.
+func CalcSolarRadiation(latRad float64, dayOfYear int) float64 {
+	return earthPlanet.SolarRadiation(latRad, dayOfYear)
+}
+
+// SolarRadiation calculates the incoming solar (or shortwave) radiation, *Rs*
+// (radiation hitting a horizontal plane after scattering by the atmosphere)
+// from latitude, and day of year.
 //
 // 'latitude': Latitude [radians].
-// 'dayOfYear': Day of year integer between 1 and 365 or 366).
+// 'dayOfYear': Day of year integer between 1 and DaysPerYear.
 //
 // Returns incoming solar (or shortwave) radiation [MJ m-2 day-1]
-func CalcSolarRadiation(latRad float64, dayOfYear int) float64 {
-	daylightHours := calcDaylightHoursByLatitudeAndDayOfYear(latRad, dayOfYear)
+func (p *PlanetConfig) SolarRadiation(latRad float64, dayOfYear int) float64 {
+	daylightHours := p.calcDaylightHoursByLatitudeAndDayOfYear(latRad, dayOfYear)
 	sunshineHours := daylightHours * 0.7 // 70% of daylight hours
 
-	sd := solarDeclination(dayOfYear)
+	sd := p.solarDeclination(dayOfYear)
 	sha := sunsetHourAngle(latRad, sd)
-	ird := invRelDistEarthSun(dayOfYear)
+	ird := p.invRelDistSun(dayOfYear)
 
 	// TODO: Use clearSkyRadiation to calculate et at a given altitude.
-	et := extraterrRadiation(latRad, sd, sha, ird)
+	et := p.extraterrRadiation(latRad, sd, sha, ird)
 	sr := solRadFromSunHours(daylightHours, sunshineHours, et)
 
 	// At the poles, we spread the solar radiation over a wider area since
@@ -568,13 +585,13 @@ func CalcSolarRadiation(latRad float64, dayOfYear int) float64 {
 // Based on FAO equation 34 in Allen et al (1998).
 //
 // 'latitude': Latitude [radians]
-// 'dayOfYear': Day of year integer between 1 and 365 or 366).
+// 'dayOfYear': Day of year integer between 1 and DaysPerYear.
 //
 // Returns daylight hours.
-func calcDaylightHoursByLatitudeAndDayOfYear(latRad float64, dayOfYear int) float64 {
-	sd := solarDeclination(dayOfYear)
+func (p *PlanetConfig) calcDaylightHoursByLatitudeAndDayOfYear(latRad float64, dayOfYear int) float64 {
+	sd := p.solarDeclination(dayOfYear)
 	sha := sunsetHourAngle(latRad, sd)
-	return daylightHours(sha)
+	return p.daylightHours(sha)
 }
 
 // Calculate incoming solar (or shortwave) radiation, *Rs* (radiation hitting
@@ -622,13 +639,14 @@ func sunsetHourAngle(latRad float64, solDec float64) float64 {
 }
 
 // Calculate solar declination from day of the year.
-// Based on FAO equation 24 in Allen et al (1998).
+// Based on FAO equation 24 in Allen et al (1998), where the amplitude is the
+// axial tilt (0.409 radians for earth).
 //
-// 'dayOfYear': Day of year integer between 1 and 365 or 366).
+// 'dayOfYear': Day of year integer between 1 and DaysPerYear.
 //
 // Returns solar declination [radians]
-func solarDeclination(dayOfYear int) float64 {
-	return 0.409 * math.Sin((2.0*math.Pi/365.0)*float64(dayOfYear)-1.39)
+func (p *PlanetConfig) solarDeclination(dayOfYear int) float64 {
+	return various.DegToRad(p.AxialTilt) * math.Sin((2.0*math.Pi/float64(p.DaysPerYear))*float64(dayOfYear)-1.39)
 }
 
 // Calculate daylight hours from sunset hour angle.
@@ -637,8 +655,8 @@ func solarDeclination(dayOfYear int) float64 {
 // 'sha': Sunset hour angle [rad].
 //
 // Returns daylight hours.
-func daylightHours(sha float64) float64 {
-	return (24.0 / math.Pi) * sha
+func (p *PlanetConfig) daylightHours(sha float64) float64 {
+	return (p.HoursPerDay / math.Pi) * sha
 }
 
 // Estimate daily extraterrestrial radiation (*Ra*, 'top of the atmosphere
@@ -657,8 +675,8 @@ func daylightHours(sha float64) float64 {
 // 'ird': Inverse relative distance earth-sun [dimensionless].
 //
 // Returns daily extraterrestrial radiation [MJ m-2 day-1]
-func extraterrRadiation(latitude, solDec, sha, ird float64) float64 {
-	tmp1 := (24.0 * 60.0) / math.Pi
+func (p *PlanetConfig) extraterrRadiation(latitude, solDec, sha, ird float64) float64 {
+	tmp1 := (p.HoursPerDay * 60.0) / math.Pi
 	tmp2 := sha * math.Sin(latitude) * math.Sin(solDec)
 	tmp3 := math.Cos(latitude) * math.Cos(solDec) * math.Sin(sha)
 	return tmp1 * solarConstant * ird * (tmp2 + tmp3)
@@ -676,12 +694,13 @@ func clearSkyRadiation(altitude float64, etRad float64) float64 {
 	return (0.00002*altitude + 0.75) * etRad
 }
 
-// Calculate the inverse relative distance between earth and sun from
-// day of the year. Based on FAO equation 23 in Allen et al (1998).
+// Calculate the inverse relative distance between the planet and the sun
+// from day of the year. Based on FAO equation 23 in Allen et al (1998), where
+// the amplitude is roughly twice the orbital eccentricity (0.033 for earth).
 //
-// 'dayOfYear': Day of the year [1 to 366]
+// 'dayOfYear': Day of the year [1 to DaysPerYear]
 //
-// Returns inverse relative distance between earth and the sun.
-func invRelDistEarthSun(dayOfYear int) float64 {
-	return 1 + (0.033 * math.Cos((2.0*math.Pi/365.0)*float64(dayOfYear)))
+// Returns inverse relative distance between the planet and the sun.
+func (p *PlanetConfig) invRelDistSun(dayOfYear int) float64 {
+	return 1 + (2 * p.Eccentricity * math.Cos((2.0*math.Pi/float64(p.DaysPerYear))*float64(dayOfYear)))
 }
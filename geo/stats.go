@@ -4,16 +4,22 @@ import (
 	"log"
 
 	"github.com/Flokey82/genbiome"
-	"github.com/Flokey82/go_gens/gameconstants"
 )
 
 type Stats struct {
 	NumRegions int
-	ResMetal   [ResMaxMetals]int
+	ResMetal   [ResMaxMetals]int // Number of (non-depleted) deposits per metal
 	ResGems    [ResMaxGems]int
 	ResStones  [ResMaxStones]int
 	ResWood    [ResMaxWoods]int
-	TotalArea  float64
+	QtyMetal   [ResMaxMetals]float64 // Remaining quantity per metal
+	QtyGems    [ResMaxGems]float64
+	QtyStones  [ResMaxStones]float64
+	QtyWood    [ResMaxWoods]float64
+	ResCustom  map[string]int     // Number of (non-depleted) deposits per custom resource
+	QtyCustom  map[string]float64 // Remaining quantity per custom resource
+	TotalArea  float64            // Area on the unit sphere
+	AreaKm2    float64            // Area in km²
 	Biomes     map[int]int
 	Desert     int
 	Forest     int
@@ -40,28 +46,43 @@ func (m *Geo) GetStats(rr []int) *Stats {
 	st := &Stats{
 		NumRegions: len(rr),
 		Biomes:     make(map[int]int),
+		ResCustom:  make(map[string]int),
+		QtyCustom:  make(map[string]float64),
 	}
 	biomeFunc := m.GetRegWhittakerModBiomeFunc()
 	for _, r := range rr {
 		st.TotalArea += m.GetRegArea(r)
-		for i := 0; i < ResMaxMetals; i++ {
-			if m.Metals[r]&(1<<i) != 0 {
-				st.ResMetal[i]++
+		for _, d := range m.Deposits[r] {
+			if !d.IsAvailable() {
+				continue
 			}
-		}
-		for i := 0; i < ResMaxGems; i++ {
-			if m.Gems[r]&(1<<i) != 0 {
-				st.ResGems[i]++
+			if !d.Def.IsBuiltin() {
+				st.ResCustom[d.Name()]++
+				st.QtyCustom[d.Name()] += d.Quantity
+				continue
 			}
-		}
-		for i := 0; i < ResMaxStones; i++ {
-			if m.Stones[r]&(1<<i) != 0 {
-				st.ResStones[i]++
-			}
-		}
-		for i := 0; i < ResMaxWoods; i++ {
-			if m.Wood[r]&(1<<i) != 0 {
-				st.ResWood[i]++
+			i := d.ResourceID()
+			switch d.Type {
+			case ResourceTypeMetal:
+				if i < ResMaxMetals {
+					st.ResMetal[i]++
+					st.QtyMetal[i] += d.Quantity
+				}
+			case ResourceTypeGem:
+				if i < ResMaxGems {
+					st.ResGems[i]++
+					st.QtyGems[i] += d.Quantity
+				}
+			case ResourceTypeStone:
+				if i < ResMaxStones {
+					st.ResStones[i]++
+					st.QtyStones[i] += d.Quantity
+				}
+			case ResourceTypeWood:
+				if i < ResMaxWoods {
+					st.ResWood[i]++
+					st.QtyWood[i] += d.Quantity
+				}
 			}
 		}
 		b := biomeFunc(r)
@@ -84,22 +105,26 @@ func (m *Geo) GetStats(rr []int) *Stats {
 			st.Wetlands++
 		}
 	}
+	st.AreaKm2 = m.AreaToKm2(st.TotalArea)
 	return st
 }
 
 func (s *Stats) Log() {
-	log.Printf("Total Area: %.2f km2", s.TotalArea*gameconstants.EarthSurface/gameconstants.SphereSurface)
+	log.Printf("Total Area: %.2f km2", s.AreaKm2)
 	for i := 0; i < ResMaxMetals; i++ {
-		log.Printf("Metal %s: %d (%.6f%%)", MetalToString(i), s.ResMetal[i], float64(s.ResMetal[i])/float64(s.NumRegions))
+		log.Printf("Metal %s: %d (%.6f%%), quantity %.0f", MetalToString(i), s.ResMetal[i], float64(s.ResMetal[i])/float64(s.NumRegions), s.QtyMetal[i])
 	}
 	for i := 0; i < ResMaxGems; i++ {
-		log.Printf("Gem %s: %d (%.6f%%)", GemToString(i), s.ResGems[i], float64(s.ResGems[i])/float64(s.NumRegions))
+		log.Printf("Gem %s: %d (%.6f%%), quantity %.0f", GemToString(i), s.ResGems[i], float64(s.ResGems[i])/float64(s.NumRegions), s.QtyGems[i])
 	}
 	for i := 0; i < ResMaxStones; i++ {
-		log.Printf("Stone %s: %d (%.6f%%)", StoneToString(i), s.ResStones[i], float64(s.ResStones[i])/float64(s.NumRegions))
+		log.Printf("Stone %s: %d (%.6f%%), quantity %.0f", StoneToString(i), s.ResStones[i], float64(s.ResStones[i])/float64(s.NumRegions), s.QtyStones[i])
 	}
 	for i := 0; i < ResMaxWoods; i++ {
-		log.Printf("Wood %s: %d (%.6f%%)", WoodToString(i), s.ResWood[i], float64(s.ResWood[i])/float64(s.NumRegions))
+		log.Printf("Wood %s: %d (%.6f%%), quantity %.0f", WoodToString(i), s.ResWood[i], float64(s.ResWood[i])/float64(s.NumRegions), s.QtyWood[i])
+	}
+	for name, count := range s.ResCustom {
+		log.Printf("Custom %s: %d (%.6f%%), quantity %.0f", name, count, float64(count)/float64(s.NumRegions), s.QtyCustom[name])
 	}
 	log.Printf("Desert: %.2f%%", 100*float64(s.Desert)/float64(s.NumRegions))
 	log.Printf("RainForest: %.2f%%", 100*float64(s.RainForest)/float64(s.NumRegions))
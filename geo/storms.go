@@ -0,0 +1,171 @@
+package geo
+
+import (
+	"math"
+
+	"github.com/Flokey82/genworldvoronoi/various"
+)
+
+// StormTrack is the path of a (historical) tropical cyclone.
+type StormTrack struct {
+	DayOfYear int       // Day of the year on which the storm spawned
+	Regions   []int     // Regions traversed by the storm (in order)
+	Intensity []float64 // Intensity (0.0-1.0) at each step of the track
+}
+
+// MaxIntensity returns the peak intensity of the storm.
+func (s *StormTrack) MaxIntensity() float64 {
+	var maxInt float64
+	for _, v := range s.Intensity {
+		maxInt = math.Max(maxInt, v)
+	}
+	return maxInt
+}
+
+// Category returns the Saffir-Simpson-ish category (0-5) of the storm based
+// on its peak intensity, where 0 is a tropical storm.
+func (s *StormTrack) Category() int {
+	return int(math.Min(5, math.Floor(s.MaxIntensity()*6)))
+}
+
+// Tropical cyclone seasons (day of year) for each hemisphere.
+//
+// NOTE: The southern season wraps around the end of the year.
+const (
+	cycloneSeasonNorthStart = 152 // June
+	cycloneSeasonNorthEnd   = 334 // November
+	cycloneSeasonSouthStart = 305 // November
+	cycloneSeasonSouthEnd   = 120 // April
+)
+
+// isCycloneSeason returns true if the given (earth) day of the year falls in
+// the cyclone season of the hemisphere of the given latitude.
+func isCycloneSeason(lat float64, dayOfYear int) bool {
+	if lat >= 0 {
+		return dayOfYear >= cycloneSeasonNorthStart && dayOfYear <= cycloneSeasonNorthEnd
+	}
+	return dayOfYear >= cycloneSeasonSouthStart || dayOfYear <= cycloneSeasonSouthEnd
+}
+
+// assignStormTracks generates the historical storm tracks for the map.
+func (m *Geo) assignStormTracks() {
+	m.StormTracks = m.genStormTracks(m.NumStormTracks)
+}
+
+// genStormTracks generates n storm tracks.
+//
+// Cyclones spawn over warm ocean regions (above CycloneMinSeaTemp) between
+// 5° and 30° latitude during their respective season. They are steered by the
+// wind and ocean currents (with a slight poleward drift due to the coriolis
+// effect), intensify over warm water and decay over land and colder water.
+func (m *Geo) genStormTracks(n int) []*StormTrack {
+	// Reset the random number generator.
+	m.ResetRand()
+
+	// Find all regions where cyclones can spawn.
+	var spawnRegs []int
+	for r := 0; r < m.SphereMesh.NumRegions; r++ {
+		absLat := math.Abs(m.LatLon[r][0])
+		if m.Elevation.At(r) > 0 || absLat < 5 || absLat > 30 {
+			continue
+		}
+		if m.OceanTemperature.At(r) >= m.CycloneMinSeaTemp {
+			spawnRegs = append(spawnRegs, r)
+		}
+	}
+	if len(spawnRegs) == 0 {
+		return nil
+	}
+	_, maxElev := m.Elevation.MinMax()
+
+	// Calculate an approximation of the distance between regions in degrees,
+	// which we use as step size for the storm movement.
+	distRegionDeg := various.RadToDeg(math.Sqrt(4 * math.Pi / float64(m.SphereMesh.NumRegions)))
+
+	// Maximum number of steps a storm can last.
+	const maxSteps = 200
+
+	outRegs := make([]int, 0, 8)
+	var tracks []*StormTrack
+	for attempts := 0; len(tracks) < n && attempts < n*100; attempts++ {
+		r := spawnRegs[m.Rand.Intn(len(spawnRegs))]
+		lat := m.LatLon[r][0]
+
+		// Pick a day within the season of the hemisphere.
+		day := m.Rand.Intn(m.DaysPerYear) + 1
+		if !isCycloneSeason(lat, m.toEarthDayOfYear(day)) {
+			continue
+		}
+
+		track := &StormTrack{DayOfYear: day}
+		intensity := 0.1 + 0.1*m.Rand.Float64()
+		seen := make(map[int]bool)
+		for step := 0; step < maxSteps && intensity > 0.05; step++ {
+			track.Regions = append(track.Regions, r)
+			track.Intensity = append(track.Intensity, intensity)
+			seen[r] = true
+
+			// Update the intensity depending on what is below the storm.
+			if m.Elevation.At(r) > 0 {
+				// Over land the storm loses its energy source and friction
+				// (especially in mountains) tears it apart.
+				intensity *= 0.75 - 0.25*m.Elevation.At(r)/maxElev
+			} else if temp := m.OceanTemperature.At(r); temp >= m.CycloneMinSeaTemp {
+				// Warm water feeds the storm.
+				intensity += (1 - intensity) * 0.05 * (1 + temp - m.CycloneMinSeaTemp)
+			} else {
+				// Colder water weakens the storm.
+				intensity *= 0.95
+			}
+
+			// Steer the storm using wind and ocean currents and add a poleward drift.
+			lat = m.LatLon[r][0]
+			vec := various.Add2(various.Normalize2(m.RegionToWindVec[r]), various.Scale2(m.RegionToOceanVec[r], 0.5))
+			poleward := 0.3 + math.Abs(lat)/30
+			if lat < 0 {
+				poleward = -poleward
+			}
+			vec = various.Add2(vec, [2]float64{0, poleward})
+			if various.Len2(vec) == 0 {
+				break
+			}
+			nr := m.GetClosestNeighbor(outRegs, r, various.Scale2(various.Normalize2(vec), distRegionDeg))
+			if nr < 0 || nr == r || seen[nr] {
+				break
+			}
+			r = nr
+		}
+
+		// Skip storms that died immediately.
+		if len(track.Regions) < 2 {
+			continue
+		}
+		tracks = append(tracks, track)
+	}
+	return tracks
+}
+
+// GetCycloneChance returns the normalized chance of a region being hit by a
+// tropical cyclone based on the historical storm tracks.
+func (m *Geo) GetCycloneChance() []float64 {
+	chance := make([]float64, m.SphereMesh.NumRegions)
+	outRegs := make([]int, 0, 8)
+	for _, t := range m.StormTracks {
+		for i, r := range t.Regions {
+			// The storm affects the region it passes and (to a lesser
+			// degree) the neighboring regions.
+			chance[r] += t.Intensity[i]
+			for _, nb := range m.SphereMesh.R_circulate_r(outRegs, r) {
+				chance[nb] += t.Intensity[i] / 2
+			}
+		}
+	}
+
+	// Normalize the chance.
+	if _, maxChance := minMax(chance); maxChance > 0 {
+		for r := range chance {
+			chance[r] /= maxChance
+		}
+	}
+	return chance
+}
@@ -21,6 +21,11 @@ const (
 // generatePlates generates a number of plate seed points and starts growing the plates
 // starting from those seeds in a random order.
 func (m *Geo) generatePlates() {
+	// If we use an adaptive mesh, we re-use the plates of the coarse pass.
+	if m.meshTemplate != nil {
+		m.applyMeshTemplate(m.meshTemplate)
+		return
+	}
 	m.ResetRand()
 	mesh := m.SphereMesh
 	regPlate := make([]int, mesh.NumRegions)
@@ -214,7 +219,7 @@ const collisionThreshold = 0.75
 //
 // FIXME: The smaller the distance of the cells, the more likely a plate moves past the neighbor plate.
 // This causes all kinds of issues.
-func (m *Geo) findCollisions() ([]int, []int, []int, map[int]float64) {
+func (m *Geo) findCollisions() ([]int, []int, []int, []float64) {
 	// Use either the largest or smallest compression value.
 	useLargestCompression := true
 
@@ -222,7 +227,7 @@ func (m *Geo) findCollisions() ([]int, []int, []int, map[int]float64) {
 	regPlate := m.RegionToPlate
 	plateVectors := m.PlateToVector
 	numRegions := m.SphereMesh.NumRegions
-	compressionReg := make(map[int]float64)
+	compressionReg := make([]float64, numRegions)
 
 	// Initialize the compression measure to either the largest or smallest
 	// possible float64 value.
@@ -335,7 +340,7 @@ func (m *Geo) findCollisions() ([]int, []int, []int, map[int]float64) {
 
 // PropagateCompression propagates the compression values from the seed regions
 // to all other regions.
-func (m *BaseObject) PropagateCompression(compression map[int]float64) []float64 {
+func (m *BaseObject) PropagateCompression(compression []float64) []float64 {
 	// Get the min and max compression value so that we can
 	// normalize the compression value, also we need to copy
 	// the compression values into a slice so that we can
@@ -343,10 +348,11 @@ func (m *BaseObject) PropagateCompression(compression map[int]float64) []float64
 	cmp := make([]float64, m.SphereMesh.NumRegions)
 	var cmpSeeds []int
 	for r, comp := range compression {
-		cmp[r] = comp
-		cmpSeeds = append(cmpSeeds, r)
+		if comp != 0 {
+			cmp[r] = comp
+			cmpSeeds = append(cmpSeeds, r)
+		}
 	}
-	sort.Ints(cmpSeeds)
 
 	// Queue up the seed regions, shuffle them so that we don't
 	// always start with the same regions.
@@ -420,9 +426,9 @@ func (m *Geo) assignRegionElevation() {
 	// as volcanoes.
 	var gotVolcanoes int
 	for _, r := range m.Mountain_r {
-		m.RegionIsMountain[r] = true
+		m.RegionIsMountain.Set(r)
 		if gotVolcanoes < m.NumVolcanoes {
-			m.RegionIsVolcano[r] = true
+			m.RegionIsVolcano.Set(r)
 			gotVolcanoes++
 		}
 	}
@@ -477,13 +483,13 @@ func (m *Geo) assignRegionElevation() {
 		c := math.Pow(rDistanceC[r], nc) + epsilon // Distance from coastline
 		if m.PlateIsOcean[m.RegionToPlate[r]] {
 			// Ocean plates are slightly lower than other plates.
-			m.Elevation[r] = -0.1
+			m.Elevation.Set(r, -0.1)
 		}
 		if math.IsInf(rDistanceA[r], 0) && math.IsInf(rDistanceB[r], 0) {
 			// If the distance from mountains and oceans is unset (infinity),
 			// we increase the elevation by 0.1 since we wouldn't be able to
 			// calculate the harmonic mean.
-			m.Elevation[r] += 0.1
+			m.Elevation.Add(r, 0.1)
 		} else {
 			// The height is calculated as weighted harmonic mean of the
 			// three distance values.
@@ -495,7 +501,7 @@ func (m *Geo) assignRegionElevation() {
 
 			// Apply a square falloff to the elevaltion values.
 			// f *= math.Abs(f)
-			m.Elevation[r] += f
+			m.Elevation.Add(r, f)
 		}
 	}
 
@@ -504,7 +510,7 @@ func (m *Geo) assignRegionElevation() {
 		// This is to simulate the effect of the mountain ridges.
 		// NOTE: This looks very unnatural. :(
 		for r := 0; r < m.SphereMesh.NumRegions; r++ {
-			if m.Elevation[r] < 0 {
+			if m.Elevation.At(r) < 0 {
 				continue
 			}
 			// Get the distance to the closest mountain.
@@ -519,40 +525,53 @@ func (m *Geo) assignRegionElevation() {
 			// Add a cosine based on the distance to the closest mountain.
 			v := (math.Cos(minDist*math.Pi*128) + 1) / 2
 			randAmount := m.noise.Eval3(r_xyz[3*r], r_xyz[3*r+1], r_xyz[3*r+2])
-			m.Elevation[r] *= 0.5 + (0.5 * (1 - randAmount)) + 0.5*v*v*randAmount
+			m.Elevation.Set(r, m.Elevation.At(r) * (0.5 + (0.5 * (1 - randAmount)) + 0.5*v*v*randAmount))
 		}
 	*/
 
 	// Apply noise to the elevation values.
-	if m.GeoConfig.MultiplyNoise {
+	if len(m.GeoConfig.NoiseLayers) > 0 {
+		stack := noise.NewStack(m.GeoConfig.NoiseLayers, m.Seed)
+		for r := 0; r < m.SphereMesh.NumRegions; r++ {
+			m.Elevation.Set(r, stack.Apply(m.Elevation.At(r), r_xyz[3*r], r_xyz[3*r+1], r_xyz[3*r+2]))
+		}
+	} else if m.GeoConfig.MultiplyNoise {
 		for r := 0; r < m.SphereMesh.NumRegions; r++ {
-			m.Elevation[r] *= m.noise.Eval3(r_xyz[3*r], r_xyz[3*r+1], r_xyz[3*r+2])
+			m.Elevation.Set(r, m.Elevation.At(r)*m.noise.Eval3(r_xyz[3*r], r_xyz[3*r+1], r_xyz[3*r+2]))
 		}
 	} else {
 		for r := 0; r < m.SphereMesh.NumRegions; r++ {
-			m.Elevation[r] += m.noise.Eval3(r_xyz[3*r], r_xyz[3*r+1], r_xyz[3*r+2])*2 - 1 // Noise from -1.0 to 1.0
+			m.Elevation.Add(r, m.noise.Eval3(r_xyz[3*r], r_xyz[3*r+1], r_xyz[3*r+2])*2-1) // Noise from -1.0 to 1.0
 		}
 	}
 
+	// Shift the sea level so that the configured fraction of the planet
+	// is covered by water.
+	m.adjustSeaLevel(m.SeaLevelFraction)
+
 	// Normalize the elevation values to the range -1.0 - 1.0
 	// TODO: Protect against division by zero.
 	if m.GeoConfig.NormalizeElevation {
-		minElevation, maxElevation := minMax(m.Elevation)
+		minElevation, maxElevation := m.Elevation.MinMax()
 		for r := 0; r < m.SphereMesh.NumRegions; r++ {
-			if m.Elevation[r] < 0 {
-				m.Elevation[r] /= math.Abs(minElevation)
+			if m.Elevation.At(r) < 0 {
+				m.Elevation.Set(r, m.Elevation.At(r)/math.Abs(minElevation))
 			} else {
-				m.Elevation[r] /= maxElevation
+				m.Elevation.Set(r, m.Elevation.At(r)/maxElevation)
 			}
 		}
 	}
 
 	// Apply a square falloff to the elevation values above sea level.
 	if m.GeoConfig.TectonicFalloff {
-		for r := range m.Elevation {
-			if m.Elevation[r] > 0 {
-				m.Elevation[r] *= m.Elevation[r]
+		for r := 0; r < m.Elevation.Len(); r++ {
+			if m.Elevation.At(r) > 0 {
+				m.Elevation.Set(r, m.Elevation.At(r)*m.Elevation.At(r))
 			}
 		}
 	}
+
+	// Apply the configured sea level change (e.g. for an ice age).
+	m.assignElevationScale()
+	m.shiftSeaLevel(m.SeaLevelOffset)
 }
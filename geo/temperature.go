@@ -37,24 +37,30 @@ func GetMeanAnnualTemp(lat float64) float64 {
 	return (math.Sin(various.DegToRad(90-math.Abs(lat))))*RangeTemp + MinTemp
 }
 
+// getMeanAnnualTemp returns the mean annual temperature at a given latitude
+// including the global temperature offset of the current climate.
+func (m *Geo) getMeanAnnualTemp(lat float64) float64 {
+	return GetMeanAnnualTemp(lat) + m.TemperatureOffset
+}
+
 const MaxAltitudeFactor = gameconstants.EarthMaxElevation // How tall is the tallest mountain with an elevation of 1.0?
 
 // GetRegTemperature returns the average yearly temperature of the given region at the surface.
 func (m *Geo) GetRegTemperature(r int, maxElev float64) float64 {
 	// TODO: Fix maxElev caching!!!
-	return GetMeanAnnualTemp(m.LatLon[r][0]) - GetTempFalloffFromAltitude(MaxAltitudeFactor*m.Elevation[r]/maxElev)
+	return m.getMeanAnnualTemp(m.LatLon[r][0]) - GetTempFalloffFromAltitude(MaxAltitudeFactor*m.Elevation.At(r)/maxElev)
 }
 
 // GetTriTemperature returns the average yearly temperature of the given triangle at the surface.
 func (m *Geo) GetTriTemperature(t int, maxElev float64) float64 {
 	// TODO: Fix maxElev caching!!!
-	return GetMeanAnnualTemp(m.TriLatLon[t][0]) - GetTempFalloffFromAltitude(MaxAltitudeFactor*m.TriElevation[t]/maxElev)
+	return m.getMeanAnnualTemp(m.TriLatLon[t][0]) - GetTempFalloffFromAltitude(MaxAltitudeFactor*m.TriElevation.At(t)/maxElev)
 }
 
 func (m *Geo) initRegionAirTemperature() {
-	_, maxElev := minMax(m.Elevation)
+	_, maxElev := m.Elevation.MinMax()
 	for r := 0; r < m.SphereMesh.NumRegions; r++ {
-		m.AirTemperature[r] = m.GetRegTemperature(r, maxElev)
+		m.AirTemperature.Set(r, m.GetRegTemperature(r, maxElev))
 	}
 }
 
@@ -70,7 +76,7 @@ func (m *Geo) assignRegionAirTemperature() {
 		// absLat := math.Abs(lat)
 		// lon :=  m.LatLon[r][1]
 		// absLat := math.Abs(lat - m.getSunLattitude())
-		startTemp := m.AirTemperature[r]
+		startTemp := m.AirTemperature.At(r)
 		newTemperature[r] = startTemp
 		baseTemperature[r] = startTemp
 
@@ -106,7 +112,7 @@ func (m *Geo) assignRegionAirTemperature() {
 			movedCount[r]++
 
 			pr := m.getPreviousNeighbor(outregs, r, m.RegionToWindVecLocal[r])
-			movedTemp[r] += m.AirTemperature[pr]
+			movedTemp[r] += m.AirTemperature.At(pr)
 			movedCount[r]++
 
 			// add in pushed temp
@@ -121,7 +127,7 @@ func (m *Geo) assignRegionAirTemperature() {
 			// movedTemp[r] -= map.r_currents[r]*heldHeat
 			// movedTemp[nr] += map.r_currents[r]*potentialHeat
 			// movedTemp[nr] = movedTemp[nr]? movedTemp[nr] : []float64{}
-			movedTemp[nr] += transferOut*m.AirTemperature[r] + transferIn*nt
+			movedTemp[nr] += transferOut*m.AirTemperature.At(r) + transferIn*nt
 			movedCount[nr]++
 		}
 
@@ -134,16 +140,16 @@ func (m *Geo) assignRegionAirTemperature() {
 			}
 			// if (movedTemp[r] !== undefined && movedTemp[r].length > 0) newTemperature[r] = movedTemp[r].reduce((acc, temp) => acc + temp, 0) / movedTemp[r].length
 		}
-		m.AirTemperature = newTemperature
+		m.AirTemperature.SetValues(newTemperature)
 	}
-	m.AirTemperature = newTemperature
+	m.AirTemperature.SetValues(newTemperature)
 }
 
 func (m *Geo) initRegionWaterTemperature() {
-	_, maxElev := minMax(m.Elevation)
+	_, maxElev := m.Elevation.MinMax()
 	for r := 0; r < m.SphereMesh.NumRegions; r++ {
-		if m.Elevation[r] <= 0 {
-			m.OceanTemperature[r] = m.GetRegTemperature(r, maxElev)
+		if m.Elevation.At(r) <= 0 {
+			m.OceanTemperature.Set(r, m.GetRegTemperature(r, maxElev))
 		}
 	}
 }
@@ -153,14 +159,14 @@ func (m *Geo) transportRegionWaterTemperature() {
 	newTemperature := make([]float64, m.SphereMesh.NumRegions)
 	baseTemperature := make([]float64, m.SphereMesh.NumRegions)
 	for r := 0; r < m.SphereMesh.NumRegions; r++ {
-		if m.Elevation[r] > 0 {
+		if m.Elevation.At(r) > 0 {
 			newTemperature[r] = 0.5
 		}
 	}
 
 	outregs := make([]int, 0, 8)
 	for r := 0; r < m.SphereMesh.NumRegions; r++ {
-		if m.Elevation[r] > 0 {
+		if m.Elevation.At(r) > 0 {
 			continue
 		}
 
@@ -169,7 +175,7 @@ func (m *Geo) transportRegionWaterTemperature() {
 		// absLat := math.Abs(lat)
 		// lon :=  m.LatLon[r][1]
 		// absLat := math.Abs(lat - m.getSunLattitude())
-		startTemp := m.OceanTemperature[r]
+		startTemp := m.OceanTemperature.At(r)
 		newTemperature[r] = startTemp
 		baseTemperature[r] = startTemp
 
@@ -179,7 +185,7 @@ func (m *Geo) transportRegionWaterTemperature() {
 		neighborCount := 1
 		for i := 0; i < len(neighbors); i++ {
 			nr := neighbors[i]
-			if m.Elevation[nr] <= 0 {
+			if m.Elevation.At(nr) <= 0 {
 				neighborAverage += newTemperature[nr]
 				neighborCount++
 			}
@@ -207,14 +213,14 @@ func (m *Geo) transportRegionWaterTemperature() {
 			movedCount[r]++
 
 			pr := m.getPreviousNeighbor(outregs, r, m.RegionToOceanVec[r])
-			if m.Elevation[pr] <= 0 {
-				movedTemp[r] += m.OceanTemperature[pr]
+			if m.Elevation.At(pr) <= 0 {
+				movedTemp[r] += m.OceanTemperature.At(pr)
 				movedCount[r]++
 			}
 
 			// add in pushed temp
 			nr := m.GetClosestNeighbor(outregs, r, m.RegionToOceanVec[r])
-			if nr == r || m.Elevation[nr] > 0 {
+			if nr == r || m.Elevation.At(nr) > 0 {
 				continue
 			}
 			// const heldHeat = newTemperature[r] - baseTemperature[r]
@@ -224,7 +230,7 @@ func (m *Geo) transportRegionWaterTemperature() {
 			// movedTemp[r] -= map.r_currents[r]*heldHeat
 			// movedTemp[nr] += map.r_currents[r]*potentialHeat
 			// movedTemp[nr] = movedTemp[nr]? movedTemp[nr] : []float64{}
-			movedTemp[nr] += transferOut*m.OceanTemperature[r] + transferIn*nt
+			movedTemp[nr] += transferOut*m.OceanTemperature.At(r) + transferIn*nt
 			movedCount[nr]++
 		}
 
@@ -237,7 +243,7 @@ func (m *Geo) transportRegionWaterTemperature() {
 			}
 			// if (movedTemp[r] !== undefined && movedTemp[r].length > 0) newTemperature[r] = movedTemp[r].reduce((acc, temp) => acc + temp, 0) / movedTemp[r].length
 		}
-		m.OceanTemperature = newTemperature
+		m.OceanTemperature.SetValues(newTemperature)
 	}
-	m.OceanTemperature = newTemperature
+	m.OceanTemperature.SetValues(newTemperature)
 }
@@ -0,0 +1,206 @@
+package geo
+
+import (
+	"container/heap"
+	"math"
+)
+
+// Tsunami parameters.
+const (
+	tsunamiMaxWaveHeight  = 30.0   // Initial wave height (m) of a tsunami with a magnitude of 1.0
+	tsunamiMinWaveHeight  = 0.5    // Waves below this height (m) are harmless
+	tsunamiMinDepth       = 10.0   // Minimum depth (m) used for the wave speed
+	tsunamiMaxTravelTime  = 24.0   // Maximum travel time (h) of the wave
+	tsunamiRunupFactor    = 2.0    // Amplification of the wave height at the shore (shoaling)
+	tsunamiRunupDecay     = 0.015  // Fraction of the run-up height lost per km inland
+	tsunamiDevastation    = 10.0   // Flood depth (m) at which the intensity reaches 1.0
+	tsunamiMinMagnitude   = 0.3    // Minimum magnitude of a seismic / volcanic event to cause a tsunami
+	tsunamiMaxSubduction  = 150.0  // Maximum distance (km) of an earthquake to a subduction zone
+	tsunamiHazardFalloff  = 1500.0 // Distance (km) at which the tsunami hazard is halved
+	tsunamiSourceLength   = 500.0  // Length (km) of the sea floor displaced by an event with a magnitude of 1.0
+	standardGravity       = 9.81   // in m/s²
+	metersPerSecondToKmph = 3.6
+)
+
+// getTsunamiSources returns all ocean regions where a tsunami might originate
+// from, which are the ocean regions close to subduction zones (where
+// undersea megathrust earthquakes happen) and next to coastal volcanoes.
+// 'distSubduction' is the distance (km) of each region to the nearest
+// subduction zone.
+func (m *Geo) getTsunamiSources(distSubduction []float64) []int {
+	var sources []int
+	outRegs := make([]int, 0, 8)
+	for r := 0; r < m.SphereMesh.NumRegions; r++ {
+		if m.Elevation.At(r) > 0 {
+			continue
+		}
+		if distSubduction[r] <= tsunamiMaxSubduction {
+			sources = append(sources, r)
+			continue
+		}
+		for _, nb := range m.SphereMesh.R_circulate_r(outRegs, r) {
+			if m.RegionIsVolcano.Has(nb) {
+				sources = append(sources, r)
+				break
+			}
+		}
+	}
+	return sources
+}
+
+// GetTsunamiHazard returns the tsunami hazard (0.0-1.0) of all regions.
+//
+// The hazard is high for low-lying coastal regions that face an ocean close
+// to subduction zones or coastal volcanoes.
+func (m *Geo) GetTsunamiHazard() []float64 {
+	sources := m.getTsunamiSources(m.AssignGeodesicDistanceField(m.getSubductionZones(), nil, nil).Distance)
+
+	// Calculate the distance of each ocean region to the tsunami sources.
+	isLand := make(map[int]bool)
+	for r := 0; r < m.SphereMesh.NumRegions; r++ {
+		if m.Elevation.At(r) > 0 {
+			isLand[r] = true
+		}
+	}
+	distSource := m.AssignGeodesicDistanceField(sources, isLand, nil).Distance
+
+	// The potential run-up at each coastal region depends on the distance
+	// (km) to the nearest source.
+	runup := make(map[int]float64)
+	outRegs := make([]int, 0, 8)
+	for r := range isLand {
+		for _, nb := range m.SphereMesh.R_circulate_r(outRegs, r) {
+			if m.Elevation.At(nb) > 0 || math.IsInf(distSource[nb], 0) {
+				continue
+			}
+			exposure := tsunamiHazardFalloff / (tsunamiHazardFalloff + distSource[nb])
+			runup[r] = math.Max(runup[r], tsunamiMaxWaveHeight*tsunamiRunupFactor*exposure)
+		}
+	}
+
+	hazard := make([]float64, m.SphereMesh.NumRegions)
+	m.runUpTsunami(runup, func(r int, depth float64) {
+		hazard[r] = math.Max(hazard[r], math.Min(1, depth/tsunamiDevastation))
+	})
+	return hazard
+}
+
+// GetTsunamiEvent returns a tsunami originating in the given ocean region.
+//
+// The wave propagates across the ocean with the speed of a shallow water wave
+// (sqrt(g * depth)), so it travels fast across the deep ocean and slows down
+// near the coast, while the wave height decreases with the distance from the
+// origin. When it reaches the shore, the wave runs up the low-lying coastal
+// regions.
+func (m *Geo) GetTsunamiEvent(origin int, magnitude float64) *DisasterEvent {
+	ev := newDisasterEvent(DisTsunami, origin, magnitude)
+	ev.TravelTime = map[int]float64{origin: 0}
+
+	metersPerElev := 1 / m.metersToElevation(1)
+	initialHeight := tsunamiMaxWaveHeight * magnitude
+
+	// The wave height decreases with the (radial) spreading of the wave,
+	// starting at the edge of the displaced sea floor, which grows with the
+	// magnitude of the event.
+	sourceLength := tsunamiSourceLength * math.Max(magnitude, tsunamiMinMagnitude)
+	waveHeight := func(r int) float64 {
+		dist := m.DistToKm(m.GetDistance(origin, r))
+		return initialHeight * math.Sqrt(sourceLength/(sourceLength+dist))
+	}
+
+	// Propagate the wave across the ocean, ordered by the travel time.
+	var queue AscPriorityQueue
+	heap.Init(&queue)
+	heap.Push(&queue, &QueueEntry{
+		Score:       0,
+		Origin:      origin,
+		Destination: origin,
+	})
+	runup := make(map[int]float64)
+	outRegs := make([]int, 0, 8)
+	for queue.Len() > 0 {
+		e := heap.Pop(&queue).(*QueueEntry)
+		u := e.Destination
+		if e.Score > ev.TravelTime[u] {
+			continue // We have found a faster path already.
+		}
+		height := waveHeight(u)
+		if height < tsunamiMinWaveHeight {
+			continue
+		}
+		ev.addRegion(u, math.Min(1, height/tsunamiMaxWaveHeight))
+		for _, v := range m.SphereMesh.R_circulate_r(outRegs, u) {
+			// If we reach the shore, the wave runs up the coast.
+			if m.Elevation.At(v) > 0 {
+				if t, ok := ev.TravelTime[v]; !ok || e.Score < t {
+					ev.TravelTime[v] = e.Score
+				}
+				runup[v] = math.Max(runup[v], height*tsunamiRunupFactor)
+				continue
+			}
+
+			// The wave speed depends on the depth of the ocean.
+			depth := math.Max(tsunamiMinDepth, -m.Elevation.At(v)*metersPerElev)
+			speed := math.Sqrt(standardGravity*depth) * metersPerSecondToKmph
+			t := e.Score + m.DistToKm(m.GetDistance(u, v))/speed
+			if t > tsunamiMaxTravelTime {
+				continue
+			}
+			if old, ok := ev.TravelTime[v]; ok && old <= t {
+				continue
+			}
+			ev.TravelTime[v] = t
+			heap.Push(&queue, &QueueEntry{
+				Score:       t,
+				Origin:      origin,
+				Destination: v,
+			})
+		}
+	}
+
+	// Flood the low-lying coastal regions.
+	m.runUpTsunami(runup, func(r int, depth float64) {
+		ev.addRegion(r, math.Min(1, depth/tsunamiDevastation))
+	})
+	return ev
+}
+
+// runUpTsunami floods the land regions starting at the given coastal regions
+// with the given run-up heights (m) and calls 'fn' for each flooded region with
+// the flood depth (m).
+//
+// The run-up height decreases with each km the wave travels inland, and the
+// wave stops where the terrain is higher than the run-up height.
+func (m *Geo) runUpTsunami(runup map[int]float64, fn func(r int, depth float64)) {
+	metersPerElev := 1 / m.metersToElevation(1)
+	best := make(map[int]float64)
+	var queue []int
+	for r, h := range runup {
+		best[r] = h
+		queue = append(queue, r)
+	}
+	outRegs := make([]int, 0, 8)
+	for len(queue) > 0 {
+		r := queue[0]
+		queue = queue[1:]
+		h := best[r]
+		depth := h - m.Elevation.At(r)*metersPerElev
+		if depth <= 0 {
+			continue
+		}
+		fn(r, depth)
+
+		// Continue inland.
+		for _, nb := range m.SphereMesh.R_circulate_r(outRegs, r) {
+			if m.Elevation.At(nb) <= 0 {
+				continue
+			}
+			next := h * math.Pow(1-tsunamiRunupDecay, m.DistToKm(m.GetDistance(r, nb)))
+			if next < tsunamiMinWaveHeight || best[nb] >= next {
+				continue
+			}
+			best[nb] = next
+			queue = append(queue, nb)
+		}
+	}
+}
@@ -24,7 +24,7 @@ func (m *BaseObject) getWaterBodies() []int {
 	// Initialize the waterbody (ocean) mapping.
 	done := make([]int, m.SphereMesh.NumRegions)
 	for i := range done {
-		if m.Elevation[i] > 0 {
+		if m.Elevation.At(i) > 0 {
 			done[i] = -2 // Non-ocean regions above sealevel.
 		} else {
 			done[i] = -1 // Ocean regions that have not been visited yet.
@@ -53,7 +53,7 @@ func (m *BaseObject) getWaterBodies() []int {
 			out_rc := make([]int, 0, 8)
 			for _, nbs := range m.R_circulate_r(out_r, rd) {
 				// If we have reached land or already visited nbs, skip.
-				if m.Elevation[nbs] > 0 || done[nbs] != -1 {
+				if m.Elevation.At(nbs) > 0 || done[nbs] != -1 {
 					continue
 				}
 				// Assign the source region index to nbs.
@@ -0,0 +1,206 @@
+package geo
+
+import (
+	"math"
+	"math/bits"
+
+	"github.com/Flokey82/genbiome"
+)
+
+// Wildfire parameters.
+const (
+	wildfireBurnRate       = 0.3  // Fraction of the fuel consumed per day at full intensity
+	wildfireSpreadSpeed    = 25.0 // Speed (km/day) of the fire front (at full intensity, fuel and dryness)
+	wildfireMinIntensity   = 0.05 // Fires below this intensity die out
+	wildfireMinFuel        = 0.1  // Regions with less fuel don't burn
+	wildfireRegrowthYears  = 20   // Years until burned vegetation has fully regrown
+	wildfireWoodLossFactor = 0.25 // Wood resources are lost if the fuel drops below this fraction of the capacity
+	wildfireWoodGainFactor = 0.5  // Wood resources are restored if the fuel regrows above this fraction of the capacity
+)
+
+// assignFuel calculates the vegetation (fuel) capacity of all regions based on
+// the biome and wood resources and initializes the fuel to the capacity.
+func (m *Geo) assignFuel() {
+	biomeFunc := m.GetRegWhittakerModBiomeFunc()
+	capacity := make([]float64, m.SphereMesh.NumRegions)
+	for r := 0; r < m.SphereMesh.NumRegions; r++ {
+		if m.Elevation.At(r) <= 0 || m.Waterpool.At(r) > 0 {
+			continue
+		}
+		var val float64
+		switch biomeFunc(r) {
+		case genbiome.WhittakerModBiomeTemperateSeasonalForest,
+			genbiome.WhittakerModBiomeTropicalSeasonalForest,
+			genbiome.WhittakerModBiomeBorealForestTaiga:
+			val = 0.8
+		case genbiome.WhittakerModBiomeTemperateRainforest,
+			genbiome.WhittakerModBiomeTropicalRainforest:
+			val = 0.7 // Lots of fuel, but rarely dry enough to burn.
+		case genbiome.WhittakerModBiomeWoodlandShrubland,
+			genbiome.WhittakerModBiomeSavannah:
+			val = 0.6
+		case genbiome.WhittakerModBiomeTemperateGrassland:
+			val = 0.4
+		case genbiome.WhittakerModBiomeHotSwamp,
+			genbiome.WhittakerModBiomeWetlands:
+			val = 0.3
+		case genbiome.WhittakerModBiomeTundra:
+			val = 0.1
+		}
+		// Each type of wood adds to the available fuel.
+		val += 0.05 * float64(bits.OnesCount8(m.Wood[r]))
+		capacity[r] = math.Min(1, val)
+	}
+	m.FuelCapacity = capacity
+	m.Fuel = make([]float64, len(capacity))
+	copy(m.Fuel, capacity)
+	m.FireIntensity = make([]float64, len(capacity))
+	m.burnedWood = make(map[int]byte)
+}
+
+// getFireDrynessFunc returns a function that returns the dryness (0.0-1.0) of
+// the vegetation in the given region, based on the rainfall and the current
+// season.
+func (m *Geo) getFireDrynessFunc() func(r int) float64 {
+	_, maxRain := m.Rainfall.MinMax()
+	if maxRain <= 0 {
+		maxRain = 1
+	}
+	return func(r int) float64 {
+		dry := 1 - m.Rainfall.At(r)/maxRain
+		switch m.GetSeason(m.LatLon[r][0]) {
+		case SeasonSummer:
+			return dry
+		case SeasonSpring, SeasonAutumn:
+			return dry * 0.6
+		default:
+			return dry * 0.2
+		}
+	}
+}
+
+// IsRegBurning returns true if there is a wildfire in the given region.
+func (m *Geo) IsRegBurning(r int) bool {
+	return r >= 0 && r < len(m.FireIntensity) && m.FireIntensity[r] > 0
+}
+
+// IgniteWildfire starts a wildfire in the given region (e.g. from a settlement)
+// and returns true if the vegetation caught fire.
+func (m *Geo) IgniteWildfire(r int) bool {
+	return m.igniteWildfire(r, m.getFireDrynessFunc()(r))
+}
+
+// igniteWildfire starts a wildfire in the given region with the given dryness.
+func (m *Geo) igniteWildfire(r int, dryness float64) bool {
+	if r < 0 || r >= len(m.Fuel) || m.FireIntensity[r] > 0 || m.Fuel[r] < wildfireMinFuel {
+		return false
+	}
+	intensity := m.Fuel[r] * dryness
+	if intensity < wildfireMinIntensity {
+		return false
+	}
+	m.FireIntensity[r] = intensity
+	return true
+}
+
+// tickWildfires advances the wildfires by one day.
+//
+// - Lightning strikes ignite dry vegetation.
+// - Fires consume the fuel (vegetation) and spread to neighboring regions,
+// preferably downwind and through dry vegetation.
+// - Burned regions lose their wood resources until the vegetation has regrown.
+func (m *Geo) tickWildfires() {
+	if m.Fuel == nil {
+		return // Not generated yet.
+	}
+	dryness := m.getFireDrynessFunc()
+
+	// Lightning strikes.
+	expected := m.LightningIgnitionChance * float64(m.SphereMesh.NumRegions)
+	strikes := int(expected)
+	if m.Rand.Float64() < expected-float64(strikes) {
+		strikes++
+	}
+	for i := 0; i < strikes; i++ {
+		r := m.Rand.Intn(m.SphereMesh.NumRegions)
+		if dry := dryness(r); m.Rand.Float64() < dry*m.Fuel[r] {
+			m.igniteWildfire(r, dry)
+		}
+	}
+
+	// Burn and spread the fires.
+	outRegs := make([]int, 0, 8)
+	ignite := make(map[int]bool)
+	for r, intensity := range m.FireIntensity {
+		if intensity <= 0 {
+			continue
+		}
+
+		// Consume the fuel.
+		m.Fuel[r] = math.Max(0, m.Fuel[r]-wildfireBurnRate*intensity)
+		if m.Fuel[r] < m.FuelCapacity[r]*wildfireWoodLossFactor && m.Wood[r] != 0 {
+			m.burnedWood[r] = m.Wood[r]
+			m.Wood[r] = 0
+			m.suspendWoodDeposits(r, true)
+		}
+
+		// The fire dies down as it runs out of fuel.
+		intensity = math.Min(1, (intensity+m.Fuel[r]*dryness(r))/2)
+		if m.Fuel[r] < wildfireMinFuel || intensity < wildfireMinIntensity {
+			intensity = 0
+		}
+		m.FireIntensity[r] = intensity
+		if intensity <= 0 {
+			continue
+		}
+
+		// Spread to the neighbors, preferably downwind. The chance that the
+		// fire front reaches a neighbor within a day depends on the distance
+		// between the regions (in km), so the spread doesn't depend on the
+		// resolution of the mesh.
+		downwind := m.GetClosestNeighbor(outRegs, r, m.RegionToWindVec[r])
+		upwind := m.getPreviousNeighbor(outRegs, r, m.RegionToWindVec[r])
+		for _, nb := range m.SphereMesh.R_circulate_r(outRegs, r) {
+			if m.FireIntensity[nb] > 0 || m.Fuel[nb] < wildfireMinFuel {
+				continue
+			}
+			wind := 1.0
+			if nb == downwind {
+				wind = 2.0
+			} else if nb == upwind {
+				wind = 0.25
+			}
+			speed := wildfireSpreadSpeed * intensity * m.Fuel[nb] * dryness(nb) * wind
+			if m.Rand.Float64() < 1-math.Exp(-speed/m.DistToKm(m.GetDistance(r, nb))) {
+				ignite[nb] = true
+			}
+		}
+	}
+	for r := range ignite {
+		m.igniteWildfire(r, dryness(r))
+	}
+
+	// Regrow the vegetation.
+	regrowth := 1 / float64(wildfireRegrowthYears*m.DaysPerYear)
+	for r, capacity := range m.FuelCapacity {
+		if m.FireIntensity[r] > 0 || m.Fuel[r] >= capacity {
+			continue
+		}
+		m.Fuel[r] = math.Min(capacity, m.Fuel[r]+capacity*regrowth)
+		if wood, ok := m.burnedWood[r]; ok && m.Fuel[r] >= capacity*wildfireWoodGainFactor {
+			m.Wood[r] |= wood
+			delete(m.burnedWood, r)
+			m.suspendWoodDeposits(r, false)
+		}
+	}
+}
+
+// suspendWoodDeposits suspends (or resumes) the wood deposits in the given
+// region, so burned forests can't be logged until they have regrown.
+func (m *Geo) suspendWoodDeposits(r int, suspended bool) {
+	for _, d := range m.Deposits[r] {
+		if d.Type == ResourceTypeWood {
+			d.Suspended = suspended
+		}
+	}
+}
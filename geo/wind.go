@@ -69,7 +69,7 @@ func (m *Geo) assignWindVectors() {
 	useGoRoutines := true
 	if useGoRoutines {
 		// Use goroutines to calculate wind vectors.
-		various.KickOffChunkWorkers(m.NumRegions, chunkProcessorWind)
+		m.GetExecutor().ParallelFor(m.NumRegions, chunkProcessorWind)
 	} else {
 		// Use single threaded calculation of wind vectors.
 		chunkProcessorWind(0, m.NumRegions)
@@ -85,7 +85,7 @@ func (m *Geo) assignWindVectors() {
 	regWindVecLocal := make([][2]float64, m.NumRegions)
 
 	// NOTE: This is currently overridden by the altitude changes below.
-	_, maxElev := minMax(m.Elevation)
+	_, maxElev := m.Elevation.MinMax()
 	switch calcMode {
 	case localWindModeTemperature:
 		// Add local wind vectors based on local temperature gradients.
@@ -100,7 +100,7 @@ func (m *Geo) assignWindVectors() {
 		// Determine all sea regions.
 		var seaRegs []int
 		for r := 0; r < m.SphereMesh.NumRegions; r++ {
-			if m.Elevation[r] <= 0 {
+			if m.Elevation.At(r) <= 0 {
 				seaRegs = append(seaRegs, r)
 			}
 		}
@@ -112,8 +112,8 @@ func (m *Geo) assignWindVectors() {
 				regVec := regWindVec[r]
 				lat := m.LatLon[r][0]
 				lon := m.LatLon[r][1]
-				tempReg := GetMeanAnnualTemp(lat) - GetTempFalloffFromAltitude(MaxAltitudeFactor*m.Elevation[r]/maxElev)
-				if m.Elevation[r] < 0 {
+				tempReg := m.getMeanAnnualTemp(lat) - GetTempFalloffFromAltitude(MaxAltitudeFactor*m.Elevation.At(r)/maxElev)
+				if m.Elevation.At(r) < 0 {
 					// TODO: Use actual distance from ocean to calculate temperature falloff.
 					tempReg -= 1 / (regDistanceSea[r] + 1)
 				}
@@ -125,8 +125,8 @@ func (m *Geo) assignWindVectors() {
 				for _, nb := range m.SphereMesh.R_circulate_r(outRegs, r) {
 					nbLat := m.LatLon[nb][0]
 					nbLon := m.LatLon[nb][1]
-					tempNb := GetMeanAnnualTemp(nbLat) - GetTempFalloffFromAltitude(MaxAltitudeFactor*m.Elevation[nb]/maxElev)
-					if m.Elevation[nb] < 0 {
+					tempNb := m.getMeanAnnualTemp(nbLat) - GetTempFalloffFromAltitude(MaxAltitudeFactor*m.Elevation.At(nb)/maxElev)
+					if m.Elevation.At(nb) < 0 {
 						// TODO: Use actual distance from ocean to calculate temperature falloff.
 						tempNb -= 1 / (regDistanceSea[nb] + 1)
 					}
@@ -149,7 +149,7 @@ func (m *Geo) assignWindVectors() {
 				// Get polar coordinates.
 				regLat := m.LatLon[r][0]
 				regLon := m.LatLon[r][1]
-				h := m.Elevation[r]
+				h := m.Elevation.At(r)
 				if h < 0 {
 					h = 0
 				}
@@ -189,7 +189,7 @@ func (m *Geo) assignWindVectors() {
 					// current neighbor.
 					// See: https://www.scratchapixel.com/lessons/3d-basic-rendering/introduction-to-shading/shading-normals
 					dotV := vectors.Dot3(va, vb)
-					hnb := m.Elevation[nbReg]
+					hnb := m.Elevation.At(nbReg)
 					if hnb < 0 {
 						hnb = 0
 					}
@@ -230,7 +230,7 @@ func (m *Geo) assignWindVectors() {
 				// Elevation change is negative if the current region is higher than the region the wind blows past.
 				// This will result in wind slowing down if it blows towards a mountain and to speed up if it blows
 				// towards a valley.
-				elevationChange := math.Max(m.Elevation[blowsPastReg], WATER_LEVEL) - math.Max(m.Elevation[r], WATER_LEVEL)
+				elevationChange := math.Max(m.Elevation.At(blowsPastReg), WATER_LEVEL) - math.Max(m.Elevation.At(r), WATER_LEVEL)
 				windSpeed := (1 - (2*elevationChange)*ELEVATION_CHANGE_FACTOR)
 				windSpeed = math.Max(0.1, windSpeed)
 				// map.r_wind[r] = 5*(1-(terrain.depthMap[i][j]-terrain.depthMap[k][l])/1000);
@@ -259,7 +259,7 @@ func (m *Geo) assignWindVectors() {
 
 	if useGoRoutines {
 		// Split the work into chunks and process them in parallel.
-		various.KickOffChunkWorkers(m.SphereMesh.NumRegions, chunkProcessor)
+		m.GetExecutor().ParallelFor(m.SphereMesh.NumRegions, chunkProcessor)
 	} else {
 		chunkProcessor(0, m.SphereMesh.NumRegions)
 	}
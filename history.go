@@ -30,6 +30,7 @@ const (
 	ObjectTypeSea
 	ObjectTypeVolcano
 	ObjectTypePerson
+	ObjectTypeSpecies
 )
 
 type ObjectReference struct {
@@ -0,0 +1,97 @@
+package noise
+
+// LayerType is the type of noise of a Layer.
+type LayerType int
+
+// Layer types.
+const (
+	LayerTypeFBM    LayerType = iota // Fractal brownian motion (smooth blobs)
+	LayerTypeRidged                  // Ridged multifractal (sharp ridgelines)
+	LayerTypeBillow                  // Billow (rounded hills and flat valleys)
+)
+
+// BlendMode determines how a Layer is combined with the value it is applied to.
+type BlendMode int
+
+// Blend modes.
+const (
+	BlendAdd      BlendMode = iota // Add the noise (-Amplitude to +Amplitude)
+	BlendMultiply                  // Multiply by the noise (1-Amplitude to 1)
+)
+
+// Mask limits a Layer to positive or negative values (e.g. land or ocean if
+// the layer is applied to elevation values).
+type Mask int
+
+// Masks.
+const (
+	MaskNone     Mask = iota // Apply to all values
+	MaskPositive             // Apply only to values above zero
+	MaskNegative             // Apply only to values at or below zero
+)
+
+// Layer is a configurable noise layer.
+type Layer struct {
+	Type        LayerType // Type of noise
+	Blend       BlendMode // How the noise is combined with the value
+	Mask        Mask      // Values the layer is applied to
+	Octaves     int       // Number of octaves
+	Persistence float64   // Amplitude falloff per octave
+	Frequency   float64   // Frequency of the first octave (scale of the input coordinates)
+	Amplitude   float64   // Strength of the layer
+	Warp        float64   // Strength of the domain warping (0 = no warping)
+}
+
+// Stack is a stack of noise layers that are applied in order.
+type Stack struct {
+	Layers []Layer
+	noises []*Noise
+}
+
+// NewStack returns a new noise stack with the given layers. Each layer is
+// initialized with a different seed derived from the given one.
+func NewStack(layers []Layer, seed int64) *Stack {
+	s := &Stack{
+		Layers: layers,
+		noises: make([]*Noise, len(layers)),
+	}
+	for i, l := range layers {
+		s.noises[i] = NewNoise(l.Octaves, l.Persistence, seed+int64(i))
+	}
+	return s
+}
+
+// Apply applies all layers to the given value at the given point.
+func (s *Stack) Apply(val, x, y, z float64) float64 {
+	for i, l := range s.Layers {
+		if l.Mask == MaskPositive && val <= 0 || l.Mask == MaskNegative && val > 0 {
+			continue
+		}
+		v := s.eval(i, x, y, z)
+		switch l.Blend {
+		case BlendAdd:
+			val += l.Amplitude * (v*2 - 1)
+		case BlendMultiply:
+			val *= 1 - l.Amplitude + l.Amplitude*v
+		}
+	}
+	return val
+}
+
+// eval returns the noise value (0.0-1.0) of the given layer at the given point.
+func (s *Stack) eval(i int, x, y, z float64) float64 {
+	l := s.Layers[i]
+	n := s.noises[i]
+	x, y, z = x*l.Frequency, y*l.Frequency, z*l.Frequency
+	if l.Warp > 0 {
+		x, y, z = n.Warp3(x, y, z, l.Warp)
+	}
+	switch l.Type {
+	case LayerTypeRidged:
+		return n.Ridged3(x, y, z)
+	case LayerTypeBillow:
+		return n.Billow3(x, y, z)
+	default:
+		return n.Eval3(x, y, z)
+	}
+}
@@ -62,3 +62,46 @@ func (n *Noise) Eval2(x, y float64) float64 {
 func (n *Noise) PlusOneOctave() *Noise {
 	return NewNoise(n.Octaves+1, n.Persistence, n.Seed)
 }
+
+// Ridged3 returns the ridged multifractal noise value at the given point,
+// which produces sharp ridgelines. Each octave is weighted by the value of
+// the previous octave, so that the detail is concentrated on the ridges.
+func (n *Noise) Ridged3(x, y, z float64) float64 {
+	var sum, sumOfAmplitudes float64
+	weight := 1.0
+	for octave := 0; octave < n.Octaves; octave++ {
+		frequency := 1 << octave
+		fFreq := float64(frequency)
+		v := 1 - math.Abs(2*n.OS.Eval3(x*fFreq, y*fFreq, z*fFreq)-1)
+		v *= v * weight
+		weight = math.Min(1, 2*v)
+		sum += n.Amplitudes[octave] * v
+		sumOfAmplitudes += n.Amplitudes[octave]
+	}
+	return sum / sumOfAmplitudes
+}
+
+// Billow3 returns the billow noise value at the given point, which produces
+// rounded hills and flat valleys.
+func (n *Noise) Billow3(x, y, z float64) float64 {
+	var sum, sumOfAmplitudes float64
+	for octave := 0; octave < n.Octaves; octave++ {
+		frequency := 1 << octave
+		fFreq := float64(frequency)
+		sum += n.Amplitudes[octave] * math.Abs(2*n.OS.Eval3(x*fFreq, y*fFreq, z*fFreq)-1)
+		sumOfAmplitudes += n.Amplitudes[octave]
+	}
+	return sum / sumOfAmplitudes
+}
+
+// Warp3 returns the given point displaced by the noise (domain warping),
+// where 'strength' is the maximum displacement along each axis.
+// See: https://iquilezles.org/articles/warp/
+func (n *Noise) Warp3(x, y, z, strength float64) (float64, float64, float64) {
+	// Sample the noise at arbitrary offsets to get independent values for
+	// each axis.
+	wx := n.Eval3(x+5.2, y+1.3, z+2.8)*2 - 1
+	wy := n.Eval3(x+1.7, y+9.2, z+3.4)*2 - 1
+	wz := n.Eval3(x+8.3, y+2.8, z+6.1)*2 - 1
+	return x + wx*strength, y + wy*strength, z + wz*strength
+}
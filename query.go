@@ -0,0 +1,88 @@
+package genworldvoronoi
+
+import (
+	"github.com/Flokey82/genworldvoronoi/geo"
+	geojson "github.com/paulmach/go.geojson"
+)
+
+// GetNearestCities returns up to k cities closest to the given lat/lon (in
+// degrees), sorted by distance.
+func (m *Map) GetNearestCities(lat, lon float64, k int) []*City {
+	regToCity := make(map[int]*City, len(m.Cities))
+	regs := make([]int, 0, len(m.Cities))
+	for _, c := range m.Cities {
+		regToCity[c.ID] = c
+		regs = append(regs, c.ID)
+	}
+	var cities []*City
+	for _, r := range m.Query.Nearest(lat, lon, regs, k) {
+		cities = append(cities, regToCity[r])
+	}
+	return cities
+}
+
+// GetNearestFeatures returns up to k named geographic features with the
+// center closest to the given lat/lon (in degrees), sorted by distance.
+func (m *Map) GetNearestFeatures(lat, lon float64, k int) []*geo.Feature {
+	regToFeatures := make(map[int][]*geo.Feature)
+	var regs []int
+	for _, f := range m.Features {
+		if f.Name == "" {
+			continue
+		}
+		if _, ok := regToFeatures[f.Center]; !ok {
+			regs = append(regs, f.Center)
+		}
+		regToFeatures[f.Center] = append(regToFeatures[f.Center], f)
+	}
+	var features []*geo.Feature
+	for _, r := range m.Query.Nearest(lat, lon, regs, -1) {
+		features = append(features, regToFeatures[r]...)
+		if k >= 0 && len(features) >= k {
+			return features[:k]
+		}
+	}
+	return features
+}
+
+// GetGeoJSONNearest returns the region at the given lat/lon (in degrees) and
+// up to k of the closest cities and named features as GeoJSON.
+func (m *Map) GetGeoJSONNearest(lat, lon float64, k int) ([]byte, error) {
+	geoJSON := geojson.NewFeatureCollection()
+
+	// The region at the given location.
+	r := m.Query.RegionAt(lat, lon)
+	gr := geojson.NewPointFeature([]float64{m.LatLon[r][1], m.LatLon[r][0]})
+	gr.ID = r
+	gr.SetProperty("kind", "region")
+	gr.SetProperty("elevation", m.Elevation.At(r))
+	gr.SetProperty("distance", m.Query.Distance(lat, lon, r))
+	if name := m.getRegName(r); name != "" {
+		gr.SetProperty("name", name)
+	}
+	geoJSON.AddFeature(gr)
+
+	// The closest cities.
+	for _, c := range m.GetNearestCities(lat, lon, k) {
+		gc := geojson.NewPointFeature([]float64{m.LatLon[c.ID][1], m.LatLon[c.ID][0]})
+		gc.ID = c.ID
+		gc.SetProperty("kind", "city")
+		gc.SetProperty("name", c.Name)
+		gc.SetProperty("type", string(c.Type))
+		gc.SetProperty("population", c.Population)
+		gc.SetProperty("distance", m.Query.Distance(lat, lon, c.ID))
+		geoJSON.AddFeature(gc)
+	}
+
+	// The closest named features.
+	for _, f := range m.GetNearestFeatures(lat, lon, k) {
+		gf := geojson.NewPointFeature([]float64{f.Centroid[1], f.Centroid[0]})
+		gf.ID = f.ID
+		gf.SetProperty("kind", "feature")
+		gf.SetProperty("name", f.Name)
+		gf.SetProperty("type", f.Type)
+		gf.SetProperty("distance", m.Query.Distance(lat, lon, f.Center))
+		geoJSON.AddFeature(gf)
+	}
+	return geoJSON.MarshalJSON()
+}
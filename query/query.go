@@ -0,0 +1,262 @@
+// Package query provides spatial queries (nearest region, radius, bounding
+// box and polygon search, k-nearest neighbors) on a sphere mesh.
+//
+// All queries are performed on the unit sphere using the 3D coordinates of
+// the regions, so they handle the antimeridian and the poles correctly.
+package query
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/Flokey82/genworldvoronoi/spheremesh"
+	"github.com/Flokey82/genworldvoronoi/various"
+	"github.com/Flokey82/geoquad"
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// Index answers spatial queries on a sphere mesh.
+type Index struct {
+	*spheremesh.SphereMesh
+	Radius float64 // Radius of the sphere in km
+}
+
+// New returns a new index for the given mesh on a sphere with the given
+// radius (in km).
+func New(mesh *spheremesh.SphereMesh, radius float64) *Index {
+	return &Index{
+		SphereMesh: mesh,
+		Radius:     radius,
+	}
+}
+
+// RegionAt returns the region containing the given lat/lon (in degrees),
+// which is the region closest to the point.
+func (idx *Index) RegionAt(lat, lon float64) int {
+	p := toVec3(lat, lon)
+
+	// Use the quadtree for a first guess.
+	r := 0
+	if res, ok := idx.RegQuadTree.FindNearestNeighbor(geoquad.Point{Lat: lat, Lon: lon}); ok {
+		r = res.Data.(int)
+	}
+
+	// The quadtree works on lat/lon, which is distorted near the poles and
+	// the antimeridian, so we walk towards the closest region. Since the mesh
+	// is a Delaunay triangulation, a neighbor is always closer unless we
+	// have found the closest region.
+	outRegs := make([]int, 0, 8)
+	best := vectors.Dot3(p, idx.regVec3(r))
+	for {
+		next := r
+		for _, nb := range idx.R_circulate_r(outRegs, r) {
+			if dot := vectors.Dot3(p, idx.regVec3(nb)); dot > best {
+				best = dot
+				next = nb
+			}
+		}
+		if next == r {
+			return r
+		}
+		r = next
+	}
+}
+
+// RegionsWithinRadius returns all regions within the given distance (in km)
+// of the given lat/lon (in degrees).
+func (idx *Index) RegionsWithinRadius(lat, lon, km float64) []int {
+	p := toVec3(lat, lon)
+	maxAngle := km / idx.Radius
+	if maxAngle >= math.Pi {
+		regs := make([]int, idx.NumRegions)
+		for r := range regs {
+			regs[r] = r
+		}
+		return regs
+	}
+	minDot := math.Cos(maxAngle)
+
+	// Expand from the closest region. The regions within the radius are
+	// connected in the mesh (Delaunay triangulation), so we only need to
+	// expand through regions within the radius (and the closest region,
+	// which might be outside of a very small radius).
+	start := idx.RegionAt(lat, lon)
+	seen := map[int]bool{start: true}
+	queue := []int{start}
+	var regs []int
+	outRegs := make([]int, 0, 8)
+	for i := 0; i < len(queue); i++ {
+		r := queue[i]
+		inside := vectors.Dot3(p, idx.regVec3(r)) >= minDot
+		if inside {
+			regs = append(regs, r)
+		} else if r != start {
+			continue
+		}
+		for _, nb := range idx.R_circulate_r(outRegs, r) {
+			if !seen[nb] {
+				seen[nb] = true
+				queue = append(queue, nb)
+			}
+		}
+	}
+	sort.Ints(regs)
+	return regs
+}
+
+// RegionsInBounds returns all regions within the given lat/lon bounding box
+// (in degrees). If lon1 is larger than lon2, the bounding box crosses the
+// antimeridian.
+func (idx *Index) RegionsInBounds(lat1, lon1, lat2, lon2 float64) []int {
+	lat1, lat2 = math.Min(lat1, lat2), math.Max(lat1, lat2)
+	rects := []geoquad.Rect{{MinLat: lat1, MaxLat: lat2, MinLon: lon1, MaxLon: lon2}}
+	if lon1 > lon2 {
+		rects = []geoquad.Rect{
+			{MinLat: lat1, MaxLat: lat2, MinLon: lon1, MaxLon: 180},
+			{MinLat: lat1, MaxLat: lat2, MinLon: -180, MaxLon: lon2},
+		}
+	}
+	var regs []int
+	for _, rect := range rects {
+		for _, qd := range idx.RegQuadTree.FindPointsInRect(rect) {
+			regs = append(regs, qd.Data.(int))
+		}
+	}
+	sort.Ints(regs)
+	return regs
+}
+
+// RegionsInPolygon returns all regions within the given polygon, where each
+// vertex is a lat/lon pair (in degrees) and the edges are great circle arcs.
+//
+// NOTE: The polygon must fit within a hemisphere.
+func (idx *Index) RegionsInPolygon(polygon [][2]float64) ([]int, error) {
+	if len(polygon) < 3 {
+		return nil, errors.New("polygon needs at least 3 vertices")
+	}
+
+	// Use the (normalized) average of the vertices as center.
+	verts := make([]vectors.Vec3, len(polygon))
+	var sum vectors.Vec3
+	for i, ll := range polygon {
+		verts[i] = toVec3(ll[0], ll[1])
+		sum = vectors.Add3(sum, verts[i])
+	}
+	if vectors.Dot3(sum, sum) == 0 {
+		return nil, errors.New("polygon has no well defined center")
+	}
+	center := sum.Normalize()
+
+	// The gnomonic projection maps great circle arcs to straight lines, so
+	// we can use a planar point in polygon test.
+	proj := newGnomonic(center)
+	projVerts := make([][2]float64, len(verts))
+	maxAngle := 0.0
+	for i, v := range verts {
+		var err error
+		if projVerts[i], err = proj.project(v); err != nil {
+			return nil, errors.New("polygon does not fit within a hemisphere")
+		}
+		maxAngle = math.Max(maxAngle, math.Acos(math.Min(1, vectors.Dot3(center, v))))
+	}
+
+	// Test all regions within the circle around the polygon.
+	cLat, cLon := various.LatLonFromVec3(center, 1.0)
+	var regs []int
+	for _, r := range idx.RegionsWithinRadius(cLat, cLon, maxAngle*idx.Radius) {
+		p, err := proj.project(idx.regVec3(r))
+		if err == nil && isPointInPolygon(p, projVerts) {
+			regs = append(regs, r)
+		}
+	}
+	return regs, nil
+}
+
+// Nearest returns up to k of the given regions (e.g. the regions of cities)
+// closest to the given lat/lon (in degrees), sorted by distance. If k is
+// negative, all regions are returned.
+func (idx *Index) Nearest(lat, lon float64, regs []int, k int) []int {
+	p := toVec3(lat, lon)
+	res := make([]int, len(regs))
+	copy(res, regs)
+	dots := make(map[int]float64, len(regs))
+	for _, r := range regs {
+		dots[r] = vectors.Dot3(p, idx.regVec3(r))
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return dots[res[i]] > dots[res[j]]
+	})
+	if k >= 0 && k < len(res) {
+		res = res[:k]
+	}
+	return res
+}
+
+// Distance returns the great circle distance (in km) between the given
+// lat/lon (in degrees) and the given region.
+func (idx *Index) Distance(lat, lon float64, r int) float64 {
+	dot := vectors.Dot3(toVec3(lat, lon), idx.regVec3(r))
+	return math.Acos(math.Max(-1, math.Min(1, dot))) * idx.Radius
+}
+
+// regVec3 returns the position of the given region on the unit sphere.
+func (idx *Index) regVec3(r int) vectors.Vec3 {
+	return various.ConvToVec3(idx.XYZ[3*r : 3*r+3])
+}
+
+// toVec3 returns the position of the given lat/lon (in degrees) on the unit
+// sphere.
+func toVec3(lat, lon float64) vectors.Vec3 {
+	return various.ConvToVec3(various.LatLonToCartesian(lat, lon))
+}
+
+// gnomonic is a gnomonic projection centered at the given point.
+type gnomonic struct {
+	center vectors.Vec3
+	e1, e2 vectors.Vec3 // Basis of the tangent plane
+}
+
+func newGnomonic(center vectors.Vec3) *gnomonic {
+	// Pick an axis that is not parallel to the center.
+	axis := vectors.Vec3{Z: 1}
+	if math.Abs(center.Z) > 0.9 {
+		axis = vectors.Vec3{X: 1}
+	}
+	e1 := cross3(axis, center).Normalize()
+	return &gnomonic{
+		center: center,
+		e1:     e1,
+		e2:     cross3(center, e1),
+	}
+}
+
+// project returns the coordinates of the given point on the tangent plane.
+func (g *gnomonic) project(p vectors.Vec3) ([2]float64, error) {
+	d := vectors.Dot3(p, g.center)
+	if d <= 1e-9 {
+		return [2]float64{}, errors.New("point is not within the hemisphere")
+	}
+	return [2]float64{vectors.Dot3(p, g.e1) / d, vectors.Dot3(p, g.e2) / d}, nil
+}
+
+// isPointInPolygon returns true if the point is within the (planar) polygon
+// using the even-odd rule.
+func isPointInPolygon(p [2]float64, polygon [][2]float64) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a[1] > p[1]) != (b[1] > p[1]) && p[0] < (b[0]-a[0])*(p[1]-a[1])/(b[1]-a[1])+a[0] {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func cross3(a, b vectors.Vec3) vectors.Vec3 {
+	return vectors.Vec3{
+		X: a.Y*b.Z - a.Z*b.Y,
+		Y: a.Z*b.X - a.X*b.Z,
+		Z: a.X*b.Y - a.Y*b.X,
+	}
+}
@@ -0,0 +1,434 @@
+package spheremesh
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// MeshGenerator generates a hierarchy of point distributions on the unit
+// sphere. Each level has roughly four times the points of the previous level
+// and each point (region) of a level has a parent region in the previous
+// level, which allows us to generate coarse meshes for LOD with true
+// parent/child relationships (see MakeCoarseSphereMesh).
+type MeshGenerator interface {
+	// NumPoints returns the (target) number of points of the given level.
+	NumPoints(level int) int
+
+	// Generate returns the xyz coordinates of the points of the given level
+	// as a flat array and the parent region in the previous level for each
+	// point (nil for level 0).
+	Generate(level int) (xyz []float64, parents []int)
+
+	// Parents returns the parent region in the previous level for each
+	// point of the given level (nil for level 0). The result is cached, so
+	// walking up the hierarchy doesn't regenerate the points of each level.
+	Parents(level int) []int
+}
+
+// parentCache caches the parents of each level of a mesh generator.
+type parentCache struct {
+	mu      sync.Mutex
+	parents map[int][]int
+}
+
+// get returns the cached parents of the given level or generates them.
+func (c *parentCache) get(level int, generate func(int) ([]float64, []int)) []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if parents, ok := c.parents[level]; ok {
+		return parents
+	}
+	if c.parents == nil {
+		c.parents = make(map[int][]int)
+	}
+	_, parents := generate(level)
+	c.parents[level] = parents
+	return parents
+}
+
+// maxMeshLevel is the highest level we consider when picking a level.
+const maxMeshLevel = 16
+
+// NewMeshGenerator returns the mesh generator with the given name
+// ("icosahedron", "healpix" or "poisson"). The seed is only used by
+// generators with random point placement.
+func NewMeshGenerator(name string, seed int64) (MeshGenerator, error) {
+	switch name {
+	case "icosahedron":
+		return NewIcosahedronGenerator(), nil
+	case "healpix":
+		return NewHEALPixGenerator(), nil
+	case "poisson":
+		return NewPoissonDiskGenerator(seed), nil
+	}
+	return nil, fmt.Errorf("unknown mesh generator %q", name)
+}
+
+// LevelForPoints returns the level of the given generator with the number of
+// points closest to numPoints.
+func LevelForPoints(g MeshGenerator, numPoints int) int {
+	level := 0
+	for level < maxMeshLevel {
+		// Advance if numPoints is above the geometric mean of the two levels.
+		n0 := float64(g.NumPoints(level))
+		n1 := float64(g.NumPoints(level + 1))
+		if n0*n1 >= float64(numPoints)*float64(numPoints) {
+			break
+		}
+		level++
+	}
+	return level
+}
+
+// MakeHierarchicalSphere returns a sphere mesh using the level of the given
+// generator that is closest to the requested number of points.
+func MakeHierarchicalSphere(g MeshGenerator, numPoints int) (*SphereMesh, error) {
+	return MakeSphereLevel(g, LevelForPoints(g, numPoints))
+}
+
+// MakeSphereLevel returns a sphere mesh of the given level of the generator.
+func MakeSphereLevel(g MeshGenerator, level int) (*SphereMesh, error) {
+	xyz, parents := g.Generate(level)
+	m, err := newSphereMeshFromXYZ(xyz)
+	if err != nil {
+		return nil, err
+	}
+	m.Generator = g
+	m.Level = level
+	m.Parents = parents
+	return m, nil
+}
+
+// IcosahedronGenerator generates points by repeatedly subdividing the faces
+// of an icosahedron.
+//
+// All points of a level are kept in the next level, so the regions of a
+// level are the first regions of all finer levels (with the same IDs). The
+// parent of a new point is the edge endpoint with the lower ID.
+type IcosahedronGenerator struct {
+	cache parentCache
+}
+
+// NewIcosahedronGenerator returns a new subdivided icosahedron generator.
+func NewIcosahedronGenerator() *IcosahedronGenerator {
+	return &IcosahedronGenerator{}
+}
+
+// NumPoints returns the number of points of the given level.
+func (g *IcosahedronGenerator) NumPoints(level int) int {
+	return 10*(1<<(2*level)) + 2
+}
+
+// Generate returns the points of the given level and their parents.
+func (g *IcosahedronGenerator) Generate(level int) ([]float64, []int) {
+	phi := (1 + math.Sqrt(5)) / 2
+	verts := [][3]float64{
+		{-1, phi, 0}, {1, phi, 0}, {-1, -phi, 0}, {1, -phi, 0},
+		{0, -1, phi}, {0, 1, phi}, {0, -1, -phi}, {0, 1, -phi},
+		{phi, 0, -1}, {phi, 0, 1}, {-phi, 0, -1}, {-phi, 0, 1},
+	}
+	faces := [][3]int{
+		{0, 11, 5}, {0, 5, 1}, {0, 1, 7}, {0, 7, 10}, {0, 10, 11},
+		{1, 5, 9}, {5, 11, 4}, {11, 10, 2}, {10, 7, 6}, {7, 1, 8},
+		{3, 9, 4}, {3, 4, 2}, {3, 2, 6}, {3, 6, 8}, {3, 8, 9},
+		{4, 9, 5}, {2, 4, 11}, {6, 2, 10}, {8, 6, 7}, {9, 8, 1},
+	}
+	xyz := make([]float64, 0, 3*g.NumPoints(level))
+	for _, v := range verts {
+		xyz = appendNormalized(xyz, v[0], v[1], v[2])
+	}
+
+	var parents []int
+	for l := 0; l < level; l++ {
+		numPrev := len(xyz) / 3
+		parents = make([]int, numPrev, g.NumPoints(l+1))
+		for r := range parents {
+			parents[r] = r
+		}
+
+		// Split each edge at its midpoint (once) and each face into four.
+		midpoints := make(map[[2]int]int)
+		getMidpoint := func(a, b int) int {
+			if a > b {
+				a, b = b, a
+			}
+			key := [2]int{a, b}
+			if r, ok := midpoints[key]; ok {
+				return r
+			}
+			r := len(xyz) / 3
+			xyz = appendNormalized(xyz,
+				xyz[3*a]+xyz[3*b],
+				xyz[3*a+1]+xyz[3*b+1],
+				xyz[3*a+2]+xyz[3*b+2])
+			parents = append(parents, a)
+			midpoints[key] = r
+			return r
+		}
+		newFaces := make([][3]int, 0, 4*len(faces))
+		for _, f := range faces {
+			ab := getMidpoint(f[0], f[1])
+			bc := getMidpoint(f[1], f[2])
+			ca := getMidpoint(f[2], f[0])
+			newFaces = append(newFaces,
+				[3]int{f[0], ab, ca},
+				[3]int{f[1], bc, ab},
+				[3]int{f[2], ca, bc},
+				[3]int{ab, bc, ca})
+		}
+		faces = newFaces
+	}
+	return xyz, parents
+}
+
+// Parents returns the (cached) parents of the given level.
+func (g *IcosahedronGenerator) Parents(level int) []int {
+	return g.cache.get(level, g.Generate)
+}
+
+// HEALPixGenerator generates the pixel centers of a HEALPix grid, which
+// divides the sphere into pixels of equal area.
+//
+// The regions use the NESTED pixel numbering, so the parent of a region is
+// its ID divided by four.
+// See: https://healpix.jpl.nasa.gov/
+type HEALPixGenerator struct {
+	cache parentCache
+}
+
+// NewHEALPixGenerator returns a new HEALPix generator.
+func NewHEALPixGenerator() *HEALPixGenerator {
+	return &HEALPixGenerator{}
+}
+
+// NumPoints returns the number of points of the given level.
+func (g *HEALPixGenerator) NumPoints(level int) int {
+	return 12 * (1 << (2 * level))
+}
+
+// Generate returns the points of the given level and their parents.
+func (g *HEALPixGenerator) Generate(level int) ([]float64, []int) {
+	numPoints := g.NumPoints(level)
+	xyz := make([]float64, 0, 3*numPoints)
+	var parents []int
+	if level > 0 {
+		parents = make([]int, numPoints)
+	}
+	for p := 0; p < numPoints; p++ {
+		z, phi := healpixNestToZPhi(level, p)
+		s := math.Sqrt(1 - z*z)
+		xyz = append(xyz, s*math.Cos(phi), s*math.Sin(phi), z)
+		if parents != nil {
+			parents[p] = p >> 2
+		}
+	}
+	return xyz, parents
+}
+
+// Parents returns the (cached) parents of the given level.
+func (g *HEALPixGenerator) Parents(level int) []int {
+	return g.cache.get(level, g.Generate)
+}
+
+// healpixNestToZPhi returns the z coordinate and the longitude (in radians)
+// of the center of the given NESTED pixel at the given level (nside = 2^level).
+// This is a port of pix2ang_nest_z_phi from the HEALPix C library.
+func healpixNestToZPhi(level, p int) (float64, float64) {
+	jrll := [12]int{2, 2, 2, 2, 3, 3, 3, 3, 4, 4, 4, 4}
+	jpll := [12]int{1, 3, 5, 7, 0, 2, 4, 6, 1, 3, 5, 7}
+
+	nside := 1 << level
+	npface := nside * nside
+	fact2 := 4 / float64(12*npface)
+
+	// Get the base pixel (face) and the coordinates within the face.
+	face := p / npface
+	ipf := p % npface
+	ix := compressBits(ipf)
+	iy := compressBits(ipf >> 1)
+
+	var z float64
+	var nr, kshift int
+	jr := jrll[face]*nside - ix - iy - 1
+	switch {
+	case jr < nside: // North polar cap
+		nr = jr
+		z = 1 - float64(nr*nr)*fact2
+	case jr > 3*nside: // South polar cap
+		nr = 4*nside - jr
+		z = float64(nr*nr)*fact2 - 1
+	default: // Equatorial region
+		nr = nside
+		z = float64(2*nside-jr) * float64(2*nside) * fact2
+		kshift = (jr - nside) & 1
+	}
+
+	jp := (jpll[face]*nr + ix - iy + 1 + kshift) / 2
+	if jp > 4*nside {
+		jp -= 4 * nside
+	}
+	if jp < 1 {
+		jp += 4 * nside
+	}
+	return z, (float64(jp) - float64(kshift+1)*0.5) * (math.Pi / 2 / float64(nr))
+}
+
+// compressBits returns the value of the even bits of v (de-interleaving).
+func compressBits(v int) int {
+	var res int
+	for i := 0; v>>(2*i) != 0; i++ {
+		res |= ((v >> (2 * i)) & 1) << i
+	}
+	return res
+}
+
+// PoissonDiskGenerator generates randomly placed points on the sphere with a
+// minimum distance between them (Poisson-disk sampling).
+//
+// Each level keeps the points of the previous level and adds new points with
+// a smaller minimum distance, so the regions of a level are the first regions
+// of all finer levels (with the same IDs). The parent of a new point is the
+// closest point of the previous level.
+type PoissonDiskGenerator struct {
+	Seed  int64 // Seed for the random point placement
+	cache parentCache
+}
+
+// NewPoissonDiskGenerator returns a new Poisson-disk generator.
+func NewPoissonDiskGenerator(seed int64) *PoissonDiskGenerator {
+	return &PoissonDiskGenerator{Seed: seed}
+}
+
+// NumPoints returns the target number of points of the given level. The
+// actual number might be slightly lower if the sphere fills up early.
+func (g *PoissonDiskGenerator) NumPoints(level int) int {
+	return 12 * (1 << (2 * level))
+}
+
+// Generate returns the points of the given level and their parents.
+func (g *PoissonDiskGenerator) Generate(level int) ([]float64, []int) {
+	rnd := rand.New(rand.NewSource(g.Seed))
+	var xyz []float64
+	var parents []int
+	var prevGrid *sphereGrid
+	for l := 0; l <= level; l++ {
+		target := g.NumPoints(l)
+
+		// The minimum distance is chosen so that the target number of points
+		// fits comfortably below the maximum packing density.
+		minDist := 0.75 * math.Sqrt(4*math.Pi/float64(target))
+		grid := newSphereGrid(2 * math.Sin(minDist/2))
+		numPrev := len(xyz) / 3
+		for r := 0; r < numPrev; r++ {
+			grid.add(xyz, r)
+		}
+		if l == level && l > 0 {
+			parents = make([]int, numPrev, target)
+			for r := range parents {
+				parents[r] = r
+			}
+		}
+
+		// Throw darts until we reach the target or give up.
+		for attempts := 30 * target; attempts > 0 && len(xyz)/3 < target; attempts-- {
+			z := 2*rnd.Float64() - 1
+			lon := 2 * math.Pi * rnd.Float64()
+			s := math.Sqrt(1 - z*z)
+			p := [3]float64{s * math.Cos(lon), s * math.Sin(lon), z}
+			if grid.nearest(xyz, p) != -1 {
+				continue
+			}
+			if parents != nil {
+				parents = append(parents, prevGrid.nearestOrAll(xyz, p, numPrev))
+			}
+			xyz = append(xyz, p[0], p[1], p[2])
+			grid.add(xyz, len(xyz)/3-1)
+		}
+		prevGrid = grid
+	}
+	return xyz, parents
+}
+
+// Parents returns the (cached) parents of the given level.
+func (g *PoissonDiskGenerator) Parents(level int) []int {
+	return g.cache.get(level, g.Generate)
+}
+
+// sphereGrid is a uniform grid for finding points within a given (chord)
+// distance on the unit sphere.
+type sphereGrid struct {
+	cellSize float64
+	cells    map[[3]int][]int
+}
+
+func newSphereGrid(cellSize float64) *sphereGrid {
+	return &sphereGrid{
+		cellSize: cellSize,
+		cells:    make(map[[3]int][]int),
+	}
+}
+
+func (g *sphereGrid) cell(p [3]float64) [3]int {
+	return [3]int{
+		int(math.Floor(p[0] / g.cellSize)),
+		int(math.Floor(p[1] / g.cellSize)),
+		int(math.Floor(p[2] / g.cellSize)),
+	}
+}
+
+func (g *sphereGrid) add(xyz []float64, r int) {
+	c := g.cell([3]float64{xyz[3*r], xyz[3*r+1], xyz[3*r+2]})
+	g.cells[c] = append(g.cells[c], r)
+}
+
+// nearest returns the closest point within the cell size of p or -1 if
+// there is none.
+func (g *sphereGrid) nearest(xyz []float64, p [3]float64) int {
+	c := g.cell(p)
+	best := -1
+	bestDist := g.cellSize * g.cellSize
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			for dz := -1; dz <= 1; dz++ {
+				for _, r := range g.cells[[3]int{c[0] + dx, c[1] + dy, c[2] + dz}] {
+					if d := chordDistSq(xyz, r, p); d < bestDist {
+						best, bestDist = r, d
+					}
+				}
+			}
+		}
+	}
+	return best
+}
+
+// nearestOrAll returns the closest point within the cell size of p or the
+// closest of the first numPoints points if there is none nearby.
+func (g *sphereGrid) nearestOrAll(xyz []float64, p [3]float64, numPoints int) int {
+	if r := g.nearest(xyz, p); r != -1 {
+		return r
+	}
+	best := -1
+	bestDist := math.Inf(1)
+	for r := 0; r < numPoints; r++ {
+		if d := chordDistSq(xyz, r, p); d < bestDist {
+			best, bestDist = r, d
+		}
+	}
+	return best
+}
+
+// chordDistSq returns the squared (chord) distance between region r and p.
+func chordDistSq(xyz []float64, r int, p [3]float64) float64 {
+	dx := xyz[3*r] - p[0]
+	dy := xyz[3*r+1] - p[1]
+	dz := xyz[3*r+2] - p[2]
+	return dx*dx + dy*dy + dz*dz
+}
+
+// appendNormalized appends the given vector normalized to unit length.
+func appendNormalized(xyz []float64, x, y, z float64) []float64 {
+	l := math.Sqrt(x*x + y*y + z*z)
+	return append(xyz, x/l, y/l, z/l)
+}
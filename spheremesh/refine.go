@@ -0,0 +1,115 @@
+package spheremesh
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/Flokey82/genworldvoronoi/various"
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// RefineSphere returns a new sphere mesh with a total of numPoints regions,
+// which contains all regions of the given mesh plus additional points that
+// are distributed among the regions proportional to the given weights. This
+// way we can increase the resolution where we need the detail (coastlines,
+// mountains, ...) without wasting points on the open ocean.
+//
+// The regions of the given mesh keep their IDs, except for the south pole
+// (the last region), which is re-added as the last region of the new mesh.
+// The new points are appended after the original regions.
+//
+// The second return value maps each region of the new mesh to the region of
+// the given mesh it was placed in (the region itself for original regions).
+//
+// NOTE: The given mesh needs to be created with the south pole added (see
+// MakeSphere).
+func RefineSphere(m *SphereMesh, seed int64, numPoints int, weights []float64) (*SphereMesh, []int, error) {
+	rnd := rand.New(rand.NewSource(seed))
+	southPole := m.NumRegions - 1
+
+	// Calculate the number of additional points per region.
+	counts := distributePoints(rnd, weights[:southPole], numPoints-m.NumRegions)
+
+	// Copy the original regions (skip the existing south pole).
+	xyz := make([]float64, 0, 3*numPoints)
+	latLon := make([][2]float64, 0, numPoints)
+	parents := make([]int, 0, numPoints)
+	for r := 0; r < southPole; r++ {
+		xyz = append(xyz, m.XYZ[3*r:3*r+3]...)
+		latLon = append(latLon, m.LatLon[r])
+		parents = append(parents, r)
+	}
+
+	// Place the additional points randomly within the regions. We split each
+	// region into triangles between the region center and the centroids of
+	// two adjacent triangles and pick a random point within one of them.
+	outTris := make([]int, 0, 8)
+	for r, n := range counts {
+		if n == 0 {
+			continue
+		}
+		center := various.ConvToVec3(m.XYZ[3*r : 3*r+3])
+		tris := m.R_circulate_t(outTris, r)
+		for i := 0; i < n; i++ {
+			// Cycle through the triangles to spread out the points.
+			sector := (i + rnd.Intn(len(tris))) % len(tris)
+			t0 := tris[sector]
+			t1 := tris[(sector+1)%len(tris)]
+			a := various.ConvToVec3(m.TriXYZ[3*t0 : 3*t0+3])
+			b := various.ConvToVec3(m.TriXYZ[3*t1 : 3*t1+3])
+
+			// Uniform random point within the triangle (center, a, b).
+			u := math.Sqrt(rnd.Float64())
+			v := rnd.Float64()
+			p := vectors.Add3(vectors.Add3(center.Mul(1-u), a.Mul(u*(1-v))), b.Mul(u*v)).Normalize()
+			xyz = append(xyz, p.X, p.Y, p.Z)
+			nla, nlo := various.LatLonFromVec3(p, 1.0)
+			latLon = append(latLon, [2]float64{nla, nlo})
+			parents = append(parents, r)
+		}
+	}
+
+	// The south pole is added by NewSphereMesh.
+	parents = append(parents, southPole)
+	sm, err := NewSphereMesh(latLon, xyz, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sm, parents, nil
+}
+
+// distributePoints distributes n points among the given weights using the
+// largest remainder method, breaking ties randomly.
+func distributePoints(rnd *rand.Rand, weights []float64, n int) []int {
+	counts := make([]int, len(weights))
+	var sum float64
+	for _, w := range weights {
+		sum += math.Max(0, w)
+	}
+	if n <= 0 || sum <= 0 {
+		return counts
+	}
+	remainders := make([]float64, len(weights))
+	var assigned int
+	for r, w := range weights {
+		share := float64(n) * math.Max(0, w) / sum
+		counts[r] = int(share)
+		remainders[r] = share - float64(counts[r]) + rnd.Float64()*1e-9
+		assigned += counts[r]
+	}
+
+	// Assign the remaining points to the regions with the largest remainders.
+	idxs := make([]int, len(weights))
+	for i := range idxs {
+		idxs[i] = i
+	}
+	sort.Slice(idxs, func(a, b int) bool {
+		return remainders[idxs[a]] > remainders[idxs[b]]
+	})
+	for i := 0; assigned < n && i < len(idxs); i++ {
+		counts[idxs[i]]++
+		assigned++
+	}
+	return counts
+}
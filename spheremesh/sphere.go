@@ -6,6 +6,7 @@ import (
 
 	"github.com/Flokey82/genworldvoronoi/various"
 	"github.com/Flokey82/geoquad"
+	"github.com/Flokey82/go_gens/utils"
 	"github.com/fogleman/delaunay"
 )
 
@@ -151,6 +152,9 @@ type SphereMesh struct {
 	TriLatLon   [][2]float64      // Triangle latitude and longitude
 	RegQuadTree *geoquad.QuadTree // Quadtree for region lookup
 	TriQuadTree *geoquad.QuadTree // Quadtree for triangle lookup
+	Generator   MeshGenerator     // Generator of the mesh hierarchy (nil if not hierarchical)
+	Level       int               // Level of the mesh in the hierarchy of the generator
+	Parents     []int             // Parent region in the previous level for each region (nil for level 0)
 }
 
 func NewSphereMesh(latLon [][2]float64, xyz []float64, addSouthPole bool) (*SphereMesh, error) {
@@ -174,7 +178,83 @@ func NewSphereMesh(latLon [][2]float64, xyz []float64, addSouthPole bool) (*Sphe
 		latLon = append(latLon, [2]float64{-90.0, 45.0})
 		tri = addSouthPoleToMesh((len(xyz)/3)-1, tri)
 	}
+	return newSphereMeshFromTriangulation(latLon, xyz, tri), nil
+}
+
+// newSphereMeshFromXYZ returns a sphere mesh of the given points (as a flat
+// xyz array), which keep their order as region IDs.
+//
+// Instead of adding a south pole, we rotate the point closest to the
+// projection pole into the pole for the stereographic projection and use it
+// to close the hole in the triangulation.
+func newSphereMeshFromXYZ(xyz []float64) (*SphereMesh, error) {
+	numPoints := len(xyz) / 3
+	latLon := make([][2]float64, numPoints)
+	pole := 0
+	for r := 0; r < numPoints; r++ {
+		nla, nlo := various.LatLonFromVec3(various.ConvToVec3(xyz[3*r:3*r+3]), 1.0)
+		latLon[r] = [2]float64{nla, nlo}
+		if xyz[3*r+2] > xyz[3*pole+2] {
+			pole = r
+		}
+	}
+
+	// Rotate all points so that the pole point ends up at (0, 0, 1) and
+	// project all other points onto the plane.
+	rotated := rotateToPole(xyz, pole)
+	pts := make([]delaunay.Point, 0, numPoints-1)
+	ids := make([]int, 0, numPoints-1)
+	for r := 0; r < numPoints; r++ {
+		if r == pole {
+			continue
+		}
+		x, y, z := rotated[3*r], rotated[3*r+1], rotated[3*r+2]
+		pts = append(pts, delaunay.Point{X: x / (1 - z), Y: y / (1 - z)})
+		ids = append(ids, r)
+	}
+	tri, err := delaunay.Triangulate(pts)
+	if err != nil {
+		return nil, err
+	}
 
+	// Map the indices of the projected points back to the region IDs.
+	for i, p := range tri.Triangles {
+		tri.Triangles[i] = ids[p]
+	}
+	tri = addSouthPoleToMesh(pole, tri)
+	return newSphereMeshFromTriangulation(latLon, xyz, tri), nil
+}
+
+// rotateToPole returns a copy of the given points (as a flat xyz array)
+// rotated so that the given region ends up at (0, 0, 1).
+func rotateToPole(xyz []float64, pole int) []float64 {
+	px, py, pz := xyz[3*pole], xyz[3*pole+1], xyz[3*pole+2]
+
+	// The rotation axis is the cross product of the pole point and (0, 0, 1).
+	kx, ky := py, -px
+	sinA := math.Sqrt(kx*kx + ky*ky)
+	cosA := pz
+	res := make([]float64, len(xyz))
+	if sinA < 1e-12 {
+		copy(res, xyz)
+		return res
+	}
+	kx, ky = kx/sinA, ky/sinA
+
+	// Rodrigues' rotation formula (the axis has no z component).
+	for i := 0; i < len(xyz); i += 3 {
+		x, y, z := xyz[i], xyz[i+1], xyz[i+2]
+		dot := kx*x + ky*y
+		res[i] = x*cosA + ky*z*sinA + kx*dot*(1-cosA)
+		res[i+1] = y*cosA - kx*z*sinA + ky*dot*(1-cosA)
+		res[i+2] = z*cosA + (kx*y-ky*x)*sinA
+	}
+	return res
+}
+
+// newSphereMeshFromTriangulation returns a sphere mesh of the given points
+// and their (closed) triangulation.
+func newSphereMeshFromTriangulation(latLon [][2]float64, xyz []float64, tri *delaunay.Triangulation) *SphereMesh {
 	// Create a mesh from the triangulation.
 	m := &SphereMesh{
 		TriangleMesh: NewTriangleMesh(len(latLon), tri.Triangles, tri.Halfedges),
@@ -206,7 +286,7 @@ func NewSphereMesh(latLon [][2]float64, xyz []float64, addSouthPole bool) (*Sphe
 	// Create a quadtree for triangle lookup.
 	m.TriQuadTree = NewQuadTreeFromLatLon(m.TriLatLon)
 
-	return m, nil
+	return m
 }
 
 func NewQuadTreeFromLatLon(latLon [][2]float64) *geoquad.QuadTree {
@@ -223,7 +303,18 @@ func NewQuadTreeFromLatLon(latLon [][2]float64) *geoquad.QuadTree {
 }
 
 // MakeCoarseSphereMesh returns a sphere mesh with 1/step density.
+//
+// If the mesh was created by a mesh generator, the coarse mesh is the level
+// of the hierarchy with the closest density, so the regions of this mesh map
+// to their ancestors in the coarse mesh (see CoarseRegions). Otherwise, we
+// use every step-th region.
 func (m *SphereMesh) MakeCoarseSphereMesh(step int) (*SphereMesh, error) {
+	if m.Generator != nil {
+		// Each level has four times the points of the previous level.
+		levels := int(math.Round(math.Log(float64(step)) / math.Log(4)))
+		return MakeSphereLevel(m.Generator, utils.Max(m.Level-levels, 0))
+	}
+
 	// Convert the lat/lon coordinates to x,y,z. (skip the existing south pole)
 	var xyz []float64
 	var latLon [][2]float64
@@ -235,3 +326,28 @@ func (m *SphereMesh) MakeCoarseSphereMesh(step int) (*SphereMesh, error) {
 	// Now adjust the indices of the triangles
 	return NewSphereMesh(latLon, xyz, true)
 }
+
+// CoarseRegions returns for each region of the mesh the ancestor region in
+// the given coarse mesh of the same hierarchy (see MakeCoarseSphereMesh) or
+// nil if the meshes are not part of the same hierarchy.
+func (m *SphereMesh) CoarseRegions(coarse *SphereMesh) []int {
+	if m.Generator == nil || coarse.Generator != m.Generator || coarse.Level > m.Level {
+		return nil
+	}
+	res := make([]int, m.NumRegions)
+	for r := range res {
+		res[r] = r
+	}
+
+	// Walk up the hierarchy one level at a time.
+	for level := m.Level; level > coarse.Level; level-- {
+		parents := m.Parents
+		if level < m.Level {
+			parents = m.Generator.Parents(level)
+		}
+		for r, p := range res {
+			res[r] = parents[p]
+		}
+	}
+	return res
+}
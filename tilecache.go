@@ -0,0 +1,204 @@
+package genworldvoronoi
+
+import (
+	"container/list"
+	"log"
+	"math"
+	"sync"
+
+	"github.com/Flokey82/genworldvoronoi/geo"
+)
+
+// Tile refinement parameters.
+const (
+	tileRefineMinZoom   = 6    // Zoom level of the first refinement
+	tileRefineEveryZoom = 2    // Refine every n zoom levels (each refinement roughly quadruples the number of regions)
+	tileRefinePadding   = 0.25 // Padding around a refined tile (fraction of the tile size)
+	tileCacheMaxRefined = 256  // Maximum number of refined tiles in the cache
+)
+
+// TileCache is a cache for refined terrain patches stored as a quadtree.
+//
+// Starting at tileRefineMinZoom, every tileRefineEveryZoom zoom levels the
+// terrain of the parent tile is refined for the requested tile (see
+// geo.BaseObject.Refine), so high zoom tiles show real detail instead of
+// huge regions.
+//
+// The lock only guards the quadtree, the refinement itself happens outside
+// of the lock (once per tile), so other tiles can be served meanwhile. If
+// there are more than tileCacheMaxRefined refined tiles, the least recently
+// used ones are evicted.
+type TileCache struct {
+	mu  sync.Mutex
+	lru *list.List // Refined tiles, most recently used first
+	*TileTree
+}
+
+// NewTileCache returns a new tile cache for the given (global) terrain.
+func NewTileCache(bo *geo.BaseObject) *TileCache {
+	return &TileCache{
+		lru: list.New(),
+		TileTree: &TileTree{
+			BaseObject: bo,
+		},
+	}
+}
+
+// Get returns the tile for the given tile coordinates and zoom level,
+// refining the terrain if necessary.
+func (c *TileCache) Get(x, y, zoom int) *TileTree {
+	c.mu.Lock()
+	t := c.TileTree.get(x, y, zoom, c.lru)
+	if t != nil {
+		c.touch(t)
+	}
+	c.mu.Unlock()
+	if t == nil {
+		return nil
+	}
+	t.resolve()
+	return t
+}
+
+// touch marks the given tile and its parents as recently used and evicts
+// the least recently used refined tiles if there are too many.
+func (c *TileCache) touch(t *TileTree) {
+	for p := t; p != nil; p = p.Parent {
+		if p.elem != nil {
+			c.lru.MoveToFront(p.elem)
+		}
+	}
+	for c.lru.Len() > tileCacheMaxRefined {
+		c.evict(c.lru.Back().Value.(*TileTree))
+	}
+}
+
+// evict removes the given tile and all its children from the quadtree.
+//
+// NOTE: Tiles that are still in use elsewhere stay valid, they are just no
+// longer cached.
+func (c *TileCache) evict(t *TileTree) {
+	if t.Parent != nil {
+		t.Parent.Children[t.childIndex()] = nil
+	}
+	var remove func(t *TileTree)
+	remove = func(t *TileTree) {
+		if t.elem != nil {
+			c.lru.Remove(t.elem)
+			t.elem = nil
+		}
+		for _, child := range t.Children {
+			if child != nil {
+				remove(child)
+			}
+		}
+	}
+	remove(t)
+}
+
+// TileTree is a quadtree of tiles.
+type TileTree struct {
+	Parent          *TileTree
+	Children        [4]*TileTree
+	X, Y, Zoom      int
+	*geo.BaseObject // Terrain of the tile (shared with the parent if not refined)
+
+	refined bool          // The terrain of the tile is refined
+	once    sync.Once     // Sets the terrain of the tile (see resolve)
+	elem    *list.Element // Element in the LRU list of the cache (if refined)
+}
+
+// get returns the tile for the given tile coordinates and zoom level.
+// Missing tiles are added to the quadtree, refined tiles are added to the
+// given LRU list.
+//
+// NOTE: The terrain of the returned tile is only set after calling resolve.
+func (t *TileTree) get(x, y, zoom int, lru *list.List) *TileTree {
+	x, y = wrapTileCoordinates(x, y, zoom)
+
+	// If the tile is not in the quadtree, return.
+	if zoom < t.Zoom {
+		return nil
+	}
+
+	// If the tile is exactly the one requested, return.
+	if zoom == t.Zoom && x == t.X && y == t.Y {
+		return t
+	}
+
+	// Check if the tile is a parent of the tile we are looking for.
+	xAtCurrentTile := x >> uint(zoom-t.Zoom)
+	yAtCurrentTile := y >> uint(zoom-t.Zoom)
+	if xAtCurrentTile != t.X || yAtCurrentTile != t.Y {
+		return nil
+	}
+
+	// Get the index of the child that will contain the tile that we are looking for.
+	childIndex := 0
+	xChild := x >> uint(zoom-t.Zoom-1)
+	yChild := y >> uint(zoom-t.Zoom-1)
+	if xChild%2 == 1 {
+		childIndex += 1
+	}
+	if yChild%2 == 1 {
+		childIndex += 2
+	}
+
+	// If the child is not nil, find the tile in the child.
+	if t.Children[childIndex] != nil {
+		return t.Children[childIndex].get(x, y, zoom, lru)
+	}
+
+	// Add the child tile, which will be refined if required.
+	childZoom := t.Zoom + 1
+	child := &TileTree{
+		Parent:  t,
+		X:       xChild,
+		Y:       yChild,
+		Zoom:    childZoom,
+		refined: childZoom >= tileRefineMinZoom && (childZoom-tileRefineMinZoom)%tileRefineEveryZoom == 0,
+	}
+	if child.refined {
+		child.elem = lru.PushFront(child)
+	}
+	t.Children[childIndex] = child
+	return child.get(x, y, zoom, lru)
+}
+
+// childIndex returns the index of the tile in the children of its parent.
+func (t *TileTree) childIndex() int {
+	return t.X%2 + 2*(t.Y%2)
+}
+
+// resolve sets the terrain of the tile and its parents, refining the
+// terrain of the parent if required.
+func (t *TileTree) resolve() {
+	if t.Parent == nil {
+		return
+	}
+	t.once.Do(func() {
+		t.Parent.resolve()
+		baseObject := t.Parent.BaseObject
+		if t.refined {
+			if ipl, err := t.Parent.refine(t.X, t.Y, t.Zoom); err != nil {
+				log.Println("Failed to refine tile", t.X, t.Y, t.Zoom, err)
+			} else {
+				baseObject = &ipl.BaseObject
+			}
+		}
+		t.BaseObject = baseObject
+	})
+}
+
+// refine returns the refined terrain of the tile at the given coordinates.
+func (t *TileTree) refine(x, y, zoom int) (*geo.Interpolated, error) {
+	tbb := newTileBoundingBox(x, y, zoom)
+	la1, lo1, la2, lo2 := tbb.toLatLon()
+	la1, la2 = math.Min(la1, la2), math.Max(la1, la2)
+	lo1, lo2 = math.Min(lo1, lo2), math.Max(lo1, lo2)
+
+	// Make sure we have some padding around the tile.
+	padLat := (la2 - la1) * tileRefinePadding
+	padLon := (lo2 - lo1) * tileRefinePadding
+	return t.BaseObject.Refine(la1-padLat, lo1-padLon, la2+padLat, lo2+padLon)
+}
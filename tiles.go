@@ -13,7 +13,6 @@ import (
 	"github.com/Flokey82/genworldvoronoi/geo"
 	"github.com/Flokey82/genworldvoronoi/various"
 	"github.com/Flokey82/geoquad"
-	"github.com/Flokey82/go_gens/gameconstants"
 	"github.com/Flokey82/go_gens/vectors"
 	"github.com/davvo/mercator"
 	"github.com/llgcode/draw2d/draw2dimg"
@@ -23,22 +22,23 @@ import (
 )
 
 // GetTile returns the image of the tile at the given coordinates and zoom level.
-func (m *Map) GetTile(x, y, zoom, displayMode, vectorMode int, drawRivers, drawTradeRoutes, drawLakes, drawShadows, aspectShading bool) image.Image {
+func (m *Map) GetTile(x, y, zoom, displayMode, vectorMode int, drawRivers, drawTradeRoutes, drawLakes, drawShadows, aspectShading, drawFires bool) image.Image {
 	// NOTE:
 	//
-	// For now we don't use the LOD logic until we have figured out how to handle triangles.
-	// While regions simply have n*step as their ID, triangles aren't stable as they are
-	// generated from the voronoi diagram from scratch. This means their order changes...
-	// Which also means we can't easily re-use the pre-computed triangle values from the
-	// primary mesh.
-	//
-	// mesh, step := m.getCoarseForZoom(zoom)
+	// Coarse meshes for LOD are only available for hierarchical meshes, where
+	// each coarse region is drawn using the values of a representative region
+	// of the global mesh. Triangles aren't stable across the levels, so
+	// everything based on triangles (like the shadows) still uses the global
+	// mesh.
 	mesh := m.SphereMesh
-	step := 1
+	var meshRegions []int // Region of the global mesh for each region of the mesh (nil: same IDs)
+	if lod := m.getCoarseForZoom(zoom); lod != nil {
+		mesh, meshRegions = lod.SphereMesh, lod.Regions
+	}
 
 	var colorFunc func(int, float64) color.Color
 	switch displayMode {
-	case 14, 15, 16, 17, 18, 19:
+	case 14, 15, 16, 17, 18, 19, 28:
 		colorGrad := colorgrad.Rainbow()
 		terrToColor := make(map[int]int)
 		var territory []int
@@ -78,6 +78,12 @@ func (m *Map) GetTile(x, y, zoom, displayMode, vectorMode int, drawRivers, drawT
 				terrToColor[c.Origin] = i
 			}
 			territory = m.SpeciesRegions
+		} else if displayMode == 28 {
+			terrLen = geo.LithologyMax
+			for i := 0; i < terrLen; i++ {
+				terrToColor[i] = i
+			}
+			territory = m.RegionToLithology
 		} else {
 			terr := m.PlateRegs
 			terrLen = len(terr)
@@ -87,12 +93,12 @@ func (m *Map) GetTile(x, y, zoom, displayMode, vectorMode int, drawRivers, drawT
 			territory = m.RegionToPlate
 		}
 
-		min, max := minMax(m.Elevation)
-		_, maxMois := minMax(m.Moisture)
+		min, max := m.Elevation.MinMax()
+		_, maxMois := m.Moisture.MinMax()
 		cols := colorGrad.Colors(uint(terrLen))
 		colorFunc = func(i int, n float64) color.Color {
 			// Calculate the color of the region.
-			elev := m.Elevation[i]
+			elev := m.Elevation.At(i)
 			val := (elev - min) / (max - min)
 
 			// If we have a territory, return the color of the territory.
@@ -102,14 +108,14 @@ func (m *Map) GetTile(x, y, zoom, displayMode, vectorMode int, drawRivers, drawT
 			}
 
 			// Return blue for water (oceans and lakes).
-			if elev <= 0 || (m.Waterpool[i] > 0 && drawLakes) {
+			if elev <= 0 || (m.Waterpool.At(i) > 0 && drawLakes) {
 				return genBlue(val)
 			}
 
 			// Return the biome color for land.
 			rLat := m.LatLon[i][0]
 			valElev := elev / max
-			valMois := m.Moisture[i] / maxMois
+			valMois := m.Moisture.At(i) / maxMois
 			return geo.GetWhittakerModBiomeColor(rLat, valElev, valMois, math.Pow(val, 1/n))
 		}
 	case 20, 21, 22: // Temperatures and elevation.
@@ -128,39 +134,39 @@ func (m *Map) GetTile(x, y, zoom, displayMode, vectorMode int, drawRivers, drawT
 		}
 
 		if displayMode == 20 { // Elevation.
-			_, max := minMax(m.Elevation)
+			_, max := m.Elevation.MinMax()
 
 			// Create the color function.
 			colorFunc = func(i int, n float64) color.Color {
 				// Calculate the color of the region.
-				val := m.Elevation[i] / max
+				val := m.Elevation.At(i) / max
 				return genColor(cb.At(val), math.Pow(val, 1/n))
 			}
 		} else if displayMode == 21 { // Air temperature.
 			temp := m.AirTemperature
-			minTemp, maxTemp := minMax(temp)
+			minTemp, maxTemp := temp.MinMax()
 
 			// Create the color function.
 			colorFunc = func(i int, n float64) color.Color {
 				// Calculate the color of the region.
-				val := (temp[i] - minTemp) / (maxTemp - minTemp)
+				val := (temp.At(i) - minTemp) / (maxTemp - minTemp)
 				return genColor(cb.At(val), math.Pow(val, 1/n))
 			}
 		} else if displayMode == 22 { // Ocean temperature.
 			temp := m.OceanTemperature
-			minTemp, maxTemp := minMax(temp)
+			minTemp, maxTemp := temp.MinMax()
 
 			// Create the color function.
 			colorFunc = func(i int, n float64) color.Color {
 				// Calculate the color of the region.
-				val := (temp[i] - minTemp) / (maxTemp - minTemp)
+				val := (temp.At(i) - minTemp) / (maxTemp - minTemp)
 				return genColor(cb.At(val), math.Pow(val, 1/n))
 			}
 		}
 	default:
 		vals := m.Elevation
 		if displayMode == 1 {
-			vals = m.CalcCurrentPressure(m.RegionToOceanVec)
+			vals = various.NewFloatsFrom(m.CalcCurrentPressure(m.RegionToOceanVec), false)
 		} else if displayMode == 2 {
 			vals = m.Moisture
 		} else if displayMode == 3 {
@@ -168,49 +174,101 @@ func (m *Map) GetTile(x, y, zoom, displayMode, vectorMode int, drawRivers, drawT
 		} else if displayMode == 4 {
 			vals = m.Flux
 		} else if displayMode == 5 {
-			vals = m.PropagateCompression(m.RegionCompression)
+			vals = various.NewFloatsFrom(m.PropagateCompression(m.RegionCompression), false)
 		} else if displayMode == 6 {
-			vals = m.GetEarthquakeChance()
+			vals = various.NewFloatsFrom(m.GetEarthquakeChance(), false)
 		} else if displayMode == 7 {
-			vals = m.GetVolcanoEruptionChance()
+			vals = various.NewFloatsFrom(m.GetVolcanoEruptionChance(), false)
 		} else if displayMode == 8 {
-			vals = m.GetRockSlideAvalancheChance()
+			vals = various.NewFloatsFrom(m.GetRockSlideAvalancheChance(), false)
 		} else if displayMode == 9 {
-			vals = m.GetFloodChance()
+			vals = various.NewFloatsFrom(m.GetFloodChance(), false)
 		} else if displayMode == 10 {
-			vals = m.GetErosionRate()
+			vals = various.NewFloatsFrom(m.GetErosionRate(), false)
 		} else if displayMode == 11 {
-			vals = m.GetErosionRate2()
+			vals = various.NewFloatsFrom(m.GetErosionRate2(), false)
 		} else if displayMode == 12 {
-			vals = m.GetSteepness()
+			vals = various.NewFloatsFrom(m.GetSteepness(), false)
 		} else if displayMode == 13 {
-			vals = m.GetSlope()
+			vals = various.NewFloatsFrom(m.GetSlope(), false)
 		} else if displayMode == 23 {
-			vals = m.Geo.AvgInsolation
+			vals = various.NewFloatsFrom(m.Geo.AvgInsolation, false)
+		} else if displayMode == 24 {
+			vals = various.NewFloatsFrom(m.GetCycloneChance(), false)
+		} else if displayMode == 25 {
+			vals = m.OceanSalinity
+		} else if displayMode == 26 {
+			vals = m.OceanUpwelling
+		} else if displayMode == 27 {
+			vals = m.CoastalFog
+		} else if displayMode == 29 {
+			vals = various.NewFloatsFrom(m.Energy, false)
+		} else if displayMode == 30 {
+			vals = various.NewFloatsFrom(m.GetTsunamiHazard(), false)
+		} else if displayMode == 31 {
+			vals = various.NewFloatsFrom(m.GetLandslideChance(), false)
 		}
 
 		// Calculate the min and max elevation.
-		_, max := minMax(m.Elevation)
-		_, maxMois := minMax(m.Moisture)
-		minVal, maxVal := minMax(vals)
+		_, max := m.Elevation.MinMax()
+		_, maxMois := m.Moisture.MinMax()
+		minVal, maxVal := vals.MinMax()
 		colorFunc = func(i int, n float64) color.Color {
 			// Calculate the color of the region.
-			elev := m.Elevation[i]
-			val := (vals[i] - minVal) / (maxVal - minVal)
+			elev := m.Elevation.At(i)
+			val := (vals.At(i) - minVal) / (maxVal - minVal)
 
 			// Return blue for water (oceans and lakes).
-			if elev <= 0 || (m.Waterpool[i] > 0 && drawLakes) {
+			if elev <= 0 || (m.Waterpool.At(i) > 0 && drawLakes) {
 				return genBlue(val)
 			}
 
 			// Return the biome color for land.
 			rLat := m.LatLon[i][0]
 			valElev := elev / max
-			valMois := m.Moisture[i] / maxMois
+			valMois := m.Moisture.At(i) / maxMois
 			return geo.GetWhittakerModBiomeColor(rLat, valElev, valMois, math.Pow(val, 1/n))
 		}
 	}
 
+	// Custom data layers (see geo.RegisterLayer).
+	layerName := m.getDisplayModeLayer(displayMode)
+	if layerName != "" {
+		colorFunc = m.getLayerColorFunc(m.BaseObject, layerName)
+	}
+
+	// Custom resources (see geo.ResourceRegistry).
+	if def := m.getDisplayModeResource(displayMode); def != nil {
+		colorFunc = m.getResourceColorFunc(def, colorFunc)
+	}
+
+	// At high zoom levels we use the refined terrain (if available) to show
+	// more detail than the global mesh provides.
+	// NOTE: Only the terrain based display modes and custom data layers are
+	// supported. Refined terrain holds a copy of the layers at the time of the
+	// refinement, so later changes to the layers are not shown.
+	bo := m.BaseObject
+	if m.TileCache != nil && (isRefinableDisplayMode(displayMode) || layerName != "") {
+		if t := m.TileCache.Get(x, y, zoom); t != nil && t.BaseObject != m.BaseObject && (layerName == "" || t.GetLayer(layerName) != nil) {
+			bo = t.BaseObject
+			mesh, meshRegions = bo.SphereMesh, nil
+			if layerName != "" {
+				colorFunc = m.getLayerColorFunc(bo, layerName)
+			} else {
+				colorFunc = m.getRefinedColorFunc(bo, displayMode, drawLakes)
+			}
+		}
+	}
+	isRefined := bo != m.BaseObject
+
+	// Overlay the wildfires and burn scars.
+	if drawFires && !isRefined {
+		baseColorFunc := colorFunc
+		colorFunc = func(i int, n float64) color.Color {
+			return m.getFireColor(i, baseColorFunc(i, n))
+		}
+	}
+
 	// Wrap the tile coordinates.
 	x, y = wrapTileCoordinates(x, y, zoom)
 
@@ -302,14 +360,20 @@ func (m *Map) GetTile(x, y, zoom, displayMode, vectorMode int, drawRivers, drawT
 			}
 		}
 
-		// Calculate the color of the region.
-		col := colorFunc(i*step, 1.0)
-
 		// If the path is empty, we can skip it.
 		if len(path) == 0 {
 			continue
 		}
 
+		// Calculate the color of the region.
+		r := i
+		if meshRegions != nil {
+			if r = meshRegions[i]; r == -1 {
+				continue
+			}
+		}
+		col := colorFunc(r, 1.0)
+
 		// Draw the path.
 		gc.SetStrokeColor(col)
 		gc.SetFillColor(col)
@@ -328,7 +392,7 @@ func (m *Map) GetTile(x, y, zoom, displayMode, vectorMode int, drawRivers, drawT
 	if drawShadows {
 		// Get all triangles that are within the tile bounds.
 		var inQuadTreeTris []int
-		qds = m.TriQuadTree.FindPointsInRect(geoquad.Rect{
+		qds = bo.TriQuadTree.FindPointsInRect(geoquad.Rect{
 			MinLat: la1Margin,
 			MaxLat: la2Margin,
 			MinLon: lo1Margin,
@@ -347,8 +411,8 @@ func (m *Map) GetTile(x, y, zoom, displayMode, vectorMode int, drawRivers, drawT
 	Loop:
 		for _, i := range inQuadTreeTris {
 			// Hacky way to filter paths/triangles that wrap around the entire SVG.
-			triLat := m.TriLatLon[i][0]
-			triLon := m.TriLatLon[i][1]
+			triLat := bo.TriLatLon[i][0]
+			triLon := bo.TriLatLon[i][1]
 
 			// Check if we are within the tile with a small margin, taking
 			// into account that we might have wrapped around the world.
@@ -358,9 +422,9 @@ func (m *Map) GetTile(x, y, zoom, displayMode, vectorMode int, drawRivers, drawT
 
 			// Draw the path that outlines the region.
 			var path [][2]float64
-			for _, j := range m.T_circulate_r(out_t, i) {
-				rLat := m.LatLon[j][0]
-				rLon := m.LatLon[j][1]
+			for _, j := range bo.T_circulate_r(out_t, i) {
+				rLat := bo.LatLon[j][0]
+				rLon := bo.LatLon[j][1]
 
 				// Check if we the region is across the +/- 180 degrees longitude line
 				// compared to the triangle.
@@ -396,10 +460,10 @@ func (m *Map) GetTile(x, y, zoom, displayMode, vectorMode int, drawRivers, drawT
 			}
 
 			// Get the 3 regions of the triangle.
-			regions := m.T_circulate_r(out_t, i)
+			regions := bo.T_circulate_r(out_t, i)
 
 			// Get the normal of the triangle.
-			normal := m.RegTriNormal(i, regions)
+			normal := bo.RegTriNormal(i, regions)
 
 			// Now take the dot product of the slope and our global light
 			// direction to get the amount of light on the triangle.
@@ -451,8 +515,8 @@ func (m *Map) GetTile(x, y, zoom, displayMode, vectorMode int, drawRivers, drawT
 				r1 := regions[j]
 				r2 := regions[(j+1)%3]
 				// Get the 2 points of the triangle segment.
-				x1, y1, z1 := path[j][0], path[j][1], m.Elevation[r1]
-				x2, y2, z2 := path[(j+1)%3][0], path[(j+1)%3][1], m.Elevation[r2]
+				x1, y1, z1 := path[j][0], path[j][1], bo.Elevation.At(r1)
+				x2, y2, z2 := path[(j+1)%3][0], path[(j+1)%3][1], bo.Elevation.At(r2)
 
 				if z1 <= 0 {
 					regsBelowSeaLevel[j] = true
@@ -588,11 +652,27 @@ func (m *Map) GetTile(x, y, zoom, displayMode, vectorMode int, drawRivers, drawT
 			vects = m.RegionToWindVecLocal
 		} else if vectorMode == 3 {
 			vects = m.RegionToOceanVec
+		} else if vectorMode == 4 {
+			vects = m.RegionToDeepOceanVec
 		}
 		// Set the color and line width of the wind vectors.
 		gc.SetStrokeColor(color.NRGBA{0, 0, 0, 255})
 		gc.SetLineWidth(1)
-		for _, i := range inQuadTreeRegs {
+
+		// The vectors are only available for the regions of the global mesh.
+		vectorRegs := inQuadTreeRegs
+		if isRefined {
+			vectorRegs = nil
+			for _, qd := range m.RegQuadTree.FindPointsInRect(geoquad.Rect{
+				MinLat: la1Margin,
+				MaxLat: la2Margin,
+				MinLon: lo1Margin,
+				MaxLon: lo2Margin,
+			}) {
+				vectorRegs = append(vectorRegs, qd.Data.(int))
+			}
+		}
+		for _, i := range vectorRegs {
 			rLat := m.LatLon[i][0]
 			rLon := m.LatLon[i][1]
 
@@ -640,8 +720,14 @@ func (m *Map) GetTile(x, y, zoom, displayMode, vectorMode int, drawRivers, drawT
 	// fetch all the rivers and filter them by the tile.
 	// We should filter this stuff before we generate the rivers.
 	if drawRivers {
-		rivers := m.GetRiversInLatLonBB(0.001/float64(int(1)<<zoom), la1Margin, lo1Margin, la2Margin, lo2Margin)
-		_, maxFlux := minMax(m.Flux)
+		// NOTE: The river limit is relative to the maximum flux of the terrain,
+		// so we scale it for refined terrain to match the global maximum flux.
+		_, maxFlux := m.Flux.MinMax()
+		limit := 0.001 / float64(int(1)<<zoom)
+		if _, maxFluxRefined := bo.Flux.MinMax(); isRefined && maxFluxRefined > 0 {
+			limit *= maxFlux / maxFluxRefined
+		}
+		rivers := bo.GetRiversInLatLonBB(limit, la1Margin, lo1Margin, la2Margin, lo2Margin)
 
 		// Set our stroke color to a nice river blue.
 		gc.SetStrokeColor(color.NRGBA{0, 0, 255, 255})
@@ -652,20 +738,20 @@ func (m *Map) GetTile(x, y, zoom, displayMode, vectorMode int, drawRivers, drawT
 			gc.BeginPath()
 
 			// Move to the first point.
-			rLat, rLon := m.LatLon[river[0]][0], m.LatLon[river[0]][1]
+			rLat, rLon := bo.LatLon[river[0]][0], bo.LatLon[river[0]][1]
 			x, y := latLonToPixels(rLat, rLon, zoom)
 			gc.MoveTo(x-dx, y-dy2)
 			for i, p := range river[1:] {
 				// Set the line width based on the flux of the river, averaged with the previous flux.
-				gc.SetLineWidth(4 * math.Sqrt((m.Flux[p]+m.Flux[river[i]])/(2*maxFlux)))
+				gc.SetLineWidth(4 * math.Sqrt((bo.Flux.At(p)+bo.Flux.At(river[i]))/(2*maxFlux)))
 
 				// Set the line width based on the flux of the river.
-				rLat, rLon = m.LatLon[p][0], m.LatLon[p][1]
+				rLat, rLon = bo.LatLon[p][0], bo.LatLon[p][1]
 
 				// Now compare the longitude to the previous longitude.
 				// If we have crossed the +- 180 degree boundary, we need to
 				// draw to a fake point at the same latitude but on the same side of the world.
-				if diff := rLon - m.LatLon[river[i]][1]; math.Abs(diff) > 110 {
+				if diff := rLon - bo.LatLon[river[i]][1]; math.Abs(diff) > 110 {
 					rLonFake := rLon - 360
 					if diff < 0 {
 						rLonFake = rLon + 360
@@ -688,8 +774,8 @@ func (m *Map) GetTile(x, y, zoom, displayMode, vectorMode int, drawRivers, drawT
 				// If both points are in a pool or below sea level, we end the path,
 				// move to the new point and start a new path.
 				// TODO: Calculate intercept of the river with the sea level.
-				if (m.Elevation[p] <= 0 || m.Waterpool[p] > 0 && drawLakes) &&
-					(m.Elevation[river[i]] <= 0 || m.Waterpool[river[i]] > 0 && drawLakes) {
+				if (bo.Elevation.At(p) <= 0 || bo.Waterpool.At(p) > 0 && drawLakes) &&
+					(bo.Elevation.At(river[i]) <= 0 || bo.Waterpool.At(river[i]) > 0 && drawLakes) {
 					// Draw from the last position to the midpoint.
 					// This will cause the river to end at the sea level.
 					gc.Stroke()
@@ -698,7 +784,7 @@ func (m *Map) GetTile(x, y, zoom, displayMode, vectorMode int, drawRivers, drawT
 					// Move to the new point and start a new path.
 					gc.BeginPath()
 					gc.MoveTo(x, y)
-				} else if m.Elevation[p] <= 0 || (m.Waterpool[p] > 0 && drawLakes) {
+				} else if bo.Elevation.At(p) <= 0 || (bo.Waterpool.At(p) > 0 && drawLakes) {
 					// If we are below sea level, interpolate the point with the previous point.
 					// Draw from the last position to the midpoint.
 					// This will cause the river to end at the sea level.
@@ -707,7 +793,7 @@ func (m *Map) GetTile(x, y, zoom, displayMode, vectorMode int, drawRivers, drawT
 
 					// Move to the new point.
 					gc.MoveTo(x, y)
-				} else if m.Elevation[river[i]] <= 0 || (m.Waterpool[river[i]] > 0 && drawLakes) {
+				} else if bo.Elevation.At(river[i]) <= 0 || (bo.Waterpool.At(river[i]) > 0 && drawLakes) {
 					// If the previous point was below sea level, interpolate the point with the next point.
 					// This will cause the river to start at the sea level.
 					lx, ly := gc.LastPoint()
@@ -776,6 +862,160 @@ func (m *Map) GetTile(x, y, zoom, displayMode, vectorMode int, drawRivers, drawT
 	return dest
 }
 
+// DisplayModeLayers is the display mode of the first custom data layer. The
+// display mode DisplayModeLayers+i shows the i-th layer in order of
+// registration (see geo.RegisterLayer).
+const DisplayModeLayers = 100
+
+// getDisplayModeLayer returns the name of the custom data layer shown in the
+// given display mode (or an empty string).
+func (m *Map) getDisplayModeLayer(displayMode int) string {
+	names := m.GetLayerNames()
+	if i := displayMode - DisplayModeLayers; i >= 0 && i < len(names) {
+		return names[i]
+	}
+	return ""
+}
+
+// DisplayModeResources is the display mode of the first custom resource. The
+// display mode DisplayModeResources+i shows the deposits of the i-th custom
+// resource in order of registration (see geo.ResourceRegistry.GetCustom) on
+// top of the terrain.
+const DisplayModeResources = 200
+
+// getDisplayModeResource returns the custom resource shown in the given
+// display mode (or nil).
+func (m *Map) getDisplayModeResource(displayMode int) *geo.ResourceDef {
+	custom := m.ResourceRegistry.GetCustom()
+	if i := displayMode - DisplayModeResources; i >= 0 && i < len(custom) {
+		return custom[i]
+	}
+	return nil
+}
+
+// getResourceColorFunc returns the color function highlighting the regions
+// with available deposits of the given resource using the color of the
+// resource. All other regions use the given base color function.
+func (m *Map) getResourceColorFunc(def *geo.ResourceDef, baseColorFunc func(int, float64) color.Color) func(int, float64) color.Color {
+	var col color.Color = def.Color
+	if def.Color.A == 0 {
+		col = color.RGBA{255, 0, 255, 255}
+	}
+	return func(i int, n float64) color.Color {
+		for _, d := range m.GetDeposits(i) {
+			if d.Def == def && d.IsAvailable() {
+				return col
+			}
+		}
+		return baseColorFunc(i, n)
+	}
+}
+
+// getLayerColorFunc returns the color function for the given custom data
+// layer of the given (possibly refined) terrain using the color ramp of the
+// layer. The values are normalized using the global layer, so refined tiles
+// match the surrounding tiles.
+func (m *Map) getLayerColorFunc(bo *geo.BaseObject, name string) func(int, float64) color.Color {
+	layer := bo.GetLayer(name)
+	global := m.GetLayer(name)
+	minVal, maxVal := math.Inf(1), math.Inf(-1)
+	for r := 0; r < m.SphereMesh.NumRegions; r++ {
+		v := global.GetFloat(r)
+		minVal = math.Min(minVal, v)
+		maxVal = math.Max(maxVal, v)
+	}
+
+	// Use a blue to red color gradient if the layer has no color ramp.
+	ramp := layer.GetColorRamp()
+	if len(ramp) < 2 {
+		ramp = []color.Color{
+			color.RGBA{0, 0, 255, 255},
+			color.RGBA{0, 255, 255, 255},
+			color.RGBA{0, 255, 0, 255},
+			color.RGBA{255, 255, 0, 255},
+			color.RGBA{255, 0, 0, 255},
+		}
+	}
+	colorGrad := colorgrad.NewGradient()
+	colorGrad.Colors(ramp...)
+	cb, err := colorGrad.Build()
+	if err != nil {
+		log.Println("invalid color ramp for layer", name, err)
+		cb = colorgrad.Rainbow()
+	}
+	return func(i int, n float64) color.Color {
+		var val float64
+		if maxVal > minVal {
+			val = (layer.GetFloat(i) - minVal) / (maxVal - minVal)
+		}
+		return genColor(cb.At(val), math.Pow(val, 1/n))
+	}
+}
+
+// isRefinableDisplayMode returns true if the given display mode only depends
+// on the terrain, so we can use refined terrain patches (see TileCache).
+func isRefinableDisplayMode(displayMode int) bool {
+	switch displayMode {
+	case 0, 2, 3, 4:
+		return true
+	}
+	return false
+}
+
+// getRefinedColorFunc returns the color function for the given (refined)
+// terrain and display mode. The values are normalized using the global
+// terrain, so refined tiles match the surrounding tiles.
+func (m *Map) getRefinedColorFunc(bo *geo.BaseObject, displayMode int, drawLakes bool) func(int, float64) color.Color {
+	vals, globalVals := bo.Elevation, m.Elevation
+	switch displayMode {
+	case 2:
+		vals, globalVals = bo.Moisture, m.Moisture
+	case 3:
+		vals, globalVals = bo.Rainfall, m.Rainfall
+	case 4:
+		vals, globalVals = bo.Flux, m.Flux
+	}
+	_, max := m.Elevation.MinMax()
+	_, maxMois := m.Moisture.MinMax()
+	minVal, maxVal := globalVals.MinMax()
+	return func(i int, n float64) color.Color {
+		// Calculate the color of the region.
+		elev := bo.Elevation.At(i)
+		val := math.Max(0, math.Min(1, (vals.At(i)-minVal)/(maxVal-minVal)))
+
+		// Return blue for water (oceans and lakes).
+		if elev <= 0 || (bo.Waterpool.At(i) > 0 && drawLakes) {
+			return genBlue(val)
+		}
+
+		// Return the biome color for land.
+		rLat := bo.LatLon[i][0]
+		valElev := elev / max
+		valMois := bo.Moisture.At(i) / maxMois
+		return geo.GetWhittakerModBiomeColor(rLat, valElev, valMois, math.Pow(val, 1/n))
+	}
+}
+
+// getFireColor returns the given color of the region with the wildfire overlay
+// applied. Burning regions glow red to yellow depending on the intensity of the
+// fire, while burned regions are darkened until the vegetation has regrown.
+func (m *Map) getFireColor(r int, col color.Color) color.Color {
+	if m.IsRegBurning(r) {
+		return color.NRGBA{255, uint8(200 * (1 - m.FireIntensity[r])), 0, 255}
+	}
+	if r >= len(m.Fuel) || m.Fuel[r] >= m.FuelCapacity[r] {
+		return col
+	}
+	burned := 1 - 0.7*(1-m.Fuel[r]/m.FuelCapacity[r])
+	cr, cg, cb, _ := col.RGBA()
+	return color.NRGBA{
+		R: uint8(float64(cr>>8) * burned),
+		G: uint8(float64(cg>>8) * burned),
+		B: uint8(float64(cb>>8) * burned),
+		A: 255,
+	}
+}
+
 func wrapTileCoordinates(x, y, zoom int) (int, int) {
 	// Wrap the tile coordinates.
 	x = x % (1 << uint(zoom))
@@ -919,8 +1159,8 @@ func (m *Map) GetGeoJSONCities(la1, lo1, la2, lo2 float64, zoom int) ([]byte, er
 	distRegion := math.Sqrt(4 * math.Pi / float64(m.NumRegions))
 
 	biomeFunc := m.GetRegWhittakerModBiomeFunc()
-	_, maxElev := minMax(m.Elevation)
-	_, maxMois := minMax(m.Moisture)
+	_, maxElev := m.Elevation.MinMax()
+	_, maxMois := m.Moisture.MinMax()
 
 	regPropertyFunc := m.GetRegPropertyFunc()
 
@@ -982,8 +1222,8 @@ func (m *Map) GetGeoJSONCities(la1, lo1, la2, lo2 float64, zoom int) ([]byte, er
 		f.SetProperty("maxpoplimit", c.MaxPopulationLimit())
 		f.SetProperty("settled", maxSettled-c.Founded)
 		temperature := m.GetRegTemperature(c.ID, maxElev)
-		precip := geo.MaxPrecipitation * m.Moisture[c.ID] / maxMois
-		elev := geo.MaxAltitudeFactor * m.Elevation[c.ID] / maxElev
+		precip := geo.MaxPrecipitation * m.Moisture.At(c.ID) / maxMois
+		elev := geo.MaxAltitudeFactor * m.Elevation.At(c.ID) / maxElev
 		f.SetProperty("biome", genbiome.WhittakerModBiomeToString(biomeFunc(c.ID))+
 			fmt.Sprintf(" (%.1f°C, %.1fdm, %.1fm)", temperature, precip, elev))
 		f.SetProperty("coordinates", fmt.Sprintf("lat %.2f, lon %.2f", cLat, cLon))
@@ -992,7 +1232,7 @@ func (m *Map) GetGeoJSONCities(la1, lo1, la2, lo2 float64, zoom int) ([]byte, er
 		f.SetProperty("agriculture", c.PotentialAgricultural)
 		f.SetProperty("trade", c.PotentialTrade)
 		f.SetProperty("resources", c.PotentialResources)
-		f.SetProperty("radius", (c.radius()+2*distRegion)*gameconstants.EarthCircumference/(2*math.Pi))
+		f.SetProperty("radius", m.DistToKm(c.radius(m.Circumference())+2*distRegion))
 		f.SetProperty("tradepartners", c.TradePartners)
 		f.SetProperty("flavortext", m.generateCityFlavorText(c, regPropertyFunc(c.ID)))
 		var sName string
@@ -1025,36 +1265,11 @@ func (m *Map) GetGeoJSONCities(la1, lo1, la2, lo2 float64, zoom int) ([]byte, er
 		}
 		f.SetProperty("history", msgs)
 
-		// Generate the list of local resources.
+		// Generate the list of local resources (including custom resources).
 		var resources []string
-		// Metals.
-		for i := 0; i < geo.ResMaxMetals; i++ {
-			if m.Metals[c.ID]&(1<<i) != 0 {
-				resources = append(resources, geo.MetalToString(i))
-			}
-		}
-		// Gems.
-		for i := 0; i < geo.ResMaxGems; i++ {
-			if m.Gems[c.ID]&(1<<i) != 0 {
-				resources = append(resources, geo.GemToString(i))
-			}
-		}
-		// Stones.
-		for i := 0; i < geo.ResMaxStones; i++ {
-			if m.Stones[c.ID]&(1<<i) != 0 {
-				resources = append(resources, geo.StoneToString(i))
-			}
-		}
-		// Woods.
-		for i := 0; i < geo.ResMaxWoods; i++ {
-			if m.Wood[c.ID]&(1<<i) != 0 {
-				resources = append(resources, geo.WoodToString(i))
-			}
-		}
-		// Various.
-		for i := 0; i < geo.ResMaxVarious; i++ {
-			if m.Various[c.ID]&(1<<i) != 0 {
-				resources = append(resources, geo.VariousToString(i))
+		for _, d := range m.GetDeposits(c.ID) {
+			if d.IsAvailable() {
+				resources = append(resources, d.Name())
 			}
 		}
 		f.SetProperty("reslist", resources)
@@ -1127,6 +1342,145 @@ func (m *Map) GetGeoJSONBorders(la1, lo1, la2, lo2 float64, zoom, displayMode in
 	return geoJSONBytes, nil
 }
 
+// GetGeoJSONStormTracks returns all historical storm tracks as GeoJSON line strings within the given bounds and zoom level.
+func (m *Map) GetGeoJSONStormTracks(la1, lo1, la2, lo2 float64, zoom int) ([]byte, error) {
+	geoJSON := geojson.NewFeatureCollection()
+	// Wrap the latitude only if we see less than 180 degrees, otherwise just limit it.
+	if math.Abs(la1-la2) < 180 {
+		la1 = wrapLatitude(la1)
+		la2 = wrapLatitude(la2)
+	} else {
+		la1 = limitLatitude(la1)
+		la2 = limitLatitude(la2)
+	}
+	// Wrap the longitude only if we see less than 360 degrees.
+	if math.Abs(lo1-lo2) < 360 {
+		lo1 = wrapLongitude(lo1)
+		lo2 = wrapLongitude(lo2)
+	} else {
+		lo1 = limitLongitude(lo1)
+		lo2 = limitLongitude(lo2)
+	}
+	lbb := latLonBounds{la1, lo1, la2, lo2}
+
+	// addTrack adds the given (partial) storm track to the GeoJSON as a feature.
+	addTrack := func(id int, t *geo.StormTrack, latLons [][]float64, intensities []float64) {
+		if len(latLons) < 2 {
+			return
+		}
+		f := geojson.NewLineStringFeature(latLons)
+		f.ID = id
+		f.SetProperty("day", t.DayOfYear)
+		f.SetProperty("category", t.Category())
+		f.SetProperty("maxintensity", t.MaxIntensity())
+		f.SetProperty("intensity", intensities)
+		geoJSON.AddFeature(f)
+	}
+
+	for i, t := range m.StormTracks {
+		// Skip the track if no point is within the bounds.
+		var inBounds bool
+		for _, r := range t.Regions {
+			if lbb.InBounds(m.LatLon[r][0], m.LatLon[r][1]) {
+				inBounds = true
+				break
+			}
+		}
+		if !inBounds {
+			continue
+		}
+
+		var trackLatLons [][]float64
+		var trackIntensities []float64
+		for j, r := range t.Regions {
+			la := m.LatLon[r][0]
+			lo := m.LatLon[r][1]
+
+			// Check if we have crossed the 180 degree longitude line.
+			// If so, we stop here, add the track to the GeoJSON and start a new one.
+			if len(trackLatLons) > 0 && math.Abs(trackLatLons[len(trackLatLons)-1][0]-lo) > 180 {
+				addTrack(i, t, trackLatLons, trackIntensities)
+				trackLatLons = nil
+				trackIntensities = nil
+			}
+			trackLatLons = append(trackLatLons, []float64{lo, la})
+			trackIntensities = append(trackIntensities, t.Intensity[j])
+		}
+		addTrack(i, t, trackLatLons, trackIntensities)
+	}
+
+	// Now encode the GeoJSON.
+	geoJSONBytes, err := geoJSON.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return geoJSONBytes, nil
+}
+
+// GetGeoJSONFeatures returns the labels of all named geographic features
+// (mountain ranges, seas, ...) as GeoJSON points within the given bounds and
+// zoom level. At lower zoom levels only the largest features are shown.
+func (m *Map) GetGeoJSONFeatures(la1, lo1, la2, lo2 float64, zoom int) ([]byte, error) {
+	geoJSON := geojson.NewFeatureCollection()
+	// Wrap the latitude only if we see less than 180 degrees, otherwise just limit it.
+	if math.Abs(la1-la2) < 180 {
+		la1 = wrapLatitude(la1)
+		la2 = wrapLatitude(la2)
+	} else {
+		la1 = limitLatitude(la1)
+		la2 = limitLatitude(la2)
+	}
+	// Wrap the longitude only if we see less than 360 degrees.
+	if math.Abs(lo1-lo2) < 360 {
+		lo1 = wrapLongitude(lo1)
+		lo2 = wrapLongitude(lo2)
+	} else {
+		lo1 = limitLongitude(lo1)
+		lo2 = limitLongitude(lo2)
+	}
+	lbb := latLonBounds{la1, lo1, la2, lo2}
+
+	// Sort the features by size (descending).
+	sortedFeatures := make([]*geo.Feature, 0, len(m.Features))
+	for _, f := range m.Features {
+		if f.Name != "" {
+			sortedFeatures = append(sortedFeatures, f)
+		}
+	}
+	sort.SliceStable(sortedFeatures, func(i, j int) bool {
+		return len(sortedFeatures[i].Regions) > len(sortedFeatures[j].Regions)
+	})
+
+	// Depending on the zoom level we want to show more or less features.
+	showNumFeatures := len(sortedFeatures)
+	if zoom < 9 {
+		showNumFeatures = 5 * (1 << uint(zoom))
+	}
+
+	for idx, f := range sortedFeatures {
+		if idx >= showNumFeatures {
+			break
+		}
+		if !lbb.InBounds(f.Centroid[0], f.Centroid[1]) {
+			continue
+		}
+		gf := geojson.NewPointFeature([]float64{f.Centroid[1], f.Centroid[0]})
+		gf.ID = f.ID
+		gf.SetProperty("name", f.Name)
+		gf.SetProperty("type", f.Type)
+		gf.SetProperty("size", len(f.Regions))
+		gf.SetProperty("water", m.Elevation.At(f.Center) <= 0)
+		geoJSON.AddFeature(gf)
+	}
+
+	// Now encode the GeoJSON.
+	geoJSONBytes, err := geoJSON.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return geoJSONBytes, nil
+}
+
 const tileSize = 256
 
 // sizeFromZoom returns the expected size of the world for the mercato projection used below.
@@ -116,14 +116,14 @@ func (m *Map) GetHeightMapTile(x, y, zoom int) []byte {
 			log.Println("no triangle found")
 			return 0
 		}
-		if m.TriElevation[minDistIndex] <= 0 {
+		if m.TriElevation.At(minDistIndex) <= 0 {
 			return 0
 		}
 		// Now we measure the distance of each point in the triangle from the point
 		// and use their respektive weights to calculate the height.
-		height := calcHeightMercator(m.LatLon[regs[0]], m.LatLon[regs[1]], m.LatLon[regs[2]], latlon, m.Elevation[regs[0]], m.Elevation[regs[1]], m.Elevation[regs[2]])
+		height := calcHeightMercator(m.LatLon[regs[0]], m.LatLon[regs[1]], m.LatLon[regs[2]], latlon, m.Elevation.At(regs[0]), m.Elevation.At(regs[1]), m.Elevation.At(regs[2]))
 		// Reverse lat and long.
-		// height := calcHeight(m.LatLon[regs[0]][0], m.LatLon[regs[0]][1], m.Elevation[regs[0]], m.LatLon[regs[1]][0], m.LatLon[regs[1]][1], m.Elevation[regs[1]], m.LatLon[regs[2]][0], m.LatLon[regs[2]][1], m.Elevation[regs[2]], latlon[0], latlon[1])
+		// height := calcHeight(m.LatLon[regs[0]][0], m.LatLon[regs[0]][1], m.Elevation.At(regs[0]), m.LatLon[regs[1]][0], m.LatLon[regs[1]][1], m.Elevation.At(regs[1]), m.LatLon[regs[2]][0], m.LatLon[regs[2]][1], m.Elevation.At(regs[2]), latlon[0], latlon[1])
 
 		if height <= 0 {
 			return 0
@@ -178,7 +178,7 @@ func (m *Map) Get3DTile(x, y, zoom int) *Tile3D {
 		rLon := m.LatLon[i][1]
 		if isLatLonInBounds(rLat, rLon) {
 			regionsInBounds = append(regionsInBounds, i)
-			elev := m.Elevation[i]
+			elev := m.Elevation.At(i)
 			if elev < minElev {
 				minElev = elev
 			}
@@ -227,7 +227,7 @@ func (m *Map) Get3DTile(x, y, zoom int) *Tile3D {
 		// within the tile.
 		// - For other values, the vertex's height is a linear interpolation between the
 		// minimum and maximum heights.
-		heightBuffer[i] = int64((m.Elevation[r] - minElev) / (maxElev - minElev) * 32767)
+		heightBuffer[i] = int64((m.Elevation.At(r) - minElev) / (maxElev - minElev) * 32767)
 	}
 
 	// Zig-zag encode the values.
@@ -0,0 +1,52 @@
+package various
+
+import "math/bits"
+
+// Bitset is a compact set of non-negative integers (e.g. regions), using a
+// single bit per possible value.
+type Bitset []uint64
+
+// NewBitset returns a new bitset that can hold the values 0 to n-1.
+func NewBitset(n int) Bitset {
+	return make(Bitset, (n+63)/64)
+}
+
+// Has returns true if the given value is in the set. Values outside of the
+// range of the set are never in the set.
+func (b Bitset) Has(i int) bool {
+	if i < 0 || i/64 >= len(b) {
+		return false
+	}
+	return b[i/64]&(1<<uint(i%64)) != 0
+}
+
+// Set adds the given value to the set.
+func (b Bitset) Set(i int) {
+	b[i/64] |= 1 << uint(i%64)
+}
+
+// Unset removes the given value from the set.
+func (b Bitset) Unset(i int) {
+	b[i/64] &^= 1 << uint(i%64)
+}
+
+// Count returns the number of values in the set.
+func (b Bitset) Count() int {
+	var n int
+	for _, w := range b {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// Values returns all values in the set in ascending order.
+func (b Bitset) Values() []int {
+	vals := make([]int, 0, b.Count())
+	for i, w := range b {
+		for w != 0 {
+			vals = append(vals, i*64+bits.TrailingZeros64(w))
+			w &= w - 1
+		}
+	}
+	return vals
+}
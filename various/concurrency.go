@@ -1,27 +1,111 @@
 package various
 
-import "sync"
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
 
-func KickOffChunkWorkers(totalItems int, fn func(start, end int)) {
-	numWorkers := 8
+	"github.com/Flokey82/go_gens/utils"
+)
 
-	var wg sync.WaitGroup
-	var chunkStart int
-	chunkSize := (totalItems / numWorkers) + 1
-	for i := 0; i < numWorkers; i++ {
-		curChunk := chunkSize
-		if rem := totalItems - chunkStart; rem < curChunk {
-			curChunk = rem
-		}
-		if curChunk <= 0 {
-			break
+// executorNumChunks is the (maximum) number of chunks the work is split into.
+const executorNumChunks = 64
+
+// Executor runs work in parallel on a fixed number of workers.
+//
+// The work is split into chunks that only depend on the number of items, not
+// on the number of workers, so the results of ParallelReduce are identical
+// regardless of the configured parallelism.
+type Executor struct {
+	NumWorkers int // Number of workers
+}
+
+// NewExecutor returns a new executor with the given number of workers. If
+// numWorkers is 0 or less, GOMAXPROCS workers are used.
+func NewExecutor(numWorkers int) *Executor {
+	if numWorkers <= 0 {
+		numWorkers = runtime.GOMAXPROCS(0)
+	}
+	return &Executor{NumWorkers: numWorkers}
+}
+
+// DefaultExecutor is the executor used by KickOffChunkWorkers and as a
+// fallback if no executor has been configured.
+//
+// NOTE: The executor of a world is set via GeoConfig.Executor. This is only
+// read, never replaced, so concurrent generation of worlds is safe.
+var DefaultExecutor = NewExecutor(0)
+
+// Chunks splits the given number of items into chunks and returns the start
+// and end index of each chunk. The chunks only depend on the number of items.
+func (e *Executor) Chunks(totalItems int) [][2]int {
+	if totalItems <= 0 {
+		return nil
+	}
+	chunkSize := (totalItems + executorNumChunks - 1) / executorNumChunks
+	chunks := make([][2]int, 0, (totalItems+chunkSize-1)/chunkSize)
+	for start := 0; start < totalItems; start += chunkSize {
+		chunks = append(chunks, [2]int{start, utils.Min(start+chunkSize, totalItems)})
+	}
+	return chunks
+}
+
+// ParallelFor calls fn for each chunk of the given number of items (see
+// Chunks) on the workers of the executor and waits until all chunks have
+// been processed.
+func (e *Executor) ParallelFor(totalItems int, fn func(start, end int)) {
+	e.run(e.Chunks(totalItems), func(_ int, chunk [2]int) {
+		fn(chunk[0], chunk[1])
+	})
+}
+
+// run calls fn for each chunk on the workers of the executor.
+func (e *Executor) run(chunks [][2]int, fn func(i int, chunk [2]int)) {
+	numWorkers := utils.Min(e.NumWorkers, len(chunks))
+	if numWorkers <= 1 {
+		for i, chunk := range chunks {
+			fn(i, chunk)
 		}
+		return
+	}
+
+	// The workers pick the next unprocessed chunk until all are done.
+	var next int64 = -1
+	var wg sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
 		wg.Add(1)
-		go func(start, end int) {
-			fn(start, end)
-			wg.Done()
-		}(chunkStart, chunkStart+curChunk)
-		chunkStart += curChunk
+		go func() {
+			defer wg.Done()
+			for i := int(atomic.AddInt64(&next, 1)); i < len(chunks); i = int(atomic.AddInt64(&next, 1)) {
+				fn(i, chunks[i])
+			}
+		}()
 	}
 	wg.Wait()
 }
+
+// ParallelReduce calls mapFn for each chunk of the given number of items
+// (see Chunks) on the workers of the given executor and combines the results
+// using reduceFn in order of the chunks, so the result is deterministic.
+func ParallelReduce[T any](e *Executor, totalItems int, mapFn func(start, end int) T, reduceFn func(a, b T) T) T {
+	chunks := e.Chunks(totalItems)
+	results := make([]T, len(chunks))
+	e.run(chunks, func(i int, chunk [2]int) {
+		results[i] = mapFn(chunk[0], chunk[1])
+	})
+	var res T
+	for i, r := range results {
+		if i == 0 {
+			res = r
+		} else {
+			res = reduceFn(res, r)
+		}
+	}
+	return res
+}
+
+// KickOffChunkWorkers processes the given number of items in chunks in
+// parallel using the default executor (see DefaultExecutor).
+func KickOffChunkWorkers(totalItems int, fn func(start, end int)) {
+	DefaultExecutor.ParallelFor(totalItems, fn)
+}
@@ -0,0 +1,128 @@
+package various
+
+// Floats is a dense slice of float values (e.g. one per region), stored
+// either with float64 or, to save memory, with float32 precision.
+//
+// All values are read and written as float64, so callers don't need to know
+// which precision is used.
+type Floats struct {
+	f64 []float64
+	f32 []float32
+}
+
+// NewFloats returns a new slice of n zero values. If lowPrecision is true,
+// the values are stored as float32.
+func NewFloats(n int, lowPrecision bool) Floats {
+	if lowPrecision {
+		return Floats{f32: make([]float32, n)}
+	}
+	return Floats{f64: make([]float64, n)}
+}
+
+// NewFloatsFrom returns a new slice holding the given values. If lowPrecision
+// is false, the given slice is used directly instead of being copied.
+func NewFloatsFrom(vals []float64, lowPrecision bool) Floats {
+	if !lowPrecision {
+		return Floats{f64: vals}
+	}
+	f := NewFloats(len(vals), true)
+	f.SetValues(vals)
+	return f
+}
+
+// LowPrecision returns true if the values are stored as float32.
+func (f Floats) LowPrecision() bool {
+	return f.f32 != nil
+}
+
+// Len returns the number of values.
+func (f Floats) Len() int {
+	if f.f32 != nil {
+		return len(f.f32)
+	}
+	return len(f.f64)
+}
+
+// At returns the value at index i.
+func (f Floats) At(i int) float64 {
+	if f.f32 != nil {
+		return float64(f.f32[i])
+	}
+	return f.f64[i]
+}
+
+// Set sets the value at index i to v.
+func (f Floats) Set(i int, v float64) {
+	if f.f32 != nil {
+		f.f32[i] = float32(v)
+	} else {
+		f.f64[i] = v
+	}
+}
+
+// Add adds v to the value at index i.
+func (f Floats) Add(i int, v float64) {
+	f.Set(i, f.At(i)+v)
+}
+
+// Fill sets all values to v.
+func (f Floats) Fill(v float64) {
+	for i := 0; i < f.Len(); i++ {
+		f.Set(i, v)
+	}
+}
+
+// SetValues copies the given values into the slice. The number of values
+// must match the length of the slice.
+func (f Floats) SetValues(vals []float64) {
+	if len(vals) != f.Len() {
+		panic("various: Floats.SetValues length mismatch")
+	}
+	if f.f32 == nil {
+		copy(f.f64, vals)
+		return
+	}
+	for i, v := range vals {
+		f.f32[i] = float32(v)
+	}
+}
+
+// Append appends v to the slice.
+func (f *Floats) Append(v float64) {
+	if f.f32 != nil {
+		f.f32 = append(f.f32, float32(v))
+	} else {
+		f.f64 = append(f.f64, v)
+	}
+}
+
+// Float64s returns a copy of all values as float64.
+func (f Floats) Float64s() []float64 {
+	res := make([]float64, f.Len())
+	if f.f32 == nil {
+		copy(res, f.f64)
+		return res
+	}
+	for i, v := range f.f32 {
+		res[i] = float64(v)
+	}
+	return res
+}
+
+// MinMax returns the minimum and maximum value (0, 0 if the slice is empty).
+func (f Floats) MinMax() (float64, float64) {
+	if f.Len() == 0 {
+		return 0, 0
+	}
+	min, max := f.At(0), f.At(0)
+	for i := 1; i < f.Len(); i++ {
+		v := f.At(i)
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	return min, max
+}
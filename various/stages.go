@@ -0,0 +1,94 @@
+package various
+
+import (
+	"log"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stage holds the measurements of a generation stage (see LogStage).
+type Stage struct {
+	Name     string        // Name of the stage
+	Duration time.Duration // Duration of the stage
+	Allocs   uint64        // Number of heap allocations during the stage
+	Bytes    uint64        // Number of bytes allocated during the stage
+}
+
+// StageRecorder records the generation stages logged using LogStage.
+type StageRecorder struct {
+	Stages []Stage // Recorded stages in order of completion
+}
+
+var (
+	stageMu       sync.Mutex   // Guards the stages of the active recorder
+	stageRecorder atomic.Value // Active *StageRecorder (nil if not recording)
+)
+
+// RecordStages starts recording all stages logged using LogStage and returns
+// the recorder. Any previous recorder is stopped.
+//
+// NOTE: Measuring allocations stops the world briefly at the start and end
+// of each stage, so recording should only be enabled for benchmarks.
+func RecordStages() *StageRecorder {
+	rec := &StageRecorder{}
+	stageRecorder.Store(rec)
+	return rec
+}
+
+// StopRecordingStages stops recording stages.
+func StopRecordingStages() {
+	stageRecorder.Store((*StageRecorder)(nil))
+}
+
+// activeStageRecorder returns the active recorder (nil if not recording).
+func activeStageRecorder() *StageRecorder {
+	rec, _ := stageRecorder.Load().(*StageRecorder)
+	return rec
+}
+
+// StageStart is the start of a generation stage (see StartStage).
+type StageStart struct {
+	time      time.Time
+	mem       runtime.MemStats // Memory statistics at the start (if recording)
+	recording bool             // Recording was enabled at the start
+}
+
+// StartStage returns the start of a generation stage, which is passed to
+// LogStage once the stage is done.
+func StartStage() StageStart {
+	var st StageStart
+	if activeStageRecorder() != nil {
+		runtime.ReadMemStats(&st.mem)
+		st.recording = true
+	}
+	st.time = time.Now()
+	return st
+}
+
+// LogStage logs the completion of the generation stage with the given name
+// and records it if recording was enabled at the start of the stage (see
+// RecordStages).
+func LogStage(name string, start StageStart) {
+	dur := time.Since(start.time)
+	log.Println("Done "+name+" in ", dur.String())
+	if !start.recording {
+		return
+	}
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	rec := activeStageRecorder()
+	if rec == nil {
+		return
+	}
+	stageMu.Lock()
+	defer stageMu.Unlock()
+	rec.Stages = append(rec.Stages, Stage{
+		Name:     name,
+		Duration: dur,
+		Allocs:   ms.Mallocs - start.mem.Mallocs,
+		Bytes:    ms.TotalAlloc - start.mem.TotalAlloc,
+	})
+}